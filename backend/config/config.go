@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 type Config struct {
@@ -16,6 +21,94 @@ type Config struct {
 	DBPassword  string
 	DBName      string
 	Environment string
+
+	// Optional read replica for list and report endpoints; it shares the credentials and database name of the primary
+	DBReplicaHost string
+	DBReplicaPort string
+
+	// Queries slower than this are logged as slow queries
+	SlowQueryThreshold time.Duration
+
+	// Directory holding the spell check dictionaries, one file per language
+	SpellcheckDictDir string
+
+	// Account created on first start; its password must be changed on first login
+	AdminUsername string
+	AdminPassword string
+
+	// Sessions expire after this long without a request
+	SessionTTL time.Duration
+
+	// AUTH_MODE=cookie also accepts session cookies with CSRF tokens for browser frontends
+	AuthMode           string
+	CookieSecure       bool
+	CookieSameSite     string
+	CORSAllowedOrigins []string
+
+	// Reverse proxies trusted to report the client IP in ClientIPHeaders; other requests use the connection address
+	TrustedProxies  []string
+	ClientIPHeaders []string
+
+	// Failed logins allowed per username and per client IP before a lockout
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIP int
+	LoginLockout          time.Duration
+
+	// CRAWLER_MODE=mock returns synthetic crawl results without network access
+	CrawlerMode            string
+	CrawlerMockLatency     time.Duration
+	CrawlerMockFailPercent int
+
+	// Connection pooling and DNS caching of the crawler's HTTP transport
+	CrawlerMaxIdleConns        int
+	CrawlerMaxIdleConnsPerHost int
+	CrawlerIdleConnTimeout     time.Duration
+	CrawlerKeepAlives          bool
+	CrawlerDNSCacheTTL         time.Duration
+
+	// Resolver of the crawler: specific DNS servers or a DNS-over-HTTPS endpoint instead of the system resolver
+	CrawlerDNSServers []string
+	CrawlerDoHURL     string
+
+	// GitHub commit statuses of project crawls; FRONTEND_URL is linked from the statuses
+	GitHubAPIURL string
+	FrontendURL  string
+
+	// Message broker crawl events and findings are published to
+	EventsBroker             string
+	EventsBrokerURL          string
+	EventsTopicCrawlStarted  string
+	EventsTopicCrawlFinished string
+	EventsTopicFindings      string
+
+	// AES-GCM keys (id:base64) encrypting stored credentials, the first one encrypts new values
+	EncryptionKeys   []string
+	EncryptSnapshots bool
+
+	// Logical backups written to BackupDir, and uploaded to an S3 compatible bucket when one is set
+	BackupDir         string
+	BackupRetention   int
+	BackupInterval    time.Duration
+	BackupS3Endpoint  string
+	BackupS3Region    string
+	BackupS3Bucket    string
+	BackupS3Prefix    string
+	BackupS3AccessKey string
+	BackupS3SecretKey string
+
+	// Service answering with the IP address requests come from, used to report the crawler's egress IP
+	EgressIPCheckURL string
+
+	// Hosted mode: plans (name:urls:crawls_per_month:workers) limit what each user may crawl, and Stripe
+	// subscription webhooks move users between them; STRIPE_PRICE_PLANS maps prices to plans (price:plan)
+	BillingEnabled      bool
+	BillingPlans        []string
+	BillingDefaultPlan  string
+	StripeWebhookSecret string
+	StripePricePlans    []string
+
+	// Field name casing of JSON responses, snake or camel; clients override it with the X-JSON-Case header
+	JSONFieldCase string
 }
 
 func Load() *Config {
@@ -26,6 +119,72 @@ func Load() *Config {
 		DBPassword:  getEnv("DB_PASSWORD", ""),
 		DBName:      getEnv("DB_NAME", "sykell_url_analyzer"),
 		Environment: getEnv("ENVIRONMENT", "development"),
+
+		DBReplicaHost: getEnv("DB_REPLICA_HOST", ""),
+		DBReplicaPort: getEnv("DB_REPLICA_PORT", getEnv("DB_PORT", "3306")),
+
+		SlowQueryThreshold: time.Duration(getEnvInt("SLOW_QUERY_THRESHOLD_MS", 200)) * time.Millisecond,
+		SpellcheckDictDir:  getEnv("SPELLCHECK_DICT_DIR", "dictionaries"),
+		AdminUsername:      getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword:      getEnv("ADMIN_PASSWORD", "admin"),
+		SessionTTL:         time.Duration(getEnvInt("SESSION_TTL_HOURS", 24)) * time.Hour,
+
+		AuthMode:           getEnv("AUTH_MODE", "header"),
+		CookieSecure:       getEnv("COOKIE_SECURE", "true") == "true",
+		CookieSameSite:     getEnv("COOKIE_SAMESITE", "lax"),
+		CORSAllowedOrigins: strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173"), ","),
+
+		TrustedProxies:  splitList(getEnv("TRUSTED_PROXIES", "")),
+		ClientIPHeaders: splitList(getEnv("CLIENT_IP_HEADERS", "X-Forwarded-For,X-Real-IP")),
+
+		LoginMaxFailures:      getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxFailuresPerIP: getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginLockout:          time.Duration(getEnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+
+		CrawlerMode:            getEnv("CRAWLER_MODE", "live"),
+		CrawlerMockLatency:     time.Duration(getEnvInt("CRAWLER_MOCK_LATENCY_MS", 200)) * time.Millisecond,
+		CrawlerMockFailPercent: getEnvInt("CRAWLER_MOCK_FAILURE_PERCENT", 0),
+
+		CrawlerMaxIdleConns:        getEnvInt("CRAWLER_MAX_IDLE_CONNS", 100),
+		CrawlerMaxIdleConnsPerHost: getEnvInt("CRAWLER_MAX_IDLE_CONNS_PER_HOST", 10),
+		CrawlerIdleConnTimeout:     time.Duration(getEnvInt("CRAWLER_IDLE_CONN_TIMEOUT_SECONDS", 90)) * time.Second,
+		CrawlerKeepAlives:          getEnv("CRAWLER_KEEP_ALIVES", "true") == "true",
+		CrawlerDNSCacheTTL:         time.Duration(getEnvInt("CRAWLER_DNS_CACHE_SECONDS", 60)) * time.Second,
+
+		CrawlerDNSServers: splitList(getEnv("CRAWLER_DNS_SERVERS", "")),
+		CrawlerDoHURL:     getEnv("CRAWLER_DOH_URL", ""),
+
+		GitHubAPIURL: getEnv("GITHUB_API_URL", "https://api.github.com"),
+		FrontendURL:  getEnv("FRONTEND_URL", ""),
+
+		EventsBroker:             getEnv("EVENTS_BROKER", ""),
+		EventsBrokerURL:          getEnv("EVENTS_BROKER_URL", ""),
+		EventsTopicCrawlStarted:  getEnv("EVENTS_TOPIC_CRAWL_STARTED", "url-analyzer.crawl.started"),
+		EventsTopicCrawlFinished: getEnv("EVENTS_TOPIC_CRAWL_FINISHED", "url-analyzer.crawl.finished"),
+		EventsTopicFindings:      getEnv("EVENTS_TOPIC_FINDINGS", "url-analyzer.findings"),
+
+		EncryptionKeys:   encryptionKeys(),
+		EncryptSnapshots: getEnv("ENCRYPT_SNAPSHOTS", "false") == "true",
+
+		BackupDir:         getEnv("BACKUP_DIR", "backups"),
+		BackupRetention:   getEnvInt("BACKUP_RETENTION", 7),
+		BackupInterval:    time.Duration(getEnvInt("BACKUP_INTERVAL_HOURS", 0)) * time.Hour,
+		BackupS3Endpoint:  getEnv("BACKUP_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		BackupS3Region:    getEnv("BACKUP_S3_REGION", "us-east-1"),
+		BackupS3Bucket:    getEnv("BACKUP_S3_BUCKET", ""),
+		BackupS3Prefix:    getEnv("BACKUP_S3_PREFIX", ""),
+		BackupS3AccessKey: getEnv("BACKUP_S3_ACCESS_KEY", ""),
+		BackupS3SecretKey: getEnv("BACKUP_S3_SECRET_KEY", ""),
+
+		EgressIPCheckURL: getEnv("EGRESS_IP_CHECK_URL", "https://api.ipify.org"),
+
+		BillingEnabled:      getEnv("BILLING_ENABLED", "false") == "true",
+		BillingPlans:        splitList(getEnv("BILLING_PLANS", "free:10:100:1,pro:500:10000:5,business:5000:100000:20")),
+		BillingDefaultPlan:  getEnv("BILLING_DEFAULT_PLAN", "free"),
+		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripePricePlans:    splitList(getEnv("STRIPE_PRICE_PLANS", "")),
+
+		JSONFieldCase: getEnv("JSON_FIELD_CASE", "snake"),
 	}
 }
 
@@ -36,20 +195,83 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// encryptionKeys reads ENCRYPTION_KEYS, or the file named by ENCRYPTION_KEYS_FILE, where a secret manager
+// or KMS agent can place the keys; entries are separated by commas or newlines
+func encryptionKeys() []string {
+	keys := getEnv("ENCRYPTION_KEYS", "")
+	if file := getEnv("ENCRYPTION_KEYS_FILE", ""); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatal("Failed to read ENCRYPTION_KEYS_FILE:", err)
+		}
+		keys = strings.ReplaceAll(string(content), "\n", ",")
+	}
+	return splitList(keys)
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// InitDB connects to the primary database and registers the read replica when one is configured
+// The returned handle always uses the primary, so reads see preceding writes; repository.Store.Replica
+// opts single queries into the replica
 func InitDB(cfg *Config) *gorm.DB {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-	)
+	dsn := databaseDSN(cfg, cfg.DBHost, cfg.DBPort)
+
+	// Log slow queries and errors, but not every statement
+	dbLogger := logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             cfg.SlowQueryThreshold,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+	})
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError maps driver errors such as duplicate keys to gorm.ErrDuplicatedKey
+	// Timestamps are stored in UTC (loc=UTC above) so results compare correctly across server time zones
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		Logger:         dbLogger,
+		TranslateError: true,
+		NowFunc:        func() time.Time { return time.Now().UTC() },
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if cfg.DBReplicaHost != "" {
+		err := db.Use(dbresolver.Register(dbresolver.Config{
+			Replicas: []gorm.Dialector{mysql.Open(databaseDSN(cfg, cfg.DBReplicaHost, cfg.DBReplicaPort))},
+		}))
+		if err != nil {
+			log.Fatal("Failed to connect to read replica:", err)
+		}
+		log.Printf("Read replica registered at %s:%s", cfg.DBReplicaHost, cfg.DBReplicaPort)
+	}
+
 	log.Println("Database connection established")
-	return db
+	return db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+}
+
+// databaseDSN builds the MySQL DSN of the server at host:port
+func databaseDSN(cfg *Config, host, port string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+		cfg.DBUser,
+		cfg.DBPassword,
+		host,
+		port,
+		cfg.DBName,
+	)
 }
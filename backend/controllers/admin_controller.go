@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController handles operator endpoints such as runtime settings
+type AdminController struct {
+	store           repository.Store
+	settingsService *services.SettingsService
+	hostMetrics     *services.HostMetrics
+	transport       *services.HTTPTransport
+	seedService     *services.SeedService
+	reprocess       *services.ReprocessService
+	encryption      *services.EncryptionService
+	crawlerService  *services.CrawlerService
+	backups         *services.BackupService
+	identity        *services.CrawlerIdentityService
+	responseUtil    *utils.ResponseUtil
+}
+
+// NewAdminController creates a new instance of AdminController
+func NewAdminController(store repository.Store, settingsService *services.SettingsService, hostMetrics *services.HostMetrics, transport *services.HTTPTransport, seedService *services.SeedService, reprocess *services.ReprocessService, encryption *services.EncryptionService, crawlerService *services.CrawlerService, backups *services.BackupService, identity *services.CrawlerIdentityService) *AdminController {
+	return &AdminController{
+		store:           store,
+		settingsService: settingsService,
+		hostMetrics:     hostMetrics,
+		transport:       transport,
+		seedService:     seedService,
+		reprocess:       reprocess,
+		encryption:      encryption,
+		crawlerService:  crawlerService,
+		backups:         backups,
+		identity:        identity,
+		responseUtil:    utils.NewResponseUtil(),
+	}
+}
+
+// UpdateSettingsRequest represents the request body for updating runtime settings
+// Omitted fields keep their current value
+type UpdateSettingsRequest struct {
+	WorkerCount             *int    `json:"worker_count"`
+	MaxQueuedCrawls         *int    `json:"max_queued_crawls"`
+	SchedulingMode          *string `json:"scheduling_mode"`
+	PriorityAgingSeconds    *int    `json:"priority_aging_seconds"`
+	LinkCheckConcurrency    *int    `json:"link_check_concurrency"`
+	CrawlTimeoutSeconds     *int    `json:"crawl_timeout_seconds"`
+	LinkCheckTimeoutSeconds *int    `json:"link_check_timeout_seconds"`
+	LinkChecksPerSecond     *int    `json:"link_checks_per_second"`
+	UserAgent               *string `json:"user_agent"`
+	AcceptHeader            *string `json:"accept_header"`
+	CrawlerInfoURL          *string `json:"crawler_info_url"`
+	CompressionEnabled      *bool   `json:"compression_enabled"`
+	CompressionMinBytes     *int    `json:"compression_min_bytes"`
+	CompressionTypes        *string `json:"compression_types"`
+}
+
+// GetSettings handles GET /api/admin/settings - Returns the current runtime settings
+func (ac *AdminController) GetSettings(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.settingsService.Get(), "Settings retrieved successfully")
+}
+
+// UpdateSettings handles PUT /api/admin/settings - Persists and applies new runtime settings
+func (ac *AdminController) UpdateSettings(c *gin.Context) {
+	var request UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body")
+		return
+	}
+
+	// Merge the provided fields into the current settings
+	settings := ac.settingsService.Get()
+	if request.WorkerCount != nil {
+		settings.WorkerCount = *request.WorkerCount
+	}
+	if request.MaxQueuedCrawls != nil {
+		settings.MaxQueuedCrawls = *request.MaxQueuedCrawls
+	}
+	if request.SchedulingMode != nil {
+		settings.SchedulingMode = *request.SchedulingMode
+	}
+	if request.PriorityAgingSeconds != nil {
+		settings.PriorityAgingSeconds = *request.PriorityAgingSeconds
+	}
+	if request.LinkCheckConcurrency != nil {
+		settings.LinkCheckConcurrency = *request.LinkCheckConcurrency
+	}
+	if request.CrawlTimeoutSeconds != nil {
+		settings.CrawlTimeoutSeconds = *request.CrawlTimeoutSeconds
+	}
+	if request.LinkCheckTimeoutSeconds != nil {
+		settings.LinkCheckTimeoutSeconds = *request.LinkCheckTimeoutSeconds
+	}
+	if request.LinkChecksPerSecond != nil {
+		settings.LinkChecksPerSecond = *request.LinkChecksPerSecond
+	}
+	if request.UserAgent != nil {
+		settings.UserAgent = *request.UserAgent
+	}
+	if request.AcceptHeader != nil {
+		settings.AcceptHeader = *request.AcceptHeader
+	}
+	if request.CrawlerInfoURL != nil {
+		settings.CrawlerInfoURL = strings.TrimSpace(*request.CrawlerInfoURL)
+	}
+	if request.CompressionEnabled != nil {
+		settings.CompressionEnabled = *request.CompressionEnabled
+	}
+	if request.CompressionMinBytes != nil {
+		settings.CompressionMinBytes = *request.CompressionMinBytes
+	}
+	if request.CompressionTypes != nil {
+		settings.CompressionTypes = strings.TrimSpace(*request.CompressionTypes)
+	}
+
+	if err := services.ValidateSettings(settings); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid settings: %v", err))
+		return
+	}
+
+	updated, err := ac.settingsService.Update(settings)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update settings: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to update settings")
+		return
+	}
+
+	ac.responseUtil.Success(c, updated, "Settings updated successfully")
+}
+
+// GetHosts handles GET /api/admin/hosts - Returns request statistics per crawled host
+func (ac *AdminController) GetHosts(c *gin.Context) {
+	ac.responseUtil.Success(c, map[string]interface{}{
+		"hosts": ac.hostMetrics.Snapshot(),
+	}, "Host metrics retrieved successfully")
+}
+
+// GetUsage handles GET /api/admin/usage - Returns the requests, bytes downloaded, and wall time of the crawls
+// month by month, split by project and by the user who added the URL, for capacity planning and billing
+func (ac *AdminController) GetUsage(c *gin.Context) {
+	months, err := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if err == nil {
+		err = services.ValidateUsageMonths(months)
+	}
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "months must be between 1 and 60")
+		return
+	}
+
+	// Start at the beginning of the month, so the oldest month isn't cut short
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	usage, err := ac.store.Replica().CrawlResults().CrawlUsage(since)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve crawl usage: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve usage")
+		return
+	}
+
+	ac.responseUtil.Success(c, services.BuildUsageReport(since, usage), "Usage retrieved successfully")
+}
+
+// GetTransport handles GET /api/admin/transport - Returns the crawler transport settings and connection reuse statistics
+func (ac *AdminController) GetTransport(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.transport.Stats(), "Transport statistics retrieved successfully")
+}
+
+// GetCrawlerIdentity handles GET /api/admin/crawler-identity - Returns the User-Agent and egress IP of the crawler
+// Site owners need them to allow the crawler through firewalls and bot protection
+func (ac *AdminController) GetCrawlerIdentity(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.identity.Identity(), "Crawler identity retrieved successfully")
+}
+
+// SeedDemoData handles POST /api/admin/seed - Fills the database with demo URLs and crawl results
+// The route is only registered outside production
+func (ac *AdminController) SeedDemoData(c *gin.Context) {
+	result, err := ac.seedService.SeedDemoData()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to seed demo data: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to seed demo data")
+		return
+	}
+
+	ac.responseUtil.Success(c, result, fmt.Sprintf("Seeded %d demo URL(s)", len(result.Created)))
+}
+
+// ReprocessRequest selects the URLs and analyzers of a reprocess job; empty filters match every URL
+type ReprocessRequest struct {
+	IDs       []uint   `json:"ids"`
+	ProjectID *uint    `json:"project_id"`
+	Tag       string   `json:"tag"`
+	Status    string   `json:"status"`
+	Outdated  bool     `json:"outdated"`  // Only URLs whose latest crawl predates the current analyzer version
+	Analyzers []string `json:"analyzers"` // Empty re-runs every analyzer
+}
+
+// Reprocess handles POST /api/admin/reprocess - Re-runs analyzers over the stored crawls of the matching URLs
+// URLs without a page snapshot are crawled again; progress is tracked as a batch job
+func (ac *AdminController) Reprocess(c *gin.Context) {
+	var request ReprocessRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body")
+		return
+	}
+	if err := services.ValidateAnalyzers(request.Analyzers); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	job, err := ac.reprocess.Start(services.ReprocessFilter{
+		IDs:       request.IDs,
+		ProjectID: request.ProjectID,
+		Tag:       request.Tag,
+		Status:    request.Status,
+		Outdated:  request.Outdated,
+	}, request.Analyzers)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to start reprocessing: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to start reprocessing")
+		return
+	}
+	if job == nil {
+		ac.responseUtil.Success(c, map[string]interface{}{"job_id": nil, "total": 0}, "No URL matches the filter")
+		return
+	}
+
+	ac.responseUtil.Accepted(c, map[string]interface{}{
+		"job_id":           job.ID,
+		"total":            job.Total,
+		"analyzer_version": services.AnalyzerVersion,
+	}, fmt.Sprintf("Reprocessing %d URL(s)", job.Total))
+}
+
+// GetEncryption handles GET /api/admin/encryption - Returns the IDs of the keys encrypting sensitive columns
+func (ac *AdminController) GetEncryption(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.encryption.Status(), "Encryption status retrieved successfully")
+}
+
+// ReencryptFields handles POST /api/admin/encryption/reencrypt - Rewrites encrypted columns with the active key
+// Run it after adding a new first key to ENCRYPTION_KEYS, then the old keys can be removed
+func (ac *AdminController) ReencryptFields(c *gin.Context) {
+	result, err := ac.encryption.Reencrypt()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to re-encrypt: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to re-encrypt stored values")
+		return
+	}
+
+	ac.responseUtil.Success(c, result, fmt.Sprintf("Re-encrypted %d project(s) and %d snapshot(s)", result.Projects, result.Snapshots))
+}
+
+// MaintenanceRequest represents the request body for switching maintenance mode
+type MaintenanceRequest struct {
+	Enabled *bool   `json:"enabled" binding:"required"`
+	Message *string `json:"message"` // Omitted keeps the current message, empty uses the default one
+}
+
+// GetMaintenance handles GET /api/admin/maintenance - Returns the maintenance mode and the crawls still draining
+func (ac *AdminController) GetMaintenance(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.maintenanceStatus(ac.settingsService.Get()), "Maintenance status retrieved successfully")
+}
+
+// SetMaintenance handles PUT /api/admin/maintenance - Switches maintenance mode on or off
+func (ac *AdminController) SetMaintenance(c *gin.Context) {
+	var request MaintenanceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: enabled is required")
+		return
+	}
+
+	settings := ac.settingsService.Get()
+	settings.MaintenanceMode = *request.Enabled
+	if request.Message != nil {
+		settings.MaintenanceMessage = strings.TrimSpace(*request.Message)
+	}
+	if len(settings.MaintenanceMessage) > 255 {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "message must be at most 255 characters")
+		return
+	}
+
+	updated, err := ac.settingsService.Update(settings)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update maintenance mode: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to update maintenance mode")
+		return
+	}
+	utils.AppLogger.Info(fmt.Sprintf("audit: maintenance mode set to %t by user=%q", updated.MaintenanceMode, c.GetString(middleware.ContextUserKey)))
+
+	message := "Maintenance mode disabled"
+	if updated.MaintenanceMode {
+		message = "Maintenance mode enabled"
+	}
+	ac.responseUtil.Success(c, ac.maintenanceStatus(updated), message)
+}
+
+// CrawlingRequest represents the request body for pausing or resuming crawling
+type CrawlingRequest struct {
+	Paused *bool `json:"paused" binding:"required"`
+}
+
+// GetCrawling handles GET /api/admin/crawling - Reports whether crawling is paused and the crawls still running
+func (ac *AdminController) GetCrawling(c *gin.Context) {
+	ac.responseUtil.Success(c, ac.crawlingStatus(ac.settingsService.Get()), "Crawling status retrieved successfully")
+}
+
+// SetCrawling handles PUT /api/admin/crawling - Pauses or resumes every crawl
+// Pausing lets running crawls finish and holds queued ones, which start when crawling is resumed
+func (ac *AdminController) SetCrawling(c *gin.Context) {
+	var request CrawlingRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: paused is required")
+		return
+	}
+
+	settings := ac.settingsService.Get()
+	settings.CrawlingPaused = *request.Paused
+	updated, err := ac.settingsService.Update(settings)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update crawling status: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to update crawling status")
+		return
+	}
+	utils.AppLogger.Info(fmt.Sprintf("audit: crawling paused set to %t by user=%q", updated.CrawlingPaused, c.GetString(middleware.ContextUserKey)))
+
+	message := "Crawling resumed"
+	if updated.CrawlingPaused {
+		message = "Crawling paused"
+	}
+	ac.responseUtil.Success(c, ac.crawlingStatus(updated), message)
+}
+
+// crawlingStatus reports whether crawling is paused together with the crawls that are still running or held
+func (ac *AdminController) crawlingStatus(settings models.Settings) map[string]interface{} {
+	queue := ac.crawlerService.QueueStats()
+	return map[string]interface{}{
+		"paused": settings.CrawlingPaused,
+		"queue":  queue,
+		"idle":   queue.Running == 0,
+	}
+}
+
+// maintenanceStatus reports the mode together with the crawls that are still running, waiting for a worker, or deferred
+func (ac *AdminController) maintenanceStatus(settings models.Settings) map[string]interface{} {
+	queue := ac.crawlerService.QueueStats()
+	return map[string]interface{}{
+		"maintenance_mode": settings.MaintenanceMode,
+		"message":          settings.MaintenanceMessage,
+		"queue":            queue,
+		"drained":          queue.Running == 0 && queue.Queued == 0 && queue.Deferred == 0,
+	}
+}
+
+// GetBackups handles GET /api/admin/backups - Lists the local backup files, newest first
+func (ac *AdminController) GetBackups(c *gin.Context) {
+	backups, err := ac.backups.List()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to list backups: %v", err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to list backups")
+		return
+	}
+
+	ac.responseUtil.Success(c, backups, "Backups retrieved successfully")
+}
+
+// CreateBackup handles POST /api/admin/backups - Writes a logical backup of every table
+// The backup runs in the request, which takes a while on large databases
+func (ac *AdminController) CreateBackup(c *gin.Context) {
+	info, err := ac.backups.Backup()
+	if errors.Is(err, services.ErrBackupInProgress) {
+		ac.responseUtil.Conflict(c, utils.ErrCodeBackupInProgress, "A backup is already in progress", nil)
+		return
+	}
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Backup failed: %v", err))
+		if info != nil {
+			// The local file is complete, only the upload failed
+			c.JSON(http.StatusBadGateway, utils.APIResponse{
+				Success: false,
+				Data:    info,
+				Error:   utils.Localize(c, "Backup was written but could not be uploaded to object storage"),
+				Code:    utils.ErrCodeBackupUploadFailed,
+			})
+			return
+		}
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to write backup")
+		return
+	}
+	utils.AppLogger.Info(fmt.Sprintf("audit: backup %s written by user=%q", info.Name, c.GetString(middleware.ContextUserKey)))
+
+	ac.responseUtil.Created(c, info, fmt.Sprintf("Backup %s written (%d rows)", info.Name, info.Rows))
+}
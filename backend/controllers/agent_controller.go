@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AgentController handles the fetch agents crawls of other regions go through
+type AgentController struct {
+	agents       *services.FetchAgentService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewAgentController creates a new instance of AgentController
+func NewAgentController(agents *services.FetchAgentService) *AgentController {
+	return &AgentController{
+		agents:       agents,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// FetchAgentRequest represents the request body for registering the fetch agent of a region
+type FetchAgentRequest struct {
+	Region   string `json:"region" binding:"required"`
+	ProxyURL string `json:"proxy_url" binding:"required"`
+	Token    string `json:"token"`
+}
+
+// GetAgents handles GET /api/admin/agents - Lists the fetch agents
+func (ac *AgentController) GetAgents(c *gin.Context) {
+	agents, err := ac.agents.List()
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve fetch agents")
+		return
+	}
+
+	ac.responseUtil.Success(c, agents, "Fetch agents retrieved successfully")
+}
+
+// RegisterAgent handles POST /api/admin/agents - Registers the fetch agent of a region
+// Registering a region again replaces its proxy URL and token
+func (ac *AgentController) RegisterAgent(c *gin.Context) {
+	var request FetchAgentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: region and proxy_url are required")
+		return
+	}
+
+	agent := models.FetchAgent{
+		Region:    strings.TrimSpace(request.Region),
+		ProxyURL:  strings.TrimSpace(request.ProxyURL),
+		Token:     strings.TrimSpace(request.Token),
+		CreatedBy: c.GetString(middleware.ContextUserKey),
+	}
+	if err := services.ValidateFetchAgent(agent); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	if err := ac.agents.Register(&agent); err != nil {
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to register fetch agent")
+		return
+	}
+
+	ac.responseUtil.Success(c, agent, "Fetch agent registered successfully")
+}
+
+// DeleteAgent handles DELETE /api/admin/agents/:id - Removes a fetch agent
+func (ac *AgentController) DeleteAgent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid fetch agent ID")
+		return
+	}
+
+	if err := ac.agents.Delete(uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ac.responseUtil.NotFound(c, utils.ErrCodeFetchAgentNotFound, "Fetch agent not found")
+			return
+		}
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete fetch agent")
+		return
+	}
+
+	ac.responseUtil.Success(c, nil, "Fetch agent deleted successfully")
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAnalyzeHTMLBytes = 5 << 20             // Largest HTML document accepted by POST /api/analyze
+	defaultAnalyzeBase  = "http://localhost/" // Base URL of submitted HTML when none is given
+)
+
+// AnalyzeController handles analysis of submitted HTML that is not fetched from the web
+type AnalyzeController struct {
+	store          repository.Store
+	crawlerService *services.CrawlerService
+	responseUtil   *utils.ResponseUtil
+}
+
+// NewAnalyzeController creates a new instance of AnalyzeController
+func NewAnalyzeController(store repository.Store, crawlerService *services.CrawlerService) *AnalyzeController {
+	return &AnalyzeController{
+		store:          store,
+		crawlerService: crawlerService,
+		responseUtil:   utils.NewResponseUtil(),
+	}
+}
+
+// AnalyzeRequest represents the JSON request body of POST /api/analyze
+// Multipart uploads send the same fields as form values and the document as the "file" part
+type AnalyzeRequest struct {
+	HTML       string `json:"html" form:"html"`
+	BaseURL    string `json:"base_url" form:"base_url"` // URL the page will be served at
+	CheckLinks bool   `json:"check_links" form:"check_links"`
+	SpellCheck bool   `json:"spell_check" form:"spell_check"`
+	ProjectID  *uint  `json:"project_id" form:"project_id"`
+}
+
+// Analyze handles POST /api/analyze - Runs the analyzers on submitted HTML and returns the crawl result without storing it
+func (ac *AnalyzeController) Analyze(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAnalyzeHTMLBytes+64<<10)
+
+	var request AnalyzeRequest
+	content, ok := ac.readDocument(c, &request)
+	if !ok {
+		return
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: html or file is required")
+		return
+	}
+
+	if request.CheckLinks && ac.crawlerService.Paused() {
+		ac.responseUtil.Error(c, http.StatusServiceUnavailable, utils.ErrCodeCrawlingPaused, "Crawling is paused, retry later")
+		return
+	}
+
+	options := services.AnalyzeOptions{
+		CheckLinks: request.CheckLinks,
+		SpellCheck: request.SpellCheck,
+	}
+	if options.BaseURL, ok = parseBaseURL(request.BaseURL); !ok {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "base_url must be an absolute http or https URL")
+		return
+	}
+
+	if request.ProjectID != nil {
+		if options.Project, ok = loadRuleProject(c, ac.store, ac.responseUtil, *request.ProjectID); !ok {
+			return
+		}
+	}
+
+	result, err := ac.crawlerService.AnalyzeHTML(content, options)
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	ac.responseUtil.Success(c, result, "HTML analyzed successfully")
+}
+
+// readDocument binds the request and returns the submitted HTML, from the "file" upload or the html field
+func (ac *AnalyzeController) readDocument(c *gin.Context, request *AnalyzeRequest) ([]byte, bool) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.ShouldBindJSON(request); err != nil {
+			ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: "+utils.BindingError(err))
+			return nil, false
+		}
+		if len(request.HTML) > maxAnalyzeHTMLBytes {
+			ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("HTML must be at most %d bytes", maxAnalyzeHTMLBytes))
+			return nil, false
+		}
+		return []byte(request.HTML), true
+	}
+
+	if err := c.ShouldBind(request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid form data: "+utils.BindingError(err))
+		return nil, false
+	}
+	header, err := c.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		return []byte(request.HTML), true
+	}
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid file upload: %v", err))
+		return nil, false
+	}
+	if header.Size > maxAnalyzeHTMLBytes {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("file must be at most %d bytes", maxAnalyzeHTMLBytes))
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid file upload: %v", err))
+		return nil, false
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid file upload: %v", err))
+		return nil, false
+	}
+	return content, true
+}
+
+// parseBaseURL validates the URL submitted HTML will be served at, defaulting to defaultAnalyzeBase
+func parseBaseURL(raw string) (string, bool) {
+	if raw == "" {
+		return defaultAnalyzeBase, true
+	}
+	base, err := url.Parse(raw)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", false
+	}
+	return base.String(), true
+}
+
+// loadRuleProject loads the project whose rules an analysis is checked against, responding when it doesn't exist
+func loadRuleProject(c *gin.Context, store repository.Store, responseUtil *utils.ResponseUtil, projectID uint) (*models.Project, bool) {
+	project, err := store.Projects().Get(projectID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			responseUtil.BadRequest(c, utils.ErrCodeProjectNotFound, "Project not found")
+			return nil, false
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve project %d: %v", projectID, err))
+		responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve project")
+		return nil, false
+	}
+	return &project, true
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AnnotationController handles the notes and triage statuses of the findings and links of a URL
+type AnnotationController struct {
+	store        repository.Store
+	annotations  *services.AnnotationService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewAnnotationController creates a new instance of AnnotationController
+func NewAnnotationController(store repository.Store, annotations *services.AnnotationService) *AnnotationController {
+	return &AnnotationController{
+		store:        store,
+		annotations:  annotations,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// AnnotationRequest represents the request body for annotating a finding or a link
+// Exactly one of finding_id, a finding of the latest crawl, and link_url must be given
+type AnnotationRequest struct {
+	FindingID *uint  `json:"finding_id"`
+	LinkURL   string `json:"link_url"`
+	Status    string `json:"status" binding:"required"`
+	Note      string `json:"note"`
+}
+
+// GetAnnotations handles GET /api/urls/:id/annotations - Lists the annotations of a URL
+func (ac *AnnotationController) GetAnnotations(c *gin.Context) {
+	url, ok := ac.findURL(c)
+	if !ok {
+		return
+	}
+
+	annotations, err := ac.annotations.List(url.ID)
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve annotations")
+		return
+	}
+
+	ac.responseUtil.Success(c, annotations, "Annotations retrieved successfully")
+}
+
+// SaveAnnotation handles PUT /api/urls/:id/annotations - Sets the note and triage status of a finding or link
+// Annotating the same finding or link again replaces its note and status
+func (ac *AnnotationController) SaveAnnotation(c *gin.Context) {
+	url, ok := ac.findURL(c)
+	if !ok {
+		return
+	}
+
+	var request AnnotationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: status is required")
+		return
+	}
+	request.LinkURL = strings.TrimSpace(request.LinkURL)
+	if (request.FindingID == nil) == (request.LinkURL == "") {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Either finding_id or link_url is required")
+		return
+	}
+
+	annotation := models.Annotation{
+		URLID:     url.ID,
+		Status:    request.Status,
+		Note:      strings.TrimSpace(request.Note),
+		UpdatedBy: c.GetString(middleware.ContextUserKey),
+	}
+	if err := services.ValidateAnnotation(annotation); err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid annotation: %v", err))
+		return
+	}
+
+	if request.FindingID != nil {
+		finding, ok := ac.latestFinding(c, url.ID, *request.FindingID)
+		if !ok {
+			return
+		}
+		annotation.Kind = models.AnnotationFinding
+		annotation.Target = services.FindingTarget(finding)
+	} else {
+		annotation.Kind = models.AnnotationLink
+		annotation.Target = services.LinkTarget(request.LinkURL)
+	}
+
+	if err := ac.annotations.Save(&annotation); err != nil {
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save annotation")
+		return
+	}
+
+	ac.responseUtil.Success(c, annotation, "Annotation saved successfully")
+}
+
+// DeleteAnnotation handles DELETE /api/urls/:id/annotations/:annotationId - Removes an annotation
+func (ac *AnnotationController) DeleteAnnotation(c *gin.Context) {
+	url, ok := ac.findURL(c)
+	if !ok {
+		return
+	}
+	annotationID, err := strconv.ParseUint(c.Param("annotationId"), 10, 32)
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid annotation ID")
+		return
+	}
+
+	if err := ac.annotations.Delete(url.ID, uint(annotationID)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ac.responseUtil.NotFound(c, utils.ErrCodeAnnotationNotFound, "Annotation not found")
+			return
+		}
+		utils.AppLogger.Error(err.Error())
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete annotation")
+		return
+	}
+
+	ac.responseUtil.Success(c, nil, "Annotation deleted successfully")
+}
+
+// findURL loads the URL of the :id path parameter, answering 400 or 404 when that fails
+func (ac *AnnotationController) findURL(c *gin.Context) (models.URL, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		ac.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid URL ID")
+		return models.URL{}, false
+	}
+	url, err := ac.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			ac.responseUtil.NotFound(c, utils.ErrCodeURLNotFound, "URL not found")
+			return models.URL{}, false
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve URL %d: %v", id, err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URL")
+		return models.URL{}, false
+	}
+	return url, true
+}
+
+// latestFinding returns a finding of the URL's latest crawl, answering 404 when it has no such finding
+func (ac *AnnotationController) latestFinding(c *gin.Context, urlID, findingID uint) (models.Finding, bool) {
+	resultID, err := ac.store.CrawlResults().LatestID(urlID)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve the latest crawl of URL %d: %v", urlID, err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve crawl results")
+		return models.Finding{}, false
+	}
+	findings, err := ac.store.CrawlResults().Findings(resultID, repository.FindingFilter{})
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve the findings of crawl %d: %v", resultID, err))
+		ac.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve findings")
+		return models.Finding{}, false
+	}
+	for _, finding := range findings {
+		if finding.ID == findingID {
+			return finding, true
+		}
+	}
+	ac.responseUtil.NotFound(c, utils.ErrCodeFindingNotFound, "Finding not found in the latest crawl")
+	return models.Finding{}, false
+}
@@ -1,19 +1,35 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthController handles authentication endpoints
-type AuthController struct{}
+type AuthController struct {
+	users    *services.UserService
+	sessions *services.SessionService
+	limiter  *services.LoginLimiter
+	cookies  middleware.CookieConfig
+}
 
 // NewAuthController creates a new auth controller instance
-func NewAuthController() *AuthController {
-	return &AuthController{}
+func NewAuthController(users *services.UserService, sessions *services.SessionService, limiter *services.LoginLimiter, cookies middleware.CookieConfig) *AuthController {
+	return &AuthController{
+		users:    users,
+		sessions: sessions,
+		limiter:  limiter,
+		cookies:  cookies,
+	}
 }
 
 // LoginRequest represents the login request payload
@@ -26,40 +42,164 @@ type LoginRequest struct {
 func (ac *AuthController) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.Localize(c, "Invalid request format"), "code": utils.ErrCodeValidationFailed})
 		return
 	}
 
-	token, success := middleware.Login(req.Username, req.Password)
-	if !success {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	ip := c.ClientIP()
+
+	// Refuse attempts from locked IPs and for locked usernames without checking the password
+	attempt, retryAfter, allowed := ac.limiter.Allow(ip, req.Username)
+	if !allowed {
+		utils.AppLogger.Info(fmt.Sprintf("audit: login blocked user=%q ip=%s retry_after=%s", req.Username, ip, retryAfter.Round(time.Second)))
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": utils.Localize(c, "Too many failed login attempts, try again later"), "code": utils.ErrCodeTooManyAttempts})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-	})
+	user, err := ac.users.Authenticate(req.Username, req.Password)
+	if err != nil && err != services.ErrInvalidCredentials {
+		attempt.Cancel()
+		utils.AppLogger.Error(fmt.Sprintf("Failed to authenticate user: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.Localize(c, "Failed to log in"), "code": utils.ErrCodeInternalError})
+		return
+	}
+	if err != nil {
+		locked := attempt.Failed()
+		utils.AppLogger.Info(fmt.Sprintf("audit: login failed user=%q ip=%s locked=%t", req.Username, ip, locked))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.Localize(c, "Invalid credentials"), "code": utils.ErrCodeInvalidCredentials})
+		return
+	}
+
+	attempt.Succeeded()
+	utils.AppLogger.Info(fmt.Sprintf("audit: login succeeded user=%q ip=%s", req.Username, ip))
+
+	token, _, err := ac.sessions.Create(user.Username, ip, c.Request.UserAgent(), user.MustChangePassword)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to create session: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.Localize(c, "Failed to create session"), "code": utils.ErrCodeInternalError})
+		return
+	}
+
+	response := gin.H{
+		"message":              utils.Localize(c, "Login successful"),
+		"token":                token,
+		"must_change_password": user.MustChangePassword,
+	}
+
+	// Browser frontends in cookie mode send the CSRF token back in the X-CSRF-Token header
+	if ac.cookies.Enabled {
+		middleware.SetSessionCookies(c, ac.cookies, token)
+		response["csrf_token"] = middleware.CSRFToken(token)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ChangePasswordRequest represents the change password request payload
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword handles POST /api/auth/change-password - Replaces the password and ends all other sessions
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.Localize(c, "Invalid request format"), "code": utils.ErrCodeValidationFailed})
+		return
+	}
+
+	username := c.GetString(middleware.ContextUserKey)
+	if err := ac.users.ChangePassword(username, req.CurrentPassword, req.NewPassword); err != nil {
+		var policyErr *services.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": utils.Localize(c, policyErr.Error()), "code": utils.ErrCodeValidationFailed})
+			return
+		}
+		if err == services.ErrInvalidCredentials {
+			utils.AppLogger.Info(fmt.Sprintf("audit: password change failed user=%q ip=%s", username, c.ClientIP()))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": utils.Localize(c, "Current password is wrong"), "code": utils.ErrCodeInvalidCredentials})
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to change password: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.Localize(c, "Failed to change password"), "code": utils.ErrCodeInternalError})
+		return
+	}
+
+	if err := ac.sessions.PasswordChanged(username, c.GetUint(middleware.ContextSessionKey)); err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update sessions after password change: %v", err))
+	}
+	utils.AppLogger.Info(fmt.Sprintf("audit: password changed user=%q ip=%s", username, c.ClientIP()))
+
+	c.JSON(http.StatusOK, gin.H{"message": utils.Localize(c, "Password changed")})
 }
 
 // Logout handles user logout
 func (ac *AuthController) Logout(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" {
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 {
-			token := parts[1]
-			middleware.Logout(token)
+	if token := middleware.SessionToken(c, ac.cookies); token != "" {
+		if err := ac.sessions.Revoke(token); err != nil {
+			utils.AppLogger.Error(fmt.Sprintf("Failed to revoke session: %v", err))
 		}
 	}
+	if ac.cookies.Enabled {
+		middleware.ClearSessionCookies(c, ac.cookies)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
+	c.JSON(http.StatusOK, gin.H{"message": utils.Localize(c, "Logout successful")})
 }
 
 // Me returns current user info (for testing authentication)
 func (ac *AuthController) Me(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"username": "admin",
-		"message":  "Authentication successful",
+		"username": c.GetString(middleware.ContextUserKey),
+		"message":  utils.Localize(c, "Authentication successful"),
 	})
 }
+
+// GetSessions handles GET /api/auth/sessions - Lists the active sessions of the current user
+func (ac *AuthController) GetSessions(c *gin.Context) {
+	sessions, err := ac.sessions.List(c.GetString(middleware.ContextUserKey))
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to list sessions: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.Localize(c, "Failed to list sessions"), "code": utils.ErrCodeInternalError})
+		return
+	}
+
+	currentID := c.GetUint(middleware.ContextSessionKey)
+	entries := make([]map[string]interface{}, 0, len(sessions))
+	for _, session := range sessions {
+		entries = append(entries, map[string]interface{}{
+			"id":           session.ID,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": entries})
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/:id - Ends one of the current user's sessions
+func (ac *AuthController) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": utils.Localize(c, "Invalid session ID"), "code": utils.ErrCodeInvalidID})
+		return
+	}
+
+	if err := ac.sessions.RevokeID(c.GetString(middleware.ContextUserKey), uint(id)); err != nil {
+		if err == services.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": utils.Localize(c, "Session not found"), "code": utils.ErrCodeSessionNotFound})
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to revoke session %d: %v", id, err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": utils.Localize(c, "Failed to revoke session"), "code": utils.ErrCodeInternalError})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": utils.Localize(c, "Session revoked")})
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// BatchRequest represents the request body shared by all batch URL operations
+type BatchRequest struct {
+	IDs []BatchID `json:"ids" binding:"required"`
+}
+
+func (r *BatchRequest) batchIDs() []BatchID {
+	return r.IDs
+}
+
+// BatchTagRequest represents the request body for adding and removing tags of many URLs
+type BatchTagRequest struct {
+	BatchRequest
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"` // Applied after add, so a tag in both lists is removed
+}
+
+// BatchConfigRequest represents the request body for changing the crawl settings of many URLs
+// Omitted fields keep each URL's current value
+type BatchConfigRequest struct {
+	BatchRequest
+	CrawlConfig    CrawlConfigChanges `json:"crawl_config"`
+	MonitorEnabled *bool              `json:"monitor_enabled"` // Include the URLs in their project's scheduled runs
+}
+
+// CrawlConfigChanges holds the crawl config fields a batch update sets; nil fields are left alone
+type CrawlConfigChanges struct {
+	TimeoutSeconds    *int    `json:"timeout_seconds"`
+	UserAgent         *string `json:"user_agent"`
+	MaxPages          *int    `json:"max_pages"`
+	MaxDepth          *int    `json:"max_depth"`
+	SpellCheck        *bool   `json:"spell_check"`
+	Priority          *int    `json:"priority"`
+	RotateUserAgents  *bool   `json:"rotate_user_agents"`
+	FollowMetaRefresh *bool   `json:"follow_meta_refresh"`
+	IncludeFrames     *bool   `json:"include_frames"`
+
+	Scope    *models.LinkScope `json:"scope"`
+	Regions  *[]string         `json:"regions"`
+	Profiles *[]string         `json:"profiles"`
+}
+
+// empty reports whether no field is set
+func (ch CrawlConfigChanges) empty() bool {
+	return ch == CrawlConfigChanges{}
+}
+
+// apply sets the changed fields on config
+func (ch CrawlConfigChanges) apply(config *models.CrawlConfig) {
+	if ch.TimeoutSeconds != nil {
+		config.TimeoutSeconds = *ch.TimeoutSeconds
+	}
+	if ch.UserAgent != nil {
+		config.UserAgent = *ch.UserAgent
+	}
+	if ch.MaxPages != nil {
+		config.MaxPages = *ch.MaxPages
+	}
+	if ch.MaxDepth != nil {
+		config.MaxDepth = *ch.MaxDepth
+	}
+	if ch.SpellCheck != nil {
+		config.SpellCheck = *ch.SpellCheck
+	}
+	if ch.Priority != nil {
+		config.Priority = *ch.Priority
+	}
+	if ch.RotateUserAgents != nil {
+		config.RotateUserAgents = *ch.RotateUserAgents
+	}
+	if ch.Scope != nil {
+		config.Scope = ch.Scope
+	}
+	if ch.Regions != nil {
+		config.Regions = *ch.Regions
+	}
+	if ch.Profiles != nil {
+		config.Profiles = *ch.Profiles
+	}
+	if ch.FollowMetaRefresh != nil {
+		config.FollowMetaRefresh = *ch.FollowMetaRefresh
+	}
+	if ch.IncludeFrames != nil {
+		config.IncludeFrames = *ch.IncludeFrames
+	}
+}
+
+// batchPayload is a batch request body carrying the IDs it applies to
+type batchPayload interface {
+	batchIDs() []BatchID
+}
+
+// BatchID is a URL ID in a batch payload, accepted as either a JSON number or a string
+// The original value is kept so per-ID errors can be reported exactly as the client sent it
+type BatchID struct {
+	Raw string
+}
+
+// UnmarshalJSON accepts "42" as well as 42; any other JSON value is kept verbatim and reported as invalid later
+func (b *BatchID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		b.Raw = str
+		return nil
+	}
+
+	b.Raw = string(data)
+	return nil
+}
+
+// batchIDError is the error of one entry of the ids of a batch request
+// Entries are reported by position, so a duplicated ID and its first occurrence each get their own error
+type batchIDError struct {
+	Index int    `json:"index"` // Position in ids
+	ID    string `json:"id"`    // As the client sent it
+	Error string `json:"error"`
+}
+
+// batchIDSet holds the parsed IDs of a batch request and the per-ID errors found while processing it
+type batchIDSet struct {
+	ids      []uint
+	original map[uint]string
+	position map[uint]int // Index of the entry an ID was parsed from
+	errors   []string
+	idErrors []batchIDError
+}
+
+// parseBatchIDs parses the requested IDs, rejecting invalid and duplicate values
+func parseBatchIDs(rawIDs []BatchID) *batchIDSet {
+	set := &batchIDSet{
+		original: make(map[uint]string),
+		position: make(map[uint]int),
+		idErrors: []batchIDError{},
+	}
+
+	for index, rawID := range rawIDs {
+		id, err := strconv.ParseUint(rawID.Raw, 10, 32)
+		if err != nil {
+			set.fail(index, rawID.Raw, "Invalid ID")
+			continue
+		}
+		if _, seen := set.original[uint(id)]; seen {
+			set.fail(index, rawID.Raw, "Duplicate ID")
+			continue
+		}
+		set.original[uint(id)] = rawID.Raw
+		set.position[uint(id)] = index
+		set.ids = append(set.ids, uint(id))
+	}
+
+	return set
+}
+
+// fail records an error for the entry at index that was sent as original
+func (s *batchIDSet) fail(index int, original, message string) {
+	s.errors = append(s.errors, fmt.Sprintf("%s: %s", message, original))
+	s.idErrors = append(s.idErrors, batchIDError{Index: index, ID: original, Error: message})
+}
+
+// failID records an error for a parsed ID at the entry it was parsed from
+func (s *batchIDSet) failID(id uint, message string) {
+	s.fail(s.position[id], s.original[id], message)
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseBatchIDs(t *testing.T) {
+	var request struct {
+		IDs []BatchID `json:"ids"`
+	}
+	if err := json.Unmarshal([]byte(`{"ids": [42, "42", "7", "x", 7, true]}`), &request); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	// "42" and 42 are the same ID, and every rejected entry gets its own error
+	set := parseBatchIDs(request.IDs)
+	set.failID(7, "URL not found")
+	if !reflect.DeepEqual(set.ids, []uint{42, 7}) {
+		t.Errorf("ids = %v, want [42 7]", set.ids)
+	}
+	want := []batchIDError{
+		{Index: 1, ID: "42", Error: "Duplicate ID"},
+		{Index: 3, ID: "x", Error: "Invalid ID"},
+		{Index: 4, ID: "7", Error: "Duplicate ID"},
+		{Index: 5, ID: "true", Error: "Invalid ID"},
+		{Index: 2, ID: "7", Error: "URL not found"},
+	}
+	if !reflect.DeepEqual(set.idErrors, want) {
+		t.Errorf("idErrors = %+v, want %+v", set.idErrors, want)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBytes bounds the body of a Stripe webhook; events are a few kilobytes
+const maxWebhookBytes = 1 << 20
+
+// BillingController handles the plans of hosted mode and the Stripe webhooks that change them
+type BillingController struct {
+	billing      *services.BillingService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewBillingController creates a new instance of BillingController
+func NewBillingController(billing *services.BillingService) *BillingController {
+	return &BillingController{
+		billing:      billing,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// GetPlan handles GET /api/billing/plan - Returns the plan of the signed-in user, its limits, and their usage
+func (bc *BillingController) GetPlan(c *gin.Context) {
+	entitlement, err := bc.billing.Entitlement(c.GetString(middleware.ContextUserKey))
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		bc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve plan")
+		return
+	}
+
+	bc.responseUtil.Success(c, entitlement, "Plan retrieved successfully")
+}
+
+// StripeWebhook handles POST /api/billing/stripe/webhook - Applies subscription events sent by Stripe
+// Requests are authenticated by their Stripe-Signature header rather than a session
+func (bc *BillingController) StripeWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		bc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body")
+		return
+	}
+
+	err = bc.billing.HandleStripeWebhook(payload, c.GetHeader("Stripe-Signature"))
+	if errors.Is(err, services.ErrInvalidSignature) {
+		bc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid webhook signature")
+		return
+	}
+	if err != nil {
+		// Stripe retries events that weren't acknowledged
+		utils.AppLogger.Error(fmt.Sprintf("Failed to process Stripe webhook: %v", err))
+		bc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to process webhook")
+		return
+	}
+
+	bc.responseUtil.Success(c, nil, "Webhook processed")
+}
+
+// respondQuotaExceeded refuses a request that needs more than the plan of the user allows
+func respondQuotaExceeded(c *gin.Context, quotaErr *services.QuotaError) {
+	c.JSON(http.StatusPaymentRequired, gin.H{
+		"error": utils.Localize(c, quotaErr.Error()),
+		"code":  utils.ErrCodeQuotaExceeded,
+		"plan":  quotaErr.Plan,
+		"limit": quotaErr.Limit,
+		"used":  quotaErr.Used,
+	})
+}
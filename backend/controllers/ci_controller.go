@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CIController handles pre-deploy checks called from CI pipelines
+type CIController struct {
+	store             repository.Store
+	crawlerService    *services.CrawlerService
+	validationService *services.URLValidationService
+	responseUtil      *utils.ResponseUtil
+}
+
+// NewCIController creates a new instance of CIController
+func NewCIController(store repository.Store, crawlerService *services.CrawlerService) *CIController {
+	return &CIController{
+		store:             store,
+		crawlerService:    crawlerService,
+		validationService: services.NewURLValidationService(),
+		responseUtil:      utils.NewResponseUtil(),
+	}
+}
+
+// CICheckRequest represents the request body of POST /api/ci/check; either url or html is required
+type CICheckRequest struct {
+	URL            string `json:"url"`
+	HTML           string `json:"html"`
+	BaseURL        string `json:"base_url"` // URL the submitted html will be served at
+	ProjectID      *uint  `json:"project_id"`
+	FailOn         string `json:"fail_on"` // info, warning, error (default)
+	CheckLinks     bool   `json:"check_links"`
+	MaxBrokenLinks *int   `json:"max_broken_links"`
+}
+
+// Check handles POST /api/ci/check - Analyzes a page synchronously and reports whether it passes the project's rules
+// A failed check answers 422, so pipelines can gate on the status code alone
+func (cc *CIController) Check(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAnalyzeHTMLBytes+64<<10)
+
+	var request CICheckRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: "+utils.BindingError(err))
+		return
+	}
+	if (request.URL == "") == (strings.TrimSpace(request.HTML) == "") {
+		cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: exactly one of url or html is required")
+		return
+	}
+	if request.FailOn != "" && !services.ValidSeverity(request.FailOn) {
+		cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "fail_on must be one of info, warning, error")
+		return
+	}
+	if request.MaxBrokenLinks != nil && *request.MaxBrokenLinks < 0 {
+		cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "max_broken_links must not be negative")
+		return
+	}
+
+	options := services.CICheckOptions{
+		CheckLinks:     request.CheckLinks,
+		FailOn:         request.FailOn,
+		MaxBrokenLinks: request.MaxBrokenLinks,
+	}
+	if (request.URL != "" || request.CheckLinks) && cc.crawlerService.Paused() {
+		cc.responseUtil.Error(c, http.StatusServiceUnavailable, utils.ErrCodeCrawlingPaused, "Crawling is paused, retry later")
+		return
+	}
+	if request.URL != "" {
+		normalized, err := cc.validationService.ValidateAndNormalizeURL(request.URL)
+		if err != nil {
+			cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err))
+			return
+		}
+		options.URL = normalized.ASCII
+	} else {
+		if len(request.HTML) > maxAnalyzeHTMLBytes {
+			cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("HTML must be at most %d bytes", maxAnalyzeHTMLBytes))
+			return
+		}
+		options.HTML = []byte(request.HTML)
+		var ok bool
+		if options.BaseURL, ok = parseBaseURL(request.BaseURL); !ok {
+			cc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "base_url must be an absolute http or https URL")
+			return
+		}
+	}
+
+	if request.ProjectID != nil {
+		project, ok := loadRuleProject(c, cc.store, cc.responseUtil, *request.ProjectID)
+		if !ok {
+			return
+		}
+		options.Project = project
+	}
+
+	verdict := cc.crawlerService.CICheck(options)
+	if !verdict.Passed {
+		c.JSON(http.StatusUnprocessableEntity, utils.APIResponse{
+			Success: false,
+			Error:   utils.Localize(c, "Check failed: "+strings.Join(verdict.Reasons, "; ")),
+			Code:    utils.ErrCodeCICheckFailed,
+			Data:    verdict,
+		})
+		return
+	}
+
+	cc.responseUtil.Success(c, verdict, "Check passed")
+}
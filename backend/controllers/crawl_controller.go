@@ -1,39 +1,54 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type CrawlController struct {
-	db *gorm.DB
+	store       repository.Store
+	annotations *services.AnnotationService
 }
 
 // NewCrawlController creates a new instance of CrawlController
-func NewCrawlController(db *gorm.DB) *CrawlController {
+func NewCrawlController(store repository.Store, annotations *services.AnnotationService) *CrawlController {
 	return &CrawlController{
-		db: db,
+		store:       store,
+		annotations: annotations,
 	}
 }
 
 // GetCrawelResults - GET /api/urls/crawls
 func (cc *CrawlController) GetCrawelResults(c *gin.Context) {
-	var urls []models.URL
-	if err := cc.db.Find(&urls).Error; err != nil {
+	loc, ok := parseTimezone(c)
+	if !ok {
+		return
+	}
+
+	replica := cc.store.Replica()
+	urls, err := replica.URLs().List()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve URLs",
+			"error": utils.Localize(c, "Failed to retrieve URLs"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
-	var enrichedURLs []map[string]interface{}
+	fields := utils.ParseFields(c)
+	var enrichedURLs []interface{}
 	for _, url := range urls {
-		enrichedURL := utils.EnrichURL(cc.db, url)
+		enrichedURL := utils.EnrichURLFields(replica.CrawlResults(), url, fields, loc)
 		enrichedURLs = append(enrichedURLs, enrichedURL)
 	}
 
@@ -47,34 +62,44 @@ func (cc *CrawlController) GetCrawlResults(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL ID",
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
 		})
 		return
 	}
+	loc, ok := parseTimezone(c)
+	if !ok {
+		return
+	}
 
 	// Check if URL exists
-	var url models.URL
-	if err := cc.db.First(&url, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	url, err := cc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
-				"error": "URL not found",
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
 			})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve URL",
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
 	// Get crawl results for this URL
-	var crawlResults models.CrawlResult
-	if err := cc.db.Preload("Links").Where("url_id = ?", id).Find(&crawlResults).Error; err != nil {
+	crawlResults, err := cc.store.CrawlResults().First(uint(id))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve crawl results",
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
+	utils.LocalizeURL(&url, loc)
+	utils.LocalizeCrawlResult(&crawlResults, loc)
 
 	// Return results (empty array if no results yet)
 	c.JSON(http.StatusOK, gin.H{
@@ -82,3 +107,466 @@ func (cc *CrawlController) GetCrawlResults(c *gin.Context) {
 		"results": crawlResults,
 	})
 }
+
+// GetLatestCrawlResult - GET /api/urls/:id/latest
+// Returns only the most recent crawl result; links can be trimmed with ?links=false or ?links_limit=N
+func (cc *CrawlController) GetLatestCrawlResult(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	loc, ok := parseTimezone(c)
+	if !ok {
+		return
+	}
+
+	includeLinks := c.DefaultQuery("links", "true") != "false"
+	linksLimit := 0
+	if limitParam := c.Query("links_limit"); limitParam != "" {
+		linksLimit, err = strconv.Atoi(limitParam)
+		if err != nil || linksLimit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": utils.Localize(c, "Invalid links_limit"),
+				"code":  utils.ErrCodeValidationFailed,
+			})
+			return
+		}
+	}
+
+	// Check if URL exists
+	url, err := cc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// Find the most recent crawl result for this URL
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{
+		Links:      includeLinks,
+		LinksLimit: linksLimit,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// Report the full link count so clients know whether the list was trimmed
+	linksTotal := int64(url.LinksCount)
+	if url.LatestCrawlID == nil || *url.LatestCrawlID != result.ID {
+		linksTotal, _ = cc.store.CrawlResults().CountLinks(result.ID)
+	}
+
+	utils.LocalizeURL(&url, loc)
+	utils.LocalizeCrawlResult(&result, loc)
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":         url,
+		"result":      result,
+		"links_total": linksTotal,
+	})
+}
+
+// GetCrawlBundle - GET /api/urls/:id/bundle.zip
+// Downloads the latest crawl as a ZIP archive with its JSON result, links CSV, and HTML snapshot
+func (cc *CrawlController) GetCrawlBundle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	loc, ok := parseTimezone(c)
+	if !ok {
+		return
+	}
+
+	url, err := cc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{Links: true, InternalBrokenLinks: true})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// Crawls older than the snapshots, or of pages too large to keep, are bundled without HTML
+	var snapshot *models.PageSnapshot
+	if stored, err := cc.store.CrawlResults().Snapshot(result.ID); err == nil {
+		snapshot = &stored
+	}
+
+	utils.LocalizeURL(&url, loc)
+	utils.LocalizeCrawlResult(&result, loc)
+
+	// Build the archive first, so a failure can still be answered with an error
+	var archive bytes.Buffer
+	if err := services.WriteCrawlBundle(&archive, url, result, snapshot); err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to build crawl bundle for URL ID %d: %v", id, err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to build the crawl bundle"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="crawl-%d-%d.zip"`, id, result.ID))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// GetLinks - GET /api/urls/:id/links
+// Pages through the links of the latest crawl, 20 per page unless ?per_page= is given
+// ?paging=keyset walks them by id, which stays fast on crawls with millions of links
+func (cc *CrawlController) GetLinks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	pageRequest, err := utils.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, err.Error()),
+			"code":  utils.ErrCodeValidationFailed,
+		})
+		return
+	}
+	if pageRequest == nil {
+		pageRequest = &utils.PageRequest{Page: 1, PerPage: utils.DefaultPerPage}
+	}
+
+	replica := cc.store.Replica()
+	resultID, err := replica.CrawlResults().LatestID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+	if resultID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": utils.Localize(c, "No crawl results for this URL yet"),
+			"code":  utils.ErrCodeCrawlResultNotFound,
+		})
+		return
+	}
+
+	var links []models.Link
+	var pagination interface{}
+	if pageRequest.Keyset {
+		var afterID uint
+		if pageRequest.After != nil {
+			afterID = pageRequest.After.ID
+		}
+		// One extra row tells whether another page follows
+		links, err = replica.CrawlResults().LinksAfter(resultID, afterID, pageRequest.PerPage+1)
+		if err == nil {
+			var page utils.KeysetPagination
+			links, page = utils.KeysetPage(*pageRequest, links, func(link models.Link) repository.Cursor {
+				return repository.Cursor{ID: link.ID}
+			})
+			utils.SetKeysetLinkHeader(c, page)
+			pagination = page
+		}
+	} else {
+		var total int64
+		links, total, err = replica.CrawlResults().LinksPage(resultID, pageRequest.Offset(), pageRequest.PerPage)
+		if err == nil {
+			page := utils.NewPagination(*pageRequest, total)
+			utils.SetLinkHeader(c, page)
+			pagination = page
+		}
+	}
+	if err == nil {
+		err = cc.annotations.AnnotateLinks(uint(id), links)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve links"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": resultID,
+		"links":           links,
+		"pagination":      pagination,
+	})
+}
+
+// GetRedirectSuggestions - GET /api/urls/:id/redirects
+// Lists links of the latest crawl that permanently redirect, suggesting their final URL as a replacement
+func (cc *CrawlController) GetRedirectSuggestions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	links, err := cc.store.CrawlResults().PermanentRedirects(result.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve links"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	suggestions := make([]map[string]interface{}, 0, len(links))
+	for _, link := range links {
+		suggestions = append(suggestions, map[string]interface{}{
+			"link":          link.URL,
+			"status_code":   link.InitialStatusCode,
+			"suggested_url": link.FinalURL,
+			"final_status":  link.StatusCode,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": result.ID,
+		"suggestions":     suggestions,
+	})
+}
+
+// GetInternalBrokenLinks - GET /api/urls/:id/internal-404s
+// Reports broken internal link targets of the latest site crawl with the pages referencing them
+func (cc *CrawlController) GetInternalBrokenLinks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{InternalBrokenLinks: true})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// The root page is always crawled in addition to the recorded pages
+	pagesCrawled, _ := cc.store.CrawlResults().CountPages(result.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": result.ID,
+		"pages_crawled":   pagesCrawled + 1,
+		"broken_links":    result.InternalBrokenLinks,
+	})
+}
+
+// GetProfiles - GET /api/urls/:id/profiles
+// Compares the device profiles of the latest crawl with the crawl, e.g. to spot mobile-only issues
+func (cc *CrawlController) GetProfiles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// Crawls without profiles, e.g. before the URL opted in, compare nothing
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": result.ID,
+		"comparisons":     services.CompareProfiles(result.Profiles),
+	})
+}
+
+// GetFindings - GET /api/urls/:id/findings
+// Lists the findings of the latest crawl, optionally filtered with ?type=, ?severity= and ?category=
+func (cc *CrawlController) GetFindings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
+		})
+		return
+	}
+
+	result, err := cc.store.CrawlResults().Latest(uint(id), repository.LoadOptions{})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": utils.Localize(c, "No crawl results for this URL yet"),
+				"code":  utils.ErrCodeCrawlResultNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve crawl results"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	filter := repository.FindingFilter{
+		Type:      c.Query("type"),
+		Severity:  c.Query("severity"),
+		Category:  c.Query("category"),
+		Lifecycle: c.Query("lifecycle"),
+	}
+	var findings []models.Finding
+	switch filter.Lifecycle {
+	case "", models.FindingNew, models.FindingPersisting:
+		findings, err = cc.store.CrawlResults().Findings(result.ID, filter)
+	case models.FindingResolved:
+		findings, err = cc.resolvedFindings(result, filter)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, "lifecycle must be one of new, persisting, resolved"),
+			"code":  utils.ErrCodeValidationFailed,
+		})
+		return
+	}
+	if err == nil {
+		err = cc.annotations.AnnotateFindings(uint(id), findings)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve findings"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"crawl_result_id": result.ID,
+		"findings":        findings,
+	})
+}
+
+// resolvedFindings lists the findings of the crawl before result, narrowed by filter, that result no longer has
+func (cc *CrawlController) resolvedFindings(result models.CrawlResult, filter repository.FindingFilter) ([]models.Finding, error) {
+	previousID, err := cc.store.CrawlResults().PreviousID(result.URLID, result.ID)
+	if err != nil || previousID == 0 {
+		return []models.Finding{}, err
+	}
+	filter.Lifecycle = ""
+	previous, err := cc.store.CrawlResults().Findings(previousID, filter)
+	if err != nil {
+		return nil, err
+	}
+	current, err := cc.store.CrawlResults().Findings(result.ID, repository.FindingFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return services.ResolvedFindings(previous, current), nil
+}
+
+// parseTimezone reads the ?tz= parameter, responding with 400 when the zone is unknown
+func parseTimezone(c *gin.Context) (*time.Location, bool) {
+	loc, err := utils.ParseTimezone(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": utils.Localize(c, err.Error()),
+			"code":  utils.ErrCodeValidationFailed,
+		})
+		return nil, false
+	}
+	return loc, true
+}
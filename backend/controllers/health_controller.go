@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthController handles readiness checks for load balancers and orchestrators
+type HealthController struct {
+	store          repository.Store
+	crawlerService *services.CrawlerService
+}
+
+// NewHealthController creates a new instance of HealthController
+func NewHealthController(store repository.Store, crawlerService *services.CrawlerService) *HealthController {
+	return &HealthController{
+		store:          store,
+		crawlerService: crawlerService,
+	}
+}
+
+// Ready handles GET /health/ready - Reports whether the API can accept new crawls
+// It responds with 503 when the database is unreachable or the crawl queue is full
+func (hc *HealthController) Ready(c *gin.Context) {
+	status := http.StatusOK
+	database := "ok"
+	if err := hc.store.Ping(); err != nil {
+		status = http.StatusServiceUnavailable
+		database = "unavailable"
+	}
+
+	queue := hc.crawlerService.QueueStats()
+	if queue.Queued >= queue.Capacity {
+		status = http.StatusServiceUnavailable
+	}
+
+	ready := "ready"
+	if status != http.StatusOK {
+		ready = "not_ready"
+	}
+
+	c.JSON(status, gin.H{
+		"status":   ready,
+		"database": database,
+		"queue":    queue,
+	})
+}
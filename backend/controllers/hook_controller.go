@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// HookController handles REST hook subscriptions used by no-code platforms such as Zapier and Make
+type HookController struct {
+	hookService  *services.HookService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewHookController creates a new instance of HookController
+func NewHookController(hookService *services.HookService) *HookController {
+	return &HookController{
+		hookService:  hookService,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// SubscribeRequest represents the request body for subscribing to an event
+type SubscribeRequest struct {
+	Event     string `json:"event" binding:"required"`
+	TargetURL string `json:"target_url" binding:"required"`
+}
+
+// Subscribe handles POST /api/hooks - Subscribes a target URL to an event
+func (hc *HookController) Subscribe(c *gin.Context) {
+	var request SubscribeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		hc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: event and target_url are required")
+		return
+	}
+	request.TargetURL = strings.TrimSpace(request.TargetURL)
+	if err := services.ValidateHookSubscription(request.Event, request.TargetURL); err != nil {
+		hc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	subscription, err := hc.hookService.Subscribe(request.Event, request.TargetURL, c.GetString(middleware.ContextUserKey))
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		hc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to create subscription")
+		return
+	}
+
+	hc.responseUtil.Created(c, subscription, "Subscribed successfully")
+}
+
+// Unsubscribe handles DELETE /api/hooks/:id - Removes a subscription
+func (hc *HookController) Unsubscribe(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		hc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid subscription ID format")
+		return
+	}
+
+	deleted, err := hc.hookService.Unsubscribe(uint(id))
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		hc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete subscription")
+		return
+	}
+	if !deleted {
+		hc.responseUtil.NotFound(c, utils.ErrCodeHookNotFound, "Subscription not found")
+		return
+	}
+
+	hc.responseUtil.Success(c, nil, "Unsubscribed successfully")
+}
+
+// GetHooks handles GET /api/hooks - Lists the subscriptions
+func (hc *HookController) GetHooks(c *gin.Context) {
+	subscriptions, err := hc.hookService.List()
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		hc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve subscriptions")
+		return
+	}
+
+	hc.responseUtil.Success(c, map[string]interface{}{
+		"hooks":  subscriptions,
+		"events": services.HookEvents,
+	}, "Subscriptions retrieved successfully")
+}
+
+// GetSample handles GET /api/hooks/samples/:event - Returns example payloads of an event for setting up a zap
+// The payloads are returned as a bare array, which is what Zapier's perform list expects
+func (hc *HookController) GetSample(c *gin.Context) {
+	event := c.Param("event")
+	if err := services.ValidateHookSubscription(event, "https://example.com/"); err != nil {
+		hc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Unknown event %q", event))
+		return
+	}
+
+	c.JSON(http.StatusOK, hc.hookService.Sample(event))
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobController handles HTTP requests for tracking batch jobs
+type JobController struct {
+	batchJobService *services.BatchJobService
+	responseUtil    *utils.ResponseUtil
+}
+
+// NewJobController creates a new instance of JobController
+func NewJobController(batchJobService *services.BatchJobService) *JobController {
+	return &JobController{
+		batchJobService: batchJobService,
+		responseUtil:    utils.NewResponseUtil(),
+	}
+}
+
+// GetJob handles GET /api/jobs/:id - Reports the progress of a batch job
+func (jc *JobController) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		jc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid job ID format")
+		return
+	}
+
+	job, err := jc.batchJobService.GetJob(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			jc.responseUtil.NotFound(c, utils.ErrCodeJobNotFound, "Job not found")
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve job %d: %v", id, err))
+		jc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve job")
+		return
+	}
+
+	// Calculate overall progress as a percentage of finished crawls
+	progress := 100.0
+	if job.Total > 0 {
+		progress = float64(job.Completed+job.Failed) / float64(job.Total) * 100
+	}
+
+	jc.responseUtil.Success(c, map[string]interface{}{
+		"job":      job,
+		"progress": progress,
+	}, "Job retrieved successfully")
+}
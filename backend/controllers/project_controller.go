@@ -0,0 +1,577 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectController handles HTTP requests for projects and their analysis rules
+type ProjectController struct {
+	store        repository.Store
+	issueService *services.IssueService
+	schedules    *services.ScheduleService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewProjectController creates a new instance of ProjectController
+func NewProjectController(store repository.Store, issueService *services.IssueService, schedules *services.ScheduleService) *ProjectController {
+	return &ProjectController{
+		store:        store,
+		issueService: issueService,
+		schedules:    schedules,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// ProjectRequest represents the request body for creating or updating a project
+// Omitted fields keep their current value on update
+type ProjectRequest struct {
+	Name        *string              `json:"name"`
+	PolicyTerms *[]models.PolicyTerm `json:"policy_terms"`
+	Budget      *models.PageBudget   `json:"budget"`
+	GitHub      *GitHubRequest       `json:"github"`
+
+	IssueTracker *IssueTrackerRequest `json:"issue_tracker"`
+	HeadingRules *models.HeadingRules `json:"heading_rules"`
+
+	CrawlSchedule *models.CrawlSchedule `json:"crawl_schedule"`
+
+	LinkScope *models.LinkScope `json:"link_scope"`
+
+	CustomRules *[]models.CustomRule `json:"custom_rules"`
+	Plugins     *PluginsRequest      `json:"plugins"`
+
+	WatchGroup *bool `json:"watch_group"`
+}
+
+// ScheduleRequest represents the request body for setting the crawl schedule of a project
+type ScheduleRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=hourly daily weekly adaptive"`
+	Time      string `json:"time"` // HH:MM, required unless adaptive
+	Weekday   int    `json:"weekday"`
+	Timezone  string `json:"timezone"`
+	Enabled   *bool  `json:"enabled"` // Defaults to true
+
+	// Bounds of the recrawl intervals of adaptive schedules, 1 and 168 hours by default
+	MinIntervalHours int `json:"min_interval_hours"`
+	MaxIntervalHours int `json:"max_interval_hours"`
+}
+
+// IssueTrackerRequest configures the Jira or Linear integration of a project
+// An empty provider disables the integration; an omitted token keeps the current one
+type IssueTrackerRequest struct {
+	models.IssueTrackerIntegration
+	Token *string `json:"token"`
+}
+
+// GitHubRequest configures the GitHub commit status integration of a project
+// An empty repo disables the integration; an omitted token keeps the current one
+type GitHubRequest struct {
+	Repo    string  `json:"repo"`
+	Context string  `json:"context"`
+	Token   *string `json:"token"`
+}
+
+// PluginsRequest configures the analyzer plugins of a project
+// An empty list disables them; an omitted secret keeps the current one, an empty one sends unsigned requests
+type PluginsRequest struct {
+	Plugins []models.AnalyzerPlugin `json:"plugins"`
+	Secret  *string                 `json:"secret"`
+}
+
+// CreateProject handles POST /api/projects - Creates a project
+func (pc *ProjectController) CreateProject(c *gin.Context) {
+	var request ProjectRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Name == nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: name is required")
+		return
+	}
+
+	var project models.Project
+	if !pc.applyRequest(c, &project, request) {
+		return
+	}
+
+	if err := pc.store.Projects().Create(&project); err != nil {
+		// A concurrent request or a deleted project, which keeps its name, holds the name
+		if errors.Is(err, repository.ErrDuplicate) {
+			pc.responseUtil.Conflict(c, utils.ErrCodeProjectAlreadyExists, "A project with this name already exists", nil)
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to create project: %v", err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to create project")
+		return
+	}
+
+	pc.responseUtil.Created(c, project, "Project created successfully")
+}
+
+// GetProjects handles GET /api/projects - Lists all projects
+func (pc *ProjectController) GetProjects(c *gin.Context) {
+	projects, err := pc.store.Replica().Projects().List()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve projects: %v", err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve projects")
+		return
+	}
+
+	pc.responseUtil.Success(c, projects, "Projects retrieved successfully")
+}
+
+// GetProject handles GET /api/projects/:id - Returns a project
+func (pc *ProjectController) GetProject(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	pc.responseUtil.Success(c, project, "Project retrieved successfully")
+}
+
+// UpdateProject handles PATCH /api/projects/:id - Renames a project or replaces its policy terms or budget
+func (pc *ProjectController) UpdateProject(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	var request ProjectRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body")
+		return
+	}
+
+	if !pc.applyRequest(c, &project, request) {
+		return
+	}
+
+	if err := pc.store.Projects().Update(&project, "name", "policy_terms", "budget", "github", "github_token", "issue_tracker", "issue_tracker_token", "heading_rules", "crawl_schedule", "link_scope", "custom_rules", "plugins", "plugin_secret", "watch_group"); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			pc.responseUtil.Conflict(c, utils.ErrCodeProjectAlreadyExists, "A project with this name already exists", nil)
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to update project")
+		return
+	}
+
+	pc.responseUtil.Success(c, project, "Project updated successfully")
+}
+
+// DeleteProject handles DELETE /api/projects/:id - Deletes a project and unassigns its URLs
+func (pc *ProjectController) DeleteProject(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.store.Projects().Delete(project.ID); err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to delete project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete project")
+		return
+	}
+	if err := pc.schedules.Delete(project.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to delete schedule of project %d: %v", project.ID, err))
+	}
+
+	pc.responseUtil.Success(c, nil, "Project deleted successfully")
+}
+
+// GetProjectFindings handles GET /api/projects/:id/findings - Lists the findings of the latest crawl of every project URL
+// Supports ?type=, ?severity=, ?category= and ?url_id= filters
+func (pc *ProjectController) GetProjectFindings(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.FindingFilter{
+		Type:     c.Query("type"),
+		Severity: c.Query("severity"),
+		Category: c.Query("category"),
+	}
+	if rawURLID := c.Query("url_id"); rawURLID != "" {
+		urlID, err := strconv.ParseUint(rawURLID, 10, 32)
+		if err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid url_id")
+			return
+		}
+		filter.URLID = uint(urlID)
+	}
+
+	findings, err := pc.store.Replica().CrawlResults().ProjectFindings(project.ID, filter)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve findings of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve findings")
+		return
+	}
+
+	pc.responseUtil.Success(c, map[string]interface{}{
+		"project_id": project.ID,
+		"findings":   findings,
+	}, "Findings retrieved successfully")
+}
+
+// GetMetaDescriptionReport handles GET /api/projects/:id/meta-descriptions - Reports missing, badly sized, and duplicated meta descriptions
+func (pc *ProjectController) GetMetaDescriptionReport(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	pages, err := pc.store.Replica().CrawlResults().ProjectMetaDescriptions(project.ID, "")
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve meta descriptions of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve meta descriptions")
+		return
+	}
+
+	pc.responseUtil.Success(c, services.BuildMetaDescriptionReport(project.ID, pages), "Meta description report retrieved successfully")
+}
+
+// GetThirdPartyTrend handles GET /api/projects/:id/third-parties - Reports the first-party/third-party split
+// of the project's pages month by month; ?months= sets how far back (default 12), ?url_id= narrows it to one URL
+func (pc *ProjectController) GetThirdPartyTrend(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	months, err := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if err == nil {
+		err = services.ValidatePartyTrendMonths(months)
+	}
+	if err != nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "months must be between 1 and 60")
+		return
+	}
+	var urlID uint64
+	if param := c.Query("url_id"); param != "" {
+		if urlID, err = strconv.ParseUint(param, 10, 32); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid URL ID")
+			return
+		}
+	}
+
+	// Start at the beginning of the month, so the oldest month isn't cut short
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	splits, err := pc.store.Replica().CrawlResults().ProjectPartySplits(project.ID, uint(urlID), since)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve third-party trend of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve third-party trend")
+		return
+	}
+
+	pc.responseUtil.Success(c, services.BuildPartyTrend(project.ID, uint(urlID), since, splits), "Third-party trend retrieved successfully")
+}
+
+// GetWatchReport handles GET /api/projects/:id/watch-report - Compares the last two crawls of every URL
+// of a watch group: title changes, pages new to or gone from the sitemaps, and technology changes
+func (pc *ProjectController) GetWatchReport(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+	if !project.WatchGroup {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Project is not a watch group")
+		return
+	}
+
+	store := pc.store.Replica()
+	members, err := store.URLs().ListProject(project.ID)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to list URLs of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to build watch report")
+		return
+	}
+
+	report, err := services.BuildWatchReport(store.CrawlResults(), project.ID, members)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to build watch report of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to build watch report")
+		return
+	}
+
+	pc.responseUtil.Success(c, report, "Watch report retrieved successfully")
+}
+
+// GetTrackedIssues handles GET /api/projects/:id/issues - Lists the tickets opened in the project's issue tracker
+func (pc *ProjectController) GetTrackedIssues(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	issues, err := pc.issueService.List(project.ID)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve tracked issues of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve tracked issues")
+		return
+	}
+
+	pc.responseUtil.Success(c, map[string]interface{}{
+		"project_id": project.ID,
+		"issues":     issues,
+	}, "Tracked issues retrieved successfully")
+}
+
+// GetSchedule handles GET /api/projects/:id/schedule - Retrieves the crawl schedule of a project
+func (pc *ProjectController) GetSchedule(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := pc.schedules.Get(project.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			pc.responseUtil.NotFound(c, utils.ErrCodeScheduleNotFound, "Project has no schedule")
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve schedule of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve schedule")
+		return
+	}
+
+	pc.responseUtil.Success(c, schedule, "Schedule retrieved successfully")
+}
+
+// SetSchedule handles PUT /api/projects/:id/schedule - Creates or replaces the crawl schedule of a project
+// Each run crawls the project's URLs that have monitor_enabled set; adaptive schedules crawl each of them when it is due
+func (pc *ProjectController) SetSchedule(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	var request ScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: "+utils.BindingError(err))
+		return
+	}
+
+	schedule := models.Schedule{
+		ProjectID: project.ID,
+		Frequency: request.Frequency,
+		Time:      strings.TrimSpace(request.Time),
+		Weekday:   request.Weekday,
+		Timezone:  strings.TrimSpace(request.Timezone),
+		Enabled:   request.Enabled == nil || *request.Enabled,
+	}
+	if schedule.Frequency == models.ScheduleAdaptive {
+		schedule.MinIntervalHours, schedule.MaxIntervalHours = request.MinIntervalHours, request.MaxIntervalHours
+		if schedule.MinIntervalHours == 0 {
+			schedule.MinIntervalHours = services.DefaultMinIntervalHours
+		}
+		if schedule.MaxIntervalHours == 0 {
+			schedule.MaxIntervalHours = services.DefaultMaxIntervalHours
+		}
+	}
+	if err := services.ValidateSchedule(schedule); err != nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid schedule: %v", err))
+		return
+	}
+
+	if err := pc.schedules.Save(&schedule); err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to save schedule of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save schedule")
+		return
+	}
+
+	pc.responseUtil.Success(c, schedule, "Schedule saved successfully")
+}
+
+// DeleteSchedule handles DELETE /api/projects/:id/schedule - Stops the scheduled crawls of a project
+func (pc *ProjectController) DeleteSchedule(c *gin.Context) {
+	project, ok := pc.findProject(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.schedules.Delete(project.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			pc.responseUtil.NotFound(c, utils.ErrCodeScheduleNotFound, "Project has no schedule")
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to delete schedule of project %d: %v", project.ID, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete schedule")
+		return
+	}
+
+	pc.responseUtil.Success(c, nil, "Schedule deleted successfully")
+}
+
+// findProject loads the project named by the :id path parameter, writing the error response when it fails
+func (pc *ProjectController) findProject(c *gin.Context) (models.Project, bool) {
+	var project models.Project
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		pc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid project ID format")
+		return project, false
+	}
+
+	project, err = pc.store.Projects().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			pc.responseUtil.NotFound(c, utils.ErrCodeProjectNotFound, "Project not found")
+			return project, false
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve project %d: %v", id, err))
+		pc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve project")
+		return project, false
+	}
+
+	return project, true
+}
+
+// applyRequest validates the request and copies its fields into project, writing the error response when it fails
+func (pc *ProjectController) applyRequest(c *gin.Context, project *models.Project, request ProjectRequest) bool {
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if name == "" || len(name) > 255 {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Project name must be between 1 and 255 characters")
+			return false
+		}
+
+		if existing, err := pc.store.Projects().FindByName(name, project.ID); err == nil {
+			pc.responseUtil.Conflict(c, utils.ErrCodeProjectAlreadyExists, "A project with this name already exists", map[string]interface{}{
+				"existing_project": existing,
+			})
+			return false
+		}
+		project.Name = name
+	}
+
+	if request.PolicyTerms != nil {
+		if err := services.ValidatePolicyTerms(*request.PolicyTerms); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid policy terms: %v", err))
+			return false
+		}
+		project.PolicyTerms = *request.PolicyTerms
+	}
+
+	if request.Budget != nil {
+		if err := services.ValidatePageBudget(*request.Budget); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid budget: %v", err))
+			return false
+		}
+		project.Budget = *request.Budget
+	}
+
+	if request.HeadingRules != nil {
+		rules := *request.HeadingRules
+		for i, keyword := range rules.Keywords {
+			rules.Keywords[i] = strings.TrimSpace(keyword)
+		}
+		if err := services.ValidateHeadingRules(rules); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid heading rules: %v", err))
+			return false
+		}
+		project.HeadingRules = rules
+	}
+
+	if request.CrawlSchedule != nil {
+		schedule := *request.CrawlSchedule
+		schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+		if err := services.ValidateCrawlSchedule(schedule); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid crawl schedule: %v", err))
+			return false
+		}
+		project.CrawlSchedule = schedule
+	}
+
+	if request.LinkScope != nil {
+		scope := *request.LinkScope
+		scope.PathPrefix = strings.TrimSpace(scope.PathPrefix)
+		if err := services.ValidateLinkScope(scope); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid link scope: %v", err))
+			return false
+		}
+		project.LinkScope = scope
+	}
+
+	if request.CustomRules != nil {
+		rules := *request.CustomRules
+		for i := range rules {
+			rules[i].Name = strings.TrimSpace(rules[i].Name)
+			rules[i].Message = strings.TrimSpace(rules[i].Message)
+		}
+		if err := services.ValidateCustomRules(rules); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid custom rules: %v", err))
+			return false
+		}
+		project.CustomRules = rules
+	}
+
+	if request.GitHub != nil {
+		github := models.GitHubIntegration{
+			Repo:    strings.TrimSpace(request.GitHub.Repo),
+			Context: strings.TrimSpace(request.GitHub.Context),
+		}
+		if err := services.ValidateGitHubIntegration(github); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid GitHub integration: %v", err))
+			return false
+		}
+		if request.GitHub.Token != nil {
+			project.GitHubToken = strings.TrimSpace(*request.GitHub.Token)
+		}
+		if github.Repo == "" {
+			project.GitHubToken = ""
+		}
+		github.TokenSet = project.GitHubToken != ""
+		project.GitHub = github
+	}
+
+	if request.WatchGroup != nil {
+		project.WatchGroup = *request.WatchGroup
+	}
+
+	if request.Plugins != nil {
+		plugins := models.PluginIntegration{Plugins: request.Plugins.Plugins}
+		for i := range plugins.Plugins {
+			plugins.Plugins[i].Name = strings.TrimSpace(plugins.Plugins[i].Name)
+			plugins.Plugins[i].URL = strings.TrimSpace(plugins.Plugins[i].URL)
+		}
+		if err := services.ValidateAnalyzerPlugins(plugins.Plugins); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid plugins: %v", err))
+			return false
+		}
+		if request.Plugins.Secret != nil {
+			project.PluginSecret = strings.TrimSpace(*request.Plugins.Secret)
+		}
+		if len(plugins.Plugins) == 0 {
+			project.PluginSecret = ""
+		}
+		plugins.SecretSet = project.PluginSecret != ""
+		project.Plugins = plugins
+	}
+
+	if request.IssueTracker != nil {
+		tracker := request.IssueTracker.IssueTrackerIntegration
+		if err := services.ValidateIssueTracker(tracker); err != nil {
+			pc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid issue tracker: %v", err))
+			return false
+		}
+		if request.IssueTracker.Token != nil {
+			project.IssueTrackerToken = strings.TrimSpace(*request.IssueTracker.Token)
+		}
+		if tracker.Provider == "" {
+			tracker, project.IssueTrackerToken = models.IssueTrackerIntegration{}, ""
+		}
+		tracker.TokenSet = project.IssueTrackerToken != ""
+		project.IssueTracker = tracker
+	}
+
+	return true
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SchemaController serves JSON Schema definitions of the public response payloads
+type SchemaController struct {
+	builder      *utils.SchemaBuilder
+	responseUtil *utils.ResponseUtil
+}
+
+// NewSchemaController creates a new instance of SchemaController
+// The schemas are generated once from the model types, so they always match the encoded responses
+func NewSchemaController() *SchemaController {
+	builder := utils.NewSchemaBuilder()
+	builder.Define(utils.URLSummary{})
+	builder.Define(utils.URLDetail{})
+	builder.Define(models.URL{})
+	builder.Define(models.CrawlResult{})
+	builder.Define(models.Link{})
+	builder.Define(models.Finding{})
+	builder.Define(models.Project{})
+
+	return &SchemaController{
+		builder:      builder,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// GetSchema handles GET /api/schema - Returns all definitions in one JSON Schema document
+func (sc *SchemaController) GetSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, sc.builder.Document("/api/schema"))
+}
+
+// GetDefinition handles GET /api/schema/:name - Returns a single definition, e.g. CrawlResult
+// Referenced definitions are included under $defs so the document is self-contained
+func (sc *SchemaController) GetDefinition(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := sc.builder.Definitions()[name]; !ok {
+		sc.responseUtil.NotFound(c, utils.ErrCodeSchemaNotFound, "Schema not found")
+		return
+	}
+
+	document := sc.builder.Document("/api/schema/" + name)
+	document["$ref"] = "#/$defs/" + name
+	document["title"] = name
+	c.JSON(http.StatusOK, document)
+}
@@ -1,30 +1,38 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // URLController handles HTTP requests related to URL management and crawling operations
 type URLController struct {
-	db                *gorm.DB
+	store             repository.Store
 	crawlerService    *services.CrawlerService
+	batchJobService   *services.BatchJobService
 	validationService *services.URLValidationService
+	billing           *services.BillingService // Plan limits of hosted mode, nil outside it
 	responseUtil      *utils.ResponseUtil
 }
 
 // NewURLController creates a new instance of URLController with all required dependencies
-func NewURLController(db *gorm.DB) *URLController {
+// billing is nil unless the analyzer runs in hosted mode
+func NewURLController(store repository.Store, crawlerService *services.CrawlerService, batchJobService *services.BatchJobService, billing *services.BillingService) *URLController {
 	return &URLController{
-		db:                db,
-		crawlerService:    services.NewCrawlerService(db),
+		store:             store,
+		crawlerService:    crawlerService,
+		batchJobService:   batchJobService,
+		billing:           billing,
 		validationService: services.NewURLValidationService(),
 		responseUtil:      utils.NewResponseUtil(),
 	}
@@ -32,7 +40,9 @@ func NewURLController(db *gorm.DB) *URLController {
 
 // AddURLRequest represents the request body for adding a new URL
 type AddURLRequest struct {
-	URL string `json:"url" binding:"required"`
+	URL       string `json:"url" binding:"required"`
+	ProjectID *uint  `json:"project_id"`
+	Verify    bool   `json:"verify"` // Same as ?verify=true
 }
 
 // AddURL handles POST /api/urls - Adds a new URL to the system and starts crawling automatically
@@ -41,74 +51,244 @@ func (uc *URLController) AddURL(c *gin.Context) {
 
 	// Parse and validate request body
 	if err := c.ShouldBindJSON(&request); err != nil {
-		uc.responseUtil.BadRequest(c, "Invalid request body: URL is required")
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: URL is required")
 		return
 	}
 
 	// Validate and sanitize the URL
-	sanitizedURL, err := uc.validationService.ValidateAndSanitizeURL(request.URL)
+	normalized, err := uc.validationService.ValidateAndNormalizeURL(request.URL)
 	if err != nil {
-		uc.responseUtil.BadRequest(c, fmt.Sprintf("Invalid URL: %v", err))
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err))
+		return
+	}
+
+	if request.ProjectID != nil && !uc.projectExists(c, *request.ProjectID) {
+		return
+	}
+
+	// A retried request with the same Idempotency-Key gets the URL it already created
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Idempotency-Key must be at most 255 characters")
+		return
+	}
+	if idempotencyKey != "" && uc.replayIdempotentAdd(c, idempotencyKey, normalized.ASCII) {
 		return
 	}
 
 	// Check if URL already exists in the database
-	var existingURL models.URL
-	if err := uc.db.Where("url = ?", sanitizedURL).First(&existingURL).Error; err == nil {
-		uc.responseUtil.Conflict(c, "URL already exists in the system", map[string]interface{}{
+	if existingURL, err := uc.store.URLs().FindByURL(normalized.ASCII, 0); err == nil {
+		uc.responseUtil.Conflict(c, utils.ErrCodeURLAlreadyExists, "URL already exists in the system", map[string]interface{}{
 			"existing_url": existingURL,
 		})
 		return
 	}
 
+	// Optionally reject URLs whose host doesn't resolve or answer, instead of storing a crawl that will fail
+	if request.Verify || c.Query("verify") == "true" {
+		if err := uc.crawlerService.VerifyReachable(normalized.ASCII); err != nil {
+			uc.responseUtil.BadRequest(c, utils.ErrCodeURLUnreachable, fmt.Sprintf("URL is not reachable: %v", err))
+			return
+		}
+	}
+
+	// Refuse the URL up front when the plan doesn't allow it or the crawl it needs can't be queued
+	// The URL and its crawls are accounted to the signed-in user, who adds it
+	owner := c.GetString(middleware.ContextUserKey)
+	if !uc.withinQuota(c, owner, 1, 0) {
+		return
+	}
+	reservation, ok := uc.reserveCrawls(c, []string{owner})
+	if !ok {
+		return
+	}
+	defer reservation.Release()
+
 	// Create new URL record with initial status
 	url := models.URL{
-		URL:    sanitizedURL,
-		Status: "running", // Start as running since crawling begins immediately
+		URL:        normalized.ASCII,
+		DisplayURL: normalized.Display,
+		Status:     "running", // Start as running since crawling begins immediately
+		ProjectID:  request.ProjectID,
+		CreatedBy:  owner,
+	}
+	if idempotencyKey != "" {
+		url.IdempotencyKey = &idempotencyKey
 	}
 
-	// Save URL to database
-	if err := uc.db.Create(&url).Error; err != nil {
+	// Save URL to database; a deleted URL still holds its address, so adding it again restores it
+	err = uc.store.URLs().Restore(&url)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = uc.store.URLs().Create(&url)
+	}
+	if err != nil {
+		// A concurrent request added the same URL or used the same key between our checks and the insert
+		if errors.Is(err, repository.ErrDuplicate) {
+			if idempotencyKey != "" && uc.replayIdempotentAdd(c, idempotencyKey, normalized.ASCII) {
+				return
+			}
+			if existingURL, err := uc.store.URLs().FindByURL(normalized.ASCII, 0); err == nil {
+				uc.responseUtil.Conflict(c, utils.ErrCodeURLAlreadyExists, "URL already exists in the system", map[string]interface{}{
+					"existing_url": existingURL,
+				})
+				return
+			}
+		}
 		utils.AppLogger.Error(fmt.Sprintf("Failed to save URL to database: %v", err))
-		uc.responseUtil.InternalServerError(c, "Failed to save URL")
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save URL")
 		return
 	}
 
 	// Start crawling process asynchronously (non-blocking)
-	go func() {
-		if err := uc.crawlerService.CrawlURL(url.ID); err != nil {
+	reservation.Start(url.ID, func(err error) {
+		if err != nil {
 			utils.AppLogger.Error(fmt.Sprintf("Crawling failed for URL ID %d: %v", url.ID, err))
 		}
-	}()
+	})
+
+	data := map[string]interface{}{
+		"id":          url.ID,
+		"url":         url.URL,
+		"display_url": url.DisplayURL,
+		"status":      url.Status,
+	}
+
+	// The crawl waits for a worker when all of them are busy
+	if reservation.Position > 0 {
+		data["queue_position"] = reservation.Position
+		uc.responseUtil.Accepted(c, data, "URL added successfully and queued for crawling")
+		return
+	}
 
 	// Return success response
-	uc.responseUtil.Created(c, map[string]interface{}{
-		"id":     url.ID,
-		"url":    url.URL,
-		"status": url.Status,
-	}, "URL added successfully and crawling started")
+	uc.responseUtil.Created(c, data, "URL added successfully and crawling started")
+}
+
+// ValidateURLRequest represents the request body for a dry-run validation of a URL
+type ValidateURLRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// ValidateURL handles POST /api/urls/validate - Shows the URL that adding it would store, without storing it
+// The warnings list every rewrite of the URL as entered, e.g. the www. prefix and the https:// scheme
+func (uc *URLController) ValidateURL(c *gin.Context) {
+	var request ValidateURLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: URL is required")
+		return
+	}
+
+	normalized, err := uc.validationService.ValidateAndNormalizeURL(request.URL)
+	if err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err))
+		return
+	}
+
+	data := map[string]interface{}{
+		"url":         normalized.ASCII,
+		"display_url": normalized.Display,
+		"warnings":    uc.validationService.NormalizationWarnings(request.URL),
+		"exists":      false,
+	}
+	if existingURL, err := uc.store.URLs().FindByURL(normalized.ASCII, 0); err == nil {
+		data["exists"] = true
+		data["existing_id"] = existingURL.ID
+	}
+	uc.responseUtil.Success(c, data, "URL is valid")
 }
 
 // GetURLs handles GET /api/urls - Retrieves all URLs with their enriched crawl data
+// With ?page=, ?per_page=, ?paging=keyset or ?cursor= one page is returned together with pagination metadata
 func (uc *URLController) GetURLs(c *gin.Context) {
-	var urls []models.URL
+	loc, err := utils.ParseTimezone(c)
+	if err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	pageRequest, err := utils.ParsePagination(c)
+	if err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	// The list tolerates replica lag, unlike the single-URL endpoints polled right after a change
+	replica := uc.store.Replica()
 
-	// Fetch all URLs ordered by creation date (newest first)
-	if err := uc.db.Order("created_at desc").Find(&urls).Error; err != nil {
+	// Answer polling clients with 304 when nothing changed since their last request
+	if version, err := replica.URLs().Version(); err == nil && utils.CheckETag(c, version.Count, version.LastUpdated, version.LastCrawlID) {
+		return
+	}
+
+	// Fetch URLs ordered by creation date (newest first), one page at a time when paging was requested
+	var urls []models.URL
+	var pagination interface{}
+	switch {
+	case pageRequest == nil:
+		urls, err = replica.URLs().List()
+	case pageRequest.Keyset:
+		// One extra row tells whether another page follows
+		urls, err = replica.URLs().ListAfter(pageRequest.After, pageRequest.PerPage+1)
+		if err == nil {
+			var page utils.KeysetPagination
+			urls, page = utils.KeysetPage(*pageRequest, urls, func(url models.URL) repository.Cursor {
+				return repository.Cursor{CreatedAt: url.CreatedAt, ID: url.ID}
+			})
+			utils.SetKeysetLinkHeader(c, page)
+			pagination = page
+		}
+	default:
+		var total int64
+		urls, total, err = replica.URLs().ListPage(pageRequest.Offset(), pageRequest.PerPage)
+		if err == nil {
+			page := utils.NewPagination(*pageRequest, total)
+			utils.SetLinkHeader(c, page)
+			pagination = page
+		}
+	}
+	if err != nil {
 		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve URLs from database: %v", err))
-		uc.responseUtil.InternalServerError(c, "Failed to retrieve URLs")
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URLs")
 		return
 	}
 
-	// Enrich each URL with crawl data
-	var enrichedURLs []map[string]interface{}
-	for _, url := range urls {
-		enrichedURLs = append(enrichedURLs, utils.EnrichURL(uc.db, url))
+	// Enrich the URLs with their latest crawls in one go, trimmed to the requested fields
+	summaries, err := utils.EnrichURLs(replica.CrawlResults(), urls)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve crawl results from database: %v", err))
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URLs")
+		return
+	}
+	fields := utils.ParseFields(c)
+	var enrichedURLs []interface{}
+	for _, summary := range summaries {
+		enrichedURLs = append(enrichedURLs, utils.SummaryFields(replica.CrawlResults(), summary, fields, loc))
 	}
 
-	uc.responseUtil.Success(c, map[string]interface{}{
+	data := map[string]interface{}{
 		"urls": enrichedURLs,
-	}, "URLs retrieved successfully")
+	}
+	if pagination != nil {
+		data["pagination"] = pagination
+	}
+	uc.responseUtil.Success(c, data, "URLs retrieved successfully")
+}
+
+// GetURLFacets handles GET /api/urls/facets - Counts the URLs per status, HTML version, domain, and tag
+// The frontend builds its column filters from these instead of loading every URL
+func (uc *URLController) GetURLFacets(c *gin.Context) {
+	replica := uc.store.Replica()
+	if version, err := replica.URLs().Version(); err == nil && utils.CheckETag(c, version.Count, version.LastUpdated, version.LastCrawlID) {
+		return
+	}
+
+	facets, err := replica.URLs().Facets()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to count URL facets: %v", err))
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve facets")
+		return
+	}
+
+	uc.responseUtil.Success(c, facets, "Facets retrieved successfully")
 }
 
 // GetURL handles GET /api/urls/:id - Retrieves a specific URL with its enriched crawl data
@@ -116,63 +296,276 @@ func (uc *URLController) GetURL(c *gin.Context) {
 	// Parse and validate URL ID from path parameter
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		uc.responseUtil.BadRequest(c, "Invalid URL ID format")
+		uc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid URL ID format")
+		return
+	}
+
+	loc, err := utils.ParseTimezone(c)
+	if err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	// Fetch URL from database
-	var url models.URL
-	if err := uc.db.First(&url, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			uc.responseUtil.NotFound(c, "URL not found")
+	url, err := uc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			uc.responseUtil.NotFound(c, utils.ErrCodeURLNotFound, "URL not found")
 			return
 		}
 		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve URL %d: %v", id, err))
-		uc.responseUtil.InternalServerError(c, "Failed to retrieve URL")
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URL")
 		return
 	}
 
-	// Return enriched URL data
-	enrichedURL := utils.EnrichURL(uc.db, url)
+	// Answer polling clients with 304 when neither the URL nor its crawl results changed
+	latestCrawlID, _ := uc.store.CrawlResults().LatestID(url.ID)
+	if utils.CheckETag(c, url.UpdatedAt.UnixNano(), latestCrawlID) {
+		return
+	}
+
+	// Return enriched URL data, trimmed to the requested fields
+	enrichedURL := utils.EnrichURLFields(uc.store.CrawlResults(), url, utils.ParseFields(c), loc)
 	uc.responseUtil.Success(c, enrichedURL, "URL retrieved successfully")
 }
 
+// UpdateURLRequest represents the request body for editing a URL
+// Omitted fields keep their current value
+type UpdateURLRequest struct {
+	URL            *string             `json:"url"`
+	Tags           *[]string           `json:"tags"`
+	CrawlConfig    *models.CrawlConfig `json:"crawl_config"`
+	MonitorEnabled *bool               `json:"monitor_enabled"`
+	ProjectID      *uint               `json:"project_id"` // 0 removes the URL from its project
+	CommitSHA      *string             `json:"commit_sha"` // Commit now deployed at the URL, empty clears it
+}
+
+// UpdateURL handles PATCH /api/urls/:id - Edits a URL record while keeping its crawl history
+func (uc *URLController) UpdateURL(c *gin.Context) {
+	// Parse and validate URL ID from path parameter
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid URL ID format")
+		return
+	}
+
+	var request UpdateURLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body")
+		return
+	}
+
+	// Fetch URL from database
+	url, err := uc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			uc.responseUtil.NotFound(c, utils.ErrCodeURLNotFound, "URL not found")
+			return
+		}
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve URL %d: %v", id, err))
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URL")
+		return
+	}
+
+	// Track the changed columns so only those are written (serialized fields need a struct update)
+	var columns []string
+
+	// Rename the target URL with the same validation and duplicate check as AddURL
+	if request.URL != nil {
+		normalized, err := uc.validationService.ValidateAndNormalizeURL(*request.URL)
+		if err != nil {
+			uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err))
+			return
+		}
+
+		if normalized.ASCII != url.URL {
+			// Like StartProcessing this goes by the registered crawls, so a URL left running by a stopped
+			// server can still be renamed
+			crawling, err := uc.crawlerService.Crawling(url.ID)
+			if err != nil {
+				utils.AppLogger.Error(fmt.Sprintf("Failed to check the crawls of URL %d: %v", url.ID, err))
+				uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URL")
+				return
+			}
+			if crawling {
+				uc.responseUtil.Error(c, http.StatusConflict, utils.ErrCodeCrawlInProgress, "Cannot rename a URL while it is being crawled")
+				return
+			}
+
+			if existingURL, err := uc.store.URLs().FindByURL(normalized.ASCII, url.ID); err == nil {
+				uc.responseUtil.Conflict(c, utils.ErrCodeURLAlreadyExists, "URL already exists in the system", map[string]interface{}{
+					"existing_url": existingURL,
+				})
+				return
+			}
+
+			// A deleted URL keeps its address, which AddURL restores it with
+			if _, err := uc.store.URLs().FindDeletedByURL(normalized.ASCII); err == nil {
+				uc.responseUtil.Conflict(c, utils.ErrCodeURLAlreadyExists, "URL belongs to a deleted URL, add it again to restore it", nil)
+				return
+			}
+
+			// Previous results describe the old target, so the renamed URL needs a fresh crawl
+			url.URL = normalized.ASCII
+			url.DisplayURL = normalized.Display
+			url.Status = "queued"
+			columns = append(columns, "url", "display_url", "status")
+		}
+	}
+
+	if request.Tags != nil {
+		url.Tags = normalizeTags(*request.Tags)
+		columns = append(columns, "tags")
+	}
+
+	if request.CrawlConfig != nil {
+		if err := services.ValidateCrawlConfig(*request.CrawlConfig); err != nil {
+			uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid crawl config: %v", err))
+			return
+		}
+		url.CrawlConfig = *request.CrawlConfig
+		columns = append(columns, "crawl_config")
+	}
+
+	if request.MonitorEnabled != nil {
+		url.MonitorEnabled = *request.MonitorEnabled
+		columns = append(columns, "monitor_enabled")
+	}
+
+	if request.ProjectID != nil {
+		if *request.ProjectID == 0 {
+			url.ProjectID = nil
+		} else {
+			if !uc.projectExists(c, *request.ProjectID) {
+				return
+			}
+			url.ProjectID = request.ProjectID
+		}
+		columns = append(columns, "project_id")
+	}
+
+	if request.CommitSHA != nil {
+		sha := strings.ToLower(strings.TrimSpace(*request.CommitSHA))
+		if err := services.ValidateCommitSHA(sha); err != nil {
+			uc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+			return
+		}
+		url.CommitSHA = sha
+		columns = append(columns, "commit_sha")
+	}
+
+	if len(columns) > 0 {
+		if err := uc.store.URLs().Update(&url, columns...); err != nil {
+			// Another request took the address after the duplicate checks
+			if errors.Is(err, repository.ErrDuplicate) {
+				uc.responseUtil.Conflict(c, utils.ErrCodeURLAlreadyExists, "URL already exists in the system", nil)
+				return
+			}
+			utils.AppLogger.Error(fmt.Sprintf("Failed to update URL %d: %v", id, err))
+			uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to update URL")
+			return
+		}
+	}
+
+	uc.responseUtil.Success(c, utils.EnrichURL(uc.store.CrawlResults(), url), "URL updated successfully")
+}
+
+// replayIdempotentAdd answers a repeated AddURL request with the URL created for its Idempotency-Key
+// It returns false when the key hasn't been used yet
+func (uc *URLController) replayIdempotentAdd(c *gin.Context, key, normalizedURL string) bool {
+	url, err := uc.store.URLs().FindByIdempotencyKey(key)
+	if err != nil {
+		return false
+	}
+
+	if url.URL != normalizedURL {
+		uc.responseUtil.Error(c, http.StatusUnprocessableEntity, utils.ErrCodeIdempotencyKeyReused, "Idempotency-Key was already used for a different URL")
+		return true
+	}
+	if url.DeletedAt.Valid {
+		uc.responseUtil.Conflict(c, utils.ErrCodeIdempotencyKeyReused, "Idempotency-Key was used for a URL that has since been deleted", nil)
+		return true
+	}
+
+	uc.responseUtil.Success(c, map[string]interface{}{
+		"id":          url.ID,
+		"url":         url.URL,
+		"display_url": url.DisplayURL,
+		"status":      url.Status,
+	}, "URL was already added with this Idempotency-Key")
+	return true
+}
+
+// projectExists checks that the project a URL is assigned to exists, writing the error response when it doesn't
+func (uc *URLController) projectExists(c *gin.Context, projectID uint) bool {
+	exists, err := uc.store.Projects().Exists(projectID)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve project %d: %v", projectID, err))
+		uc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve project")
+		return false
+	}
+	if !exists {
+		uc.responseUtil.BadRequest(c, utils.ErrCodeProjectNotFound, "Project not found")
+		return false
+	}
+	return true
+}
+
+// normalizeTags trims and lowercases tags, dropping empty and duplicate values
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
 // DeleteURL - DELETE /api/urls/:id
 func (uc *URLController) DeleteURL(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL ID",
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
 		})
 		return
 	}
 
 	// Check if URL exists
-	var url models.URL
-	if err := uc.db.First(&url, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	url, err := uc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
-				"error": "URL not found",
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
 			})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve URL",
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
 	// Delete associated crawl results and links (cascade delete)
 	// GORM will handle the cascade deletion based on foreign key constraints
-	if err := uc.db.Delete(&url).Error; err != nil {
+	if err := uc.store.URLs().Delete(url.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete URL",
+			"error": utils.Localize(c, "Failed to delete URL"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "URL deleted successfully",
+		"message": utils.Localize(c, "URL deleted successfully"),
 		"url_id":  id,
 	})
 }
@@ -182,43 +575,82 @@ func (uc *URLController) StartProcessing(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL ID",
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
 		})
 		return
 	}
 
 	// Check if URL exists
-	var url models.URL
-	if err := uc.db.First(&url, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	url, err := uc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
-				"error": "URL not found",
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
 			})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve URL",
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+
+	// Refuse to start a second crawl while one is already running; a URL left running by a server that
+	// stopped has no crawl, so it can be started again
+	crawling, err := uc.crawlerService.Crawling(url.ID)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to check the crawls of URL %d: %v", url.ID, err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
+	if crawling {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": utils.Localize(c, "URL is already being crawled"),
+			"code":  utils.ErrCodeCrawlInProgress,
 		})
 		return
 	}
 
+	reservation, ok := uc.reserveCrawls(c, []string{url.CreatedBy})
+	if !ok {
+		return
+	}
+	defer reservation.Release()
+
 	// Update status to running
-	if err := uc.db.Model(&url).Update("status", "running").Error; err != nil {
+	if err := uc.store.URLs().SetStatus("running", url.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update URL status",
+			"error": utils.Localize(c, "Failed to update URL status"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
 	// Start crawling in a goroutine
-	go func() {
-		if err := uc.crawlerService.CrawlURL(uint(id)); err != nil {
-			// Log error (in production, you'd want proper logging)
+	reservation.Start(uint(id), func(err error) {
+		if err != nil {
+			utils.AppLogger.Error(fmt.Sprintf("Crawling failed for URL ID %d: %v", id, err))
 		}
-	}()
+	})
+
+	if reservation.Position > 0 {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        utils.Localize(c, "Queued URL for processing"),
+			"url_id":         id,
+			"status":         "running",
+			"queue_position": reservation.Position,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Started processing URL",
+		"message": utils.Localize(c, "Started processing URL"),
 		"url_id":  id,
 		"status":  "running",
 	})
@@ -229,273 +661,595 @@ func (uc *URLController) StopProcessing(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid URL ID",
+			"error": utils.Localize(c, "Invalid URL ID"),
+			"code":  utils.ErrCodeInvalidID,
 		})
 		return
 	}
 
 	// Check if URL exists
-	var url models.URL
-	if err := uc.db.First(&url, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	url, err := uc.store.URLs().Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
-				"error": "URL not found",
+				"error": utils.Localize(c, "URL not found"),
+				"code":  utils.ErrCodeURLNotFound,
 			})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve URL",
+			"error": utils.Localize(c, "Failed to retrieve URL"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
-	// Update status to queued (stopped)
-	if err := uc.db.Model(&url).Update("status", "queued").Error; err != nil {
+	// Update status to queued (stopped), then cancel the crawl so it can't overwrite the status
+	if err := uc.store.URLs().SetStatus("queued", url.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update URL status",
+			"error": utils.Localize(c, "Failed to update URL status"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
+	cancelled := uc.crawlerService.StopCrawl(url.ID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Stopped processing URL",
-		"url_id":  id,
-		"status":  "queued",
+		"message":   utils.Localize(c, "Stopped processing URL"),
+		"url_id":    id,
+		"status":    "queued",
+		"cancelled": cancelled, // A crawl was in progress
 	})
 }
 
-// BatchStartProcessing - POST /api/urls/batch/start
-func (uc *URLController) BatchStartProcessing(c *gin.Context) {
-	var request struct {
-		IDs []string `json:"ids" binding:"required"`
+// resolveBatchIDs looks up the parsed IDs with a single query and returns the IDs of existing URLs
+// IDs that don't exist are recorded as not found on the set
+func (uc *URLController) resolveBatchIDs(tx repository.Store, set *batchIDSet) ([]uint, []string, error) {
+	if len(set.ids) == 0 {
+		return nil, nil, nil
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
+	existingIDs, err := tx.URLs().ExistingIDs(set.ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	var notFound []string
+	for _, id := range set.ids {
+		if !existing[id] {
+			notFound = append(notFound, set.original[id])
+			set.failID(id, "URL not found")
+		}
+	}
+
+	return existingIDs, notFound, nil
+}
+
+// startBatchCrawls creates a batch job for the given URLs and crawls them asynchronously
+// Each finished crawl is recorded on the job so clients can poll GET /api/jobs/:id
+// The reservation must hold a slot for every ID
+func (uc *URLController) startBatchCrawls(jobType string, ids []uint, reservation *services.CrawlReservation) (*models.BatchJob, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	job, err := uc.batchJobService.CreateJob(jobType, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	reservation.JobID = job.ID
+
+	for _, id := range ids {
+		urlID := id
+		reservation.Start(urlID, func(crawlErr error) {
+			if crawlErr != nil {
+				utils.AppLogger.Error(fmt.Sprintf("Crawling failed for URL ID %d: %v", urlID, crawlErr))
+			}
+			if err := uc.batchJobService.RecordResult(job.ID, crawlErr); err != nil {
+				utils.AppLogger.Error(err.Error())
+			}
+		})
+	}
+
+	return job, nil
+}
+
+// reserveCrawls admits a crawl of a URL of every owner, the users who added them, responding with 402 when
+// an owner's plan doesn't allow them and with 503 and Retry-After when the crawl queue is full
+func (uc *URLController) reserveCrawls(c *gin.Context, owners []string) (*services.CrawlReservation, bool) {
+	crawls := make(map[string]int)
+	for _, owner := range owners {
+		crawls[owner]++
+	}
+	for owner, n := range crawls {
+		if !uc.withinQuota(c, owner, 0, n) {
+			return nil, false
+		}
+	}
+	reservation, err := uc.crawlerService.Reserve(len(owners))
+	if err != nil {
+		uc.respondQueueFull(c)
+		return nil, false
+	}
+	return reservation, true
+}
+
+// withinQuota checks that the plan of the user allows adding urls URLs and starting crawls crawls,
+// responding with 402 when it doesn't; everything is allowed outside hosted mode
+func (uc *URLController) withinQuota(c *gin.Context, username string, urls, crawls int) bool {
+	if uc.billing == nil {
+		return true
+	}
+	err := uc.billing.CheckQuota(username, urls, crawls)
+	var quotaErr *services.QuotaError
+	if errors.As(err, &quotaErr) {
+		respondQuotaExceeded(c, quotaErr)
+		return false
+	}
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to check plan limits: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to retrieve plan"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return false
+	}
+	return true
+}
+
+// respondQueueFull tells the client to retry once running crawls had time to finish
+func (uc *URLController) respondQueueFull(c *gin.Context) {
+	retryAfter := uc.crawlerService.RetryAfter()
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": utils.Localize(c, "Crawl queue is full, retry later"),
+		"code":  utils.ErrCodeQueueFull,
+	})
+}
+
+// batchStartStatus returns 202 when some of the started crawls wait for a worker
+func batchStartStatus(reservation *services.CrawlReservation) int {
+	if reservation != nil && reservation.Position > 0 {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// queuePosition returns the queue position of the first crawl of a batch, 0 when none waits
+func queuePosition(reservation *services.CrawlReservation) int {
+	if reservation == nil {
+		return 0
+	}
+	return reservation.Position
+}
+
+// batchJobID returns the ID of a batch job, or nil when no job was created
+func batchJobID(job *models.BatchJob) interface{} {
+	if job == nil {
+		return nil
+	}
+	return job.ID
+}
+
+// bindBatchRequest parses the batch request body, responding with 400 when it is invalid
+func (uc *URLController) bindBatchRequest(c *gin.Context) (*batchIDSet, bool) {
+	return uc.bindBatch(c, &BatchRequest{})
+}
+
+// bindBatch parses a batch request body into request, responding with 400 when it is invalid
+func (uc *URLController) bindBatch(c *gin.Context, request batchPayload) (*batchIDSet, bool) {
+	if err := c.ShouldBindJSON(request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
+			"error": utils.Localize(c, "Invalid request body"),
+			"code":  utils.ErrCodeValidationFailed,
 		})
-		return
+		return nil, false
 	}
 
-	if len(request.IDs) == 0 {
+	if len(request.batchIDs()) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No URL IDs provided",
+			"error": utils.Localize(c, "No URL IDs provided"),
+			"code":  utils.ErrCodeValidationFailed,
 		})
-		return
+		return nil, false
 	}
 
-	var successCount int
-	var errors []string
+	return parseBatchIDs(request.batchIDs()), true
+}
 
-	for _, idStr := range request.IDs {
-		id, err := strconv.ParseUint(idStr, 10, 32)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Invalid ID: %s", idStr))
-			continue
+// BatchStartProcessing - POST /api/urls/batch/start
+func (uc *URLController) BatchStartProcessing(c *gin.Context) {
+	set, ok := uc.bindBatchRequest(c)
+	if !ok {
+		return
+	}
+
+	var notFound []string
+	var startedIDs []uint
+	var reservation *services.CrawlReservation
+	defer func() { reservation.Release() }()
+
+	// Look up and update all URLs in one transaction
+	err := uc.store.Transaction(func(tx repository.Store) error {
+		found, missing, err := uc.resolveBatchIDs(tx, set)
+		notFound = missing
+		if err != nil || len(found) == 0 {
+			return err
 		}
 
-		// Check if URL exists and update status
-		var url models.URL
-		if err := uc.db.First(&url, id).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("URL not found: %s", idStr))
-			continue
+		// Leave the URLs untouched when the whole batch can't be queued
+		if reservation, err = uc.crawlerService.Reserve(len(found)); err != nil {
+			return err
 		}
 
-		// Update status to running
-		if err := uc.db.Model(&url).Update("status", "running").Error; err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to update URL %s", idStr))
-			continue
+		if err := tx.URLs().SetStatus("running", found...); err != nil {
+			return err
 		}
 
-		// Start crawling in a goroutine
-		go func(urlID uint) {
-			if err := uc.crawlerService.CrawlURL(urlID); err != nil {
-				// Log error (in production, you'd want proper logging)
-			}
-		}(uint(id))
+		startedIDs = found
+		return nil
+	})
+	if errors.Is(err, services.ErrQueueFull) {
+		uc.respondQueueFull(c)
+		return
+	}
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to start batch processing: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to update URL status"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
+	}
 
-		successCount++
+	// Start crawling once the status change is committed
+	job, err := uc.startBatchCrawls("start", startedIDs, reservation)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to create batch job: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to start batch processing"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":       fmt.Sprintf("Started processing %d URL(s)", successCount),
-		"success_count": successCount,
-		"errors":        errors,
+	c.JSON(batchStartStatus(reservation), gin.H{
+		"message":        utils.Localize(c, fmt.Sprintf("Started processing %d URL(s)", len(startedIDs))),
+		"success_count":  len(startedIDs),
+		"queue_position": queuePosition(reservation),
+		"job_id":         batchJobID(job),
+		"not_found_ids":  notFound,
+		"errors":         set.errors,
+		"id_errors":      set.idErrors,
 	})
 }
 
 // BatchStopProcessing - POST /api/urls/batch/stop
 func (uc *URLController) BatchStopProcessing(c *gin.Context) {
-	var request struct {
-		IDs []string `json:"ids" binding:"required"`
+	set, ok := uc.bindBatchRequest(c)
+	if !ok {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
+	var notFound []string
+	var stopped []uint
+
+	// Look up and update all URLs in one transaction
+	err := uc.store.Transaction(func(tx repository.Store) error {
+		found, missing, err := uc.resolveBatchIDs(tx, set)
+		notFound = missing
+		if err != nil || len(found) == 0 {
+			return err
+		}
+
+		// Update status to queued (stopped)
+		if err := tx.URLs().SetStatus("queued", found...); err != nil {
+			return err
+		}
+
+		stopped = found
+		return nil
+	})
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to stop batch processing: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to update URL status"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
-	if len(request.IDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No URL IDs provided",
-		})
+	// Cancel the crawls once their status is committed, so they can't overwrite it
+	cancelledCount := 0
+	for _, id := range stopped {
+		if uc.crawlerService.StopCrawl(id) {
+			cancelledCount++
+		}
+	}
+	successCount := len(stopped)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         utils.Localize(c, fmt.Sprintf("Stopped processing %d URL(s)", successCount)),
+		"success_count":   successCount,
+		"cancelled_count": cancelledCount, // URLs whose crawl was in progress
+		"not_found_ids":   notFound,
+		"errors":          set.errors,
+		"id_errors":       set.idErrors,
+	})
+}
+
+// BatchDeleteUrls - DELETE /api/urls/batch/delete
+func (uc *URLController) BatchDeleteUrls(c *gin.Context) {
+	set, ok := uc.bindBatchRequest(c)
+	if !ok {
 		return
 	}
 
+	var notFound []string
 	var successCount int
-	var errors []string
-
-	for _, idStr := range request.IDs {
-		id, err := strconv.ParseUint(idStr, 10, 32)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Invalid ID: %s", idStr))
-			continue
-		}
 
-		// Check if URL exists and update status
-		var url models.URL
-		if err := uc.db.First(&url, id).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("URL not found: %s", idStr))
-			continue
+	// Look up and delete all URLs in one transaction
+	err := uc.store.Transaction(func(tx repository.Store) error {
+		found, missing, err := uc.resolveBatchIDs(tx, set)
+		notFound = missing
+		if err != nil || len(found) == 0 {
+			return err
 		}
 
-		// Update status to queued (stopped)
-		if err := uc.db.Model(&url).Update("status", "queued").Error; err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to update URL %s", idStr))
-			continue
+		// Delete the URLs (cascade delete will handle related data)
+		if err := tx.URLs().Delete(found...); err != nil {
+			return err
 		}
 
-		successCount++
+		successCount = len(found)
+		return nil
+	})
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to delete URLs: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to delete URLs"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       fmt.Sprintf("Stopped processing %d URL(s)", successCount),
+		"message":       utils.Localize(c, fmt.Sprintf("Deleted %d URL(s)", successCount)),
 		"success_count": successCount,
-		"errors":        errors,
+		"not_found_ids": notFound,
+		"errors":        set.errors,
+		"id_errors":     set.idErrors,
 	})
 }
 
-// BatchDeleteUrls - DELETE /api/urls/batch/delete
-func (uc *URLController) BatchDeleteUrls(c *gin.Context) {
-	var request struct {
-		IDs []string `json:"ids" binding:"required"`
+// BatchRerunAnalysis - POST /api/urls/batch/rerun
+func (uc *URLController) BatchRerunAnalysis(c *gin.Context) {
+	set, ok := uc.bindBatchRequest(c)
+	if !ok {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
+	// Look up the URLs first, their crawls count against the plans of the users who added them
+	var urls []models.URL
+	for _, id := range set.ids {
+		url, err := uc.store.URLs().Get(id)
+		if err != nil {
+			set.failID(id, "URL not found")
+			continue
+		}
+		urls = append(urls, url)
+	}
+
+	// Reserve a slot for every URL up front; slots of URLs that fail are released afterwards
+	owners := make([]string, len(urls))
+	for i, url := range urls {
+		owners[i] = url.CreatedBy
+	}
+	reservation, ok := uc.reserveCrawls(c, owners)
+	if !ok {
+		return
+	}
+	defer reservation.Release()
+
+	var rerunIDs []uint
+
+	for _, url := range urls {
+		id := url.ID
+
+		// Clear previous crawl data properly (handle foreign key constraints)
+		if err := uc.store.CrawlResults().DeleteForURL(id); err != nil {
+			utils.AppLogger.Error(fmt.Sprintf("Failed to clear crawl results of URL %d: %v", id, err))
+		}
+
+		// Reset URL status and start fresh analysis
+		if err := uc.store.URLs().SetStatus("running", id); err != nil {
+			set.failID(id, "Failed to update URL")
+			continue
+		}
+
+		rerunIDs = append(rerunIDs, id)
+	}
+
+	// Start fresh analysis for all reset URLs
+	job, err := uc.startBatchCrawls("rerun", rerunIDs, reservation)
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to create batch job: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to start batch analysis"),
+			"code":  utils.ErrCodeInternalError,
 		})
 		return
 	}
 
-	if len(request.IDs) == 0 {
+	c.JSON(batchStartStatus(reservation), gin.H{
+		"message":        utils.Localize(c, fmt.Sprintf("Restarted analysis for %d URL(s)", len(rerunIDs))),
+		"success_count":  len(rerunIDs),
+		"queue_position": queuePosition(reservation),
+		"job_id":         batchJobID(job),
+		"errors":         set.errors,
+		"id_errors":      set.idErrors,
+	})
+}
+
+// BatchTagURLs - POST /api/urls/batch/tag
+func (uc *URLController) BatchTagURLs(c *gin.Context) {
+	var request BatchTagRequest
+	set, ok := uc.bindBatch(c, &request)
+	if !ok {
+		return
+	}
+
+	add, remove := normalizeTags(request.Add), normalizeTags(request.Remove)
+	if len(add) == 0 && len(remove) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No URL IDs provided",
+			"error": utils.Localize(c, "No tags provided"),
+			"code":  utils.ErrCodeValidationFailed,
 		})
 		return
 	}
 
+	var notFound []string
 	var successCount int
-	var errors []string
 
-	for _, idStr := range request.IDs {
-		id, err := strconv.ParseUint(idStr, 10, 32)
+	// Update the tags of all URLs in one transaction, so a failure leaves every URL as it was
+	err := uc.store.Transaction(func(tx repository.Store) error {
+		found, missing, err := uc.resolveBatchIDs(tx, set)
+		notFound = missing
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("Invalid ID: %s", idStr))
-			continue
-		}
-
-		// Check if URL exists
-		var url models.URL
-		if err := uc.db.First(&url, id).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("URL not found: %s", idStr))
-			continue
+			return err
 		}
 
-		// Delete the URL (cascade delete will handle related data)
-		if err := uc.db.Delete(&url).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to delete URL %s", idStr))
-			continue
+		for _, id := range found {
+			url, err := tx.URLs().Get(id)
+			if err != nil {
+				return err
+			}
+			url.Tags = normalizeTags(append(url.Tags, add...))
+			kept := []string{}
+			for _, tag := range url.Tags {
+				if !containsTag(remove, tag) {
+					kept = append(kept, tag)
+				}
+			}
+			url.Tags = kept
+			if err := tx.URLs().Update(&url, "tags"); err != nil {
+				return err
+			}
 		}
 
-		successCount++
+		successCount = len(found)
+		return nil
+	})
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to tag URLs: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to tag URLs"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       fmt.Sprintf("Deleted %d URL(s)", successCount),
+		"message":       utils.Localize(c, fmt.Sprintf("Updated the tags of %d URL(s)", successCount)),
 		"success_count": successCount,
-		"errors":        errors,
+		"not_found_ids": notFound,
+		"errors":        set.errors,
+		"id_errors":     set.idErrors,
 	})
 }
 
-// BatchRerunAnalysis - POST /api/urls/batch/rerun
-func (uc *URLController) BatchRerunAnalysis(c *gin.Context) {
-	var request struct {
-		IDs []string `json:"ids" binding:"required"`
+// BatchUpdateConfig - POST /api/urls/batch/config
+func (uc *URLController) BatchUpdateConfig(c *gin.Context) {
+	var request BatchConfigRequest
+	set, ok := uc.bindBatch(c, &request)
+	if !ok {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
+	if request.CrawlConfig.empty() && request.MonitorEnabled == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
+			"error": utils.Localize(c, "No changes provided"),
+			"code":  utils.ErrCodeValidationFailed,
 		})
 		return
 	}
 
-	if len(request.IDs) == 0 {
+	// Every rule checks a single field, so validating the changes on their own covers every URL
+	var changed models.CrawlConfig
+	request.CrawlConfig.apply(&changed)
+	if err := services.ValidateCrawlConfig(changed); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No URL IDs provided",
+			"error": utils.Localize(c, fmt.Sprintf("Invalid crawl config: %v", err)),
+			"code":  utils.ErrCodeValidationFailed,
 		})
 		return
 	}
 
+	var columns []string
+	if !request.CrawlConfig.empty() {
+		columns = append(columns, "crawl_config")
+	}
+	if request.MonitorEnabled != nil {
+		columns = append(columns, "monitor_enabled")
+	}
+
+	var notFound []string
 	var successCount int
-	var errors []string
 
-	for _, idStr := range request.IDs {
-		id, err := strconv.ParseUint(idStr, 10, 32)
+	// Update all URLs in one transaction, so a failure leaves every URL as it was
+	err := uc.store.Transaction(func(tx repository.Store) error {
+		found, missing, err := uc.resolveBatchIDs(tx, set)
+		notFound = missing
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("Invalid ID: %s", idStr))
-			continue
-		}
-
-		// Check if URL exists
-		var url models.URL
-		if err := uc.db.First(&url, id).Error; err != nil {
-			errors = append(errors, fmt.Sprintf("URL not found: %s", idStr))
-			continue
-		}
-
-		// Clear previous crawl data properly (handle foreign key constraints)
-		// First delete all links associated with crawl results for this URL
-		uc.db.Exec("DELETE l FROM links l INNER JOIN crawl_results cr ON l.crawl_result_id = cr.id WHERE cr.url_id = ?", id)
-
-		// Then delete crawl results for this URL
-		if err := uc.db.Where("url_id = ?", id).Delete(&models.CrawlResult{}).Error; err != nil {
-			// Log but don't fail if no data exists to delete
-			// This is normal for URLs that haven't been crawled yet
-		}
-
-		// Reset URL status and start fresh analysis
-		if err := uc.db.Model(&url).Update("status", "running").Error; err != nil {
-			errors = append(errors, fmt.Sprintf("Failed to update URL %s", idStr))
-			continue
+			return err
 		}
 
-		// Start crawling in a goroutine
-		go func(urlID uint) {
-			if err := uc.crawlerService.CrawlURL(urlID); err != nil {
-				// Log error (in production, you'd want proper logging)
+		for _, id := range found {
+			url, err := tx.URLs().Get(id)
+			if err != nil {
+				return err
 			}
-		}(uint(id))
+			request.CrawlConfig.apply(&url.CrawlConfig)
+			if request.MonitorEnabled != nil {
+				url.MonitorEnabled = *request.MonitorEnabled
+			}
+			if err := tx.URLs().Update(&url, columns...); err != nil {
+				return err
+			}
+		}
 
-		successCount++
+		successCount = len(found)
+		return nil
+	})
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to update URLs: %v", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": utils.Localize(c, "Failed to update URLs"),
+			"code":  utils.ErrCodeInternalError,
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       fmt.Sprintf("Restarted analysis for %d URL(s)", successCount),
+		"message":       utils.Localize(c, fmt.Sprintf("Updated %d URL(s)", successCount)),
 		"success_count": successCount,
-		"errors":        errors,
+		"not_found_ids": notFound,
+		"errors":        set.errors,
+		"id_errors":     set.idErrors,
 	})
 }
+
+// containsTag reports whether a normalized tag list holds tag
+func containsTag(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
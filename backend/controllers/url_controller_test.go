@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newTestURLController returns a URL controller on an in-memory store
+// The settings service needs a database, so it gets one that never runs its statements
+func newTestURLController(t *testing.T, store repository.Store) *URLController {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test@/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	settings := services.NewSettingsService(db)
+	crawler := services.NewCrawlerService(store, settings, services.NewHostMetrics(), nil, services.NewHTTPTransport(services.TransportConfig{}))
+	return NewURLController(store, crawler, nil, nil)
+}
+
+// patchURL sends PATCH /api/urls/:id with body and returns the response
+func patchURL(uc *URLController, id uint, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PATCH("/api/urls/:id", uc.UpdateURL)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/urls/%d", id), strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestUpdateURLRenameOntoDeletedURL(t *testing.T) {
+	store := repository.NewMemoryStore()
+	deleted := models.URL{URL: "https://www.example.com/old"}
+	renamed := models.URL{URL: "https://www.example.com/new"}
+	for _, url := range []*models.URL{&deleted, &renamed} {
+		if err := store.URLs().Create(url); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := store.URLs().Delete(deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// The deleted URL still holds its address, which AddURL restores it with
+	recorder := patchURL(newTestURLController(t, store), renamed.ID, `{"url": "https://example.com/old"}`)
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("PATCH = %d %s, want 409", recorder.Code, recorder.Body)
+	}
+	var response utils.APIResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if response.Code != utils.ErrCodeURLAlreadyExists {
+		t.Errorf("code = %s, want %s", response.Code, utils.ErrCodeURLAlreadyExists)
+	}
+	if url, err := store.URLs().Get(renamed.ID); err != nil || url.URL != renamed.URL {
+		t.Errorf("Get after the refused rename = %+v, %v, want URL %s", url, err, renamed.URL)
+	}
+}
+
+func TestUpdateURLRenameWhileRunning(t *testing.T) {
+	store := repository.NewMemoryStore()
+	url := models.URL{URL: "https://www.example.com/old", Status: "running"}
+	if err := store.URLs().Create(&url); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	uc := newTestURLController(t, store)
+
+	// A stored crawl of the URL is in progress
+	if err := store.CrawlQueue().Enqueue(&models.QueuedCrawl{URLID: url.ID}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if recorder := patchURL(uc, url.ID, `{"url": "https://www.example.com/new"}`); recorder.Code != http.StatusConflict {
+		t.Fatalf("PATCH during the crawl = %d %s, want 409", recorder.Code, recorder.Body)
+	}
+
+	// Without a registered crawl the status was left behind by a stopped server
+	if err := store.CrawlQueue().DequeueURL(url.ID); err != nil {
+		t.Fatalf("DequeueURL: %v", err)
+	}
+	if recorder := patchURL(uc, url.ID, `{"url": "https://www.example.com/new"}`); recorder.Code != http.StatusOK {
+		t.Fatalf("PATCH of a URL left running = %d %s, want 200", recorder.Code, recorder.Body)
+	}
+}
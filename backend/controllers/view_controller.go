@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ViewController handles the saved views of the URL list
+type ViewController struct {
+	store        repository.Store
+	views        *services.ViewService
+	responseUtil *utils.ResponseUtil
+}
+
+// NewViewController creates a new instance of ViewController
+func NewViewController(store repository.Store, views *services.ViewService) *ViewController {
+	return &ViewController{
+		store:        store,
+		views:        views,
+		responseUtil: utils.NewResponseUtil(),
+	}
+}
+
+// ViewRequest represents the request body for saving a view
+type ViewRequest struct {
+	Name   string            `json:"name" binding:"required"`
+	Filter models.ViewFilter `json:"filter"`
+	Sort   string            `json:"sort"`
+}
+
+// CreateView handles POST /api/views - Saves a named filter and sort of the URL list
+func (vc *ViewController) CreateView(c *gin.Context) {
+	view := models.View{CreatedBy: c.GetString(middleware.ContextUserKey)}
+	if !vc.applyRequest(c, &view) {
+		return
+	}
+
+	if err := vc.views.Save(&view); err != nil {
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save view")
+		return
+	}
+
+	vc.responseUtil.Created(c, view, "View saved successfully")
+}
+
+// GetViews handles GET /api/views - Lists the saved views
+func (vc *ViewController) GetViews(c *gin.Context) {
+	views, err := vc.views.List()
+	if err != nil {
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve views")
+		return
+	}
+
+	vc.responseUtil.Success(c, views, "Views retrieved successfully")
+}
+
+// GetView handles GET /api/views/:id - Returns a saved view
+func (vc *ViewController) GetView(c *gin.Context) {
+	view, ok := vc.findView(c)
+	if !ok {
+		return
+	}
+
+	vc.responseUtil.Success(c, view, "View retrieved successfully")
+}
+
+// UpdateView handles PUT /api/views/:id - Replaces the name, filter, and sort of a view
+func (vc *ViewController) UpdateView(c *gin.Context) {
+	view, ok := vc.findView(c)
+	if !ok {
+		return
+	}
+	if !vc.applyRequest(c, view) {
+		return
+	}
+
+	if err := vc.views.Save(view); err != nil {
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save view")
+		return
+	}
+
+	vc.responseUtil.Success(c, view, "View saved successfully")
+}
+
+// DeleteView handles DELETE /api/views/:id - Deletes a saved view
+func (vc *ViewController) DeleteView(c *gin.Context) {
+	view, ok := vc.findView(c)
+	if !ok {
+		return
+	}
+
+	if err := vc.views.Delete(view.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to delete view")
+		return
+	}
+
+	vc.responseUtil.Success(c, nil, "View deleted successfully")
+}
+
+// GetViewURLs handles GET /api/views/:id/urls - Lists the URLs matching a view in its order
+// Supports ?page= and ?per_page= like GET /api/urls, as well as ?fields= and ?tz=
+func (vc *ViewController) GetViewURLs(c *gin.Context) {
+	view, ok := vc.findView(c)
+	if !ok {
+		return
+	}
+	loc, err := utils.ParseTimezone(c)
+	if err != nil {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	pageRequest, err := utils.ParsePagination(c)
+	if err != nil {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, err.Error())
+		return
+	}
+	if pageRequest != nil && pageRequest.Keyset {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Views don't support keyset paging")
+		return
+	}
+
+	replica := vc.store.Replica()
+	urls, err := replica.URLs().List()
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve URLs from database: %v", err))
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URLs")
+		return
+	}
+	summaries, err := utils.ViewURLs(replica.CrawlResults(), urls, *view, time.Now())
+	if err != nil {
+		utils.AppLogger.Error(fmt.Sprintf("Failed to retrieve crawl results from database: %v", err))
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve URLs")
+		return
+	}
+
+	data := map[string]interface{}{"view": view}
+	if pageRequest != nil {
+		page := utils.NewPagination(*pageRequest, int64(len(summaries)))
+		utils.SetLinkHeader(c, page)
+		data["pagination"] = page
+
+		start := min(pageRequest.Offset(), len(summaries))
+		summaries = summaries[start:min(start+pageRequest.PerPage, len(summaries))]
+	}
+
+	fields := utils.ParseFields(c)
+	enrichedURLs := []interface{}{}
+	for _, summary := range summaries {
+		enrichedURLs = append(enrichedURLs, utils.SummaryFields(replica.CrawlResults(), summary, fields, loc))
+	}
+	data["urls"] = enrichedURLs
+
+	vc.responseUtil.Success(c, data, "URLs retrieved successfully")
+}
+
+// applyRequest validates the request body and copies it onto view, responding with an error when it is invalid
+func (vc *ViewController) applyRequest(c *gin.Context, view *models.View) bool {
+	var request ViewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, "Invalid request body: name is required")
+		return false
+	}
+
+	view.Name = strings.TrimSpace(request.Name)
+	view.Filter = request.Filter
+	view.Sort = strings.TrimSpace(request.Sort)
+	if err := services.ValidateView(*view); err != nil {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeValidationFailed, fmt.Sprintf("Invalid view: %v", err))
+		return false
+	}
+
+	if existing, err := vc.views.FindByName(view.Name, view.ID); err == nil {
+		vc.responseUtil.Conflict(c, utils.ErrCodeViewAlreadyExists, "A view with this name already exists", map[string]interface{}{
+			"existing_view": existing,
+		})
+		return false
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to save view")
+		return false
+	}
+	return true
+}
+
+// findView loads the view named by the :id path parameter, responding with an error when there is none
+func (vc *ViewController) findView(c *gin.Context) (*models.View, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		vc.responseUtil.BadRequest(c, utils.ErrCodeInvalidID, "Invalid view ID format")
+		return nil, false
+	}
+
+	view, err := vc.views.Get(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			vc.responseUtil.NotFound(c, utils.ErrCodeViewNotFound, "View not found")
+			return nil, false
+		}
+		utils.AppLogger.Error(err.Error())
+		vc.responseUtil.InternalServerError(c, utils.ErrCodeInternalError, "Failed to retrieve view")
+		return nil, false
+	}
+	return view, true
+}
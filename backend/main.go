@@ -2,10 +2,16 @@ package main
 
 import (
 	"log"
+	"os"
+	_ "time/tzdata" // Embedded zone database for ?tz= on hosts without one
 
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/config"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/routes"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -17,28 +23,76 @@ func init() {
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if cfg.JSONFieldCase != utils.JSONCaseSnake && cfg.JSONFieldCase != utils.JSONCaseCamel {
+		log.Fatal("Invalid JSON_FIELD_CASE, it must be snake or camel: ", cfg.JSONFieldCase)
+	}
+
+	// Encrypt sensitive columns; this must be set up before the models are first used
+	fieldCipher, err := repository.NewFieldCipher(cfg.EncryptionKeys)
+	if err != nil {
+		log.Fatal("Invalid encryption keys:", err)
+	}
+	repository.UseFieldCipher(fieldCipher, cfg.EncryptSnapshots)
 
 	// Initialize database
 	db := config.InitDB(cfg)
 
 	// Run migrations (create tables automatically)
-	err := db.AutoMigrate(
+	err = db.AutoMigrate(
+		&models.Project{},
 		&models.URL{},
 		&models.CrawlResult{},
 		&models.Link{},
+		&models.CrawlPage{},
+		&models.InternalBrokenLink{},
+		&models.Finding{},
+		&models.PageSnapshot{},
+		&models.SnapshotBody{},
+		&models.Settings{},
+		&models.BatchJob{},
+		&models.QueuedCrawl{},
+		&models.User{},
+		&models.Session{},
+		&models.TrackedIssue{},
+		&models.HookSubscription{},
+		&models.Schedule{},
+		&models.View{},
+		&models.Annotation{},
+		&models.FetchAgent{},
+		&models.Subscription{},
+		&models.MonthlyUsage{},
 	)
 	if err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 	log.Println("✅ Database migrations completed")
 
+	// "restore <file>" replaces the database contents with a backup instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if len(os.Args) != 3 {
+			log.Fatal("Usage: restore <backup file>")
+		}
+		info, err := services.NewBackupService(db, services.BackupConfig{}).Restore(os.Args[2])
+		if err != nil {
+			log.Fatal("Failed to restore backup:", err)
+		}
+		log.Printf("✅ Restored %d rows into %d tables from %s", info.Rows, info.Tables, os.Args[2])
+		return
+	}
+
 	// Set Gin mode based on environment
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Initialize router
-	router := gin.Default()
+	// Initialize router with recovery and access logging
+	router := gin.New()
+	// Only trust forwarded client IPs from the configured proxies, so login limits and audit logs can't be spoofed
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	router.RemoteIPHeaders = cfg.ClientIPHeaders
+	router.Use(gin.Recovery(), middleware.AccessLogMiddleware())
 
 	// Basic health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -50,7 +104,7 @@ func main() {
 	})
 
 	// Setup API routes
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, cfg, fieldCipher)
 
 	// Start server
 	port := "8080" // Simple default port
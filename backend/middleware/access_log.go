@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogMiddleware logs method, path, status, latency, and authenticated user for every request
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			path += "?" + c.Request.URL.RawQuery
+		}
+
+		c.Next()
+
+		// The auth middleware stores the username once the token has been validated
+		user := c.GetString(ContextUserKey)
+		if user == "" {
+			user = "-"
+		}
+
+		utils.AppLogger.Info(fmt.Sprintf("%s %s %d %s user=%s ip=%s",
+			c.Request.Method,
+			path,
+			c.Writer.Status(),
+			time.Since(start).Round(time.Microsecond),
+			user,
+			c.ClientIP(),
+		))
+	}
+}
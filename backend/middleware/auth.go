@@ -1,47 +1,73 @@
 package middleware
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// Simple in-memory session store
-var activeSessions = make(map[string]bool)
-
-// Default credentials
+// Gin context keys set for authenticated requests
 const (
-	defaultUsername = "admin"
-	defaultPassword = "admin"
+	ContextUserKey    = "username"
+	ContextSessionKey = "session_id"
 )
 
+// passwordChangeRoutes are the only routes a session may use before the forced password change
+var passwordChangeRoutes = map[string]bool{
+	"/api/auth/me":              true,
+	"/api/auth/change-password": true,
+}
+
 // AuthMiddleware checks for valid session token
-func AuthMiddleware() gin.HandlerFunc {
+// The token comes from the Authorization header, or from the session cookie when the cookie mode is enabled
+func AuthMiddleware(sessions *services.SessionService, cookies CookieConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var token string
+
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		if authHeader != "" {
+			// Extract token from "Bearer <token>" format
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": utils.Localize(c, "Invalid authorization header format"), "code": utils.ErrCodeInvalidAuthHeader})
+				c.Abort()
+				return
+			}
+			token = parts[1]
+		} else if token = sessionTokenFromCookie(c, cookies); token != "" {
+			// Browsers attach cookies to cross-site requests, so cookie sessions need a CSRF token
+			if !validCSRF(c, token) {
+				c.JSON(http.StatusForbidden, gin.H{"error": utils.Localize(c, "Missing or invalid CSRF token"), "code": utils.ErrCodeInvalidCSRFToken})
+				c.Abort()
+				return
+			}
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": utils.Localize(c, "Authorization header required"), "code": utils.ErrCodeAuthRequired})
 			c.Abort()
 			return
 		}
 
-		// Extract token from "Bearer <token>" format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		// Check if token belongs to an active session
+		session, err := sessions.Validate(token)
+		if err != nil {
+			if err != services.ErrSessionNotFound {
+				utils.AppLogger.Error(fmt.Sprintf("Failed to validate session: %v", err))
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": utils.Localize(c, "Invalid or expired token"), "code": utils.ErrCodeInvalidToken})
 			c.Abort()
 			return
 		}
 
-		token := parts[1]
+		c.Set(ContextUserKey, session.Username)
+		c.Set(ContextSessionKey, session.ID)
 
-		// Check if token exists in active sessions
-		if !activeSessions[token] {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		if session.MustChangePassword && !passwordChangeRoutes[c.FullPath()] {
+			c.JSON(http.StatusForbidden, gin.H{"error": utils.Localize(c, "Password must be changed before continuing"), "code": utils.ErrCodePasswordChangeRequired})
 			c.Abort()
 			return
 		}
@@ -50,35 +76,11 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// Login creates a new session token
-func Login(username, password string) (string, bool) {
-	if username == defaultUsername && password == defaultPassword {
-		// Generate a simple token (in production, use proper JWT or similar)
-		token := generateSimpleToken()
-		activeSessions[token] = true
-		return token, true
-	}
-	return "", false
-}
-
-// Logout removes the session token
-func Logout(token string) {
-	delete(activeSessions, token)
-}
-
-// Simple token generator (not secure for production)
-func generateSimpleToken() string {
-	bytes := make([]byte, 16)
-	rand.Read(bytes)
-	return "auth_token_" + hex.EncodeToString(bytes)
-}
-
-// Simple random string generator (keeping for backward compatibility)
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[len(activeSessions)%len(charset)+i%len(charset)]
+// SessionToken returns the token of a "Bearer <token>" Authorization header or the session cookie, or an empty string
+func SessionToken(c *gin.Context, cookies CookieConfig) string {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 {
+		return parts[1]
 	}
-	return string(b)
+	return sessionTokenFromCookie(c, cookies)
 }
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionMiddleware gzips responses for clients that accept it, once they reach the configured size
+// and have one of the configured content types. Link lists and exports shrink several times over;
+// small responses are sent as they are, since compressing them costs more than it saves.
+func CompressionMiddleware(settings *services.SettingsService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := settings.Get()
+		if !current.CompressionEnabled || c.Request.Method == http.MethodHead || !acceptsGzip(c.Request.Header.Get("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		// Responses are rendered whole, so they are buffered to learn their size before anything is sent
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Accept-Encoding")
+		body := writer.body.Bytes()
+		if len(body) < current.CompressionMinBytes || header.Get("Content-Encoding") != "" ||
+			!compressibleType(header.Get("Content-Type"), current.CompressionTypes) {
+			c.Writer.Write(body)
+			return
+		}
+
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(body); err != nil || gz.Close() != nil {
+			c.Writer.Write(body)
+			return
+		}
+		header.Set("Content-Encoding", "gzip")
+		header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+		c.Writer.Write(compressed.Bytes())
+	}
+}
+
+// bufferedWriter holds the response body until the handlers are done; the status is passed on,
+// gin only sends it with the first write
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, i.e. lists it or * with a non-zero weight
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			weight, err := strconv.ParseFloat(strings.TrimSpace(q), 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// compressibleType reports whether the media type of a Content-Type header is in the comma-separated list
+func compressibleType(contentType, types string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, candidate := range strings.Split(types, ",") {
+		if strings.EqualFold(strings.TrimSpace(candidate), mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"mime"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JSONCaseMiddleware rewrites the field names of JSON responses to camelCase for clients that ask for it
+// with the X-JSON-Case header, or for every client when defaultCase is camel. Handlers and models keep
+// their snake_case json tags; only the encoded response changes, so both casings always match.
+func JSONCaseMiddleware(defaultCase string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Caches must not hand a response in one casing to a client asking for the other
+		if header := c.Writer.Header(); !strings.Contains(header.Get("Vary"), utils.JSONCaseHeader) {
+			header.Add("Vary", utils.JSONCaseHeader)
+		}
+		jsonCase := utils.JSONCase(c, defaultCase)
+		c.Set(utils.JSONCaseContextKey, jsonCase)
+		if jsonCase != utils.JSONCaseCamel {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if mediaType, _, _ := mime.ParseMediaType(c.Writer.Header().Get("Content-Type")); mediaType == "application/json" && len(body) > 0 {
+			if converted, err := utils.CamelCaseJSON(body); err == nil {
+				body = converted
+				c.Header(utils.JSONCaseHeader, utils.JSONCaseCamel)
+			}
+		}
+		c.Writer.Write(body)
+	}
+}
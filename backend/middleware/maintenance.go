@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMaintenanceMessage = "The service is undergoing maintenance, please try again shortly"
+	maintenanceRetryAfter     = 60 // Seconds clients are asked to wait before retrying a refused request
+)
+
+// maintenanceRoutes keep accepting writes during maintenance: switching it off, logging in and out to read,
+// analyzing submitted HTML, which stores nothing, taking a backup before a migration, and pausing crawls
+var maintenanceRoutes = map[string]bool{
+	"/api/admin/maintenance": true,
+	"/api/auth/login":        true,
+	"/api/auth/logout":       true,
+	"/api/analyze":           true,
+	"/api/admin/backups":     true,
+	"/api/admin/crawling":    true,
+}
+
+// MaintenanceMiddleware refuses writes with 503 while maintenance mode is on; reads are still served
+// Crawls are only started by writes, so no new crawls start and the running ones drain
+func MaintenanceMiddleware(settings *services.SettingsService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := settings.Get()
+		if !current.MaintenanceMode || isRead(c.Request.Method) || maintenanceRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		message := current.MaintenanceMessage
+		if message == "" {
+			message = defaultMaintenanceMessage
+		}
+		c.Header("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, utils.APIResponse{
+			Success: false,
+			Error:   utils.Localize(c, message),
+			Code:    utils.ErrCodeMaintenance,
+			Data:    gin.H{"maintenance": true, "retry_after_seconds": maintenanceRetryAfter},
+		})
+	}
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
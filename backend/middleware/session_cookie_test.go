@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRFMiddlewareLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/logout", CSRFMiddleware(CookieConfig{Enabled: true}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		csrf   string
+		bearer bool
+		want   int
+	}{
+		{name: "cross-site request without the CSRF header", want: http.StatusForbidden},
+		{name: "wrong CSRF header", csrf: "wrong", want: http.StatusForbidden},
+		{name: "CSRF header of the session", csrf: CSRFToken("session"), want: http.StatusOK},
+		{name: "bearer token", bearer: true, want: http.StatusOK},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+		request.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session"})
+		if test.csrf != "" {
+			request.Header.Set(CSRFHeaderName, test.csrf)
+		}
+		if test.bearer {
+			request.Header.Set("Authorization", "Bearer session")
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: status %d, want %d", test.name, recorder.Code, test.want)
+		}
+	}
+}
@@ -8,42 +8,940 @@ import (
 
 // URL represents a website URL to be analyzed
 type URL struct {
-	ID        uint           `json:"id" gorm:"primarykey"`
-	URL       string         `json:"url" gorm:"unique;not null"`
-	Status    string         `json:"status" gorm:"default:'queued'"` // queued, running, completed, error
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primarykey"`
+	URL              string         `json:"url" gorm:"unique;not null"`            // ASCII form: punycode host, percent-encoded path
+	DisplayURL       string         `json:"display_url"`                           // Unicode form for display
+	Status           string         `json:"status" gorm:"default:'queued'"`        // queued, running, completed, error, blocked
+	LastError        string         `json:"last_error,omitempty" gorm:"size:1024"` // Why the last crawl failed or was blocked
+	Tags             []string       `json:"tags" gorm:"serializer:json"`
+	CrawlConfig      CrawlConfig    `json:"crawl_config" gorm:"serializer:json"`
+	MonitorEnabled   bool           `json:"monitor_enabled"` // Include the URL in scheduled monitoring
+	ProjectID        *uint          `json:"project_id" gorm:"index"`
+	CreatedBy        string         `json:"created_by,omitempty" gorm:"size:255;index"` // Username of the session that added the URL, its crawls are accounted to it
+	CommitSHA        string         `json:"commit_sha,omitempty"`                       // Commit deployed at the URL, crawl outcomes are posted to it on GitHub
+	IdempotencyKey   *string        `json:"-" gorm:"size:255;uniqueIndex"`              // Idempotency-Key header of the request that added the URL
+	LatestCrawlID    *uint          `json:"latest_crawl_id"`                            // Counters below describe this crawl; saved together with it
+	LinksCount       int            `json:"links_count"`
+	BrokenLinksCount int            `json:"broken_links_count"`      // Throttled links are unknown, not broken
+	CreatedAt        time.Time      `json:"created_at" gorm:"index"` // Keyset pagination walks this index, which includes the id
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Progress of the running crawl, reset when it starts and when it ends
+	CrawlPhase   string `json:"crawl_phase,omitempty" gorm:"size:32"` // waiting, fetching, analyzing, checking_links, crawling_site, saving
+	LinksChecked int    `json:"links_checked"`
+	LinksTotal   int    `json:"links_total"` // Grows while a site crawl discovers more links
+
+	// Recrawls of adaptive schedules, learned from how often the page changes
+	RecrawlIntervalMinutes int        `json:"recrawl_interval_minutes,omitempty"` // 0 until the first crawl under an adaptive schedule
+	NextRecrawlAt          *time.Time `json:"next_recrawl_at,omitempty"`          // Nil is due right away
+}
+
+// Phases of a running crawl
+const (
+	CrawlPhaseWaiting       = "waiting" // For a crawl window or a free worker
+	CrawlPhaseFetching      = "fetching"
+	CrawlPhaseAnalyzing     = "analyzing"
+	CrawlPhaseCheckingLinks = "checking_links"
+	CrawlPhaseCrawlingSite  = "crawling_site"
+	CrawlPhaseSaving        = "saving"
+)
+
+// CrawlConfig holds per-URL overrides of the crawler defaults
+type CrawlConfig struct {
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"` // Page fetch timeout, 0 uses the runtime setting
+	UserAgent      string `json:"user_agent,omitempty"`      // User-Agent header sent when fetching the page
+	MaxPages       int    `json:"max_pages,omitempty"`       // Pages visited by a site crawl, 0 or 1 crawls only the URL itself
+	MaxDepth       int    `json:"max_depth,omitempty"`       // Internal links followed from the URL, 0 uses the default depth
+	SpellCheck     bool   `json:"spell_check,omitempty"`     // Report misspelled words of the visible text as findings
+	Priority       int    `json:"priority,omitempty"`        // 0-10, higher priorities start first among the crawls of a project or batch
+
+	RotateUserAgents bool `json:"rotate_user_agents,omitempty"` // Retry with browser user agents when bot protection blocks the page
+
+	FollowMetaRefresh bool `json:"follow_meta_refresh,omitempty"` // Analyze the page a <meta http-equiv="refresh"> redirects to
+	IncludeFrames     bool `json:"include_frames,omitempty"`      // Fetch same-origin frames and iframes and analyze their content with the page
+
+	Scope *LinkScope `json:"scope,omitempty"` // Which links are internal; nil uses the scope of the project
+
+	Regions []string `json:"regions,omitempty"` // Regions of fetch agents the page is also fetched from, to compare what they get
+
+	Profiles []string `json:"profiles,omitempty"` // Device profiles the page is also fetched and analyzed as: desktop, mobile
+}
+
+// Link scope domains
+const (
+	LinkScopeHost              = "host"               // Only links to the exact host of the URL are internal
+	LinkScopeRegistrableDomain = "registrable_domain" // Links to any host of the same registrable domain, e.g. shop.example.co.uk, are internal
+)
+
+// LinkScope decides which links of a crawl count as internal; zero values keep the exact host
+type LinkScope struct {
+	Domain     string `json:"domain,omitempty"`      // host, registrable_domain
+	PathPrefix string `json:"path_prefix,omitempty"` // Only links below this path are internal, e.g. /blog/
+}
+
+// Policy term rules
+const (
+	PolicyRuleRequired  = "required"  // The term must appear on every page
+	PolicyRuleForbidden = "forbidden" // The term must not appear on any page
+)
+
+// PolicyTerm is a word or phrase a project requires or forbids in page text
+type PolicyTerm struct {
+	Term string `json:"term"`
+	Rule string `json:"rule"` // required, forbidden
+}
+
+// PageBudget limits the weight of every crawled page of a project; zero values are not checked
+type PageBudget struct {
+	MaxHTMLBytes         int64 `json:"max_html_bytes,omitempty"`
+	MaxScripts           int   `json:"max_scripts,omitempty"`
+	MaxThirdPartyDomains int   `json:"max_third_party_domains,omitempty"`
+}
+
+// HeadingRules checks the title and H1 headings of every crawled page of a project; zero values are not checked
+// Lengths count characters
+type HeadingRules struct {
+	TitleMinLength  int      `json:"title_min_length,omitempty"`
+	TitleMaxLength  int      `json:"title_max_length,omitempty"`
+	H1MinLength     int      `json:"h1_min_length,omitempty"`
+	H1MaxLength     int      `json:"h1_max_length,omitempty"`
+	Keywords        []string `json:"keywords,omitempty"`         // Target keywords; the title and the H1s must each contain one of them
+	LengthSeverity  string   `json:"length_severity,omitempty"`  // Severity of length findings, defaults to warning
+	KeywordSeverity string   `json:"keyword_severity,omitempty"` // Severity of missing keyword findings, defaults to warning
+}
+
+// CustomRule is a pass/fail check of a project's pages, written as an expression over the crawl result
+type CustomRule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`         // CEL-like; the page passes when it evaluates to true
+	Severity   string `json:"severity,omitempty"` // Defaults to warning
+	Category   string `json:"category,omitempty"` // Defaults to content
+	Message    string `json:"message,omitempty"`  // Message of the finding when the page fails, defaults to one naming the rule
+}
+
+// CrawlSchedule restricts when batch crawls of a project's URLs may run, e.g. only at night on production sites
+type CrawlSchedule struct {
+	Timezone string        `json:"timezone,omitempty"` // IANA time zone of the windows, e.g. Europe/Berlin; defaults to UTC
+	Windows  []CrawlWindow `json:"windows,omitempty"`  // No windows allow crawls at any time
+}
+
+// CrawlWindow is a daily period in which crawls may start, as HH:MM local times
+// A window whose end is before its start spans midnight
+type CrawlWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// GitHubIntegration posts the crawl outcomes of a project's URLs as GitHub commit statuses
+// The token is stored on the project itself so it is never serialized into responses
+type GitHubIntegration struct {
+	Repo     string `json:"repo,omitempty"`    // owner/name
+	Context  string `json:"context,omitempty"` // Status context shown on the commit, defaults to sykell-url-analyzer
+	TokenSet bool   `json:"token_set"`         // Whether a token is configured, derived when the project is loaded
+}
+
+// AnalyzerPlugin is an external HTTP service that analyzes the pages of a project after the core crawl
+// It receives a POST of the page and answers with findings, for checks specific to an organization
+type AnalyzerPlugin struct {
+	Name string `json:"name"` // Lowercase letters, digits, - and _; stored in the details of its findings
+	URL  string `json:"url"`
+}
+
+// PluginIntegration lists the analyzer plugins of a project
+// Like the GitHub token, the secret signing the requests is stored on the project itself
+type PluginIntegration struct {
+	Plugins   []AnalyzerPlugin `json:"plugins,omitempty"`
+	SecretSet bool             `json:"secret_set"`
+}
+
+// Issue tracker providers
+const (
+	IssueProviderJira   = "jira"
+	IssueProviderLinear = "linear"
+)
+
+// IssueTrackerIntegration opens tickets for new broken links and failed crawls of a project's URLs
+// Like the GitHub token, the API token is stored on the project itself
+type IssueTrackerIntegration struct {
+	Provider   string `json:"provider,omitempty"`    // jira, linear; empty disables the integration
+	BaseURL    string `json:"base_url,omitempty"`    // Jira site, e.g. https://acme.atlassian.net
+	Email      string `json:"email,omitempty"`       // Jira account the API token belongs to
+	ProjectKey string `json:"project_key,omitempty"` // Jira project tickets are created in
+	IssueType  string `json:"issue_type,omitempty"`  // Jira issue type, defaults to Bug
+	TeamID     string `json:"team_id,omitempty"`     // Linear team tickets are created in
+	TokenSet   bool   `json:"token_set"`
+}
+
+// Project groups URLs that share analysis rules
+type Project struct {
+	ID          uint              `json:"id" gorm:"primarykey"`
+	Name        string            `json:"name" gorm:"size:255;unique;not null"`
+	PolicyTerms []PolicyTerm      `json:"policy_terms" gorm:"serializer:json"`
+	Budget      PageBudget        `json:"budget" gorm:"serializer:json"`
+	GitHub      GitHubIntegration `json:"github" gorm:"column:github;serializer:json"`
+	GitHubToken string            `json:"-" gorm:"column:github_token;type:text;serializer:encrypted"` // Token with the repo:status scope
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	IssueTracker      IssueTrackerIntegration `json:"issue_tracker" gorm:"serializer:json"`
+	IssueTrackerToken string                  `json:"-" gorm:"type:text;serializer:encrypted"`
+
+	HeadingRules HeadingRules `json:"heading_rules" gorm:"serializer:json"`
+
+	CrawlSchedule CrawlSchedule `json:"crawl_schedule" gorm:"serializer:json"`
+
+	LinkScope LinkScope `json:"link_scope" gorm:"serializer:json"` // Default scope of the project's URLs
+
+	CustomRules []CustomRule `json:"custom_rules" gorm:"serializer:json"`
+
+	Plugins      PluginIntegration `json:"plugins" gorm:"serializer:json"`
+	PluginSecret string            `json:"-" gorm:"type:text;serializer:encrypted"`
+
+	// WatchGroup marks a project of competitor URLs: the schedule crawls all of them, not just the
+	// monitored ones, and their crawls record the pages of the site's sitemaps for the watch report
+	WatchGroup bool `json:"watch_group"`
+}
+
+// AfterFind derives whether the tokens of the integrations are configured
+func (p *Project) AfterFind(tx *gorm.DB) error {
+	p.GitHub.TokenSet = p.GitHubToken != ""
+	p.IssueTracker.TokenSet = p.IssueTrackerToken != ""
+	p.Plugins.SecretSet = p.PluginSecret != ""
+	return nil
+}
+
+// Kinds of problems tickets are opened for
+const (
+	IssueKindBrokenLink  = "broken_link"
+	IssueKindCrawlFailed = "crawl_failed"
+)
+
+// TrackedIssue is a ticket opened in a project's issue tracker
+// Recurring problems update their ticket instead of opening a new one every crawl
+type TrackedIssue struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	ProjectID   uint       `json:"project_id" gorm:"not null;uniqueIndex:idx_tracked_issue"`
+	Fingerprint string     `json:"-" gorm:"size:64;not null;uniqueIndex:idx_tracked_issue"` // Hash of the kind and subject
+	Kind        string     `json:"kind"`                                                    // broken_link, crawl_failed
+	Subject     string     `json:"subject" gorm:"size:2048"`                                // Broken link, or the URL that failed to crawl
+	URLID       uint       `json:"url_id"`                                                  // URL whose crawl last saw the problem
+	Provider    string     `json:"provider"`
+	ExternalID  string     `json:"external_id"`
+	ExternalKey string     `json:"external_key"` // PROJ-123 in Jira, ENG-123 in Linear
+	ExternalURL string     `json:"external_url"`
+	Occurrences int        `json:"occurrences"` // Crawls that saw the problem
+	FirstSeenAt time.Time  `json:"first_seen_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	ResolvedAt  *time.Time `json:"resolved_at"` // Set when a crawl no longer sees the problem
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Pagination describes where a page sits in a paginated series such as a blog archive
+type Pagination struct {
+	Series         string `json:"series,omitempty"`          // URL of the series with the page number replaced by {page}
+	Page           int    `json:"page,omitempty"`            // 1 for a first page without a number in its URL
+	Prev           string `json:"prev,omitempty"`            // rel=prev target
+	Next           string `json:"next,omitempty"`            // rel=next target
+	InfiniteScroll bool   `json:"infinite_scroll,omitempty"` // Page loads more items with a script, e.g. a "load more" button
+}
+
+// PaginatedSeries is a paginated series visited by a site crawl
+type PaginatedSeries struct {
+	Series string   `json:"series"`
+	Pages  int      `json:"pages"`
+	URLs   []string `json:"urls"`
 }
 
 // CrawlResult stores the analysis results for a URL
 type CrawlResult struct {
-	ID               uint      `json:"id" gorm:"primarykey"`
-	URLID            uint      `json:"url_id" gorm:"not null"`
-	Title            string    `json:"title"`
-	HTMLVersion      string    `json:"html_version"`
-	H1Count          int       `json:"h1_count"`
-	H2Count          int       `json:"h2_count"`
-	H3Count          int       `json:"h3_count"`
-	H4Count          int       `json:"h4_count"`
-	H5Count          int       `json:"h5_count"`
-	H6Count          int       `json:"h6_count"`
-	InternalLinks    int       `json:"internal_links"`
-	ExternalLinks    int       `json:"external_links"`
-	InaccessibleLinks int      `json:"inaccessible_links"`
-	HasLoginForm     bool      `json:"has_login_form"`
-	CrawledAt        time.Time `json:"crawled_at"`
-	
+	ID                 uint             `json:"id" gorm:"primarykey"`
+	URLID              uint             `json:"url_id" gorm:"not null"`
+	Title              string           `json:"title"`
+	MetaDescription    string           `json:"meta_description" gorm:"type:text"`
+	HTMLVersion        string           `json:"html_version"`
+	H1Count            int              `json:"h1_count"`
+	H2Count            int              `json:"h2_count"`
+	H3Count            int              `json:"h3_count"`
+	H4Count            int              `json:"h4_count"`
+	H5Count            int              `json:"h5_count"`
+	H6Count            int              `json:"h6_count"`
+	InternalLinks      int              `json:"internal_links"`
+	ExternalLinks      int              `json:"external_links"`
+	InaccessibleLinks  int              `json:"inaccessible_links"`
+	PermanentRedirects int              `json:"permanent_redirects"`
+	HasLoginForm       bool             `json:"has_login_form"`
+	CrawledAt          time.Time        `json:"crawled_at"`
+	Diagnostics        CrawlDiagnostics `json:"diagnostics" gorm:"serializer:json"`
+	Content            ContentAnalysis  `json:"content" gorm:"serializer:json"`
+	Weight             PageWeight       `json:"weight" gorm:"serializer:json"`
+	Security           SecurityAnalysis `json:"security" gorm:"serializer:json"`
+	Mobile             MobileAudit      `json:"mobile" gorm:"serializer:json"`
+	WellKnown          WellKnownFiles   `json:"well_known" gorm:"serializer:json"`
+	BudgetStatus       string           `json:"budget_status"`                                    // pass, fail, empty when the project has no budget
+	AnalyzerVersion    int              `json:"analyzer_version" gorm:"not null;default:0;index"` // 0 for results stored before they were stamped
+
+	Pagination      Pagination        `json:"pagination" gorm:"serializer:json"`
+	PaginatedSeries []PaginatedSeries `json:"paginated_series,omitempty" gorm:"serializer:json"` // Series of a site crawl; their findings are reported once per series
+
+	MetaRefresh *MetaRefresh `json:"meta_refresh,omitempty" gorm:"serializer:json"`
+	Frames      []Frame      `json:"frames,omitempty" gorm:"serializer:json"` // Frames of the page, when CrawlConfig.IncludeFrames is set
+
+	Alternates []Alternate `json:"alternates,omitempty" gorm:"serializer:json"` // Alternate representations of the page
+
+	Regions []RegionFetch `json:"regions,omitempty" gorm:"serializer:json"` // The page as the fetch agents of CrawlConfig.Regions got it
+
+	Profiles []ProfileResult `json:"profiles,omitempty" gorm:"serializer:json"` // The crawl followed by the page as each of CrawlConfig.Profiles got it
+
+	Technologies []string      `json:"technologies" gorm:"serializer:json"`      // Software the page is built and served with, e.g. WordPress, nginx
+	Sitemap      *SitemapPages `json:"sitemap,omitempty" gorm:"serializer:json"` // Pages of the site's sitemaps, for crawls of watch groups
+
+	// Links and resources on the page's registrable domain versus elsewhere, to follow third-party creep
+	FirstPartyLinks       int `json:"first_party_links"`
+	ThirdPartyLinks       int `json:"third_party_links"`
+	FirstPartyResources   int `json:"first_party_resources"`
+	ThirdPartyResources   int `json:"third_party_resources"`
+	ThirdPartyDomainCount int `json:"third_party_domain_count"` // Registrable domains of weight.third_party_domains
+
+	ContentHash string `json:"content_hash,omitempty" gorm:"size:64"` // SHA-256 of the HTML as served, to tell whether the page changed
+
+	// What the crawl cost, for capacity planning and billing; 0 requests for crawls stored before it was counted
+	Requests        int   `json:"requests"`         // HTTP requests sent, retries included
+	BytesDownloaded int64 `json:"bytes_downloaded"` // Response bodies as read, after Content-Encoding was decoded
+	DurationMS      int64 `json:"duration_ms"`      // Wall time of the crawl
+
+	// Findings compared with the previous crawl of the URL, see Finding.Lifecycle
+	FindingsNew        int `json:"findings_new"`
+	FindingsPersisting int `json:"findings_persisting"`
+	FindingsResolved   int `json:"findings_resolved"`
+
 	// Relationships
-	Links []Link `json:"links,omitempty"`
+	Links               []Link               `json:"links,omitempty"`
+	Pages               []CrawlPage          `json:"pages,omitempty"`                 // Extra pages visited by a site crawl
+	InternalBrokenLinks []InternalBrokenLink `json:"internal_broken_links,omitempty"` // Broken internal link targets of a site crawl
+	Findings            []Finding            `json:"findings,omitempty"`
+	Snapshot            *PageSnapshot        `json:"-"` // HTML of the page, kept for the latest crawl of each URL
+}
+
+// SitemapPages lists the pages a site's sitemaps list
+type SitemapPages struct {
+	Sitemaps  []string `json:"sitemaps"`        // Sitemap files read, including those of sitemap indexes
+	URLs      []string `json:"urls"`            // Sorted
+	Truncated bool     `json:"truncated"`       // The sitemaps list more pages or files than are read
+	Error     string   `json:"error,omitempty"` // First sitemap that couldn't be read
+}
+
+// MetaRefresh is a <meta http-equiv="refresh"> of a page, which browsers follow like a redirect after Delay seconds
+type MetaRefresh struct {
+	URL      string `json:"url"`                 // Page carrying the refresh
+	Delay    int    `json:"delay"`               // Seconds before the browser follows it
+	Target   string `json:"target,omitempty"`    // Absolute destination; empty when the page only reloads itself
+	Followed bool   `json:"followed"`            // The crawler followed it, so the result analyzes FinalURL instead of URL
+	Hops     int    `json:"hops,omitempty"`      // Meta refreshes followed one after another
+	FinalURL string `json:"final_url,omitempty"` // Page that was analyzed after following
+}
+
+// Frame is a <frame> or <iframe> of a page
+type Frame struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Included   bool   `json:"included"`          // Its content was analyzed as part of the page
+	Skipped    string `json:"skipped,omitempty"` // Why it wasn't included: cross_origin, limit, failed
+}
+
+// Kinds of alternate representations
+const (
+	AlternatePrintStylesheet = "print_stylesheet"     // <link rel="stylesheet" media="print">
+	AlternateStylesheet      = "alternate_stylesheet" // <link rel="alternate stylesheet">, offered by browsers as a style choice
+	AlternateMedia           = "media"                // rel="alternate" for a media query, e.g. a separate mobile site
+	AlternatePDF             = "pdf"
+	AlternateFeed            = "feed" // RSS or Atom
+	AlternateLanguage        = "language"
+	AlternateOther           = "other"
+)
+
+// Alternate is a stylesheet or alternate link of a page that serves it in another form
+type Alternate struct {
+	Kind       string `json:"kind"`
+	URL        string `json:"url"`
+	Media      string `json:"media,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Hreflang   string `json:"hreflang,omitempty"`
+	Checked    bool   `json:"checked"` // CI checks and analyses of stored HTML don't request alternates
+	StatusCode int    `json:"status_code,omitempty"`
+	Resolves   bool   `json:"resolves"`
+}
+
+// Differences a region fetch can have from the crawl
+const (
+	RegionDiffStatusCode = "status_code"
+	RegionDiffFinalURL   = "final_url"
+	RegionDiffTitle      = "title"
+	RegionDiffContent    = "content" // Dynamic pages differ on every request, so this alone isn't reported as a finding
+)
+
+// RegionFetch is the page as the fetch agent of a region got it
+type RegionFetch struct {
+	Region      string   `json:"region"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	FinalURL    string   `json:"final_url,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Size        int64    `json:"size,omitempty"`
+	ContentHash string   `json:"content_hash,omitempty"` // SHA-256 of the body
+	Bot         string   `json:"bot,omitempty"`          // Bot protection that blocked the agent
+	DurationMS  int64    `json:"duration_ms"`
+	Error       string   `json:"error,omitempty"`
+	Differences []string `json:"differences,omitempty"`
+}
+
+// Device profiles a page can be fetched as; ProfileCrawl is the crawl itself, with its own user agent
+const (
+	ProfileCrawl   = "crawl"
+	ProfileDesktop = "desktop"
+	ProfileMobile  = "mobile"
+)
+
+// ProfileResult is the page as fetched and analyzed with the user agent of a device profile
+// Findings only holds those of the analyzers that need nothing but the page, so profiles compare like for like
+type ProfileResult struct {
+	Profile         string    `json:"profile"`
+	UserAgent       string    `json:"user_agent"`
+	StatusCode      int       `json:"status_code,omitempty"`
+	FinalURL        string    `json:"final_url,omitempty"`
+	Title           string    `json:"title,omitempty"`
+	MetaDescription string    `json:"meta_description,omitempty"`
+	Viewport        string    `json:"viewport,omitempty"` // Content of <meta name="viewport">
+	H1Count         int       `json:"h1_count"`
+	InternalLinks   int       `json:"internal_links"`
+	ExternalLinks   int       `json:"external_links"`
+	Size            int64     `json:"size,omitempty"`
+	Bot             string    `json:"bot,omitempty"` // Bot protection that blocked the profile
+	Error           string    `json:"error,omitempty"`
+	Findings        []Finding `json:"findings,omitempty"`
+}
+
+// FetchAgent is a forward proxy in another region that crawls can fetch their page through, see CrawlConfig.Regions
+type FetchAgent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Region    string    `json:"region" gorm:"size:64;uniqueIndex;not null"` // e.g. eu, us-east
+	ProxyURL  string    `json:"proxy_url" gorm:"size:2048;not null"`        // http(s)://host:port
+	Token     string    `json:"-" gorm:"type:text;serializer:encrypted"`    // Sent as the password of the proxy credentials
+	TokenSet  bool      `json:"token_set" gorm:"-"`
+	CreatedBy string    `json:"created_by"` // Username of the session that registered the agent
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AfterFind derives whether the agent has a token
+func (a *FetchAgent) AfterFind(tx *gorm.DB) error {
+	a.TokenSet = a.Token != ""
+	return nil
 }
 
 // Link represents an individual link found on a webpage
 type Link struct {
-	ID           uint   `json:"id" gorm:"primarykey"`
-	CrawlResultID uint   `json:"crawl_result_id" gorm:"not null"`
-	URL          string `json:"url"`
-	Type         string `json:"type"` // internal, external
-	StatusCode   int    `json:"status_code"`
-	IsAccessible bool   `json:"is_accessible"`
+	ID            uint   `json:"id" gorm:"primarykey"`
+	CrawlResultID uint   `json:"crawl_result_id" gorm:"not null;index"`
+	URL           string `json:"url"`
+	Type          string `json:"type"` // internal, external
+	StatusCode    int    `json:"status_code"`
+	IsAccessible  bool   `json:"is_accessible"`
+	Throttled     bool   `json:"throttled"`    // Host kept answering 429/503, so accessibility is unknown
+	CheckMethod   string `json:"check_method"` // HTTP method that determined the status: HEAD, GET
+
+	// Redirect details; StatusCode is the status of the final URL after following redirects
+	InitialStatusCode int    `json:"initial_status_code"`
+	FinalURL          string `json:"final_url"`
+	RedirectCount     int    `json:"redirect_count"`
+	PermanentRedirect bool   `json:"permanent_redirect"` // 301/308 to a 2xx page: the link should be updated to FinalURL
+
+	Soft404        bool     `json:"soft_404"`   // Answered 200 but the content looks like a "not found" page
+	Soft404Reasons []string `json:"-" gorm:"-"` // Heuristics that fired, kept only while building findings
+
+	Annotation *Annotation `json:"annotation,omitempty" gorm:"-"` // Triage of the link, attached when it is listed
+}
+
+// PageSnapshot is the HTML a crawl analyzed, so the analyzers can be re-run without fetching the page again
+type PageSnapshot struct {
+	ID            uint                `json:"id" gorm:"primarykey"`
+	CrawlResultID uint                `json:"crawl_result_id" gorm:"not null;uniqueIndex"`
+	FinalURL      string              `json:"final_url" gorm:"size:2048"` // URL the page was served from after redirects
+	Header        map[string][]string `json:"header" gorm:"serializer:json"`
+	BodyHash      string              `json:"body_hash,omitempty" gorm:"size:64;index"` // SnapshotBody holding the HTML; empty for snapshots stored with it
+	HTML          []byte              `json:"-" gorm:"type:mediumblob;serializer:encrypted_snapshot"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// SnapshotBody is the compressed HTML of page snapshots, stored once for all the snapshots of identical pages
+type SnapshotBody struct {
+	Hash       string    `json:"hash" gorm:"primaryKey;size:64"` // Hex SHA-256 of the uncompressed HTML
+	Encoding   string    `json:"encoding" gorm:"size:16"`
+	Size       int64     `json:"size"` // Uncompressed bytes
+	Data       []byte    `json:"-" gorm:"type:mediumblob;serializer:encrypted_snapshot"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // When a snapshot last referenced it
+}
+
+// CrawlPage is an additional page visited while following internal links during a site crawl
+type CrawlPage struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CrawlResultID uint      `json:"crawl_result_id" gorm:"not null;index"`
+	URL           string    `json:"url"`
+	Depth         int       `json:"depth"` // Number of links followed from the root page
+	StatusCode    int       `json:"status_code"`
+	Title         string    `json:"title"`
+	Error         string    `json:"error,omitempty"`
+	CrawledAt     time.Time `json:"crawled_at"`
+
+	Pagination Pagination `json:"pagination" gorm:"serializer:json"`
+}
+
+// InternalBrokenLink is an internal link target of a site crawl that doesn't resolve
+// Referrers lists every crawled page that links to it
+type InternalBrokenLink struct {
+	ID            uint     `json:"id" gorm:"primarykey"`
+	CrawlResultID uint     `json:"crawl_result_id" gorm:"not null;index"`
+	URL           string   `json:"url"`
+	StatusCode    int      `json:"status_code"`
+	Crawled       bool     `json:"crawled"` // Target was fetched as a page of the site crawl
+	Referrers     []string `json:"referrers" gorm:"serializer:json"`
+}
+
+// Finding types
+const (
+	FindingSoft404         = "soft_404"
+	FindingMisspelling     = "misspelling"
+	FindingPolicyForbidden = "policy_forbidden_term"
+	FindingPolicyMissing   = "policy_missing_term"
+	FindingBudgetExceeded  = "budget_exceeded"
+	FindingCSPBlocked      = "csp_blocked_resource"
+	FindingCSPPermissive   = "csp_permissive_directive"
+	FindingSRIMissing      = "sri_missing"
+	FindingSecurityTxt     = "security_txt"
+	FindingHSTS            = "hsts"
+	FindingIPv6Broken      = "ipv6_broken"
+	FindingTitleLength     = "title_length"
+	FindingH1Length        = "h1_length"
+	FindingKeywordMissing  = "keyword_missing"
+
+	FindingMetaDescriptionMissing   = "meta_description_missing"
+	FindingMetaDescriptionLength    = "meta_description_length"
+	FindingMetaDescriptionDuplicate = "meta_description_duplicate"
+
+	FindingMetaRefresh = "meta_refresh"
+	FindingJSRedirect  = "js_redirect"
+
+	FindingHTTPSNotUsed         = "https_not_used"
+	FindingHTTPSRedirectMissing = "https_redirect_missing"
+
+	FindingAlternateBroken = "alternate_broken"
+
+	FindingCustomRule      = "custom_rule"       // A custom rule of the project evaluated to false
+	FindingCustomRuleError = "custom_rule_error" // A custom rule couldn't be evaluated, e.g. it selects a missing field
+
+	FindingGeoBlocked = "geo_blocked" // A region's fetch agent couldn't get the page the crawl got
+	FindingGeoVariant = "geo_variant" // A region got a different final URL or title
+
+	FindingPluginFailed = "plugin_failed" // An analyzer plugin didn't answer with findings; its own findings have the types it chose
+
+	FindingViewportMissing       = "viewport_missing"
+	FindingViewportNotResponsive = "viewport_not_responsive" // The viewport doesn't set width=device-width
+	FindingViewportZoomDisabled  = "viewport_zoom_disabled"
+	FindingSmallFontSize         = "small_font_size"
+	FindingSmallTapTarget        = "small_tap_target"
+)
+
+// Finding categories
+const (
+	CategoryContent     = "content"
+	CategoryPerformance = "performance"
+	CategorySecurity    = "security"
+	CategoryNetwork     = "network"
+)
+
+// Finding severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Finding is a notable issue detected during a crawl, on the crawled page or one of its links
+type Finding struct {
+	ID            uint                   `json:"id" gorm:"primarykey"`
+	CrawlResultID uint                   `json:"crawl_result_id" gorm:"not null;index"`
+	Type          string                 `json:"type" gorm:"index"`
+	Category      string                 `json:"category" gorm:"index"` // content, performance, security
+	Severity      string                 `json:"severity"`              // info, warning, error
+	URL           string                 `json:"url"`                   // Page or link the finding applies to
+	Message       string                 `json:"message"`
+	Details       map[string]interface{} `json:"details,omitempty" gorm:"serializer:json"`
+
+	Lifecycle string `json:"lifecycle,omitempty" gorm:"size:16;index"` // Compared with the previous crawl; empty for results stored before it was tracked
+
+	Annotation *Annotation `json:"annotation,omitempty" gorm:"-"` // Triage of the finding, attached when it is listed
+}
+
+// Finding lifecycles; resolved findings are the previous crawl's findings that are gone, they aren't stored
+const (
+	FindingNew        = "new"
+	FindingPersisting = "persisting"
+	FindingResolved   = "resolved"
+)
+
+// Budget statuses
+const (
+	BudgetPass = "pass"
+	BudgetFail = "fail"
+)
+
+// PageWeight measures what a page makes the browser download
+type PageWeight struct {
+	HTMLBytes         int64    `json:"html_bytes"`
+	ScriptCount       int      `json:"script_count"`
+	ThirdPartyDomains []string `json:"third_party_domains"`
+
+	// Inline code versus external references
+	InlineScripts         int   `json:"inline_scripts"`
+	InlineScriptBytes     int64 `json:"inline_script_bytes"`
+	ExternalScripts       int   `json:"external_scripts"`
+	InlineStyles          int   `json:"inline_styles"` // <style> blocks
+	InlineStyleBytes      int64 `json:"inline_style_bytes"`
+	InlineStyleAttributes int   `json:"inline_style_attributes"` // Elements with a style="" attribute
+	ExternalStyles        int   `json:"external_styles"`
+
+	Protocol HTTPProtocol `json:"protocol"`
+}
+
+// HTTPProtocol records the protocol a page was fetched over and the newer ones its site supports
+type HTTPProtocol struct {
+	Negotiated string `json:"negotiated,omitempty"` // HTTP/1.1, HTTP/2.0; empty for crawls from before it was recorded
+	HTTP2      bool   `json:"http2"`                // Negotiated or advertised in Alt-Svc
+	HTTP3      bool   `json:"http3"`                // Advertised in Alt-Svc
+	AltSvc     string `json:"alt_svc,omitempty"`
+}
+
+// SecurityAnalysis collects the security checks of a page
+type SecurityAnalysis struct {
+	SRI   SRIAudit   `json:"sri"`
+	HSTS  HSTSCheck  `json:"hsts"`
+	HTTPS HTTPSCheck `json:"https"`
+}
+
+// HTTPSCheck compares the scheme a URL was submitted with to the HTTPS support of its host
+type HTTPSCheck struct {
+	SubmittedScheme      string `json:"submitted_scheme"`
+	ServedOverHTTPS      bool   `json:"served_over_https"`       // The analyzed page, after redirects, is an https:// URL
+	HTTPSAvailable       *bool  `json:"https_available"`         // nil when the page is served over HTTPS, so the variant wasn't probed
+	HTTPRedirectsToHTTPS *bool  `json:"http_redirects_to_https"` // nil when plain HTTP could not be checked
+}
+
+// HSTSCheck evaluates the Strict-Transport-Security header against the HSTS preload list requirements
+type HSTSCheck struct {
+	Header               string   `json:"header,omitempty"`
+	MaxAge               int64    `json:"max_age"`
+	IncludeSubDomains    bool     `json:"include_subdomains"`
+	Preload              bool     `json:"preload"`
+	HTTPRedirectsToHTTPS *bool    `json:"http_redirects_to_https"` // nil when plain HTTP could not be checked
+	PreloadEligible      bool     `json:"preload_eligible"`
+	Problems             []string `json:"problems,omitempty"` // Unmet preload requirements
+}
+
+// SRIAudit reports which external scripts and stylesheets carry Subresource Integrity hashes
+type SRIAudit struct {
+	ExternalResources    int           `json:"external_resources"`
+	WithIntegrity        int           `json:"with_integrity"`
+	ThirdPartyWithoutSRI int           `json:"third_party_without_sri"`
+	Missing              []SRIResource `json:"missing,omitempty"` // Third-party resources without integrity
+}
+
+// MobileAudit reports how usable the page's static markup is on a phone
+type MobileAudit struct {
+	Viewport        string `json:"viewport,omitempty"` // Content of <meta name="viewport">
+	Responsive      bool   `json:"responsive"`         // The viewport sets width=device-width
+	ZoomDisabled    bool   `json:"zoom_disabled"`      // user-scalable=no or a low maximum-scale
+	SmallFonts      int    `json:"small_fonts"`        // Font sizes in the page's own CSS below the legible size
+	SmallTapTargets int    `json:"small_tap_targets"`  // Links and controls declared smaller than the minimum target size
+	Friendly        bool   `json:"friendly"`           // None of the checks found a problem
+}
+
+// SRIResource is an external script or stylesheet
+type SRIResource struct {
+	Kind string `json:"kind"` // script, style
+	URL  string `json:"url"`
+}
+
+// WellKnownFiles records the well-known files of the crawled domain
+type WellKnownFiles struct {
+	Host        string         `json:"host"`
+	SecurityTxt SecurityTxt    `json:"security_txt"`
+	RobotsTxt   RobotsTxt      `json:"robots_txt"`
+	HumansTxt   WellKnownProbe `json:"humans_txt"`
+}
+
+// WellKnownProbe is the outcome of fetching a well-known file
+type WellKnownProbe struct {
+	Present    bool   `json:"present"`
+	URL        string `json:"url,omitempty"` // Where the file was found
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SecurityTxt is a parsed RFC 9116 security.txt
+type SecurityTxt struct {
+	WellKnownProbe
+	Contact            []string `json:"contact,omitempty"`
+	Expires            string   `json:"expires,omitempty"`
+	Expired            bool     `json:"expired"`
+	Encryption         []string `json:"encryption,omitempty"`
+	Policy             []string `json:"policy,omitempty"`
+	Canonical          []string `json:"canonical,omitempty"`
+	PreferredLanguages string   `json:"preferred_languages,omitempty"`
+	Signed             bool     `json:"signed"`
+	Problems           []string `json:"problems,omitempty"` // Violations of RFC 9116
+}
+
+// RobotsTxt summarizes a robots.txt
+type RobotsTxt struct {
+	WellKnownProbe
+	UserAgents  []string `json:"user_agents,omitempty"`
+	Sitemaps    []string `json:"sitemaps,omitempty"`
+	DisallowAll bool     `json:"disallow_all"` // "User-agent: *" is disallowed from "/"
+	CrawlDelay  string   `json:"crawl_delay,omitempty"`
+}
+
+// ContentAnalysis describes the main textual content of a page
+type ContentAnalysis struct {
+	Language            string  `json:"language"`        // ISO 639-1 code, empty when unknown
+	LanguageSource      string  `json:"language_source"` // html_lang, detected
+	WordCount           int     `json:"word_count"`
+	SentenceCount       int     `json:"sentence_count"`
+	AvgWordsPerSentence float64 `json:"avg_words_per_sentence"`
+	AvgSyllablesPerWord float64 `json:"avg_syllables_per_word"`
+	ReadingEase         float64 `json:"reading_ease"`        // Flesch reading ease, higher is easier
+	GradeLevel          float64 `json:"grade_level"`         // Flesch-Kincaid grade level
+	ReadabilityFormula  string  `json:"readability_formula"` // flesch-kincaid, flesch-amstad
+}
+
+// CrawlDiagnostics records how a crawl went, beyond the data extracted from the page
+type CrawlDiagnostics struct {
+	ThrottledHosts []string `json:"throttled_hosts,omitempty"` // Hosts that answered 429/503 during the crawl
+	Retries        int      `json:"retries"`                   // Requests retried after being throttled
+
+	RotatedUserAgent string `json:"rotated_user_agent,omitempty"` // User agent that got past bot protection
+
+	AddressFamilies []HostAddressFamilies `json:"address_families,omitempty"` // IPv4/IPv6 reachability of the hosts contacted
+}
+
+// HostAddressFamilies reports which IP versions a host resolved to and which ones the crawler reached it over
+type HostAddressFamilies struct {
+	Host        string `json:"host"`
+	IPv4        bool   `json:"ipv4"` // Host has A records
+	IPv6        bool   `json:"ipv6"` // Host has AAAA records
+	ReachedIPv4 bool   `json:"reached_ipv4"`
+	ReachedIPv6 bool   `json:"reached_ipv6"`
+	IPv4Error   string `json:"ipv4_error,omitempty"` // Last failure connecting over IPv4
+	IPv6Error   string `json:"ipv6_error,omitempty"` // Last failure connecting over IPv6
+	IPv6Broken  bool   `json:"ipv6_broken"`          // Host advertises IPv6 but only answers over IPv4
+}
+
+// Settings stores runtime-adjustable knobs that are applied without restarting the server
+type Settings struct {
+	ID                      uint      `json:"-" gorm:"primarykey"`
+	WorkerCount             int       `json:"worker_count"`               // Maximum number of concurrent crawls
+	MaxQueuedCrawls         int       `json:"max_queued_crawls"`          // Crawls allowed to wait for a worker before new ones are refused
+	SchedulingMode          string    `json:"scheduling_mode"`            // fair or fifo, order in which waiting crawls get a worker
+	PriorityAgingSeconds    int       `json:"priority_aging_seconds"`     // Wait after which a queued crawl gains one priority level, 0 disables aging
+	LinkCheckConcurrency    int       `json:"link_check_concurrency"`     // Parallel link checks per crawl
+	CrawlTimeoutSeconds     int       `json:"crawl_timeout_seconds"`      // Timeout for fetching the target page
+	LinkCheckTimeoutSeconds int       `json:"link_check_timeout_seconds"` // Timeout for each link check
+	UserAgent               string    `json:"user_agent"`                 // User-Agent header sent by the crawler
+	AcceptHeader            string    `json:"accept_header"`              // Accept header sent by the crawler
+	CrawlerInfoURL          string    `json:"crawler_info_url"`           // Page telling site owners about the crawler, added to the User-Agent
+	MaintenanceMode         bool      `json:"maintenance_mode"`           // Refuse writes and new crawls while running crawls drain
+	MaintenanceMessage      string    `json:"maintenance_message"`        // Shown to clients whose requests are refused
+	CrawlingPaused          bool      `json:"crawling_paused"`            // Hold queued crawls while running ones finish
+	UpdatedAt               time.Time `json:"updated_at"`
+
+	// Gzip compression of API responses
+	CompressionEnabled  bool   `json:"compression_enabled"`
+	CompressionMinBytes int    `json:"compression_min_bytes"` // Smaller responses are sent uncompressed
+	CompressionTypes    string `json:"compression_types"`     // Comma-separated content types that are compressed
+
+	// Link checks a crawl starts per second, spread over its concurrent checks; 0 doesn't limit them
+	LinkChecksPerSecond int `json:"link_checks_per_second"`
+}
+
+// User is an account that can log in; only a bcrypt hash of the password is stored
+type User struct {
+	ID                 uint      `json:"id" gorm:"primarykey"`
+	Username           string    `json:"username" gorm:"size:255;uniqueIndex;not null"`
+	PasswordHash       string    `json:"-" gorm:"not null"`
+	MustChangePassword bool      `json:"must_change_password"` // Set for seeded accounts until the password is changed
+	PasswordChangedAt  time.Time `json:"password_changed_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Subscription is the plan of a user in hosted mode, kept up to date by Stripe subscription webhooks
+type Subscription struct {
+	ID                   uint       `json:"id" gorm:"primarykey"`
+	Username             string     `json:"username" gorm:"size:255;uniqueIndex;not null"`
+	Plan                 string     `json:"plan" gorm:"size:64"`   // Empty when the subscribed price maps to no plan
+	Status               string     `json:"status" gorm:"size:32"` // Stripe status, e.g. active, past_due, canceled
+	StripeCustomerID     string     `json:"stripe_customer_id" gorm:"size:255;index"`
+	StripeSubscriptionID string     `json:"stripe_subscription_id" gorm:"size:255;index"`
+	CurrentPeriodEnd     *time.Time `json:"current_period_end"`
+	EventAt              time.Time  `json:"-"` // Creation time of the last event applied; Stripe doesn't deliver events in order
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// MonthlyUsage counts the crawls of a user's URLs in a calendar month in UTC, see services.BillingService
+// A crawl is counted when it starts, so failed and stopped crawls count as well
+type MonthlyUsage struct {
+	Username  string    `json:"username" gorm:"size:255;primaryKey"`
+	Month     string    `json:"month" gorm:"size:7;primaryKey"` // 2006-01
+	Crawls    int64     `json:"crawls" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Session is a login session; only a hash of its token is stored
+type Session struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	TokenHash  string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Username   string    `json:"username" gorm:"index"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent" gorm:"size:512"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"` // Extended on every use
+
+	// The session may only change the password until it has been changed
+	MustChangePassword bool `json:"must_change_password"`
+}
+
+// REST hook events
+const (
+	HookEventURLCompleted  = "url.completed"
+	HookEventURLFailed     = "url.failed"
+	HookEventLinkBrokenNew = "link.broken.new" // A link that wasn't broken in the previous crawl of the URL
+	HookEventBudgetFailed  = "budget.failed"   // The page exceeds its project budget, which the previous crawl didn't
+)
+
+// HookSubscription is a REST hook: every event of its type is POSTed to TargetURL
+// No-code platforms such as Zapier subscribe when a zap is turned on and unsubscribe when it is turned off
+type HookSubscription struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Event     string    `json:"event" gorm:"size:64;not null;index"`
+	TargetURL string    `json:"target_url" gorm:"size:2048;not null"`
+	CreatedBy string    `json:"created_by"` // Username of the session that subscribed
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// View is a named filter and sort of the URL list, shared by every user
+type View struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	Name      string     `json:"name" gorm:"size:255;uniqueIndex;not null"`
+	Filter    ViewFilter `json:"filter" gorm:"serializer:json"`
+	Sort      string     `json:"sort"`       // One of ViewSortFields, prefixed with - for descending; empty lists newest first
+	CreatedBy string     `json:"created_by"` // Username of the session that saved the view
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ViewFilter selects the URLs of a view; empty fields match every URL
+type ViewFilter struct {
+	Status            string `json:"status,omitempty"`
+	Tag               string `json:"tag,omitempty"`
+	ProjectID         *uint  `json:"project_id,omitempty"`
+	Search            string `json:"search,omitempty"` // Part of the URL or title, ignoring case
+	HasBrokenLinks    bool   `json:"has_broken_links,omitempty"`
+	BudgetStatus      string `json:"budget_status,omitempty"`       // pass, fail
+	UpdatedWithinDays int    `json:"updated_within_days,omitempty"` // Crawled, failed, or edited in the last days
+}
+
+// ViewSortFields are the fields of the URL list a view can sort by
+var ViewSortFields = []string{"created_at", "crawled_at", "url", "title", "status", "links_count", "broken_links"}
+
+// Schedule frequencies
+const (
+	ScheduleHourly   = "hourly"
+	ScheduleDaily    = "daily"
+	ScheduleWeekly   = "weekly"
+	ScheduleAdaptive = "adaptive" // Each URL is recrawled as often as its page changes, see Schedule.MinIntervalHours
+)
+
+// Schedule recrawls the monitored URLs of a project regularly, at times local to the site owner
+type Schedule struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	ProjectID uint       `json:"project_id" gorm:"uniqueIndex;not null"`
+	Frequency string     `json:"frequency" gorm:"size:16;not null"` // hourly, daily, weekly, adaptive
+	Time      string     `json:"time" gorm:"size:5"`                // HH:MM local time; hourly schedules only use the minutes
+	Weekday   int        `json:"weekday"`                           // 0 (Sunday) to 6, for weekly schedules
+	Timezone  string     `json:"timezone" gorm:"size:64"`           // IANA time zone, e.g. Europe/Berlin; defaults to UTC
+	Enabled   bool       `json:"enabled"`
+	NextRunAt *time.Time `json:"next_run_at" gorm:"index"` // Nil while disabled
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastJobID *uint      `json:"last_job_id"` // Batch job of the last run
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Bounds of the recrawl intervals of adaptive schedules, which ignore the time and weekday
+	MinIntervalHours int `json:"min_interval_hours,omitempty"`
+	MaxIntervalHours int `json:"max_interval_hours,omitempty"`
+}
+
+// BatchJob tracks the overall progress of a batch operation that crawls many URLs
+type BatchJob struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Type      string    `json:"type"`                            // start, rerun, reprocess, scheduled
+	Status    string    `json:"status" gorm:"default:'running'"` // running, completed
+	Total     int       `json:"total"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QueuedCrawl is a crawl that was started and hasn't finished yet, kept so it survives restarts
+// Owner is the server running it; crawls of servers that stop sending heartbeats are taken over
+type QueuedCrawl struct {
+	ID          uint      `json:"id" gorm:"primarykey"` // Queue order
+	URLID       uint      `json:"url_id" gorm:"not null;index"`
+	JobID       uint      `json:"job_id"` // Batch job the crawl belongs to, 0 when none
+	Owner       string    `json:"owner" gorm:"size:32;index"`
+	HeartbeatAt time.Time `json:"heartbeat_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Annotation kinds
+const (
+	AnnotationFinding = "finding"
+	AnnotationLink    = "link"
+)
+
+// Annotation triage statuses
+const (
+	TriageOpen          = "open"
+	TriageAcknowledged  = "acknowledged"
+	TriageFixed         = "fixed"
+	TriageFalsePositive = "false_positive"
+)
+
+// Annotation is a note and triage status a user attached to a finding or link of a URL
+// It matches by Target rather than by row, so it carries over to the same finding or link of later crawls
+type Annotation struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	URLID      uint      `json:"url_id" gorm:"not null;uniqueIndex:idx_annotation_target"`
+	Kind       string    `json:"kind" gorm:"size:16;not null"`                                // finding, link
+	Target     string    `json:"target" gorm:"type:text"`                                     // Normalized link URL, or finding type, page URL, and subject
+	TargetHash string    `json:"-" gorm:"size:64;not null;uniqueIndex:idx_annotation_target"` // SHA-256 of kind and target, as targets are too long to index
+	Status     string    `json:"status" gorm:"size:32;not null"`
+	Note       string    `json:"note" gorm:"type:text"`
+	UpdatedBy  string    `json:"updated_by"` // Username of the session that last changed it
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// encryptedPrefix starts every encrypted column value, followed by the ID of the key and the sealed value
+// Values without it are plaintext written before encryption was configured, and are read as they are
+const encryptedPrefix = "enc:v1:"
+
+// Serializers of encrypted columns, referenced from the gorm tags of the models
+// Columns with "encrypted" are encrypted whenever keys are configured, "encrypted_snapshot" only when
+// snapshot encryption is enabled as well. Encrypted values are longer than the plaintext, so the token
+// columns are text rather than sized to the longest token
+const (
+	serializerEncrypted         = "encrypted"
+	serializerEncryptedSnapshot = "encrypted_snapshot"
+)
+
+// FieldCipher encrypts sensitive columns with AES-GCM
+// The first key encrypts new values; the others only decrypt, so keys can be rotated without downtime
+type FieldCipher struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// NewFieldCipher parses keys of the form id:base64 (16, 24, or 32 bytes) and returns nil when none are given
+func NewFieldCipher(keys []string) (*FieldCipher, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	c := &FieldCipher{keys: make(map[string]cipher.AEAD)}
+	for i, entry := range keys {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encryption key %d must have the form id:base64", i+1)
+		}
+		if _, exists := c.keys[id]; exists {
+			return nil, fmt.Errorf("encryption key ID %q is used twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %v", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %v", id, err)
+		}
+		c.keys[id] = aead
+		if i == 0 {
+			c.activeID = id
+		}
+	}
+	return c, nil
+}
+
+// ActiveKeyID returns the ID of the key new values are encrypted with
+func (c *FieldCipher) ActiveKeyID() string {
+	return c.activeID
+}
+
+// KeyIDs returns the IDs of all configured keys, the active one first
+func (c *FieldCipher) KeyIDs() []string {
+	ids := []string{c.activeID}
+	for id := range c.keys {
+		if id != c.activeID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// encrypt seals plaintext with the active key; the column name is authenticated so values can't be moved between columns
+func (c *FieldCipher) encrypt(column string, plaintext []byte) (string, error) {
+	aead := c.keys[c.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(column))
+	return encryptedPrefix + c.activeID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *FieldCipher) decrypt(column, value string) ([]byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return nil, errors.New("malformed encrypted value")
+	}
+	if c == nil {
+		return nil, fmt.Errorf("column %s is encrypted with key %q but no encryption keys are configured", column, id)
+	}
+	aead, ok := c.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("column %s is encrypted with unknown key %q", column, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("column %s has a malformed encrypted value", column)
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return nil, fmt.Errorf("column %s could not be decrypted with key %q: %v", column, id, err)
+	}
+	return plaintext, nil
+}
+
+// UseFieldCipher configures the encryption of sensitive columns; it must be called before the database is used
+// A nil cipher stores new values in plaintext, which still reads values encrypted earlier only if their key is configured
+func UseFieldCipher(c *FieldCipher, encryptSnapshots bool) {
+	schema.RegisterSerializer(serializerEncrypted, encryptedSerializer{cipher: c, enabled: c != nil})
+	schema.RegisterSerializer(serializerEncryptedSnapshot, encryptedSerializer{cipher: c, enabled: c != nil && encryptSnapshots})
+}
+
+func init() {
+	// Plaintext until UseFieldCipher configures keys, so the models can be used without it
+	UseFieldCipher(nil, false)
+}
+
+// encryptedSerializer encrypts string and []byte columns
+type encryptedSerializer struct {
+	cipher  *FieldCipher
+	enabled bool // Whether new values are encrypted; encrypted values are always decrypted
+}
+
+func (s encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value []byte
+	switch v := dbValue.(type) {
+	case []byte:
+		value = v
+	case string:
+		value = []byte(v)
+	case nil:
+	default:
+		return fmt.Errorf("unsupported value %T of encrypted column %s", dbValue, field.DBName)
+	}
+
+	if strings.HasPrefix(string(value), encryptedPrefix) {
+		plaintext, err := s.cipher.decrypt(field.DBName, string(value))
+		if err != nil {
+			return err
+		}
+		value = plaintext
+	}
+
+	fieldValue := reflect.New(field.FieldType).Elem()
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		fieldValue.SetString(string(value))
+	case reflect.Slice:
+		fieldValue.SetBytes(value)
+	default:
+		return fmt.Errorf("encrypted column %s must be a string or []byte", field.DBName)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+func (s encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext []byte
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = []byte(v)
+	case []byte:
+		plaintext = v
+	default:
+		return nil, fmt.Errorf("encrypted column %s must be a string or []byte", field.DBName)
+	}
+
+	// Empty values stay empty, so whether a secret is set can still be told without decrypting it
+	if !s.enabled || len(plaintext) == 0 {
+		return fieldValue, nil
+	}
+	return s.cipher.encrypt(field.DBName, plaintext)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// gormStore implements Store with GORM, for any database GORM has a driver for
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a store backed by the given database connection
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) URLs() URLRepository                 { return &gormURLs{db: s.db} }
+func (s *gormStore) CrawlResults() CrawlResultRepository { return &gormCrawlResults{db: s.db} }
+func (s *gormStore) Projects() ProjectRepository         { return &gormProjects{db: s.db} }
+func (s *gormStore) CrawlQueue() CrawlQueueRepository    { return &gormCrawlQueue{db: s.db} }
+
+func (s *gormStore) Transaction(fn func(Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+func (s *gormStore) Replica() Store {
+	return &gormStore{db: s.db.Clauses(dbresolver.Read).Session(&gorm.Session{})}
+}
+
+func (s *gormStore) Ping() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// translateError maps GORM errors to the errors of this package
+// Duplicates are only recognized when the connection is opened with TranslateError
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicate
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// gormCrawlQueue implements CrawlQueueRepository with GORM
+type gormCrawlQueue struct {
+	db *gorm.DB
+}
+
+func (r *gormCrawlQueue) Enqueue(crawl *models.QueuedCrawl) error {
+	return translateError(r.db.Create(crawl).Error)
+}
+
+func (r *gormCrawlQueue) Dequeue(id uint) error {
+	return translateError(r.db.Delete(&models.QueuedCrawl{}, id).Error)
+}
+
+func (r *gormCrawlQueue) DequeueURL(urlID uint) error {
+	return translateError(r.db.Where("url_id = ?", urlID).Delete(&models.QueuedCrawl{}).Error)
+}
+
+func (r *gormCrawlQueue) HasURL(urlID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.QueuedCrawl{}).Where("url_id = ?", urlID).Limit(1).Count(&count).Error
+	return count > 0, translateError(err)
+}
+
+func (r *gormCrawlQueue) Heartbeat(owner string) error {
+	return translateError(r.db.Model(&models.QueuedCrawl{}).Where("owner = ?", owner).Update("heartbeat_at", time.Now()).Error)
+}
+
+func (r *gormCrawlQueue) Claim(owner string, staleBefore time.Time) ([]models.QueuedCrawl, error) {
+	var stale []models.QueuedCrawl
+	if err := r.db.Where("owner <> ? AND heartbeat_at < ?", owner, staleBefore).Order("id").Find(&stale).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	claimed := stale[:0]
+	for _, crawl := range stale {
+		// The update checks the previous owner again, so two servers can't take over the same crawl
+		now := time.Now()
+		update := r.db.Model(&models.QueuedCrawl{}).
+			Where("id = ? AND owner = ? AND heartbeat_at < ?", crawl.ID, crawl.Owner, staleBefore).
+			Updates(map[string]interface{}{"owner": owner, "heartbeat_at": now})
+		if update.Error != nil {
+			return claimed, translateError(update.Error)
+		}
+		if update.RowsAffected == 1 {
+			crawl.Owner, crawl.HeartbeatAt = owner, now
+			claimed = append(claimed, crawl)
+		}
+	}
+	return claimed, nil
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// crawlResultChildTables lists the tables whose rows belong to a crawl result, apart from the snapshots
+// deleteSnapshots removes with their bodies
+var crawlResultChildTables = []string{"links", "crawl_pages", "internal_broken_links", "findings"}
+
+// gormCrawlResults implements CrawlResultRepository with GORM
+type gormCrawlResults struct {
+	db *gorm.DB
+}
+
+func (r *gormCrawlResults) Create(result *models.CrawlResult) error {
+	// The counters come from the links in memory, so saving a crawl never aggregates the links table
+	broken := 0
+	for _, link := range result.Links {
+		if !link.IsAccessible && !link.Throttled {
+			broken++
+		}
+	}
+
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if snapshot := result.Snapshot; snapshot != nil && len(snapshot.HTML) > 0 {
+			hash, err := saveSnapshotBody(tx, snapshot.HTML)
+			if err != nil {
+				return err
+			}
+			// The HTML is only stored in the body; the caller gets it back once the snapshot is saved
+			html := snapshot.HTML
+			snapshot.BodyHash, snapshot.HTML = hash, nil
+			defer func() { snapshot.HTML = html }()
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+		// Only the latest crawl of a URL keeps its snapshot
+		if err := deleteSnapshots(tx, "crawl_result_id IN (SELECT id FROM crawl_results WHERE url_id = ? AND id <> ?)", result.URLID, result.ID); err != nil {
+			return err
+		}
+		return tx.Model(&models.URL{}).Where("id = ?", result.URLID).Updates(map[string]interface{}{
+			"latest_crawl_id":    result.ID,
+			"links_count":        len(result.Links),
+			"broken_links_count": broken,
+		}).Error
+	}))
+}
+
+func (r *gormCrawlResults) First(urlID uint) (models.CrawlResult, error) {
+	var result models.CrawlResult
+	err := r.db.Preload("Links").Where("url_id = ?", urlID).Find(&result).Error
+	return result, translateError(err)
+}
+
+func (r *gormCrawlResults) Get(resultID uint) (models.CrawlResult, error) {
+	var result models.CrawlResult
+	err := r.db.First(&result, resultID).Error
+	return result, translateError(err)
+}
+
+func (r *gormCrawlResults) Latest(urlID uint, options LoadOptions) (models.CrawlResult, error) {
+	query := r.db.Where("url_id = ?", urlID).Order("crawled_at desc")
+	if options.Links {
+		query = query.Preload("Links", func(db *gorm.DB) *gorm.DB {
+			if options.LinksLimit > 0 {
+				return db.Order("id").Limit(options.LinksLimit)
+			}
+			return db.Order("id")
+		})
+	}
+	if options.InternalBrokenLinks {
+		query = query.Preload("InternalBrokenLinks")
+	}
+
+	var result models.CrawlResult
+	err := query.First(&result).Error
+	return result, translateError(err)
+}
+
+func (r *gormCrawlResults) LatestID(urlID uint) (uint, error) {
+	var id uint
+	err := r.db.Model(&models.CrawlResult{}).Where("url_id = ?", urlID).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, translateError(err)
+}
+
+func (r *gormCrawlResults) PreviousID(urlID uint, resultID uint) (uint, error) {
+	var id uint
+	err := r.db.Model(&models.CrawlResult{}).Where("url_id = ? AND id < ?", urlID, resultID).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, translateError(err)
+}
+
+func (r *gormCrawlResults) LatestResults(urlIDs []uint) (map[uint]models.CrawlResult, error) {
+	latest := make(map[uint]models.CrawlResult)
+	if len(urlIDs) == 0 {
+		return latest, nil
+	}
+	var latestIDs []uint
+	if err := r.db.Model(&models.CrawlResult{}).Where("url_id IN ?", urlIDs).Group("url_id").Pluck("MAX(id)", &latestIDs).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(latestIDs) == 0 {
+		return latest, nil
+	}
+
+	var results []models.CrawlResult
+	if err := r.db.Where("id IN ?", latestIDs).Find(&results).Error; err != nil {
+		return nil, translateError(err)
+	}
+	for _, result := range results {
+		latest[result.URLID] = result
+	}
+	return latest, nil
+}
+
+func (r *gormCrawlResults) LatestPairs(urlIDs []uint) (map[uint]CrawlPair, error) {
+	pairs := make(map[uint]CrawlPair)
+	if len(urlIDs) == 0 {
+		return pairs, nil
+	}
+	var latestIDs, previousIDs []uint
+	if err := r.db.Model(&models.CrawlResult{}).Where("url_id IN ?", urlIDs).Group("url_id").Pluck("MAX(id)", &latestIDs).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(latestIDs) == 0 {
+		return pairs, nil
+	}
+	err := r.db.Model(&models.CrawlResult{}).Where("url_id IN ? AND id NOT IN ?", urlIDs, latestIDs).Group("url_id").Pluck("MAX(id)", &previousIDs).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	// In id order, the crawl before the latest one of a URL comes first
+	var results []models.CrawlResult
+	if err := r.db.Where("id IN ?", append(latestIDs, previousIDs...)).Order("id").Find(&results).Error; err != nil {
+		return nil, translateError(err)
+	}
+	for _, result := range results {
+		pair, seen := pairs[result.URLID]
+		if seen {
+			previous := pair.Latest
+			pair.Previous = &previous
+		}
+		pair.Latest = result
+		pairs[result.URLID] = pair
+	}
+	return pairs, nil
+}
+
+func (r *gormCrawlResults) Links(resultID uint) ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Where("crawl_result_id = ?", resultID).Find(&links).Error
+	return links, translateError(err)
+}
+
+func (r *gormCrawlResults) LinksPage(resultID uint, offset, limit int) ([]models.Link, int64, error) {
+	total, err := r.CountLinks(resultID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var links []models.Link
+	err = r.db.Where("crawl_result_id = ?", resultID).Order("id").Offset(offset).Limit(limit).Find(&links).Error
+	return links, total, translateError(err)
+}
+
+func (r *gormCrawlResults) LinksAfter(resultID uint, afterID uint, limit int) ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Where("crawl_result_id = ? AND id > ?", resultID, afterID).Order("id").Limit(limit).Find(&links).Error
+	return links, translateError(err)
+}
+
+func (r *gormCrawlResults) CountLinks(resultID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Link{}).Where("crawl_result_id = ?", resultID).Count(&count).Error
+	return count, translateError(err)
+}
+
+func (r *gormCrawlResults) CountBrokenLinks(resultID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Link{}).
+		Where("crawl_result_id = ? AND is_accessible = ? AND throttled = ?", resultID, false, false).
+		Count(&count).Error
+	return count, translateError(err)
+}
+
+func (r *gormCrawlResults) CountPages(resultID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CrawlPage{}).Where("crawl_result_id = ?", resultID).Count(&count).Error
+	return count, translateError(err)
+}
+
+func (r *gormCrawlResults) PermanentRedirects(resultID uint) ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Where("crawl_result_id = ? AND permanent_redirect = ?", resultID, true).Find(&links).Error
+	return links, translateError(err)
+}
+
+func (r *gormCrawlResults) Findings(resultID uint, filter FindingFilter) ([]models.Finding, error) {
+	query := filterFindings(r.db.Where("crawl_result_id = ?", resultID), "", filter)
+
+	var findings []models.Finding
+	err := query.Order("id").Find(&findings).Error
+	return findings, translateError(err)
+}
+
+func (r *gormCrawlResults) ProjectFindings(projectID uint, filter FindingFilter) ([]ProjectFinding, error) {
+	latestCrawls := r.db.Model(&models.CrawlResult{}).Select("MAX(id)").Group("url_id")
+	query := r.db.Table("findings").
+		Select("findings.*, crawl_results.url_id").
+		Joins("JOIN crawl_results ON crawl_results.id = findings.crawl_result_id").
+		Joins("JOIN urls ON urls.id = crawl_results.url_id AND urls.deleted_at IS NULL").
+		Where("urls.project_id = ? AND findings.crawl_result_id IN (?)", projectID, latestCrawls)
+	query = filterFindings(query, "findings.", filter)
+	if filter.URLID != 0 {
+		query = query.Where("crawl_results.url_id = ?", filter.URLID)
+	}
+
+	var findings []ProjectFinding
+	err := query.Order("findings.id").Scan(&findings).Error
+	return findings, translateError(err)
+}
+
+func (r *gormCrawlResults) ProjectMetaDescriptions(projectID uint, description string) ([]PageMetaDescription, error) {
+	query := r.db.Table("urls").
+		Select("urls.id AS url_id, urls.url, crawl_results.meta_description, crawl_results.analyzer_version").
+		Joins("JOIN crawl_results ON crawl_results.id = urls.latest_crawl_id").
+		Where("urls.project_id = ? AND urls.deleted_at IS NULL", projectID)
+	if description != "" {
+		query = query.Where("crawl_results.meta_description = ?", description)
+	}
+
+	var pages []PageMetaDescription
+	err := query.Order("urls.id").Scan(&pages).Error
+	return pages, translateError(err)
+}
+
+func (r *gormCrawlResults) ProjectPartySplits(projectID uint, urlID uint, since time.Time) ([]PartySplit, error) {
+	query := r.db.Table("crawl_results").
+		Select("crawl_results.url_id, crawl_results.crawled_at, COALESCE(crawl_results.first_party_links, 0) AS first_party_links, "+
+			"COALESCE(crawl_results.third_party_links, 0) AS third_party_links, COALESCE(crawl_results.first_party_resources, 0) AS first_party_resources, "+
+			"COALESCE(crawl_results.third_party_resources, 0) AS third_party_resources, COALESCE(crawl_results.third_party_domain_count, 0) AS third_party_domain_count, "+
+			"crawl_results.first_party_links IS NOT NULL AS counted").
+		Joins("JOIN urls ON urls.id = crawl_results.url_id").
+		Where("urls.project_id = ? AND urls.deleted_at IS NULL AND crawl_results.crawled_at >= ?", projectID, since)
+	if urlID != 0 {
+		query = query.Where("crawl_results.url_id = ?", urlID)
+	}
+
+	var splits []PartySplit
+	err := query.Order("crawl_results.crawled_at, crawl_results.id").Scan(&splits).Error
+	return splits, translateError(err)
+}
+
+func (r *gormCrawlResults) CrawlUsage(since time.Time) ([]CrawlUsage, error) {
+	// Every crawl sends at least one request, so a crawl without any was stored before they were counted
+	var usage []CrawlUsage
+	err := r.db.Table("crawl_results").
+		Select("DATE_FORMAT(crawl_results.crawled_at, '%Y-%m') AS month, urls.project_id, urls.created_by, "+
+			"SUM(CASE WHEN crawl_results.requests > 0 THEN 1 ELSE 0 END) AS crawls, "+
+			"SUM(CASE WHEN crawl_results.requests > 0 THEN 0 ELSE 1 END) AS unmeasured, "+
+			"COALESCE(SUM(crawl_results.requests), 0) AS requests, "+
+			"COALESCE(SUM(CASE WHEN crawl_results.requests > 0 THEN crawl_results.bytes_downloaded ELSE 0 END), 0) AS bytes_downloaded, "+
+			"COALESCE(SUM(CASE WHEN crawl_results.requests > 0 THEN crawl_results.duration_ms ELSE 0 END), 0) AS duration_ms").
+		Joins("JOIN urls ON urls.id = crawl_results.url_id").
+		Where("crawl_results.crawled_at >= ?", since).
+		Group("month, urls.project_id, urls.created_by").
+		Order("month").
+		Scan(&usage).Error
+	return usage, translateError(err)
+}
+
+func (r *gormCrawlResults) Snapshot(resultID uint) (models.PageSnapshot, error) {
+	var snapshot models.PageSnapshot
+	if err := r.db.Where("crawl_result_id = ?", resultID).First(&snapshot).Error; err != nil {
+		return snapshot, translateError(err)
+	}
+	// Snapshots stored before the bodies were deduplicated keep their HTML themselves
+	if snapshot.BodyHash == "" {
+		return snapshot, nil
+	}
+
+	var body models.SnapshotBody
+	if err := r.db.Where("hash = ?", snapshot.BodyHash).First(&body).Error; err != nil {
+		return snapshot, translateError(err)
+	}
+	html, err := decodeSnapshotBody(body)
+	snapshot.HTML = html
+	return snapshot, err
+}
+
+func (r *gormCrawlResults) UpdateAnalysis(result *models.CrawlResult, columns []string, findingTypes []string) error {
+	// Update a copy without child rows, so GORM doesn't save the findings as associations
+	update := *result
+	update.Links, update.Pages, update.InternalBrokenLinks, update.Findings, update.Snapshot = nil, nil, nil, nil, nil
+
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&update).Select(columns).Updates(&update).Error; err != nil {
+				return err
+			}
+		}
+		if len(findingTypes) == 0 {
+			return nil
+		}
+		if err := tx.Where("crawl_result_id = ? AND type IN ?", result.ID, findingTypes).Delete(&models.Finding{}).Error; err != nil {
+			return err
+		}
+		if len(result.Findings) == 0 {
+			return nil
+		}
+		for i := range result.Findings {
+			result.Findings[i].ID = 0
+			result.Findings[i].CrawlResultID = result.ID
+		}
+		return tx.Create(&result.Findings).Error
+	}))
+}
+
+// filterFindings applies the type, severity, category and lifecycle filters; prefix qualifies the columns in joins
+func filterFindings(query *gorm.DB, prefix string, filter FindingFilter) *gorm.DB {
+	if filter.Type != "" {
+		query = query.Where(prefix+"type = ?", filter.Type)
+	}
+	if filter.Severity != "" {
+		query = query.Where(prefix+"severity = ?", filter.Severity)
+	}
+	if filter.Category != "" {
+		query = query.Where(prefix+"category = ?", filter.Category)
+	}
+	if filter.Lifecycle != "" {
+		query = query.Where(prefix+"lifecycle = ?", filter.Lifecycle)
+	}
+	return query
+}
+
+func (r *gormCrawlResults) DeleteForURL(urlID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Child rows first because of the foreign key constraints
+		if err := deleteSnapshots(tx, "crawl_result_id IN (SELECT id FROM crawl_results WHERE url_id = ?)", urlID); err != nil {
+			return err
+		}
+		for _, table := range crawlResultChildTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE crawl_result_id IN (SELECT id FROM crawl_results WHERE url_id = ?)", urlID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("url_id = ?", urlID).Delete(&models.CrawlResult{}).Error; err != nil {
+			return err
+		}
+		// The annotated findings and links went with the crawls
+		if err := tx.Where("url_id = ?", urlID).Delete(&models.Annotation{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.URL{}).Where("id = ?", urlID).Updates(map[string]interface{}{
+			"latest_crawl_id":    nil,
+			"links_count":        0,
+			"broken_links_count": 0,
+		}).Error
+	})
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// gormProjects implements ProjectRepository with GORM
+type gormProjects struct {
+	db *gorm.DB
+}
+
+func (r *gormProjects) Create(project *models.Project) error {
+	return translateError(r.db.Create(project).Error)
+}
+
+func (r *gormProjects) Get(id uint) (models.Project, error) {
+	var project models.Project
+	err := r.db.First(&project, id).Error
+	return project, translateError(err)
+}
+
+func (r *gormProjects) List() ([]models.Project, error) {
+	var projects []models.Project
+	err := r.db.Order("name").Find(&projects).Error
+	return projects, translateError(err)
+}
+
+func (r *gormProjects) FindByName(name string, excludeID uint) (models.Project, error) {
+	var project models.Project
+	err := r.db.Where("name = ? AND id <> ?", name, excludeID).First(&project).Error
+	return project, translateError(err)
+}
+
+func (r *gormProjects) Exists(id uint) (bool, error) {
+	var project models.Project
+	err := r.db.Select("id").First(&project, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *gormProjects) Update(project *models.Project, columns ...string) error {
+	return translateError(r.db.Model(project).Select(columns).Updates(project).Error)
+}
+
+func (r *gormProjects) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.URL{}).Where("project_id = ?", id).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Project{}, id).Error
+	})
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/publicsuffix"
+	"gorm.io/gorm"
+)
+
+// gormURLs implements URLRepository with GORM
+type gormURLs struct {
+	db *gorm.DB
+}
+
+func (r *gormURLs) Create(url *models.URL) error {
+	return translateError(r.db.Create(url).Error)
+}
+
+// restoredURLColumns are reset when a deleted URL is added again, like on a new URL
+var restoredURLColumns = []string{
+	"display_url", "status", "last_error", "tags", "crawl_config", "monitor_enabled", "project_id", "created_by",
+	"commit_sha", "idempotency_key", "crawl_phase", "links_checked", "links_total", "recrawl_interval_minutes",
+	"next_recrawl_at", "deleted_at",
+}
+
+func (r *gormURLs) Restore(url *models.URL) error {
+	// Only a row that is still deleted is restored, so of two requests adding the URL again one restores it
+	result := r.db.Unscoped().Model(url).Where("url = ? AND deleted_at IS NOT NULL", url.URL).
+		Select(restoredURLColumns).Updates(url)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return translateError(r.db.Where("url = ?", url.URL).First(url).Error)
+}
+
+func (r *gormURLs) Get(id uint) (models.URL, error) {
+	var url models.URL
+	err := r.db.First(&url, id).Error
+	return url, translateError(err)
+}
+
+func (r *gormURLs) List() ([]models.URL, error) {
+	var urls []models.URL
+	err := r.db.Order("created_at desc").Find(&urls).Error
+	return urls, translateError(err)
+}
+
+func (r *gormURLs) ListProject(projectID uint) ([]models.URL, error) {
+	var urls []models.URL
+	err := r.db.Where("project_id = ?", projectID).Order("created_at desc").Find(&urls).Error
+	return urls, translateError(err)
+}
+
+func (r *gormURLs) ListPage(offset, limit int) ([]models.URL, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.URL{}).Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var urls []models.URL
+	err := r.db.Order("created_at desc, id desc").Offset(offset).Limit(limit).Find(&urls).Error
+	return urls, total, translateError(err)
+}
+
+func (r *gormURLs) ListAfter(after *Cursor, limit int) ([]models.URL, error) {
+	query := r.db.Order("created_at desc, id desc").Limit(limit)
+	if after != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	var urls []models.URL
+	err := query.Find(&urls).Error
+	return urls, translateError(err)
+}
+
+func (r *gormURLs) FindByURL(rawURL string, excludeID uint) (models.URL, error) {
+	var url models.URL
+	err := r.db.Where("url = ? AND id <> ?", rawURL, excludeID).First(&url).Error
+	return url, translateError(err)
+}
+
+func (r *gormURLs) FindDeletedByURL(rawURL string) (models.URL, error) {
+	var url models.URL
+	err := r.db.Unscoped().Where("url = ? AND deleted_at IS NOT NULL", rawURL).First(&url).Error
+	return url, translateError(err)
+}
+
+func (r *gormURLs) FindByIdempotencyKey(key string) (models.URL, error) {
+	var url models.URL
+	err := r.db.Unscoped().Where("idempotency_key = ?", key).First(&url).Error
+	return url, translateError(err)
+}
+
+func (r *gormURLs) ExistingIDs(ids []uint) ([]uint, error) {
+	var existing []uint
+	err := r.db.Model(&models.URL{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	return existing, translateError(err)
+}
+
+func (r *gormURLs) OutdatedIDs(analyzerVersion int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.URL{}).
+		Joins("JOIN crawl_results ON crawl_results.id = urls.latest_crawl_id").
+		Where("crawl_results.analyzer_version < ?", analyzerVersion).
+		Order("urls.id").Pluck("urls.id", &ids).Error
+	return ids, translateError(err)
+}
+
+func (r *gormURLs) Update(url *models.URL, columns ...string) error {
+	return translateError(r.db.Model(url).Select(columns).Updates(url).Error)
+}
+
+func (r *gormURLs) SetStatus(status string, ids ...uint) error {
+	return translateError(r.db.Model(&models.URL{}).Where("id IN ?", ids).Update("status", status).Error)
+}
+
+func (r *gormURLs) Delete(ids ...uint) error {
+	// The URLs are only soft-deleted, but their annotations are removed, so a URL added again starts without them
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("url_id IN ?", ids).Delete(&models.Annotation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.URL{}).Error
+	}))
+}
+
+func (r *gormURLs) Version() (ListVersion, error) {
+	var version ListVersion
+	if err := r.db.Model(&models.URL{}).Select("COUNT(*) AS count, MAX(updated_at) AS last_updated").Scan(&version).Error; err != nil {
+		return version, err
+	}
+	if err := r.db.Model(&models.CrawlResult{}).Select("COALESCE(MAX(id), 0)").Scan(&version.LastCrawlID).Error; err != nil {
+		return version, err
+	}
+	return version, nil
+}
+
+func (r *gormURLs) Facets() (URLFacets, error) {
+	facets := URLFacets{Status: []FacetCount{}, HTMLVersion: []FacetCount{}}
+	err := r.db.Model(&models.URL{}).Select("status AS value, COUNT(*) AS count").
+		Group("status").Scan(&facets.Status).Error
+	if err != nil {
+		return facets, translateError(err)
+	}
+	err = r.db.Model(&models.URL{}).Select("crawl_results.html_version AS value, COUNT(*) AS count").
+		Joins("JOIN crawl_results ON crawl_results.id = urls.latest_crawl_id").
+		Group("crawl_results.html_version").Scan(&facets.HTMLVersion).Error
+	if err != nil {
+		return facets, translateError(err)
+	}
+
+	// Hosts aren't a column of their own, so the URLs are counted per authority, the part between "//" and the
+	// path, and the authorities are merged into registrable domains here; there are far fewer of them than URLs
+	var authorities []FacetCount
+	err = r.db.Model(&models.URL{}).Select("SUBSTRING_INDEX(SUBSTRING_INDEX(url, '/', 3), '/', -1) AS value, COUNT(*) AS count").
+		Group("value").Scan(&authorities).Error
+	if err != nil {
+		return facets, translateError(err)
+	}
+	domains := map[string]int64{}
+	for _, authority := range authorities {
+		if parsed, err := url.Parse("//" + authority.Value); err == nil && parsed.Hostname() != "" {
+			domains[facetDomain(parsed.Hostname())] += authority.Count
+		}
+	}
+	facets.Domain = facetCounts(domains)
+
+	// Tags are a JSON array, which JSON_TABLE turns into a row per tag
+	facets.Tags = []FacetCount{}
+	err = r.db.Model(&models.URL{}).Select("url_tags.tag AS value, COUNT(*) AS count").
+		Joins("JOIN JSON_TABLE(urls.tags, '$[*]' COLUMNS (tag VARCHAR(255) PATH '$')) AS url_tags ON TRUE").
+		Group("url_tags.tag").Scan(&facets.Tags).Error
+	if err != nil {
+		return facets, translateError(err)
+	}
+
+	sortFacets(facets.Status)
+	sortFacets(facets.HTMLVersion)
+	sortFacets(facets.Tags)
+	return facets, nil
+}
+
+// facetDomain groups hosts by their registrable domain according to the public suffix list,
+// so www.example.co.uk and shop.example.co.uk are one facet; IP addresses and the like stay as they are
+func facetDomain(host string) string {
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// facetCounts turns counts per value into facets, most common first
+func facetCounts(counts map[string]int64) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, FacetCount{Value: value, Count: count})
+	}
+	sortFacets(facets)
+	return facets
+}
+
+// sortFacets orders facets by count, most common first, and values of equal count alphabetically
+func sortFacets(facets []FacetCount) {
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+}
+
+func (r *gormURLs) BackfillCounters() (int64, error) {
+	latestCrawl := "(SELECT MAX(crawl_results.id) FROM crawl_results WHERE crawl_results.url_id = urls.id)"
+	result := r.db.Exec(`UPDATE urls SET
+			latest_crawl_id = `+latestCrawl+`,
+			links_count = (SELECT COUNT(*) FROM links WHERE links.crawl_result_id = `+latestCrawl+`),
+			broken_links_count = (SELECT COUNT(*) FROM links WHERE links.crawl_result_id = `+latestCrawl+`
+				AND links.is_accessible = ? AND links.throttled = ?)
+		WHERE latest_crawl_id IS NULL AND EXISTS (SELECT 1 FROM crawl_results WHERE crawl_results.url_id = urls.id)`,
+		false, false)
+	return result.RowsAffected, translateError(result.Error)
+}
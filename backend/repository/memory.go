@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm/schema"
+)
+
+// memoryStore implements Store in memory, as a test double for the services and controllers using a store
+// It keeps the behavior callers rely on, such as soft deletes, unique columns, and the URL counters,
+// but compares strings exactly where MySQL's collation ignores case
+type memoryStore struct {
+	mu   *sync.Mutex
+	data *memoryData
+}
+
+// memoryData holds the rows of a memory store; crawl results are kept without their child rows
+type memoryData struct {
+	lastID    uint // Every row gets the next ID, so IDs grow in insertion order across tables
+	urls      map[uint]models.URL
+	results   map[uint]models.CrawlResult
+	links     map[uint][]models.Link // By crawl result
+	pages     map[uint][]models.CrawlPage
+	broken    map[uint][]models.InternalBrokenLink
+	findings  map[uint][]models.Finding
+	snapshots map[uint]models.PageSnapshot
+	projects  map[uint]models.Project
+	queue     map[uint]models.QueuedCrawl
+}
+
+// NewMemoryStore creates an empty store that keeps its rows in memory
+func NewMemoryStore() Store {
+	return &memoryStore{
+		mu: &sync.Mutex{},
+		data: &memoryData{
+			urls:      make(map[uint]models.URL),
+			results:   make(map[uint]models.CrawlResult),
+			links:     make(map[uint][]models.Link),
+			pages:     make(map[uint][]models.CrawlPage),
+			broken:    make(map[uint][]models.InternalBrokenLink),
+			findings:  make(map[uint][]models.Finding),
+			snapshots: make(map[uint]models.PageSnapshot),
+			projects:  make(map[uint]models.Project),
+			queue:     make(map[uint]models.QueuedCrawl),
+		},
+	}
+}
+
+func (s *memoryStore) URLs() URLRepository                 { return &memoryURLs{store: s} }
+func (s *memoryStore) CrawlResults() CrawlResultRepository { return &memoryCrawlResults{store: s} }
+func (s *memoryStore) Projects() ProjectRepository         { return &memoryProjects{store: s} }
+func (s *memoryStore) CrawlQueue() CrawlQueueRepository    { return &memoryCrawlQueue{store: s} }
+
+// Transaction runs fn on a copy of the rows and keeps the copy when fn succeeds
+// Other callers wait until the transaction ends, so fn must only use the store it is given
+func (s *memoryStore) Transaction(fn func(Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryStore{mu: &sync.Mutex{}, data: s.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+	return nil
+}
+
+// Replica returns the store itself, whose reads never lag behind its writes
+func (s *memoryStore) Replica() Store {
+	return s
+}
+
+func (s *memoryStore) Ping() error {
+	return nil
+}
+
+// lock locks the store and returns its rows
+func (s *memoryStore) lock() *memoryData {
+	s.mu.Lock()
+	return s.data
+}
+
+func (s *memoryStore) unlock() {
+	s.mu.Unlock()
+}
+
+// nextID returns the ID of a new row
+func (d *memoryData) nextID() uint {
+	d.lastID++
+	return d.lastID
+}
+
+// clone copies the rows; stored slices are replaced rather than changed in place, so they can be shared
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		lastID:    d.lastID,
+		urls:      cloneMap(d.urls),
+		results:   cloneMap(d.results),
+		links:     cloneMap(d.links),
+		pages:     cloneMap(d.pages),
+		broken:    cloneMap(d.broken),
+		findings:  cloneMap(d.findings),
+		snapshots: cloneMap(d.snapshots),
+		projects:  cloneMap(d.projects),
+		queue:     cloneMap(d.queue),
+	}
+}
+
+func cloneMap[V any](rows map[uint]V) map[uint]V {
+	cloned := make(map[uint]V, len(rows))
+	for id, row := range rows {
+		cloned[id] = row
+	}
+	return cloned
+}
+
+// memorySchemas caches the parsed models, whose column names map the columns of updates to fields
+var memorySchemas sync.Map
+
+// copyColumns copies the fields of the given columns from src to dst, which point to values of the same model
+func copyColumns(dst, src interface{}, columns []string) error {
+	parsed, err := schema.Parse(src, &memorySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	from, to := reflect.ValueOf(src).Elem(), reflect.ValueOf(dst).Elem()
+	for _, column := range columns {
+		field := parsed.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("%s has no column %s", parsed.Table, column)
+		}
+		field.ReflectValueOf(ctx, to).Set(field.ReflectValueOf(ctx, from))
+	}
+	return nil
+}
+
+// memoryNow is the time rows are stamped with, in UTC like the timestamps of the database
+func memoryNow() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// memoryCrawlQueue implements CrawlQueueRepository in memory
+type memoryCrawlQueue struct {
+	store *memoryStore
+}
+
+func (r *memoryCrawlQueue) Enqueue(crawl *models.QueuedCrawl) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	crawl.ID = data.nextID()
+	if crawl.CreatedAt.IsZero() {
+		crawl.CreatedAt = memoryNow()
+	}
+	data.queue[crawl.ID] = *crawl
+	return nil
+}
+
+func (r *memoryCrawlQueue) Dequeue(id uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	delete(data.queue, id)
+	return nil
+}
+
+func (r *memoryCrawlQueue) DequeueURL(urlID uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for id, crawl := range data.queue {
+		if crawl.URLID == urlID {
+			delete(data.queue, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryCrawlQueue) HasURL(urlID uint) (bool, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, crawl := range data.queue {
+		if crawl.URLID == urlID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryCrawlQueue) Heartbeat(owner string) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	now := time.Now()
+	for id, crawl := range data.queue {
+		if crawl.Owner == owner {
+			crawl.HeartbeatAt = now
+			data.queue[id] = crawl
+		}
+	}
+	return nil
+}
+
+func (r *memoryCrawlQueue) Claim(owner string, staleBefore time.Time) ([]models.QueuedCrawl, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	// The lock is held throughout, so two owners can't take over the same crawl
+	claimed := []models.QueuedCrawl{}
+	now := time.Now()
+	for id, crawl := range data.queue {
+		if crawl.Owner != owner && crawl.HeartbeatAt.Before(staleBefore) {
+			crawl.Owner, crawl.HeartbeatAt = owner, now
+			data.queue[id] = crawl
+			claimed = append(claimed, crawl)
+		}
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].ID < claimed[j].ID })
+	return claimed, nil
+}
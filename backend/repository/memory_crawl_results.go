@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// memoryCrawlResults implements CrawlResultRepository in memory
+type memoryCrawlResults struct {
+	store *memoryStore
+}
+
+func (r *memoryCrawlResults) Create(result *models.CrawlResult) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	result.ID = data.nextID()
+	for i := range result.Links {
+		result.Links[i].ID, result.Links[i].CrawlResultID = data.nextID(), result.ID
+	}
+	for i := range result.Pages {
+		result.Pages[i].ID, result.Pages[i].CrawlResultID = data.nextID(), result.ID
+	}
+	for i := range result.InternalBrokenLinks {
+		result.InternalBrokenLinks[i].ID, result.InternalBrokenLinks[i].CrawlResultID = data.nextID(), result.ID
+	}
+	for i := range result.Findings {
+		result.Findings[i].ID, result.Findings[i].CrawlResultID = data.nextID(), result.ID
+	}
+	data.links[result.ID] = append([]models.Link{}, result.Links...)
+	data.pages[result.ID] = append([]models.CrawlPage{}, result.Pages...)
+	data.broken[result.ID] = append([]models.InternalBrokenLink{}, result.InternalBrokenLinks...)
+	data.findings[result.ID] = append([]models.Finding{}, result.Findings...)
+
+	// Only the latest crawl of a URL keeps its snapshot
+	for id, snapshot := range data.snapshots {
+		if data.results[snapshot.CrawlResultID].URLID == result.URLID {
+			delete(data.snapshots, id)
+		}
+	}
+	if snapshot := result.Snapshot; snapshot != nil {
+		snapshot.ID, snapshot.CrawlResultID = data.nextID(), result.ID
+		if snapshot.CreatedAt.IsZero() {
+			snapshot.CreatedAt = memoryNow()
+		}
+		stored := *snapshot
+		stored.HTML = append([]byte{}, snapshot.HTML...)
+		data.snapshots[stored.ID] = stored
+	}
+	data.results[result.ID] = withoutChildRows(*result)
+
+	if url, ok := data.urls[result.URLID]; ok {
+		latestID := result.ID
+		url.LatestCrawlID = &latestID
+		url.LinksCount = len(result.Links)
+		url.BrokenLinksCount = countBroken(result.Links)
+		data.urls[url.ID] = url
+	}
+	return nil
+}
+
+func (r *memoryCrawlResults) First(urlID uint) (models.CrawlResult, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	results := urlResults(data, urlID)
+	if len(results) == 0 {
+		return models.CrawlResult{}, nil
+	}
+	result := results[0]
+	result.Links = append([]models.Link{}, data.links[result.ID]...)
+	return result, nil
+}
+
+func (r *memoryCrawlResults) Get(resultID uint) (models.CrawlResult, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	result, ok := data.results[resultID]
+	if !ok {
+		return models.CrawlResult{}, ErrNotFound
+	}
+	return result, nil
+}
+
+func (r *memoryCrawlResults) Latest(urlID uint, options LoadOptions) (models.CrawlResult, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	results := urlResults(data, urlID)
+	if len(results) == 0 {
+		return models.CrawlResult{}, ErrNotFound
+	}
+	latest := results[0]
+	for _, result := range results[1:] {
+		if !result.CrawledAt.Before(latest.CrawledAt) {
+			latest = result
+		}
+	}
+	if options.Links {
+		latest.Links = page(data.links[latest.ID], 0, linksLimit(options.LinksLimit))
+	}
+	if options.InternalBrokenLinks {
+		latest.InternalBrokenLinks = append([]models.InternalBrokenLink{}, data.broken[latest.ID]...)
+	}
+	return latest, nil
+}
+
+func (r *memoryCrawlResults) LatestID(urlID uint) (uint, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return latestResultID(data, urlID, 0), nil
+}
+
+func (r *memoryCrawlResults) PreviousID(urlID uint, resultID uint) (uint, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return latestResultID(data, urlID, resultID), nil
+}
+
+func (r *memoryCrawlResults) LatestResults(urlIDs []uint) (map[uint]models.CrawlResult, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	latest := make(map[uint]models.CrawlResult)
+	for _, urlID := range urlIDs {
+		if latestID := latestResultID(data, urlID, 0); latestID != 0 {
+			latest[urlID] = data.results[latestID]
+		}
+	}
+	return latest, nil
+}
+
+func (r *memoryCrawlResults) LatestPairs(urlIDs []uint) (map[uint]CrawlPair, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	pairs := make(map[uint]CrawlPair)
+	for _, urlID := range urlIDs {
+		latestID := latestResultID(data, urlID, 0)
+		if latestID == 0 {
+			continue
+		}
+		pair := CrawlPair{Latest: data.results[latestID]}
+		if previousID := latestResultID(data, urlID, latestID); previousID != 0 {
+			previous := data.results[previousID]
+			pair.Previous = &previous
+		}
+		pairs[urlID] = pair
+	}
+	return pairs, nil
+}
+
+func (r *memoryCrawlResults) Links(resultID uint) ([]models.Link, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return append([]models.Link{}, data.links[resultID]...), nil
+}
+
+func (r *memoryCrawlResults) LinksPage(resultID uint, offset, limit int) ([]models.Link, int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	links := data.links[resultID]
+	return page(links, offset, limit), int64(len(links)), nil
+}
+
+func (r *memoryCrawlResults) LinksAfter(resultID uint, afterID uint, limit int) ([]models.Link, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	links := data.links[resultID]
+	start := sort.Search(len(links), func(i int) bool { return links[i].ID > afterID })
+	return page(links, start, limit), nil
+}
+
+func (r *memoryCrawlResults) CountLinks(resultID uint) (int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return int64(len(data.links[resultID])), nil
+}
+
+func (r *memoryCrawlResults) CountBrokenLinks(resultID uint) (int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return int64(countBroken(data.links[resultID])), nil
+}
+
+func (r *memoryCrawlResults) CountPages(resultID uint) (int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return int64(len(data.pages[resultID])), nil
+}
+
+func (r *memoryCrawlResults) PermanentRedirects(resultID uint) ([]models.Link, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	links := []models.Link{}
+	for _, link := range data.links[resultID] {
+		if link.PermanentRedirect {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (r *memoryCrawlResults) Findings(resultID uint, filter FindingFilter) ([]models.Finding, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	findings := []models.Finding{}
+	for _, finding := range data.findings[resultID] {
+		if matchesFindingFilter(finding, filter) {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+func (r *memoryCrawlResults) Snapshot(resultID uint) (models.PageSnapshot, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, snapshot := range data.snapshots {
+		if snapshot.CrawlResultID == resultID {
+			snapshot.HTML = append([]byte{}, snapshot.HTML...)
+			return snapshot, nil
+		}
+	}
+	return models.PageSnapshot{}, ErrNotFound
+}
+
+func (r *memoryCrawlResults) UpdateAnalysis(result *models.CrawlResult, columns []string, findingTypes []string) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	stored, ok := data.results[result.ID]
+	if !ok {
+		return nil
+	}
+	if err := copyColumns(&stored, result, columns); err != nil {
+		return err
+	}
+	data.results[result.ID] = stored
+	if len(findingTypes) == 0 {
+		return nil
+	}
+
+	replaced := make(map[string]bool, len(findingTypes))
+	for _, findingType := range findingTypes {
+		replaced[findingType] = true
+	}
+	findings := []models.Finding{}
+	for _, finding := range data.findings[result.ID] {
+		if !replaced[finding.Type] {
+			findings = append(findings, finding)
+		}
+	}
+	for i := range result.Findings {
+		result.Findings[i].ID, result.Findings[i].CrawlResultID = data.nextID(), result.ID
+		findings = append(findings, result.Findings[i])
+	}
+	data.findings[result.ID] = findings
+	return nil
+}
+
+func (r *memoryCrawlResults) ProjectFindings(projectID uint, filter FindingFilter) ([]ProjectFinding, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	findings := []ProjectFinding{}
+	for _, url := range projectURLs(data, projectID) {
+		if filter.URLID != 0 && url.ID != filter.URLID {
+			continue
+		}
+		for _, finding := range data.findings[latestResultID(data, url.ID, 0)] {
+			if matchesFindingFilter(finding, filter) {
+				findings = append(findings, ProjectFinding{Finding: finding, URLID: url.ID})
+			}
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].ID < findings[j].ID })
+	return findings, nil
+}
+
+func (r *memoryCrawlResults) ProjectMetaDescriptions(projectID uint, description string) ([]PageMetaDescription, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	pages := []PageMetaDescription{}
+	for _, url := range projectURLs(data, projectID) {
+		if url.LatestCrawlID == nil {
+			continue
+		}
+		result, ok := data.results[*url.LatestCrawlID]
+		if !ok || (description != "" && result.MetaDescription != description) {
+			continue
+		}
+		pages = append(pages, PageMetaDescription{
+			URLID:           url.ID,
+			URL:             url.URL,
+			MetaDescription: result.MetaDescription,
+			AnalyzerVersion: result.AnalyzerVersion,
+		})
+	}
+	return pages, nil
+}
+
+func (r *memoryCrawlResults) ProjectPartySplits(projectID uint, urlID uint, since time.Time) ([]PartySplit, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	splits := []PartySplit{}
+	for _, url := range projectURLs(data, projectID) {
+		if urlID != 0 && url.ID != urlID {
+			continue
+		}
+		for _, result := range urlResults(data, url.ID) {
+			if result.CrawledAt.Before(since) {
+				continue
+			}
+			// Every result stored here was counted, unlike rows stored before the columns were added
+			splits = append(splits, PartySplit{
+				URLID:                 result.URLID,
+				CrawledAt:             result.CrawledAt,
+				FirstPartyLinks:       result.FirstPartyLinks,
+				ThirdPartyLinks:       result.ThirdPartyLinks,
+				FirstPartyResources:   result.FirstPartyResources,
+				ThirdPartyResources:   result.ThirdPartyResources,
+				ThirdPartyDomainCount: result.ThirdPartyDomainCount,
+				Counted:               true,
+			})
+		}
+	}
+	sort.Slice(splits, func(i, j int) bool { return splits[i].CrawledAt.Before(splits[j].CrawledAt) })
+	return splits, nil
+}
+
+func (r *memoryCrawlResults) CrawlUsage(since time.Time) ([]CrawlUsage, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	type usageKey struct {
+		month     string
+		projectID uint // 0 is the URLs outside projects
+		createdBy string
+	}
+	groups := make(map[usageKey]*CrawlUsage)
+	for _, result := range data.results {
+		if result.CrawledAt.Before(since) {
+			continue
+		}
+		url, ok := data.urls[result.URLID]
+		if !ok {
+			continue
+		}
+		key := usageKey{month: result.CrawledAt.UTC().Format("2006-01"), createdBy: url.CreatedBy}
+		if url.ProjectID != nil {
+			key.projectID = *url.ProjectID
+		}
+		usage, ok := groups[key]
+		if !ok {
+			usage = &CrawlUsage{Month: key.month, ProjectID: url.ProjectID, CreatedBy: url.CreatedBy}
+			groups[key] = usage
+		}
+		// Every crawl sends at least one request, so a crawl without any was stored before they were counted
+		if result.Requests == 0 {
+			usage.Unmeasured++
+			continue
+		}
+		usage.Crawls++
+		usage.Requests += result.Requests
+		usage.BytesDownloaded += result.BytesDownloaded
+		usage.DurationMS += result.DurationMS
+	}
+
+	usage := make([]CrawlUsage, 0, len(groups))
+	for _, group := range groups {
+		usage = append(usage, *group)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Month != usage[j].Month {
+			return usage[i].Month < usage[j].Month
+		}
+		if usage[i].CreatedBy != usage[j].CreatedBy {
+			return usage[i].CreatedBy < usage[j].CreatedBy
+		}
+		return usageProjectID(usage[i].ProjectID) < usageProjectID(usage[j].ProjectID)
+	})
+	return usage, nil
+}
+
+func (r *memoryCrawlResults) DeleteForURL(urlID uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for id, result := range data.results {
+		if result.URLID != urlID {
+			continue
+		}
+		delete(data.results, id)
+		delete(data.links, id)
+		delete(data.pages, id)
+		delete(data.broken, id)
+		delete(data.findings, id)
+		for snapshotID, snapshot := range data.snapshots {
+			if snapshot.CrawlResultID == id {
+				delete(data.snapshots, snapshotID)
+			}
+		}
+	}
+	if url, ok := data.urls[urlID]; ok {
+		url.LatestCrawlID, url.LinksCount, url.BrokenLinksCount = nil, 0, 0
+		data.urls[urlID] = url
+	}
+	return nil
+}
+
+// withoutChildRows returns a copy of result as its row is stored, without the child rows
+func withoutChildRows(result models.CrawlResult) models.CrawlResult {
+	result.Links, result.Pages, result.InternalBrokenLinks, result.Findings, result.Snapshot = nil, nil, nil, nil, nil
+	return result
+}
+
+// urlResults returns the crawl results of a URL, oldest first
+func urlResults(data *memoryData, urlID uint) []models.CrawlResult {
+	results := []models.CrawlResult{}
+	for _, result := range data.results {
+		if result.URLID == urlID {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results
+}
+
+// latestResultID returns the ID of the latest crawl of a URL before beforeID, or of all its crawls when it is 0
+func latestResultID(data *memoryData, urlID uint, beforeID uint) uint {
+	var latestID uint
+	for id, result := range data.results {
+		if result.URLID == urlID && (beforeID == 0 || id < beforeID) && id > latestID {
+			latestID = id
+		}
+	}
+	return latestID
+}
+
+// projectURLs returns the URLs of a project that aren't deleted, ordered by ID
+func projectURLs(data *memoryData, projectID uint) []models.URL {
+	urls := []models.URL{}
+	for _, url := range data.urls {
+		if !url.DeletedAt.Valid && url.ProjectID != nil && *url.ProjectID == projectID {
+			urls = append(urls, url)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ID < urls[j].ID })
+	return urls
+}
+
+// countBroken counts the broken links; throttled links are unknown, not broken
+func countBroken(links []models.Link) int {
+	broken := 0
+	for _, link := range links {
+		if !link.IsAccessible && !link.Throttled {
+			broken++
+		}
+	}
+	return broken
+}
+
+// linksLimit turns a LoadOptions.LinksLimit into a page limit, where 0 loads every link
+func linksLimit(limit int) int {
+	if limit > 0 {
+		return limit
+	}
+	return -1
+}
+
+// matchesFindingFilter applies the type, severity, category and lifecycle filters like filterFindings
+func matchesFindingFilter(finding models.Finding, filter FindingFilter) bool {
+	return (filter.Type == "" || finding.Type == filter.Type) &&
+		(filter.Severity == "" || finding.Severity == filter.Severity) &&
+		(filter.Category == "" || finding.Category == filter.Category) &&
+		(filter.Lifecycle == "" || finding.Lifecycle == filter.Lifecycle)
+}
+
+// usageProjectID orders the URLs outside projects first
+func usageProjectID(projectID *uint) uint {
+	if projectID == nil {
+		return 0
+	}
+	return *projectID
+}
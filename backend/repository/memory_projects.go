@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// memoryProjects implements ProjectRepository in memory
+type memoryProjects struct {
+	store *memoryStore
+}
+
+// nameTaken reports whether another project, deleted or not, has the name of project
+func (r *memoryProjects) nameTaken(data *memoryData, project *models.Project) bool {
+	for id, other := range data.projects {
+		if id != project.ID && other.Name == project.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryProjects) Create(project *models.Project) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	if r.nameTaken(data, project) {
+		return ErrDuplicate
+	}
+	now := memoryNow()
+	project.ID = data.nextID()
+	if project.CreatedAt.IsZero() {
+		project.CreatedAt = now
+	}
+	if project.UpdatedAt.IsZero() {
+		project.UpdatedAt = now
+	}
+	data.projects[project.ID] = *project
+	return nil
+}
+
+func (r *memoryProjects) Get(id uint) (models.Project, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	project, ok := data.projects[id]
+	if !ok || project.DeletedAt.Valid {
+		return models.Project{}, ErrNotFound
+	}
+	return project, nil
+}
+
+func (r *memoryProjects) List() ([]models.Project, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	projects := []models.Project{}
+	for _, project := range data.projects {
+		if !project.DeletedAt.Valid {
+			projects = append(projects, project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+func (r *memoryProjects) FindByName(name string, excludeID uint) (models.Project, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for id, project := range data.projects {
+		if !project.DeletedAt.Valid && project.Name == name && id != excludeID {
+			return project, nil
+		}
+	}
+	return models.Project{}, ErrNotFound
+}
+
+func (r *memoryProjects) Exists(id uint) (bool, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	project, ok := data.projects[id]
+	return ok && !project.DeletedAt.Valid, nil
+}
+
+func (r *memoryProjects) Update(project *models.Project, columns ...string) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	stored, ok := data.projects[project.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil
+	}
+	if err := copyColumns(&stored, project, columns); err != nil {
+		return err
+	}
+	if r.nameTaken(data, &stored) {
+		return ErrDuplicate
+	}
+	stored.UpdatedAt = memoryNow()
+	project.UpdatedAt = stored.UpdatedAt
+	data.projects[project.ID] = stored
+	return nil
+}
+
+func (r *memoryProjects) Delete(id uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for urlID, url := range data.urls {
+		if !url.DeletedAt.Valid && url.ProjectID != nil && *url.ProjectID == id {
+			url.ProjectID = nil
+			data.urls[urlID] = url
+		}
+	}
+	if project, ok := data.projects[id]; ok && !project.DeletedAt.Valid {
+		project.DeletedAt.Time, project.DeletedAt.Valid = memoryNow(), true
+		data.projects[id] = project
+	}
+	return nil
+}
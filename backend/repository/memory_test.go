@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+func TestMemoryCrawlQueueClaim(t *testing.T) {
+	queue := NewMemoryStore().CrawlQueue()
+	now := time.Now()
+	crawls := []models.QueuedCrawl{
+		{URLID: 1, Owner: "stopped", HeartbeatAt: now.Add(-time.Hour)},
+		{URLID: 2, Owner: "running", HeartbeatAt: now},
+		{URLID: 3, Owner: "self", HeartbeatAt: now.Add(-time.Hour)},
+		{URLID: 4, Owner: "stopped", HeartbeatAt: now.Add(-time.Hour), JobID: 7},
+	}
+	for i := range crawls {
+		if err := queue.Enqueue(&crawls[i]); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	// Only the stale crawls of other owners are taken over, oldest first
+	claimed, err := queue.Claim("self", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].URLID != 1 || claimed[1].URLID != 4 || claimed[1].JobID != 7 {
+		t.Fatalf("Claim = %+v, want the crawls of URLs 1 and 4", claimed)
+	}
+	for _, crawl := range claimed {
+		if crawl.Owner != "self" || !crawl.HeartbeatAt.After(now.Add(-time.Minute)) {
+			t.Errorf("claimed crawl of URL %d has owner %q and heartbeat %v", crawl.URLID, crawl.Owner, crawl.HeartbeatAt)
+		}
+	}
+
+	// The claimed crawls are fresh now, so another server doesn't take them over again
+	if claimed, err := queue.Claim("other", now.Add(-time.Minute)); err != nil || len(claimed) != 1 || claimed[0].URLID != 3 {
+		t.Errorf("second Claim = %+v, %v, want only the crawl of URL 3", claimed, err)
+	}
+
+	if err := queue.DequeueURL(1); err != nil {
+		t.Fatalf("DequeueURL: %v", err)
+	}
+	if claimed, err := queue.Claim("later", now.Add(time.Minute)); err != nil || len(claimed) != 3 {
+		t.Errorf("Claim after DequeueURL = %+v, %v, want the 3 crawls left", claimed, err)
+	}
+}
+
+func TestMemoryTransactionRollback(t *testing.T) {
+	store := NewMemoryStore()
+	failed := errors.New("failed")
+
+	err := store.Transaction(func(tx Store) error {
+		if err := tx.URLs().Create(&models.URL{URL: "https://example.com/"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction = %v, want %v", err, failed)
+	}
+	if _, err := store.URLs().FindByURL("https://example.com/", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindByURL after the rollback = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryURLRestore(t *testing.T) {
+	// A deleted URL keeps its address, so adding it again restores it
+	store := NewMemoryStore()
+	url := models.URL{URL: "https://example.com/"}
+	if err := store.URLs().Create(&url); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := store.URLs().Delete(url.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.URLs().Create(&models.URL{URL: url.URL}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Create of a deleted URL = %v, want ErrDuplicate", err)
+	}
+	restored := models.URL{URL: url.URL, Status: "running"}
+	if err := store.URLs().Restore(&restored); err != nil || restored.ID != url.ID {
+		t.Errorf("Restore = %v with ID %d, want URL %d", err, restored.ID, url.ID)
+	}
+	if got, err := store.URLs().Get(url.ID); err != nil || got.Status != "running" {
+		t.Errorf("Get after Restore = %+v, %v, want a running URL", got, err)
+	}
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// memoryURLs implements URLRepository in memory
+type memoryURLs struct {
+	store *memoryStore
+}
+
+// duplicate reports whether another URL, deleted or not, has the address or the Idempotency-Key of url
+func (r *memoryURLs) duplicate(data *memoryData, url *models.URL) bool {
+	for id, other := range data.urls {
+		if id == url.ID {
+			continue
+		}
+		if other.URL == url.URL {
+			return true
+		}
+		if url.IdempotencyKey != nil && other.IdempotencyKey != nil && *other.IdempotencyKey == *url.IdempotencyKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryURLs) Create(url *models.URL) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	if r.duplicate(data, url) {
+		return ErrDuplicate
+	}
+	now := memoryNow()
+	url.ID = data.nextID()
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = now
+	}
+	if url.UpdatedAt.IsZero() {
+		url.UpdatedAt = now
+	}
+	if url.Status == "" {
+		url.Status = "queued"
+	}
+	data.urls[url.ID] = *url
+	return nil
+}
+
+func (r *memoryURLs) Restore(url *models.URL) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for id, stored := range data.urls {
+		if stored.URL != url.URL || !stored.DeletedAt.Valid {
+			continue
+		}
+		url.ID = id
+		if r.duplicate(data, url) {
+			return ErrDuplicate
+		}
+		if err := copyColumns(&stored, url, restoredURLColumns); err != nil {
+			return err
+		}
+		stored.UpdatedAt = memoryNow()
+		data.urls[id] = stored
+		*url = stored
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r *memoryURLs) Get(id uint) (models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	url, ok := data.urls[id]
+	if !ok || url.DeletedAt.Valid {
+		return models.URL{}, ErrNotFound
+	}
+	return url, nil
+}
+
+// live returns the URLs that aren't deleted and match keep, newest first
+func (r *memoryURLs) live(data *memoryData, keep func(models.URL) bool) []models.URL {
+	urls := []models.URL{}
+	for _, url := range data.urls {
+		if !url.DeletedAt.Valid && keep(url) {
+			urls = append(urls, url)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if !urls[i].CreatedAt.Equal(urls[j].CreatedAt) {
+			return urls[i].CreatedAt.After(urls[j].CreatedAt)
+		}
+		return urls[i].ID > urls[j].ID
+	})
+	return urls
+}
+
+func (r *memoryURLs) List() ([]models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return r.live(data, func(models.URL) bool { return true }), nil
+}
+
+func (r *memoryURLs) ListProject(projectID uint) ([]models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	return r.live(data, func(url models.URL) bool {
+		return url.ProjectID != nil && *url.ProjectID == projectID
+	}), nil
+}
+
+func (r *memoryURLs) ListPage(offset, limit int) ([]models.URL, int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	urls := r.live(data, func(models.URL) bool { return true })
+	return page(urls, offset, limit), int64(len(urls)), nil
+}
+
+func (r *memoryURLs) ListAfter(after *Cursor, limit int) ([]models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	urls := r.live(data, func(url models.URL) bool {
+		return after == nil || url.CreatedAt.Before(after.CreatedAt) || (url.CreatedAt.Equal(after.CreatedAt) && url.ID < after.ID)
+	})
+	return page(urls, 0, limit), nil
+}
+
+func (r *memoryURLs) FindByURL(rawURL string, excludeID uint) (models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, url := range r.live(data, func(models.URL) bool { return true }) {
+		if url.URL == rawURL && url.ID != excludeID {
+			return url, nil
+		}
+	}
+	return models.URL{}, ErrNotFound
+}
+
+func (r *memoryURLs) FindDeletedByURL(rawURL string) (models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, url := range data.urls {
+		if url.URL == rawURL && url.DeletedAt.Valid {
+			return url, nil
+		}
+	}
+	return models.URL{}, ErrNotFound
+}
+
+func (r *memoryURLs) FindByIdempotencyKey(key string) (models.URL, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, url := range data.urls {
+		if url.IdempotencyKey != nil && *url.IdempotencyKey == key {
+			return url, nil
+		}
+	}
+	return models.URL{}, ErrNotFound
+}
+
+func (r *memoryURLs) ExistingIDs(ids []uint) ([]uint, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	existing := []uint{}
+	for _, id := range ids {
+		if url, ok := data.urls[id]; ok && !url.DeletedAt.Valid {
+			existing = append(existing, id)
+		}
+	}
+	return existing, nil
+}
+
+func (r *memoryURLs) OutdatedIDs(analyzerVersion int) ([]uint, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	ids := []uint{}
+	for _, url := range r.live(data, func(models.URL) bool { return true }) {
+		if url.LatestCrawlID == nil {
+			continue
+		}
+		if result, ok := data.results[*url.LatestCrawlID]; ok && result.AnalyzerVersion < analyzerVersion {
+			ids = append(ids, url.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (r *memoryURLs) Update(url *models.URL, columns ...string) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	stored, ok := data.urls[url.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return nil
+	}
+	if err := copyColumns(&stored, url, columns); err != nil {
+		return err
+	}
+	if r.duplicate(data, &stored) {
+		return ErrDuplicate
+	}
+	stored.UpdatedAt = memoryNow()
+	url.UpdatedAt = stored.UpdatedAt
+	data.urls[url.ID] = stored
+	return nil
+}
+
+func (r *memoryURLs) SetStatus(status string, ids ...uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, id := range ids {
+		if url, ok := data.urls[id]; ok && !url.DeletedAt.Valid {
+			url.Status, url.UpdatedAt = status, memoryNow()
+			data.urls[id] = url
+		}
+	}
+	return nil
+}
+
+func (r *memoryURLs) Delete(ids ...uint) error {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	for _, id := range ids {
+		if url, ok := data.urls[id]; ok && !url.DeletedAt.Valid {
+			url.DeletedAt.Time, url.DeletedAt.Valid = memoryNow(), true
+			data.urls[id] = url
+		}
+	}
+	return nil
+}
+
+func (r *memoryURLs) Version() (ListVersion, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	var version ListVersion
+	for _, url := range r.live(data, func(models.URL) bool { return true }) {
+		version.Count++
+		if version.LastUpdated == nil || url.UpdatedAt.After(*version.LastUpdated) {
+			updated := url.UpdatedAt
+			version.LastUpdated = &updated
+		}
+	}
+	for id := range data.results {
+		if id > version.LastCrawlID {
+			version.LastCrawlID = id
+		}
+	}
+	return version, nil
+}
+
+func (r *memoryURLs) Facets() (URLFacets, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	statuses, versions, domains, tags := map[string]int64{}, map[string]int64{}, map[string]int64{}, map[string]int64{}
+	for _, row := range r.live(data, func(models.URL) bool { return true }) {
+		statuses[row.Status]++
+		if row.LatestCrawlID != nil {
+			if result, ok := data.results[*row.LatestCrawlID]; ok {
+				versions[result.HTMLVersion]++
+			}
+		}
+		if parsed, err := url.Parse(row.URL); err == nil && parsed.Hostname() != "" {
+			domains[facetDomain(parsed.Hostname())]++
+		}
+		for _, tag := range row.Tags {
+			tags[tag]++
+		}
+	}
+	return URLFacets{
+		Status:      facetCounts(statuses),
+		HTMLVersion: facetCounts(versions),
+		Domain:      facetCounts(domains),
+		Tags:        facetCounts(tags),
+	}, nil
+}
+
+func (r *memoryURLs) BackfillCounters() (int64, error) {
+	data := r.store.lock()
+	defer r.store.unlock()
+
+	// Like the UPDATE of the GORM store, deleted URLs are backfilled as well
+	var updated int64
+	for id, url := range data.urls {
+		if url.LatestCrawlID != nil {
+			continue
+		}
+		latestID := latestResultID(data, id, 0)
+		if latestID == 0 {
+			continue
+		}
+		url.LatestCrawlID = &latestID
+		url.LinksCount = len(data.links[latestID])
+		url.BrokenLinksCount = countBroken(data.links[latestID])
+		data.urls[id] = url
+		updated++
+	}
+	return updated, nil
+}
+
+// page returns the rows of a page of rows
+func page[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return append([]T{}, rows...)
+}
@@ -0,0 +1,241 @@
+// Package repository defines the storage interfaces used by controllers and services
+// Callers depend on these interfaces instead of GORM, so the persistence backend can be swapped
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// Errors returned by every implementation, so callers don't depend on driver specific errors
+var (
+	ErrNotFound  = errors.New("record not found")
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// Store gives access to all repositories of one storage backend
+type Store interface {
+	URLs() URLRepository
+	CrawlResults() CrawlResultRepository
+	Projects() ProjectRepository
+	CrawlQueue() CrawlQueueRepository
+
+	// Transaction runs fn with a store whose changes are committed together,
+	// or rolled back when fn returns an error
+	Transaction(fn func(Store) error) error
+
+	// Replica returns a store that reads from the read replica, or from the primary when none is configured
+	// Its reads may lag behind writes, so it suits list and report endpoints but not read-after-write paths
+	Replica() Store
+
+	// Ping checks that the storage backend is reachable
+	Ping() error
+}
+
+// URLRepository stores the URLs being analyzed
+type URLRepository interface {
+	Create(url *models.URL) error
+
+	// Restore brings back the deleted URL with the address of url, taking the fields a new URL is added with
+	// from url and filling in the rest; its crawls are kept. ErrNotFound when no deleted URL has the address
+	Restore(url *models.URL) error
+
+	Get(id uint) (models.URL, error)
+	List() ([]models.URL, error)                                 // Newest first
+	ListProject(projectID uint) ([]models.URL, error)            // Newest first
+	ListPage(offset, limit int) ([]models.URL, int64, error)     // Newest first, with the total count
+	ListAfter(after *Cursor, limit int) ([]models.URL, error)    // Newest first, starting behind after; nil starts at the newest
+	FindByURL(rawURL string, excludeID uint) (models.URL, error) // excludeID 0 excludes nothing
+	FindDeletedByURL(rawURL string) (models.URL, error)          // Deleted URLs only, they keep their address
+	FindByIdempotencyKey(key string) (models.URL, error)         // Deleted URLs included, they keep their key
+	ExistingIDs(ids []uint) ([]uint, error)
+	OutdatedIDs(analyzerVersion int) ([]uint, error) // URLs whose latest crawl was analyzed by an older analyzer version
+
+	// Update writes the given columns of url, including zero values
+	Update(url *models.URL, columns ...string) error
+	SetStatus(status string, ids ...uint) error
+	Delete(ids ...uint) error // Together with the annotations of the URLs
+
+	// Version returns values that change whenever a URL is added, updated, deleted, or crawled
+	Version() (ListVersion, error)
+
+	// Facets counts the URLs per value of the columns the URL list can be filtered by
+	Facets() (URLFacets, error)
+
+	// BackfillCounters fills the crawl counters of URLs crawled before they were maintained
+	// and returns the number of URLs updated
+	BackfillCounters() (int64, error)
+}
+
+// Cursor is the position of the last row of a keyset page
+// URLs are ordered by CreatedAt and ID, links by ID alone
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// ListVersion is a cheap fingerprint of the URL list
+type ListVersion struct {
+	Count       int64
+	LastUpdated *time.Time
+	LastCrawlID uint
+}
+
+// URLFacets holds the distinct values of the filterable URL columns, most common first
+type URLFacets struct {
+	Status      []FacetCount `json:"status"`
+	HTMLVersion []FacetCount `json:"html_version"` // Of the latest crawl; URLs that were never crawled aren't counted
+	Domain      []FacetCount `json:"domain"`
+	Tags        []FacetCount `json:"tags"` // A URL counts once for each of its tags
+}
+
+// FacetCount is a column value and the number of URLs having it
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// LoadOptions selects the child rows loaded with a crawl result
+type LoadOptions struct {
+	Links               bool
+	LinksLimit          int // 0 loads every link
+	InternalBrokenLinks bool
+}
+
+// FindingFilter narrows a findings query; empty fields don't filter
+type FindingFilter struct {
+	Type      string
+	Severity  string
+	Category  string
+	Lifecycle string // new or persisting; resolved findings aren't stored
+	URLID     uint
+}
+
+// ProjectFinding is a finding together with the URL whose crawl produced it
+type ProjectFinding struct {
+	models.Finding
+	URLID uint `json:"url_id"`
+}
+
+// PageMetaDescription is the meta description of the latest crawl of a URL
+type PageMetaDescription struct {
+	URLID           uint   `json:"url_id"`
+	URL             string `json:"url"`
+	MetaDescription string `json:"meta_description"`
+	AnalyzerVersion int    `json:"-"`
+}
+
+// PartySplit is the first-party/third-party split of a crawl
+type PartySplit struct {
+	URLID                 uint      `json:"url_id"`
+	CrawledAt             time.Time `json:"crawled_at"`
+	FirstPartyLinks       int       `json:"first_party_links"`
+	ThirdPartyLinks       int       `json:"third_party_links"`
+	FirstPartyResources   int       `json:"first_party_resources"`
+	ThirdPartyResources   int       `json:"third_party_resources"`
+	ThirdPartyDomainCount int       `json:"third_party_domain_count"`
+	Counted               bool      `json:"-"` // The columns are NULL for crawls stored before the split was counted
+}
+
+// CrawlPair is the latest crawl of a URL and the one before it, nil for a URL crawled once
+type CrawlPair struct {
+	Latest   models.CrawlResult
+	Previous *models.CrawlResult
+}
+
+// CrawlUsage is what the crawls of a month cost for the URLs of one project added by one user
+type CrawlUsage struct {
+	Month           string `json:"month"` // 2026-01, in UTC
+	ProjectID       *uint  `json:"project_id"`
+	CreatedBy       string `json:"created_by"`
+	Crawls          int    `json:"crawls"`
+	Unmeasured      int    `json:"unmeasured"` // Crawls stored before their cost was recorded, not included in the sums
+	Requests        int    `json:"requests"`
+	BytesDownloaded int64  `json:"bytes_downloaded"`
+	DurationMS      int64  `json:"duration_ms"`
+}
+
+// CrawlResultRepository stores crawl results and their child rows
+type CrawlResultRepository interface {
+	// Create saves a new crawl result with its child rows and makes it the latest crawl of its URL,
+	// refreshing the counters on the URL row in the same transaction
+	Create(result *models.CrawlResult) error
+	First(urlID uint) (models.CrawlResult, error)  // Oldest result with its links
+	Get(resultID uint) (models.CrawlResult, error) // Without child rows
+	Latest(urlID uint, options LoadOptions) (models.CrawlResult, error)
+	LatestID(urlID uint) (uint, error)                  // 0 when the URL hasn't been crawled
+	PreviousID(urlID uint, resultID uint) (uint, error) // Crawl before resultID, 0 when there is none
+
+	// LatestResults returns the latest crawl of each of the URLs without child rows, in two queries;
+	// URLs without a crawl are left out
+	LatestResults(urlIDs []uint) (map[uint]models.CrawlResult, error)
+
+	// LatestPairs returns the latest crawl of each of the URLs with the crawl before it, without child rows,
+	// in a fixed number of queries; URLs without a crawl are left out
+	LatestPairs(urlIDs []uint) (map[uint]CrawlPair, error)
+
+	Links(resultID uint) ([]models.Link, error)
+	LinksPage(resultID uint, offset, limit int) ([]models.Link, int64, error) // Ordered by id, with the total count
+	LinksAfter(resultID uint, afterID uint, limit int) ([]models.Link, error) // Ordered by id, starting behind afterID
+	CountLinks(resultID uint) (int64, error)
+	CountBrokenLinks(resultID uint) (int64, error) // Throttled links are unknown, not broken
+	CountPages(resultID uint) (int64, error)
+	PermanentRedirects(resultID uint) ([]models.Link, error)
+	Findings(resultID uint, filter FindingFilter) ([]models.Finding, error)
+	Snapshot(resultID uint) (models.PageSnapshot, error)
+
+	// UpdateAnalysis writes the given columns of result and replaces its findings of the given types
+	// with the ones in result.Findings, for re-running analyzers over a stored crawl
+	UpdateAnalysis(result *models.CrawlResult, columns []string, findingTypes []string) error
+
+	// ProjectFindings lists the findings of the latest crawl of every URL in the project
+	ProjectFindings(projectID uint, filter FindingFilter) ([]ProjectFinding, error)
+
+	// ProjectMetaDescriptions lists the meta description of the latest crawl of every crawled URL in the project;
+	// a non-empty description lists only the URLs using it, compared with the column's collation
+	ProjectMetaDescriptions(projectID uint, description string) ([]PageMetaDescription, error)
+
+	// ProjectPartySplits lists the first-party/third-party split of every crawl since the given time of the
+	// URLs in the project, or of only urlID when it isn't 0, ordered by crawl time
+	ProjectPartySplits(projectID uint, urlID uint, since time.Time) ([]PartySplit, error)
+
+	// CrawlUsage sums what the crawls since the given time cost by month, project, and user, ordered by month
+	// Crawls of deleted URLs are included, their requests were sent all the same
+	CrawlUsage(since time.Time) ([]CrawlUsage, error)
+
+	// DeleteForURL deletes every crawl result of the URL together with its child rows and its annotations,
+	// and resets its counters
+	DeleteForURL(urlID uint) error
+}
+
+// CrawlQueueRepository stores the crawls that were started and haven't finished, see models.QueuedCrawl
+type CrawlQueueRepository interface {
+	Enqueue(crawl *models.QueuedCrawl) error
+	Dequeue(id uint) error
+	DequeueURL(urlID uint) error
+	HasURL(urlID uint) (bool, error) // Whether a crawl of the URL is stored, by any server
+
+	// Heartbeat marks the crawls of owner as being worked on
+	Heartbeat(owner string) error
+
+	// Claim takes over the crawls whose owner sent no heartbeat since staleBefore and returns them, oldest first
+	// On an error, the crawls claimed until then are returned with it
+	Claim(owner string, staleBefore time.Time) ([]models.QueuedCrawl, error)
+}
+
+// ProjectRepository stores projects
+type ProjectRepository interface {
+	Create(project *models.Project) error
+	Get(id uint) (models.Project, error)
+	List() ([]models.Project, error) // Ordered by name
+	FindByName(name string, excludeID uint) (models.Project, error)
+	Exists(id uint) (bool, error)
+
+	// Update writes the given columns of project, including zero values
+	Update(project *models.Project, columns ...string) error
+
+	// Delete deletes the project and removes its URLs from it; the deleted project keeps its name taken
+	Delete(id uint) error
+}
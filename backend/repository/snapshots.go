@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com/klauspost/compress/zstd"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Encodings of snapshot bodies; new bodies are compressed with zstd, bodies stored before it stay gzipped
+const (
+	snapshotEncodingGzip = "gzip"
+	snapshotEncodingZstd = "zstd"
+)
+
+// maxSnapshotBodyMemory bounds what decoding a body may allocate, above the largest page a snapshot is kept of
+const maxSnapshotBodyMemory = 64 << 20
+
+// The encoder and decoder are safe for concurrent EncodeAll and DecodeAll calls, so every save shares them
+var (
+	snapshotEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	snapshotDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxSnapshotBodyMemory))
+)
+
+// saveSnapshotBody stores the HTML of a snapshot once per content and returns the hash referencing it
+// Pages that didn't change since their last crawl reuse the stored body without compressing it again
+func saveSnapshotBody(tx *gorm.DB, html []byte) (string, error) {
+	sum := sha256.Sum256(html)
+	hash := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	// Updating the row locks it, so a concurrent cleanup can't delete it before the snapshot referencing it is saved
+	update := tx.Model(&models.SnapshotBody{}).Where("hash = ?", hash).Update("last_used_at", now)
+	if update.Error != nil {
+		return "", update.Error
+	}
+	if update.RowsAffected > 0 {
+		return hash, nil
+	}
+
+	body := models.SnapshotBody{
+		Hash:       hash,
+		Encoding:   snapshotEncodingZstd,
+		Size:       int64(len(html)),
+		Data:       snapshotEncoder.EncodeAll(html, nil),
+		LastUsedAt: now,
+	}
+	// Another crawl of an identical page may have stored it in the meantime
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_used_at"}),
+	}).Create(&body).Error
+	return hash, err
+}
+
+// decodeSnapshotBody returns the uncompressed HTML of a body
+func decodeSnapshotBody(body models.SnapshotBody) ([]byte, error) {
+	switch body.Encoding {
+	case "":
+		return body.Data, nil
+	case snapshotEncodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(body.Data))
+		if err != nil {
+			return nil, fmt.Errorf("snapshot body %s is corrupt: %v", body.Hash, err)
+		}
+		defer gz.Close()
+		html, err := io.ReadAll(gz)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot body %s is corrupt: %v", body.Hash, err)
+		}
+		return html, nil
+	case snapshotEncodingZstd:
+		html, err := snapshotDecoder.DecodeAll(body.Data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot body %s is corrupt: %v", body.Hash, err)
+		}
+		return html, nil
+	default:
+		return nil, fmt.Errorf("snapshot body %s has unknown encoding %q", body.Hash, body.Encoding)
+	}
+}
+
+// deleteSnapshots deletes the snapshots matching the condition, then the bodies no other snapshot shares
+func deleteSnapshots(tx *gorm.DB, condition string, args ...interface{}) error {
+	var hashes []string
+	err := tx.Model(&models.PageSnapshot{}).Where(condition, args...).Where("body_hash <> ''").Distinct().Pluck("body_hash", &hashes).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where(condition, args...).Delete(&models.PageSnapshot{}).Error; err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return tx.Exec("DELETE FROM snapshot_bodies WHERE hash IN ? AND NOT EXISTS "+
+		"(SELECT 1 FROM page_snapshots WHERE page_snapshots.body_hash = snapshot_bodies.hash)", hashes).Error
+}
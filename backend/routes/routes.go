@@ -1,40 +1,202 @@
 package routes
 
 import (
+	"log"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/config"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/controllers"
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/middleware"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/services"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, fieldCipher *repository.FieldCipher) {
+	// Create shared services on top of the storage backend
+	store := repository.NewGormStore(db)
+	settingsService := services.NewSettingsService(db)
+	hostMetrics := services.NewHostMetrics()
+	spellChecker := services.NewSpellChecker(cfg.SpellcheckDictDir)
+	transport := services.NewHTTPTransport(services.TransportConfig{
+		MaxIdleConns:        cfg.CrawlerMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.CrawlerMaxIdleConnsPerHost,
+		IdleConnTimeout:     cfg.CrawlerIdleConnTimeout,
+		KeepAlives:          cfg.CrawlerKeepAlives,
+		DNSCacheTTL:         cfg.CrawlerDNSCacheTTL,
+		DNSServers:          cfg.CrawlerDNSServers,
+		DoHURL:              cfg.CrawlerDoHURL,
+	})
+	crawlerService := services.NewCrawlerService(store, settingsService, hostMetrics, spellChecker, transport)
+	if cfg.CrawlerMode == services.CrawlerModeMock {
+		crawlerService.EnableMock(services.MockCrawl{
+			Latency:        cfg.CrawlerMockLatency,
+			FailurePercent: cfg.CrawlerMockFailPercent,
+		})
+		log.Println("Crawler running in mock mode, pages are not fetched")
+	}
+	// Crawls can compare their page with what fetch agents in other regions get
+	fetchAgentService := services.NewFetchAgentService(db)
+	crawlerService.UseFetchAgents(fetchAgentService)
+	// Report crawl outcomes to the integrations configured on projects
+	crawlerService.Observe(services.NewGitHubNotifier(cfg.GitHubAPIURL, cfg.FrontendURL))
+	issueService := services.NewIssueService(db, cfg.FrontendURL)
+	crawlerService.Observe(issueService)
+	hookService := services.NewHookService(db, store)
+	crawlerService.Observe(hookService)
+	publisher, err := services.NewEventPublisher(services.EventStreamConfig{
+		Broker:             cfg.EventsBroker,
+		URL:                cfg.EventsBrokerURL,
+		CrawlStartedTopic:  cfg.EventsTopicCrawlStarted,
+		CrawlFinishedTopic: cfg.EventsTopicCrawlFinished,
+		FindingTopic:       cfg.EventsTopicFindings,
+	})
+	if err != nil {
+		log.Fatal("Failed to configure the event broker:", err)
+	}
+	if publisher != nil {
+		crawlerService.Observe(publisher)
+		log.Printf("Publishing crawl events to %s", cfg.EventsBroker)
+	}
+
+	// Fill the counters of URLs crawled before they were stored on the URL row
+	go func() {
+		if updated, err := store.URLs().BackfillCounters(); err != nil {
+			log.Printf("Failed to backfill URL counters: %v", err)
+		} else if updated > 0 {
+			log.Printf("Backfilled crawl counters of %d URL(s)", updated)
+		}
+	}()
+	batchJobService := services.NewBatchJobService(db)
+	userService := services.NewUserService(db)
+	if err := userService.EnsureAdmin(cfg.AdminUsername, cfg.AdminPassword); err != nil {
+		log.Fatal("Failed to create admin user:", err)
+	}
+	sessionService := services.NewSessionService(db, cfg.SessionTTL)
+	cookies := middleware.CookieConfig{
+		Enabled:  cfg.AuthMode == "cookie",
+		Secure:   cfg.CookieSecure,
+		SameSite: middleware.ParseSameSite(cfg.CookieSameSite),
+		MaxAge:   cfg.SessionTTL,
+	}
+	loginLimiter := services.NewLoginLimiter(cfg.LoginMaxFailures, cfg.LoginMaxFailuresPerIP, cfg.LoginLockout, cfg.LoginLockout)
+
+	// Hosted mode limits what each user may crawl by the plan of their Stripe subscription
+	var billingService *services.BillingService
+	if cfg.BillingEnabled {
+		billingService, err = services.NewBillingService(db, services.BillingConfig{
+			Plans:         cfg.BillingPlans,
+			DefaultPlan:   cfg.BillingDefaultPlan,
+			WebhookSecret: cfg.StripeWebhookSecret,
+			PricePlans:    cfg.StripePricePlans,
+		})
+		if err != nil {
+			log.Fatal("Failed to configure billing:", err)
+		}
+		crawlerService.UseBilling(billingService)
+		log.Println("Billing enabled, crawls are limited by the plans of their users")
+	}
+
 	// Create controller instances
-	urlController := controllers.NewURLController(db)
-	crawlController := controllers.NewCrawlController(db)
-	authController := controllers.NewAuthController()
+	urlController := controllers.NewURLController(store, crawlerService, batchJobService, billingService)
+	annotationService := services.NewAnnotationService(db)
+	crawlController := controllers.NewCrawlController(store, annotationService)
+	annotationController := controllers.NewAnnotationController(store, annotationService)
+	analyzeController := controllers.NewAnalyzeController(store, crawlerService)
+	ciController := controllers.NewCIController(store, crawlerService)
+	authController := controllers.NewAuthController(userService, sessionService, loginLimiter, cookies)
+	reprocessService := services.NewReprocessService(store, crawlerService, batchJobService)
+	encryptionService := services.NewEncryptionService(db, fieldCipher, cfg.EncryptSnapshots)
+	backupService := services.NewBackupService(db, services.BackupConfig{
+		Dir:       cfg.BackupDir,
+		Retention: cfg.BackupRetention,
+		Interval:  cfg.BackupInterval,
+		S3: services.S3Config{
+			Endpoint:  cfg.BackupS3Endpoint,
+			Region:    cfg.BackupS3Region,
+			Bucket:    cfg.BackupS3Bucket,
+			Prefix:    cfg.BackupS3Prefix,
+			AccessKey: cfg.BackupS3AccessKey,
+			SecretKey: cfg.BackupS3SecretKey,
+		},
+	})
+	backupService.StartSchedule()
+	adminController := controllers.NewAdminController(store, settingsService, hostMetrics, transport, services.NewSeedService(store), reprocessService, encryptionService, crawlerService, backupService, services.NewCrawlerIdentityService(settingsService, transport, cfg.EgressIPCheckURL))
+	jobController := controllers.NewJobController(batchJobService)
+	scheduleService := services.NewScheduleService(db, store, crawlerService, batchJobService)
+	// Adaptive schedules learn from every finished crawl how often a page changes
+	crawlerService.Observe(scheduleService)
+	// Crawls queued before a restart, or on a server that went down, are resumed once the crawler is set up
+	crawlerService.ResumeQueuedCrawls(func(crawl models.QueuedCrawl, err error) {
+		if err != nil {
+			log.Printf("Resumed crawl of URL %d failed: %v", crawl.URLID, err)
+		}
+		if crawl.JobID != 0 {
+			if err := batchJobService.RecordResult(crawl.JobID, err); err != nil {
+				log.Print(err)
+			}
+		}
+	})
+	scheduleService.Start()
+	projectController := controllers.NewProjectController(store, issueService, scheduleService)
+	healthController := controllers.NewHealthController(store, crawlerService)
+	schemaController := controllers.NewSchemaController()
+	hookController := controllers.NewHookController(hookService)
+	viewController := controllers.NewViewController(store, services.NewViewService(db))
+	agentController := controllers.NewAgentController(fetchAgentService)
+
+	// Cookie sessions need credentialed CORS, which can't be combined with allowing every origin
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AddExposeHeaders("Link", "X-Total-Count") // Pagination headers of list endpoints
+	corsConfig.AddAllowHeaders(utils.JSONCaseHeader)
+	if cookies.Enabled {
+		corsConfig.AllowOrigins = cfg.CORSAllowedOrigins
+		corsConfig.AllowCredentials = true
+		corsConfig.AddAllowHeaders("Authorization", middleware.CSRFHeaderName)
+	} else {
+		corsConfig.AllowAllOrigins = true
+	}
+	router.Use(cors.New(corsConfig))
+	requireAuth := middleware.AuthMiddleware(sessionService, cookies)
 
-	router.Use(cors.Default())
+	// Readiness check (no authentication required)
+	router.GET("/health/ready", healthController.Ready) // GET /health/ready
 
 	// API group
 	api := router.Group("/api")
+	api.Use(middleware.CompressionMiddleware(settingsService))
+	api.Use(middleware.JSONCaseMiddleware(cfg.JSONFieldCase))
+	api.Use(middleware.MaintenanceMiddleware(settingsService))
 
 	// Auth routes (no authentication required)
 	auth := api.Group("/auth")
 	{
-		auth.POST("/login", authController.Login)                       // POST /api/auth/login
-		auth.POST("/logout", authController.Logout)                     // POST /api/auth/logout
-		auth.GET("/me", middleware.AuthMiddleware(), authController.Me) // GET /api/auth/me
+		auth.POST("/login", authController.Login)                                       // POST /api/auth/login
+		auth.POST("/logout", middleware.CSRFMiddleware(cookies), authController.Logout) // POST /api/auth/logout
+		auth.GET("/me", requireAuth, authController.Me)                                 // GET /api/auth/me
+		auth.POST("/change-password", requireAuth, authController.ChangePassword)       // POST /api/auth/change-password
+		auth.GET("/sessions", requireAuth, authController.GetSessions)                  // GET /api/auth/sessions
+		auth.DELETE("/sessions/:id", requireAuth, authController.RevokeSession)         // DELETE /api/auth/sessions/1
 	}
 
+	// JSON Schema of the response payloads (no authentication required)
+	api.GET("/schema", schemaController.GetSchema)           // GET /api/schema
+	api.GET("/schema/:name", schemaController.GetDefinition) // GET /api/schema/CrawlResult
+
 	// Protected URL routes (authentication required)
 	urls := api.Group("/urls")
-	urls.Use(middleware.AuthMiddleware()) // Apply auth middleware to all URL routes
+	urls.Use(requireAuth) // Apply auth middleware to all URL routes
 	{
 		urls.POST("", urlController.AddURL)                    // POST /api/urls
 		urls.GET("", urlController.GetURLs)                    // GET /api/urls
+		urls.GET("/facets", urlController.GetURLFacets)        // GET /api/urls/facets
+		urls.POST("/validate", urlController.ValidateURL)      // POST /api/urls/validate
 		urls.GET("/:id", urlController.GetURL)                 // GET /api/urls/123
+		urls.PATCH("/:id", urlController.UpdateURL)            // PATCH /api/urls/123
 		urls.DELETE("/:id", urlController.DeleteURL)           // DELETE /api/urls/123
 		urls.POST("/:id/start", urlController.StartProcessing) // POST /api/urls/123/start
 		urls.POST("/:id/stop", urlController.StopProcessing)   // POST /api/urls/123/stop
@@ -44,8 +206,116 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 		urls.POST("/batch/stop", urlController.BatchStopProcessing)   // POST /api/urls/batch/stop
 		urls.DELETE("/batch/delete", urlController.BatchDeleteUrls)   // DELETE /api/urls/batch/delete
 		urls.POST("/batch/rerun", urlController.BatchRerunAnalysis)   // POST /api/urls/batch/rerun
+		urls.POST("/batch/tag", urlController.BatchTagURLs)           // POST /api/urls/batch/tag
+		urls.POST("/batch/config", urlController.BatchUpdateConfig)   // POST /api/urls/batch/config
+
+		urls.GET("/crawl", crawlController.GetCrawelResults)                   // GET /api/crawls
+		urls.GET("/:id/crawl", crawlController.GetCrawlResults)                // GET /api/urls/123/crawls
+		urls.GET("/:id/latest", crawlController.GetLatestCrawlResult)          // GET /api/urls/123/latest
+		urls.GET("/:id/bundle.zip", crawlController.GetCrawlBundle)            // GET /api/urls/123/bundle.zip
+		urls.GET("/:id/links", crawlController.GetLinks)                       // GET /api/urls/123/links
+		urls.GET("/:id/redirects", crawlController.GetRedirectSuggestions)     // GET /api/urls/123/redirects
+		urls.GET("/:id/internal-404s", crawlController.GetInternalBrokenLinks) // GET /api/urls/123/internal-404s
+		urls.GET("/:id/findings", crawlController.GetFindings)                 // GET /api/urls/123/findings
+		urls.GET("/:id/profiles", crawlController.GetProfiles)                 // GET /api/urls/123/profiles
+
+		urls.GET("/:id/annotations", annotationController.GetAnnotations)                    // GET /api/urls/123/annotations
+		urls.PUT("/:id/annotations", annotationController.SaveAnnotation)                    // PUT /api/urls/123/annotations
+		urls.DELETE("/:id/annotations/:annotationId", annotationController.DeleteAnnotation) // DELETE /api/urls/123/annotations/4
+	}
+
+	// Analysis of submitted HTML, nothing is fetched or stored (authentication required)
+	api.POST("/analyze", requireAuth, analyzeController.Analyze) // POST /api/analyze
+
+	// Synchronous pre-deploy checks for CI pipelines (authentication required)
+	api.POST("/ci/check", requireAuth, ciController.Check) // POST /api/ci/check
+
+	// Protected project routes (authentication required)
+	projects := api.Group("/projects")
+	projects.Use(requireAuth)
+	{
+		projects.POST("", projectController.CreateProject)                  // POST /api/projects
+		projects.GET("", projectController.GetProjects)                     // GET /api/projects
+		projects.GET("/:id", projectController.GetProject)                  // GET /api/projects/1
+		projects.PATCH("/:id", projectController.UpdateProject)             // PATCH /api/projects/1
+		projects.DELETE("/:id", projectController.DeleteProject)            // DELETE /api/projects/1
+		projects.GET("/:id/findings", projectController.GetProjectFindings) // GET /api/projects/1/findings
+		projects.GET("/:id/issues", projectController.GetTrackedIssues)     // GET /api/projects/1/issues
+
+		projects.GET("/:id/meta-descriptions", projectController.GetMetaDescriptionReport) // GET /api/projects/1/meta-descriptions
+		projects.GET("/:id/third-parties", projectController.GetThirdPartyTrend)           // GET /api/projects/1/third-parties
+		projects.GET("/:id/watch-report", projectController.GetWatchReport)                // GET /api/projects/1/watch-report
+		projects.GET("/:id/schedule", projectController.GetSchedule)                       // GET /api/projects/1/schedule
+		projects.PUT("/:id/schedule", projectController.SetSchedule)                       // PUT /api/projects/1/schedule
+		projects.DELETE("/:id/schedule", projectController.DeleteSchedule)                 // DELETE /api/projects/1/schedule
+	}
+
+	// REST hook subscriptions for Zapier and similar platforms (authentication required)
+	hooks := api.Group("/hooks")
+	hooks.Use(requireAuth)
+	{
+		hooks.POST("", hookController.Subscribe)               // POST /api/hooks
+		hooks.GET("", hookController.GetHooks)                 // GET /api/hooks
+		hooks.DELETE("/:id", hookController.Unsubscribe)       // DELETE /api/hooks/1
+		hooks.GET("/samples/:event", hookController.GetSample) // GET /api/hooks/samples/url.completed
+	}
+
+	// Saved views of the URL list, shared by all users (authentication required)
+	views := api.Group("/views")
+	views.Use(requireAuth)
+	{
+		views.POST("", viewController.CreateView)          // POST /api/views
+		views.GET("", viewController.GetViews)             // GET /api/views
+		views.GET("/:id", viewController.GetView)          // GET /api/views/1
+		views.PUT("/:id", viewController.UpdateView)       // PUT /api/views/1
+		views.DELETE("/:id", viewController.DeleteView)    // DELETE /api/views/1
+		views.GET("/:id/urls", viewController.GetViewURLs) // GET /api/views/1/urls
+	}
+
+	// Plans of hosted mode; Stripe authenticates its webhooks by signature instead of a session
+	if billingService != nil {
+		billingController := controllers.NewBillingController(billingService)
+		billing := api.Group("/billing")
+		{
+			billing.GET("/plan", requireAuth, billingController.GetPlan)     // GET /api/billing/plan
+			billing.POST("/stripe/webhook", billingController.StripeWebhook) // POST /api/billing/stripe/webhook
+		}
+	}
+
+	// Protected batch job routes (authentication required)
+	jobs := api.Group("/jobs")
+	jobs.Use(requireAuth)
+	{
+		jobs.GET("/:id", jobController.GetJob) // GET /api/jobs/123
+	}
+
+	// Protected admin routes (authentication required)
+	admin := api.Group("/admin")
+	admin.Use(requireAuth)
+	{
+		admin.GET("/settings", adminController.GetSettings)                  // GET /api/admin/settings
+		admin.PUT("/settings", adminController.UpdateSettings)               // PUT /api/admin/settings
+		admin.GET("/hosts", adminController.GetHosts)                        // GET /api/admin/hosts
+		admin.GET("/usage", adminController.GetUsage)                        // GET /api/admin/usage?months=12
+		admin.GET("/transport", adminController.GetTransport)                // GET /api/admin/transport
+		admin.GET("/crawler-identity", adminController.GetCrawlerIdentity)   // GET /api/admin/crawler-identity
+		admin.POST("/reprocess", adminController.Reprocess)                  // POST /api/admin/reprocess
+		admin.GET("/encryption", adminController.GetEncryption)              // GET /api/admin/encryption
+		admin.GET("/maintenance", adminController.GetMaintenance)            // GET /api/admin/maintenance
+		admin.PUT("/maintenance", adminController.SetMaintenance)            // PUT /api/admin/maintenance
+		admin.GET("/crawling", adminController.GetCrawling)                  // GET /api/admin/crawling
+		admin.PUT("/crawling", adminController.SetCrawling)                  // PUT /api/admin/crawling
+		admin.POST("/encryption/reencrypt", adminController.ReencryptFields) // POST /api/admin/encryption/reencrypt
+		admin.GET("/backups", adminController.GetBackups)                    // GET /api/admin/backups
+		admin.POST("/backups", adminController.CreateBackup)                 // POST /api/admin/backups
+
+		admin.GET("/agents", agentController.GetAgents)          // GET /api/admin/agents
+		admin.POST("/agents", agentController.RegisterAgent)     // POST /api/admin/agents
+		admin.DELETE("/agents/:id", agentController.DeleteAgent) // DELETE /api/admin/agents/1
 
-		urls.GET("/crawl", crawlController.GetCrawelResults)    // GET /api/crawls
-		urls.GET("/:id/crawl", crawlController.GetCrawlResults) // GET /api/urls/123/crawls
+		// Demo data must never be mixed into real results
+		if cfg.Environment != "production" {
+			admin.POST("/seed", adminController.SeedDemoData) // POST /api/admin/seed
+		}
 	}
 }
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	// ipv6FallbackDelay bounds an IPv6 connection attempt when the host also has IPv4 addresses,
+	// so a black-holed IPv6 route doesn't stall the crawl for the full dial timeout
+	ipv6FallbackDelay = 3 * time.Second
+
+	// ipv6RetryAfter is how long a host whose IPv6 failed is dialed over IPv4 first,
+	// and how long the crawler assumes it has no IPv6 route after the network said so
+	ipv6RetryAfter = 5 * time.Minute
+)
+
+// familyTracker remembers which IP versions each host resolved to and which ones connected
+// State is kept across crawls since pooled connections are shared by them
+type familyTracker struct {
+	mu    sync.Mutex
+	hosts map[string]*models.HostAddressFamilies
+
+	ipv6FailedAt map[string]time.Time // Hosts whose last IPv6 attempt failed
+	noLocalIPv6  time.Time            // When the crawler's own network last had no IPv6 route
+}
+
+func newFamilyTracker() *familyTracker {
+	return &familyTracker{
+		hosts:        make(map[string]*models.HostAddressFamilies),
+		ipv6FailedAt: make(map[string]time.Time),
+	}
+}
+
+// host returns the state of host, creating it; callers hold mu
+func (f *familyTracker) host(host string) *models.HostAddressFamilies {
+	state, ok := f.hosts[host]
+	if !ok {
+		state = &models.HostAddressFamilies{Host: host}
+		f.hosts[host] = state
+	}
+	return state
+}
+
+// resolved records the address families host resolved to
+func (f *familyTracker) resolved(host string, addrs []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state := f.host(host)
+	state.IPv4, state.IPv6 = false, false
+	for _, addr := range addrs {
+		if isIPv6(addr) {
+			state.IPv6 = true
+		} else {
+			state.IPv4 = true
+		}
+	}
+}
+
+// connected records that a connection to host over the family of addr succeeded or failed
+// Only the latest attempt per family counts, so hosts whose IPv6 was fixed stop being flagged
+func (f *familyTracker) connected(host, addr string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state := f.host(host)
+	if !isIPv6(addr) {
+		if err == nil {
+			state.ReachedIPv4, state.IPv4Error = true, ""
+		} else {
+			state.ReachedIPv4, state.IPv4Error = false, err.Error()
+		}
+		return
+	}
+
+	if err == nil {
+		state.ReachedIPv6, state.IPv6Error = true, ""
+		delete(f.ipv6FailedAt, host)
+		return
+	}
+
+	// Without an IPv6 route on our side, the host can't be blamed
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EADDRNOTAVAIL) {
+		f.noLocalIPv6 = time.Now()
+		state.IPv6Error = "crawler has no IPv6 connectivity"
+		return
+	}
+	state.ReachedIPv6, state.IPv6Error = false, err.Error()
+	f.ipv6FailedAt[host] = time.Now()
+}
+
+// order sorts the addresses of host into dialing order: IPv6 first like browsers do,
+// unless the host's IPv6 recently failed or the crawler has no IPv6 route
+func (f *familyTracker) order(host string, addrs []string) []string {
+	f.mu.Lock()
+	skipIPv6 := time.Since(f.noLocalIPv6) < ipv6RetryAfter
+	ipv6Last := time.Since(f.ipv6FailedAt[host]) < ipv6RetryAfter
+	f.mu.Unlock()
+
+	var v4, v6 []string
+	for _, addr := range addrs {
+		if isIPv6(addr) {
+			v6 = append(v6, addr)
+		} else {
+			v4 = append(v4, addr)
+		}
+	}
+
+	switch {
+	case skipIPv6 && len(v4) > 0:
+		return v4
+	case ipv6Last:
+		return append(v4, v6...)
+	}
+	return append(v6, v4...)
+}
+
+// report returns the state of the given hosts, sorted by host; hosts never dialed are left out
+// IPv6Broken is derived here so it reflects the latest attempts of both families
+func (f *familyTracker) report(hosts []string) []models.HostAddressFamilies {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var report []models.HostAddressFamilies
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		state, ok := f.hosts[host]
+		if !ok || seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		entry := *state
+		entry.IPv6Broken = entry.IPv6 && !entry.ReachedIPv6 && entry.ReachedIPv4 &&
+			entry.IPv6Error != "" && time.Since(f.noLocalIPv6) >= ipv6RetryAfter
+		report = append(report, entry)
+	}
+
+	sort.Slice(report, func(i, j int) bool { return report[i].Host < report[j].Host })
+	return report
+}
+
+// dialContext resolves the host through the DNS cache and dials its addresses in family order until one answers
+func (t *HTTPTransport) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		addrs, err := t.dns.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		t.families.resolved(host, addrs)
+
+		ordered := t.families.order(host, addrs)
+		hasIPv4 := false
+		for _, ip := range ordered {
+			hasIPv4 = hasIPv4 || !isIPv6(ip)
+		}
+
+		lastErr := fmt.Errorf("no addresses found for %s", host)
+		for _, ip := range ordered {
+			attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+			if isIPv6(ip) && hasIPv4 {
+				attemptCtx, cancel = context.WithTimeout(ctx, ipv6FallbackDelay)
+			}
+			conn, err := dialer.DialContext(attemptCtx, network, net.JoinHostPort(ip, port))
+			cancel()
+			if err == nil {
+				t.families.connected(host, ip, nil)
+				return conn, nil
+			}
+
+			// A cancelled request says nothing about the host
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			t.families.connected(host, ip, err)
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// AddressFamilies reports the IPv4/IPv6 reachability of the hosts of the given URLs
+func (t *HTTPTransport) AddressFamilies(urls []string) []models.HostAddressFamilies {
+	hosts := make([]string, 0, len(urls))
+	for _, rawURL := range urls {
+		if parsed, err := url.Parse(rawURL); err == nil && parsed.Hostname() != "" {
+			hosts = append(hosts, parsed.Hostname())
+		}
+	}
+	return t.families.report(hosts)
+}
+
+// ipv6Findings flags every host that advertises IPv6 but could only be reached over IPv4
+// The finding points at the first contacted URL of the host
+func ipv6Findings(families []models.HostAddressFamilies, contacted []string) []models.Finding {
+	var findings []models.Finding
+	for _, host := range families {
+		if !host.IPv6Broken {
+			continue
+		}
+
+		findingURL := "https://" + host.Host + "/"
+		for _, rawURL := range contacted {
+			if parsed, err := url.Parse(rawURL); err == nil && parsed.Hostname() == host.Host {
+				findingURL = rawURL
+				break
+			}
+		}
+
+		findings = append(findings, models.Finding{
+			Type:     models.FindingIPv6Broken,
+			Category: models.CategoryNetwork,
+			Severity: models.SeverityWarning,
+			URL:      findingURL,
+			Message:  fmt.Sprintf("%s has IPv6 addresses but only answers over IPv4, so IPv6-only networks can't reach it", host.Host),
+			Details: map[string]interface{}{
+				"host":       host.Host,
+				"ipv6_error": host.IPv6Error,
+			},
+		})
+	}
+	return findings
+}
+
+// isIPv6 reports whether addr is an IPv6 address
+func isIPv6(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// maxAlternates bounds the alternates recorded and checked per page, since hreflang lists can be long
+const maxAlternates = 50
+
+// detectAlternates inventories print stylesheets and the rel="alternate" links of the document
+// Screen stylesheets are left out, they are the page itself and counted by the page weight
+func detectAlternates(doc *html.Node, base string) []models.Alternate {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	var alternates []models.Alternate
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if len(alternates) >= maxAlternates {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "link" {
+			alternate := models.Alternate{
+				URL:      resolveHref(baseURL, attrValue(n, "href")),
+				Media:    attrValue(n, "media"),
+				Type:     strings.ToLower(attrValue(n, "type")),
+				Hreflang: attrValue(n, "hreflang"),
+			}
+			alternate.Kind = alternateKind(strings.Fields(strings.ToLower(attrValue(n, "rel"))), alternate)
+			if alternate.Kind != "" && isHTTPURL(alternate.URL) {
+				alternates = append(alternates, alternate)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+	return alternates
+}
+
+// alternateKind classifies a <link> by its rel values, or returns "" when it isn't an alternate
+func alternateKind(rel []string, alternate models.Alternate) string {
+	stylesheet, isAlternate := containsString(rel, "stylesheet"), containsString(rel, "alternate")
+	switch {
+	case stylesheet && isAlternate:
+		return models.AlternateStylesheet
+	case stylesheet:
+		if containsString(mediaTypes(alternate.Media), "print") {
+			return models.AlternatePrintStylesheet
+		}
+		return ""
+	case !isAlternate:
+		return ""
+	case alternate.Type == "application/pdf" || strings.HasSuffix(strings.ToLower(alternateURLPath(alternate.URL)), ".pdf"):
+		return models.AlternatePDF
+	case alternate.Type == "application/rss+xml" || alternate.Type == "application/atom+xml":
+		return models.AlternateFeed
+	case alternate.Hreflang != "":
+		return models.AlternateLanguage
+	case alternate.Media != "":
+		return models.AlternateMedia
+	}
+	return models.AlternateOther
+}
+
+// mediaTypes returns the media types of a media query list such as "screen, print and (color)"
+func mediaTypes(media string) []string {
+	var types []string
+	for _, query := range strings.Split(strings.ToLower(media), ",") {
+		if fields := strings.Fields(query); len(fields) > 0 {
+			mediaType := fields[0]
+			if (mediaType == "only" || mediaType == "not") && len(fields) > 1 {
+				mediaType = fields[1]
+			}
+			types = append(types, mediaType)
+		}
+	}
+	return types
+}
+
+// alternateURLPath returns the path of an alternate's URL, or "" when it doesn't parse
+func alternateURLPath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Path
+}
+
+// checkAlternates requests every alternate of the result and reports the ones that don't resolve
+func (c *CrawlerService) checkAlternates(ctx context.Context, result *models.CrawlResult, pageURL string, settings models.Settings, tracker *throttleTracker) {
+	for i := range result.Alternates {
+		alternate := &result.Alternates[i]
+		probe, err := c.probeLink(ctx, http.MethodHead, alternate.URL, settings, tracker)
+		if err != nil || (probe.finalStatus >= 400 && !isThrottleStatus(probe.finalStatus)) {
+			if getProbe, getErr := c.probeLink(ctx, http.MethodGet, alternate.URL, settings, tracker); getErr == nil {
+				probe, err = getProbe, nil
+			}
+		}
+		alternate.Checked = true
+		if err == nil {
+			alternate.StatusCode = probe.finalStatus
+			alternate.Resolves = probe.finalStatus < 400
+		}
+		// Throttled alternates are unknown, not broken, like throttled links
+		if !alternate.Resolves && !isThrottleStatus(alternate.StatusCode) {
+			result.Findings = append(result.Findings, alternateFinding(pageURL, *alternate))
+		}
+	}
+}
+
+// alternateFinding reports an alternate representation that can't be fetched
+func alternateFinding(pageURL string, alternate models.Alternate) models.Finding {
+	message := fmt.Sprintf("Alternate %s %s does not resolve", strings.ReplaceAll(alternate.Kind, "_", " "), alternate.URL)
+	if alternate.StatusCode > 0 {
+		message = fmt.Sprintf("Alternate %s %s returns HTTP %d", strings.ReplaceAll(alternate.Kind, "_", " "), alternate.URL, alternate.StatusCode)
+	}
+	return models.Finding{
+		Type:     models.FindingAlternateBroken,
+		Category: models.CategoryContent,
+		Severity: models.SeverityWarning,
+		URL:      pageURL,
+		Message:  message,
+		Details: map[string]interface{}{
+			"kind":        alternate.Kind,
+			"target":      alternate.URL,
+			"status_code": alternate.StatusCode,
+		},
+	}
+}
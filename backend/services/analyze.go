@@ -0,0 +1,53 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// AnalyzeOptions configures the analysis of submitted HTML
+type AnalyzeOptions struct {
+	BaseURL    string // URL the page will be served at; resolves relative links and tells internal links from external ones
+	CheckLinks bool   // Check the links found, the only step that uses the network; ignored in mock mode
+	SpellCheck bool
+	Project    *models.Project // Policy words and page budget to check against
+}
+
+// AnalyzeHTML runs the crawl analyzers on submitted HTML instead of a fetched page, so pages can be audited
+// before they are deployed; the result is returned, not stored
+func (c *CrawlerService) AnalyzeHTML(content []byte, options AnalyzeOptions) (*models.CrawlResult, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	result := &models.CrawlResult{
+		CrawledAt:       time.Now(),
+		AnalyzerVersion: AnalyzerVersion,
+	}
+	page := &fetchedPage{FinalURL: options.BaseURL, Size: int64(len(content))}
+	c.analyzeDocument(result, doc, options.BaseURL, page, models.CrawlConfig{SpellCheck: options.SpellCheck}, options.Project)
+
+	if options.CheckLinks && c.mock == nil {
+		tracker := newThrottleTracker()
+		c.checkDocumentLinks(context.Background(), result, c.settings.Get(), tracker)
+		tracker.apply(&result.Diagnostics)
+	}
+
+	return result, nil
+}
+
+// checkDocumentLinks checks the links of a single analyzed page and records soft 404 links as findings
+func (c *CrawlerService) checkDocumentLinks(ctx context.Context, result *models.CrawlResult, settings models.Settings, tracker *throttleTracker) {
+	c.checkLinkAccessibility(ctx, result, settings, tracker, nil)
+	for _, link := range result.Links {
+		if link.Soft404 {
+			result.Findings = append(result.Findings, soft404Finding(link.URL, link.Soft404Reasons))
+		}
+	}
+}
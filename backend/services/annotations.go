@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Triage statuses an annotation can have
+var triageStatuses = []string{models.TriageOpen, models.TriageAcknowledged, models.TriageFixed, models.TriageFalsePositive}
+
+// findingSubjectKeys are the details that tell findings of the same type on the same page apart,
+// e.g. the misspelled word or the resource without integrity; counts and lengths change between crawls and are left out
+var findingSubjectKeys = []string{"target", "resource", "term", "word", "directive", "metric", "host", "kind", "rule", "plugin", "region"}
+
+// maxAnnotationNote bounds the note of an annotation
+const maxAnnotationNote = 10000
+
+// AnnotationService keeps the notes and triage statuses of findings and links
+type AnnotationService struct {
+	db *gorm.DB
+}
+
+// NewAnnotationService creates an annotation service
+func NewAnnotationService(db *gorm.DB) *AnnotationService {
+	return &AnnotationService{db: db}
+}
+
+// ValidateAnnotation checks the status and note of an annotation
+func ValidateAnnotation(annotation models.Annotation) error {
+	if !containsString(triageStatuses, annotation.Status) {
+		return fmt.Errorf("status must be one of %s", strings.Join(triageStatuses, ", "))
+	}
+	if len(annotation.Note) > maxAnnotationNote {
+		return fmt.Errorf("note must be at most %d characters", maxAnnotationNote)
+	}
+	return nil
+}
+
+// FindingTarget identifies a finding across crawls by its type, page, and subject
+func FindingTarget(finding models.Finding) string {
+	target := finding.Type + " " + annotationURL(finding.URL)
+	for _, key := range findingSubjectKeys {
+		if value, ok := finding.Details[key].(string); ok && value != "" {
+			target += " " + key + "=" + value
+		}
+	}
+	return target
+}
+
+// LinkTarget identifies a link across crawls by its normalized URL
+func LinkTarget(linkURL string) string {
+	return annotationURL(linkURL)
+}
+
+// annotationURL normalizes the parts of a URL that don't change what it points to: the case of
+// the scheme and host, punycode, and the fragment
+func annotationURL(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(asciiHost(parsed.Host))
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// targetHash is the indexed key of an annotation
+func targetHash(kind, target string) string {
+	sum := sha256.Sum256([]byte(kind + "\n" + target))
+	return hex.EncodeToString(sum[:])
+}
+
+// List returns the annotations of a URL, most recently changed first
+func (s *AnnotationService) List(urlID uint) ([]models.Annotation, error) {
+	var annotations []models.Annotation
+	if err := s.db.Where("url_id = ?", urlID).Order("updated_at DESC, id DESC").Find(&annotations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list annotations of URL %d: %v", urlID, err)
+	}
+	return annotations, nil
+}
+
+// Save creates the annotation of its kind and target, or replaces the status and note of the existing one
+// The annotation must have been validated
+func (s *AnnotationService) Save(annotation *models.Annotation) error {
+	annotation.TargetHash = targetHash(annotation.Kind, annotation.Target)
+
+	// One statement, so concurrent saves of the same target update the row instead of failing on its unique index
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url_id"}, {Name: "target_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "note", "updated_by", "updated_at"}),
+	}).Create(annotation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save annotation: %v", err)
+	}
+
+	// An updated row keeps its ID and creation time, which the insert doesn't return
+	err = s.db.Where("url_id = ? AND target_hash = ?", annotation.URLID, annotation.TargetHash).First(annotation).Error
+	if err != nil {
+		return fmt.Errorf("failed to load saved annotation: %v", err)
+	}
+	return nil
+}
+
+// Delete removes an annotation of a URL
+func (s *AnnotationService) Delete(urlID, id uint) error {
+	result := s.db.Where("url_id = ?", urlID).Delete(&models.Annotation{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete annotation %d: %v", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// AnnotateFindings attaches the annotations of the URL to its findings
+func (s *AnnotationService) AnnotateFindings(urlID uint, findings []models.Finding) error {
+	byHash, err := s.byHash(urlID, models.AnnotationFinding)
+	if err != nil || len(byHash) == 0 {
+		return err
+	}
+	for i := range findings {
+		if annotation, ok := byHash[targetHash(models.AnnotationFinding, FindingTarget(findings[i]))]; ok {
+			findings[i].Annotation = annotation
+		}
+	}
+	return nil
+}
+
+// AnnotateLinks attaches the annotations of the URL to its links
+func (s *AnnotationService) AnnotateLinks(urlID uint, links []models.Link) error {
+	byHash, err := s.byHash(urlID, models.AnnotationLink)
+	if err != nil || len(byHash) == 0 {
+		return err
+	}
+	for i := range links {
+		if annotation, ok := byHash[targetHash(models.AnnotationLink, LinkTarget(links[i].URL))]; ok {
+			links[i].Annotation = annotation
+		}
+	}
+	return nil
+}
+
+// byHash loads the annotations of one kind of a URL keyed by their target hash
+func (s *AnnotationService) byHash(urlID uint, kind string) (map[string]*models.Annotation, error) {
+	var annotations []models.Annotation
+	if err := s.db.Where("url_id = ? AND kind = ?", urlID, kind).Find(&annotations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load annotations of URL %d: %v", urlID, err)
+	}
+	byHash := make(map[string]*models.Annotation, len(annotations))
+	for i := range annotations {
+		byHash[annotations[i].TargetHash] = &annotations[i]
+	}
+	return byHash, nil
+}
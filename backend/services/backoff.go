@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxThrottleRetries = 2                // Retries of a request answered with 429/503
+	maxRetryAfter      = 30 * time.Second // Upper bound for a single Retry-After wait
+)
+
+// isThrottleStatus reports whether the status code asks the client to slow down
+func isThrottleStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// retryAfterDelay returns how long to wait before retrying a throttled request
+// It honors Retry-After (seconds or HTTP date) and falls back to exponential backoff
+func retryAfterDelay(resp *http.Response, attempt int) time.Duration {
+	delay := time.Duration(1<<attempt) * time.Second
+
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(value); err == nil {
+			delay = time.Until(date)
+		}
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay
+}
+
+// throttleTracker remembers which hosts throttled us during one crawl, and what the crawl downloaded
+// Requests to a host that asked us to back off wait until its Retry-After deadline has passed
+type throttleTracker struct {
+	mu      sync.Mutex
+	until   map[string]time.Time
+	hosts   map[string]bool
+	retries int
+
+	requests int
+	bytes    int64
+}
+
+// newThrottleTracker creates a tracker for a single crawl
+func newThrottleTracker() *throttleTracker {
+	return &throttleTracker{
+		until: make(map[string]time.Time),
+		hosts: make(map[string]bool),
+	}
+}
+
+// wait blocks until the host's backoff deadline, or until ctx is done
+func (t *throttleTracker) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	delay := time.Until(t.until[host])
+	t.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// recordThrottle marks the host as throttling
+func (t *throttleTracker) recordThrottle(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hosts[host] = true
+}
+
+// backoff delays all further requests to the host and counts the upcoming retry
+func (t *throttleTracker) backoff(host string, delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if deadline := time.Now().Add(delay); deadline.After(t.until[host]) {
+		t.until[host] = deadline
+	}
+	t.retries++
+}
+
+// apply copies the throttling information into the crawl diagnostics
+func (t *throttleTracker) apply(diagnostics *models.CrawlDiagnostics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	diagnostics.ThrottledHosts = nil
+	for host := range t.hosts {
+		diagnostics.ThrottledHosts = append(diagnostics.ThrottledHosts, host)
+	}
+	sort.Strings(diagnostics.ThrottledHosts)
+	diagnostics.Retries = t.retries
+}
+
+// usage returns the requests issued and the response body bytes read during the crawl
+func (t *throttleTracker) usage() (requests int, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requests, t.bytes
+}
+
+// countRequest counts a request sent for the crawl
+func (t *throttleTracker) countRequest() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+}
+
+// countedBody counts the bytes read from a response body into its crawl's tracker
+type countedBody struct {
+	io.ReadCloser
+	tracker *throttleTracker
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.tracker.mu.Lock()
+		b.tracker.bytes += int64(n)
+		b.tracker.mu.Unlock()
+	}
+	return n, err
+}
+
+// doWithBackoff sends a request, waiting and retrying when the host answers 429/503
+// The last throttled response is returned when the retries are exhausted
+func (c *CrawlerService) doWithBackoff(client *http.Client, req *http.Request, tracker *throttleTracker) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := tracker.wait(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.do(client, req)
+		tracker.countRequest()
+		if resp != nil {
+			resp.Body = &countedBody{ReadCloser: resp.Body, tracker: tracker}
+		}
+		if err != nil || !isThrottleStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		tracker.recordThrottle(req.URL.Host)
+		if attempt >= maxThrottleRetries {
+			return resp, nil
+		}
+
+		delay := retryAfterDelay(resp, attempt)
+		resp.Body.Close()
+		tracker.backoff(req.URL.Host, delay)
+	}
+}
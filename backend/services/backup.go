@@ -0,0 +1,435 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	backupFormat  = "sykell-url-analyzer-backup"
+	backupVersion = 1
+	backupPrefix  = "backup-"
+	backupSuffix  = ".jsonl.gz"
+
+	// Rows are restored in multi-row INSERTs of at most this many rows or bytes, below MySQL's max_allowed_packet
+	restoreBatchRows  = 100
+	restoreBatchBytes = 4 << 20
+)
+
+// ErrBackupInProgress is returned when a backup is requested while another one is running
+var ErrBackupInProgress = errors.New("a backup is already in progress")
+
+// BackupConfig configures where backups are written and how many are kept
+type BackupConfig struct {
+	Dir       string        // Local directory of the backup files
+	Retention int           // Local backups kept, older ones are deleted; 0 keeps all
+	Interval  time.Duration // Time between scheduled backups, 0 disables the schedule
+	S3        S3Config      // Bucket every backup is uploaded to, when configured
+}
+
+// BackupInfo describes a backup file
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Tables    int       `json:"tables,omitempty"`
+	Rows      int64     `json:"rows,omitempty"`
+	Uploaded  bool      `json:"uploaded,omitempty"` // Copied to object storage
+}
+
+// backupHeader is the first line of a backup
+type backupHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// backupTable starts the rows of a table; every following JSON array is one row in the order of Columns
+type backupTable struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Binary  []int    `json:"binary,omitempty"` // Indexes of columns whose values are base64 encoded
+}
+
+// BackupService writes logical backups of every table as gzipped JSON lines and restores them
+// Dumping through the application needs no mysqldump binary and works the same against any MySQL host
+type BackupService struct {
+	db     *gorm.DB
+	config BackupConfig
+	s3     *s3Client
+	mu     sync.Mutex // Serializes backups
+}
+
+// NewBackupService creates a backup service
+func NewBackupService(db *gorm.DB, config BackupConfig) *BackupService {
+	s := &BackupService{db: db, config: config}
+	if config.S3.Bucket != "" {
+		s.s3 = newS3Client(config.S3)
+	}
+	return s
+}
+
+// StartSchedule runs a backup every configured interval in the background
+func (s *BackupService) StartSchedule() {
+	if s.config.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if info, err := s.Backup(); err != nil {
+				log.Printf("Scheduled backup failed: %v", err)
+			} else {
+				log.Printf("Scheduled backup %s written (%d rows)", info.Name, info.Rows)
+			}
+		}
+	}()
+}
+
+// Backup dumps every table into a new backup file, uploads it when object storage is configured,
+// and applies the retention
+func (s *BackupService) Backup() (*BackupInfo, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	createdAt := time.Now().UTC()
+	info := &BackupInfo{Name: backupPrefix + createdAt.Format("20060102T150405Z") + backupSuffix, CreatedAt: createdAt}
+	path := filepath.Join(s.config.Dir, info.Name)
+
+	// Write to a temporary name, so a failed backup never looks like a complete one
+	file, err := os.OpenFile(path+".partial", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %v", err)
+	}
+	err = s.dump(file, info)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".partial")
+		return nil, err
+	}
+	if err := os.Rename(path+".partial", path); err != nil {
+		return nil, fmt.Errorf("failed to finish backup file: %v", err)
+	}
+	if stat, err := os.Stat(path); err == nil {
+		info.Size = stat.Size()
+	}
+
+	if s.s3 != nil {
+		if err := s.s3.upload(path, info.Name); err != nil {
+			return info, fmt.Errorf("backup %s was written but not uploaded: %v", info.Name, err)
+		}
+		info.Uploaded = true
+	}
+
+	if err := s.prune(); err != nil {
+		log.Printf("Failed to apply backup retention: %v", err)
+	}
+	return info, nil
+}
+
+// dump writes the header and the rows of every table; reading in one transaction gives a consistent snapshot
+func (s *BackupService) dump(w io.Writer, info *BackupInfo) error {
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	if err := encoder.Encode(backupHeader{Format: backupFormat, Version: backupVersion, CreatedAt: info.CreatedAt}); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tables, err := tx.Migrator().GetTables()
+		if err != nil {
+			return fmt.Errorf("failed to list tables: %v", err)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			rows, err := s.dumpTable(tx, encoder, table)
+			if err != nil {
+				return fmt.Errorf("failed to back up table %s: %v", table, err)
+			}
+			info.Tables++
+			info.Rows += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (s *BackupService) dumpTable(tx *gorm.DB, encoder *json.Encoder, table string) (int64, error) {
+	rows, err := tx.Table(table).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	header := backupTable{Table: table}
+	for i, column := range columnTypes {
+		header.Columns = append(header.Columns, column.Name())
+		if isBinaryColumn(column) {
+			header.Binary = append(header.Binary, i)
+		}
+	}
+	if err := encoder.Encode(header); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columnTypes))
+	pointers := make([]interface{}, len(columnTypes))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	binary := make(map[int]bool, len(header.Binary))
+	for _, i := range header.Binary {
+		binary[i] = true
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		row := make([]interface{}, len(values))
+		for i, value := range values {
+			row[i] = backupValue(value, binary[i])
+		}
+		if err := encoder.Encode(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+func isBinaryColumn(column *sql.ColumnType) bool {
+	name := column.DatabaseTypeName()
+	return strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY")
+}
+
+// backupValue converts a scanned value to JSON that MySQL accepts back as the column's value
+func backupValue(value interface{}, binary bool) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		if binary {
+			return base64.StdEncoding.EncodeToString(v)
+		}
+		return string(v)
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04:05.999999")
+	default:
+		return v
+	}
+}
+
+// List returns the local backups, newest first
+func (s *BackupService) List() ([]BackupInfo, error) {
+	entries, err := os.ReadDir(s.config.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %v", err)
+	}
+
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		stat, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		createdAt, _ := time.Parse("20060102T150405Z", strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+		backups = append(backups, BackupInfo{Name: name, Size: stat.Size(), CreatedAt: createdAt})
+	}
+	// The timestamped names sort chronologically
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// prune deletes the local backups beyond the retention
+func (s *BackupService) prune() error {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+	for i := s.config.Retention; i < len(backups); i++ {
+		if err := os.Remove(filepath.Join(s.config.Dir, backups[i].Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore replaces the contents of every table in the backup with its rows
+// Tables missing from the backup are left alone; the schema is expected to be migrated already
+func (s *BackupService) Restore(path string) (*BackupInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("backup is not gzipped: %v", err)
+	}
+	reader := bufio.NewReaderSize(gz, 1<<20)
+
+	var header backupHeader
+	line, err := reader.ReadBytes('\n')
+	if err != nil || json.Unmarshal(line, &header) != nil || header.Format != backupFormat {
+		return nil, fmt.Errorf("%s is not a backup of this application", path)
+	}
+	if header.Version > backupVersion {
+		return nil, fmt.Errorf("backup version %d is newer than the supported version %d", header.Version, backupVersion)
+	}
+	info := &BackupInfo{Name: filepath.Base(path), CreatedAt: header.CreatedAt}
+
+	// One connection, so the disabled foreign key checks apply to every statement
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+			return err
+		}
+		defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+
+		restorer := &tableRestorer{tx: tx}
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				if err := restorer.line(line, info); err != nil {
+					return err
+				}
+			}
+			if err == io.EOF {
+				return restorer.flush()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read backup: %v", err)
+			}
+		}
+	})
+	return info, err
+}
+
+// tableRestorer inserts the rows of the table whose header was read last
+type tableRestorer struct {
+	tx        *gorm.DB
+	table     *backupTable
+	binary    map[int]bool
+	rows      [][]interface{}
+	batchSize int
+}
+
+func (r *tableRestorer) line(line []byte, info *BackupInfo) error {
+	if line[0] == '{' {
+		if err := r.flush(); err != nil {
+			return err
+		}
+		var table backupTable
+		if err := json.Unmarshal(line, &table); err != nil {
+			return fmt.Errorf("malformed table header: %v", err)
+		}
+		if err := r.tx.Exec("DELETE FROM " + quoteIdentifier(table.Table)).Error; err != nil {
+			return fmt.Errorf("failed to clear table %s: %v", table.Table, err)
+		}
+		r.table = &table
+		r.binary = make(map[int]bool, len(table.Binary))
+		for _, i := range table.Binary {
+			r.binary[i] = true
+		}
+		info.Tables++
+		return nil
+	}
+
+	if r.table == nil {
+		return errors.New("backup has rows before the first table header")
+	}
+	// Numbers stay strings, so large IDs aren't rounded through float64
+	var row []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&row); err != nil || len(row) != len(r.table.Columns) {
+		return fmt.Errorf("malformed row in table %s", r.table.Table)
+	}
+	for i, value := range row {
+		if encoded, ok := value.(string); ok && r.binary[i] {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("malformed binary value in table %s", r.table.Table)
+			}
+			row[i] = decoded
+		}
+	}
+	r.rows = append(r.rows, row)
+	r.batchSize += len(line)
+	info.Rows++
+
+	if len(r.rows) >= restoreBatchRows || r.batchSize >= restoreBatchBytes {
+		return r.flush()
+	}
+	return nil
+}
+
+// flush inserts the buffered rows with one statement
+func (r *tableRestorer) flush() error {
+	if len(r.rows) == 0 {
+		return nil
+	}
+
+	columns := make([]string, len(r.table.Columns))
+	for i, column := range r.table.Columns {
+		columns[i] = quoteIdentifier(column)
+	}
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	placeholders := make([]string, len(r.rows))
+	args := make([]interface{}, 0, len(r.rows)*len(columns))
+	for i, row := range r.rows {
+		placeholders[i] = placeholder
+		args = append(args, row...)
+	}
+
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdentifier(r.table.Table), strings.Join(columns, ","), strings.Join(placeholders, ","))
+	if err := r.tx.Exec(statement, args...).Error; err != nil {
+		return fmt.Errorf("failed to restore rows of table %s: %v", r.table.Table, err)
+	}
+	r.rows, r.batchSize = r.rows[:0], 0
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
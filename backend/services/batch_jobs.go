@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// BatchJobService creates batch jobs and records the outcome of each crawl they contain
+type BatchJobService struct {
+	db *gorm.DB
+}
+
+// NewBatchJobService creates a new batch job service
+func NewBatchJobService(db *gorm.DB) *BatchJobService {
+	return &BatchJobService{db: db}
+}
+
+// CreateJob creates a running job expecting total crawls
+func (s *BatchJobService) CreateJob(jobType string, total int) (*models.BatchJob, error) {
+	job := &models.BatchJob{
+		Type:   jobType,
+		Status: "running",
+		Total:  total,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create batch job: %v", err)
+	}
+	return job, nil
+}
+
+// RecordResult counts one finished crawl and marks the job completed once all crawls are done
+func (s *BatchJobService) RecordResult(jobID uint, crawlErr error) error {
+	column := "completed"
+	if crawlErr != nil {
+		column = "failed"
+	}
+
+	// Increment in SQL so concurrent crawls don't overwrite each other's counts
+	if err := s.db.Model(&models.BatchJob{}).Where("id = ?", jobID).
+		Update(column, gorm.Expr(column+" + ?", 1)).Error; err != nil {
+		return fmt.Errorf("failed to update batch job %d: %v", jobID, err)
+	}
+
+	return s.db.Model(&models.BatchJob{}).
+		Where("id = ? AND completed + failed >= total", jobID).
+		Update("status", "completed").Error
+}
+
+// GetJob retrieves a batch job by ID
+func (s *BatchJobService) GetJob(jobID uint) (*models.BatchJob, error) {
+	var job models.BatchJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
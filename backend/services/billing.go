@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stripeSignatureTolerance bounds the age of a webhook signature, so captured requests can't be replayed later
+const stripeSignatureTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for webhooks whose Stripe-Signature doesn't match the endpoint secret
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// entitledStatuses are the Stripe subscription statuses that grant the subscribed plan
+// past_due keeps it while Stripe retries the payment; canceled and unpaid fall back to the default plan
+var entitledStatuses = []string{"active", "trialing", "past_due"}
+
+// PlanLimits is what a plan allows a user; 0 means unlimited
+type PlanLimits struct {
+	URLs           int `json:"urls"`             // URLs added by the user
+	CrawlsPerMonth int `json:"crawls_per_month"` // Crawls of those URLs per calendar month, in UTC
+	Workers        int `json:"workers"`          // Crawls of those URLs running at once; further crawls wait
+}
+
+// BillingConfig configures hosted mode, see config.Config.BillingEnabled
+type BillingConfig struct {
+	Plans         []string // name:urls:crawls_per_month:workers
+	DefaultPlan   string   // Plan of users without an entitled subscription
+	WebhookSecret string   // Signing secret of the Stripe webhook endpoint
+	PricePlans    []string // price:plan, the plan each Stripe price subscribes to
+}
+
+// Entitlement is the plan a user currently has, with what they used of it
+type Entitlement struct {
+	Username         string     `json:"username"`
+	Plan             string     `json:"plan"`
+	Status           string     `json:"status"` // Stripe status of the subscription, empty without one
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	Limits           PlanLimits `json:"limits"`
+	Usage            PlanUsage  `json:"usage"`
+}
+
+// PlanUsage is what a user used of their plan
+type PlanUsage struct {
+	URLs            int64 `json:"urls"`
+	CrawlsThisMonth int64 `json:"crawls_this_month"`
+	RunningCrawls   int   `json:"running_crawls"`
+}
+
+// QuotaError is returned when a request needs more than the user's plan allows
+type QuotaError struct {
+	Plan  string
+	Limit int
+	Used  int64
+	What  string // urls or crawls_per_month
+}
+
+func (e *QuotaError) Error() string {
+	if e.What == "urls" {
+		return fmt.Sprintf("Plan %s allows %d URLs", e.Plan, e.Limit)
+	}
+	return fmt.Sprintf("Plan %s allows %d crawls per month", e.Plan, e.Limit)
+}
+
+// BillingService maps the Stripe subscriptions of users to plan limits and enforces them
+// URLs are accounted to the user who added them, see models.URL.CreatedBy; URLs without one aren't limited
+type BillingService struct {
+	db            *gorm.DB
+	plans         map[string]PlanLimits
+	defaultPlan   string
+	webhookSecret string
+	pricePlans    map[string]string
+	workers       workerLimiter
+}
+
+// NewBillingService creates the billing service of hosted mode, checking the plans and price mapping
+func NewBillingService(db *gorm.DB, config BillingConfig) (*BillingService, error) {
+	s := &BillingService{
+		db:            db,
+		plans:         make(map[string]PlanLimits),
+		defaultPlan:   config.DefaultPlan,
+		webhookSecret: config.WebhookSecret,
+		pricePlans:    make(map[string]string),
+	}
+	for _, entry := range config.Plans {
+		fields := strings.Split(entry, ":")
+		if len(fields) != 4 || fields[0] == "" {
+			return nil, fmt.Errorf("plan %q must be name:urls:crawls_per_month:workers", entry)
+		}
+		var limits [3]int
+		for i, field := range fields[1:] {
+			value, err := strconv.Atoi(field)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("plan %q must have non-negative limits", entry)
+			}
+			limits[i] = value
+		}
+		s.plans[fields[0]] = PlanLimits{URLs: limits[0], CrawlsPerMonth: limits[1], Workers: limits[2]}
+	}
+	if _, ok := s.plans[s.defaultPlan]; !ok {
+		return nil, fmt.Errorf("default plan %q is not defined", s.defaultPlan)
+	}
+	for _, entry := range config.PricePlans {
+		price, plan, ok := strings.Cut(entry, ":")
+		if !ok || price == "" {
+			return nil, fmt.Errorf("price plan %q must be price:plan", entry)
+		}
+		if _, defined := s.plans[plan]; !defined {
+			return nil, fmt.Errorf("price %s maps to undefined plan %q", price, plan)
+		}
+		s.pricePlans[price] = plan
+	}
+	if s.webhookSecret == "" {
+		log.Println("STRIPE_WEBHOOK_SECRET is not set, Stripe webhooks are refused")
+	}
+	return s, nil
+}
+
+// Entitlement returns the plan of a user with their usage
+func (s *BillingService) Entitlement(username string) (Entitlement, error) {
+	entitlement := Entitlement{Username: username, Plan: s.defaultPlan}
+
+	var subscription models.Subscription
+	err := s.db.Where("username = ?", username).First(&subscription).Error
+	switch {
+	case err == nil:
+		entitlement.Status = subscription.Status
+		entitlement.CurrentPeriodEnd = subscription.CurrentPeriodEnd
+		if _, defined := s.plans[subscription.Plan]; defined && containsString(entitledStatuses, subscription.Status) {
+			entitlement.Plan = subscription.Plan
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return entitlement, fmt.Errorf("failed to load the subscription of %s: %v", username, err)
+	}
+	entitlement.Limits = s.plans[entitlement.Plan]
+
+	if err := s.db.Model(&models.URL{}).Where("created_by = ?", username).Count(&entitlement.Usage.URLs).Error; err != nil {
+		return entitlement, fmt.Errorf("failed to count the URLs of %s: %v", username, err)
+	}
+	usage, err := s.monthlyUsage(username)
+	if err != nil {
+		return entitlement, err
+	}
+	entitlement.Usage.CrawlsThisMonth = usage.Crawls
+	entitlement.Usage.RunningCrawls = s.workers.running(username)
+	return entitlement, nil
+}
+
+// monthlyUsage returns the usage of the user in the current month, creating its row when the month starts
+// A new row starts at the crawls stored this month, so crawls made before usage was counted aren't free
+func (s *BillingService) monthlyUsage(username string) (models.MonthlyUsage, error) {
+	now := time.Now().UTC()
+	usage := models.MonthlyUsage{Username: username, Month: now.Format("2006-01")}
+	err := s.db.Where("username = ? AND month = ?", usage.Username, usage.Month).First(&usage).Error
+	if err == nil {
+		return usage, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return usage, fmt.Errorf("failed to load the usage of %s: %v", username, err)
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	err = s.db.Table("crawl_results").
+		Joins("JOIN urls ON urls.id = crawl_results.url_id").
+		Where("urls.created_by = ? AND crawl_results.crawled_at >= ?", username, month).
+		Count(&usage.Crawls).Error
+	if err != nil {
+		return usage, fmt.Errorf("failed to count the crawls of %s: %v", username, err)
+	}
+	// A concurrent crawl may have created the row in the meantime, then it is kept
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&usage).Error; err != nil {
+		return usage, fmt.Errorf("failed to create the usage of %s: %v", username, err)
+	}
+	if err := s.db.Where("username = ? AND month = ?", usage.Username, usage.Month).First(&usage).Error; err != nil {
+		return usage, fmt.Errorf("failed to load the usage of %s: %v", username, err)
+	}
+	return usage, nil
+}
+
+// CheckQuota returns a *QuotaError when adding urls URLs and starting crawls crawls would exceed the user's plan
+func (s *BillingService) CheckQuota(username string, urls, crawls int) error {
+	if username == "" {
+		return nil
+	}
+	entitlement, err := s.Entitlement(username)
+	if err != nil {
+		return err
+	}
+	limits, usage := entitlement.Limits, entitlement.Usage
+	if urls > 0 && limits.URLs > 0 && usage.URLs+int64(urls) > int64(limits.URLs) {
+		return &QuotaError{Plan: entitlement.Plan, Limit: limits.URLs, Used: usage.URLs, What: "urls"}
+	}
+	if crawls > 0 && limits.CrawlsPerMonth > 0 && usage.CrawlsThisMonth+int64(crawls) > int64(limits.CrawlsPerMonth) {
+		return &QuotaError{Plan: entitlement.Plan, Limit: limits.CrawlsPerMonth, Used: usage.CrawlsThisMonth, What: "crawls_per_month"}
+	}
+	return nil
+}
+
+// AllowCrawl checks the monthly crawls of the user when a crawl is admitted, which also covers crawls
+// that weren't requested through the API, e.g. scheduled ones; ChargeCrawl counts the crawl once it runs
+func (s *BillingService) AllowCrawl(username string) error {
+	return s.CheckQuota(username, 0, 1)
+}
+
+// ChargeCrawl counts a crawl of the user's URLs against this month's quota once it has a worker,
+// returning a *QuotaError when the quota was used up in the meantime
+// The check and the count are one conditional update, so concurrent crawls can't exceed the quota together
+func (s *BillingService) ChargeCrawl(username string) error {
+	if username == "" {
+		return nil
+	}
+	entitlement, err := s.Entitlement(username)
+	if err != nil {
+		return err
+	}
+	limit := entitlement.Limits.CrawlsPerMonth
+	query := s.db.Model(&models.MonthlyUsage{}).
+		Where("username = ? AND month = ?", username, time.Now().UTC().Format("2006-01"))
+	if limit > 0 {
+		query = query.Where("crawls < ?", limit)
+	}
+	result := query.UpdateColumn("crawls", gorm.Expr("crawls + 1"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to count the crawl of %s: %v", username, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return &QuotaError{Plan: entitlement.Plan, Limit: limit, Used: entitlement.Usage.CrawlsThisMonth, What: "crawls_per_month"}
+	}
+	return nil
+}
+
+// AcquireWorker waits until fewer crawls of the user run than their plan allows; release must be
+// called when the crawl ends
+func (s *BillingService) AcquireWorker(ctx context.Context, username string) (func(), error) {
+	if username == "" {
+		return func() {}, nil
+	}
+	entitlement, err := s.Entitlement(username)
+	if err != nil {
+		return nil, err
+	}
+	return s.workers.acquire(ctx, username, entitlement.Limits.Workers)
+}
+
+// UseBilling enforces the plan limits of hosted mode on every crawl; it must be called before crawls start
+func (c *CrawlerService) UseBilling(billing *BillingService) {
+	c.billing = billing
+}
+
+// stripeEvent is the envelope of a Stripe webhook event
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// stripeSubscription holds the fields of a Stripe subscription object the plan depends on
+// Newer API versions moved current_period_end from the subscription to its items, so both are read
+type stripeSubscription struct {
+	ID               string            `json:"id"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	CurrentPeriodEnd int64             `json:"current_period_end"`
+	Metadata         map[string]string `json:"metadata"`
+	Items            struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// HandleStripeWebhook verifies and applies a Stripe webhook event
+// Subscription events update the plan of the user named by the subscription's "username" metadata, or of the
+// user the subscription or customer already belongs to; other events are acknowledged and ignored
+func (s *BillingService) HandleStripeWebhook(payload []byte, signature string) error {
+	if err := s.verifySignature(payload, signature, time.Now()); err != nil {
+		return err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("invalid event: %v", err)
+	}
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+	default:
+		return nil
+	}
+	var object stripeSubscription
+	if err := json.Unmarshal(event.Data.Object, &object); err != nil {
+		return fmt.Errorf("invalid subscription in event %s: %v", event.ID, err)
+	}
+	if event.Type == "customer.subscription.deleted" {
+		object.Status = "canceled"
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var subscription models.Subscription
+		query := tx.Where("stripe_subscription_id = ?", object.ID).Or("stripe_customer_id = ? AND stripe_customer_id <> ''", object.Customer)
+		if username := object.Metadata["username"]; username != "" {
+			var users int64
+			if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&users).Error; err != nil {
+				return fmt.Errorf("failed to look up user: %v", err)
+			}
+			if users == 0 {
+				log.Printf("Ignoring Stripe event %s: user %q of subscription %s doesn't exist", event.ID, username, object.ID)
+				return nil
+			}
+			query = tx.Where("username = ?", username)
+			subscription.Username = username
+		}
+		err := query.First(&subscription).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) && subscription.Username == "" {
+			log.Printf("Ignoring Stripe event %s: subscription %s belongs to no known user", event.ID, object.ID)
+			return nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load subscription: %v", err)
+		}
+
+		eventAt := time.Unix(event.Created, 0).UTC()
+		if eventAt.Before(subscription.EventAt) {
+			return nil // A later event was already applied
+		}
+		subscription.EventAt = eventAt
+		subscription.Status = object.Status
+		subscription.StripeCustomerID = object.Customer
+		subscription.StripeSubscriptionID = object.ID
+		subscription.Plan = ""
+		periodEnd := object.CurrentPeriodEnd
+		for _, item := range object.Items.Data {
+			if plan, ok := s.pricePlans[item.Price.ID]; ok && subscription.Plan == "" {
+				subscription.Plan = plan
+			}
+			if item.CurrentPeriodEnd > periodEnd {
+				periodEnd = item.CurrentPeriodEnd
+			}
+		}
+		if subscription.Plan == "" {
+			log.Printf("Stripe subscription %s of %s subscribes to no price of STRIPE_PRICE_PLANS", object.ID, subscription.Username)
+		}
+		subscription.CurrentPeriodEnd = nil
+		if periodEnd > 0 {
+			end := time.Unix(periodEnd, 0).UTC()
+			subscription.CurrentPeriodEnd = &end
+		}
+		if err := tx.Save(&subscription).Error; err != nil {
+			return fmt.Errorf("failed to save subscription: %v", err)
+		}
+		log.Printf("Stripe event %s: %s is on plan %q (%s)", event.ID, subscription.Username, subscription.Plan, subscription.Status)
+		return nil
+	})
+}
+
+// verifySignature checks a Stripe-Signature header: t=<unix time>,v1=<hex HMAC-SHA256 of "t.payload">,...
+func (s *BillingService) verifySignature(payload []byte, header string, now time.Time) error {
+	if s.webhookSecret == "" {
+		return ErrInvalidSignature
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// workerLimiter bounds the crawls running at once per user
+type workerLimiter struct {
+	mu      sync.Mutex
+	active  map[string]int
+	changed chan struct{} // Closed and replaced whenever a crawl ends
+}
+
+// acquire waits until fewer than limit crawls of the user run, 0 being unlimited, or until ctx is done
+func (l *workerLimiter) acquire(ctx context.Context, username string, limit int) (func(), error) {
+	for {
+		l.mu.Lock()
+		if l.active == nil {
+			l.active = make(map[string]int)
+			l.changed = make(chan struct{})
+		}
+		if limit == 0 || l.active[username] < limit {
+			l.active[username]++
+			l.mu.Unlock()
+			return func() { l.release(username) }, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release ends a crawl of the user and wakes the crawls waiting for one to end
+func (l *workerLimiter) release(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[username]--; l.active[username] <= 0 {
+		delete(l.active, username)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// running returns the crawls of the user running now
+func (l *workerLimiter) running(username string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[username]
+}
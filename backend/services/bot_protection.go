@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// botChallengeBodyLimit is how much of a response body is inspected for challenge markers
+const botChallengeBodyLimit = 64 * 1024
+
+// alternateUserAgents are tried in order when CrawlConfig.RotateUserAgents is set and the page is blocked
+var alternateUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+}
+
+// BotProtectionError reports that the page answered with a bot challenge instead of its content
+type BotProtectionError struct {
+	Vendor     string
+	StatusCode int
+}
+
+func (e *BotProtectionError) Error() string {
+	return fmt.Sprintf("blocked by bot protection (%s, HTTP %d)", e.Vendor, e.StatusCode)
+}
+
+// botChallengeMarker is a body fragment identifying a vendor's challenge page
+// Widget markers also appear on ordinary pages with a form, so they only count on challenge statuses
+type botChallengeMarker struct {
+	vendor string
+	marker string
+	widget bool
+}
+
+// botChallengeMarkers are matched case-insensitively against the start of the body
+var botChallengeMarkers = []botChallengeMarker{
+	{"cloudflare", "cf-chl-", false},
+	{"cloudflare", "challenge-platform", true},
+	{"cloudflare", "attention required! | cloudflare", false},
+	{"cloudflare", "<title>just a moment...</title>", false},
+	{"imperva", "incapsula incident id", false},
+	{"imperva", "_incapsula_resource", false},
+	{"datadome", "captcha-delivery.com", false},
+	{"perimeterx", "px-captcha", false},
+	{"sucuri", "sucuri website firewall", false},
+	{"akamai", "access denied</h1>", false},
+	{"captcha", "g-recaptcha", true},
+	{"captcha", "h-captcha", true},
+}
+
+// detectBotChallenge returns the vendor of a bot challenge response, or an empty string
+// Headers are authoritative; body markers only count on 403/429/503 responses or with a vendor challenge header
+func detectBotChallenge(statusCode int, header http.Header, body []byte) string {
+	switch {
+	case strings.EqualFold(header.Get("Cf-Mitigated"), "challenge"):
+		return "cloudflare"
+	case header.Get("X-Datadome") != "" && statusCode == http.StatusForbidden:
+		return "datadome"
+	case header.Get("X-Amzn-Waf-Action") != "":
+		return "aws-waf"
+	case header.Get("X-Sucuri-Id") != "" && statusCode == http.StatusForbidden:
+		return "sucuri"
+	}
+
+	suspicious := statusCode == http.StatusForbidden || statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+	if !suspicious && !hasChallengeHeader(header) {
+		return ""
+	}
+
+	lower := bytes.ToLower(body)
+	for _, m := range botChallengeMarkers {
+		if m.widget && !suspicious {
+			continue
+		}
+		if m.vendor == "akamai" && !strings.Contains(strings.ToLower(header.Get("Server")), "akamai") {
+			continue
+		}
+		if bytes.Contains(lower, []byte(m.marker)) {
+			return m.vendor
+		}
+	}
+	return ""
+}
+
+// hasChallengeHeader reports whether a vendor marked the response as a challenge without a challenge status
+func hasChallengeHeader(header http.Header) bool {
+	return header.Get("X-Datadome") != "" || header.Get("X-Sucuri-Id") != "" || header.Get("X-Iinfo") != "" || header.Get("Cf-Chl-Bypass") != ""
+}
+
+// headBuffer keeps the first bytes written to it and discards the rest
+type headBuffer struct {
+	data  []byte
+	limit int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.data = append(b.data, p[:room]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// Files of a crawl bundle
+const (
+	bundleResultFile   = "result.json"
+	bundleLinksFile    = "links.csv"
+	bundleSnapshotFile = "snapshot.html"
+)
+
+// bundleLinkColumns is the header row of the links CSV
+var bundleLinkColumns = []string{
+	"url", "type", "status_code", "is_accessible", "throttled", "check_method",
+	"initial_status_code", "final_url", "redirect_count", "permanent_redirect", "soft_404",
+}
+
+// WriteCrawlBundle writes a ZIP archive of a crawl for client deliverables: the URL and result as JSON,
+// the links as CSV, and the analyzed HTML when the crawl kept a snapshot (snapshot may be nil)
+func WriteCrawlBundle(w io.Writer, url models.URL, result models.CrawlResult, snapshot *models.PageSnapshot) error {
+	archive := zip.NewWriter(w)
+	modified := result.CrawledAt
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	// The links get a file of their own, so the JSON describes the result as GET /api/urls/:id/latest?links=false does
+	links := result.Links
+	result.Links = nil
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: bundleResultFile, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]interface{}{"url": url, "result": result}); err != nil {
+		return err
+	}
+
+	file, err = archive.CreateHeader(&zip.FileHeader{Name: bundleLinksFile, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	if err := writeLinksCSV(file, links); err != nil {
+		return err
+	}
+
+	if snapshot != nil && len(snapshot.HTML) > 0 {
+		file, err = archive.CreateHeader(&zip.FileHeader{Name: bundleSnapshotFile, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(snapshot.HTML); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeLinksCSV writes one row per link under the bundleLinkColumns header
+func writeLinksCSV(w io.Writer, links []models.Link) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(bundleLinkColumns); err != nil {
+		return err
+	}
+	for _, link := range links {
+		row := []string{
+			link.URL,
+			link.Type,
+			strconv.Itoa(link.StatusCode),
+			strconv.FormatBool(link.IsAccessible),
+			strconv.FormatBool(link.Throttled),
+			link.CheckMethod,
+			strconv.Itoa(link.InitialStatusCode),
+			link.FinalURL,
+			strconv.Itoa(link.RedirectCount),
+			strconv.FormatBool(link.PermanentRedirect),
+			strconv.FormatBool(link.Soft404),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// severityRank orders finding severities for the fail_on threshold of CI checks
+var severityRank = map[string]int{
+	models.SeverityInfo:    0,
+	models.SeverityWarning: 1,
+	models.SeverityError:   2,
+}
+
+// ValidSeverity reports whether severity is a known finding severity
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// CICheckOptions configures a pre-deploy check of a single page
+type CICheckOptions struct {
+	URL            string // Page to fetch; ignored when HTML is set
+	HTML           []byte // Submitted page, analyzed as if served at BaseURL
+	BaseURL        string
+	Project        *models.Project // Budget and policy words to check against
+	CheckLinks     bool            // Also check the links of the page, which makes the check slower
+	FailOn         string          // Lowest finding severity that fails the check, error by default
+	MaxBrokenLinks *int            // Broken links allowed, only checked with CheckLinks; nil allows any
+}
+
+// CIVerdict is the outcome of a pre-deploy check
+type CIVerdict struct {
+	Passed       bool                `json:"passed"`
+	FailOn       string              `json:"fail_on"`
+	Reasons      []string            `json:"reasons"`  // Why the check failed, empty when it passed
+	Failures     []models.Finding    `json:"failures"` // Findings at or above FailOn
+	BudgetStatus string              `json:"budget_status"`
+	BrokenLinks  int                 `json:"broken_links"`
+	DurationMS   int64               `json:"duration_ms"`
+	Result       *models.CrawlResult `json:"result,omitempty"` // Unset when the page couldn't be fetched
+}
+
+// CICheck analyzes a page synchronously and decides whether it may be deployed
+// Site crawls, HSTS and well-known file probes are skipped so the check stays quick;
+// a page that can't be fetched fails the check rather than erroring
+func (c *CrawlerService) CICheck(options CICheckOptions) *CIVerdict {
+	start := time.Now()
+	verdict := &CIVerdict{FailOn: options.FailOn, Reasons: []string{}, Failures: []models.Finding{}}
+	if verdict.FailOn == "" {
+		verdict.FailOn = models.SeverityError
+	}
+
+	var result *models.CrawlResult
+	var err error
+	if options.HTML != nil {
+		result, err = c.AnalyzeHTML(options.HTML, AnalyzeOptions{
+			BaseURL:    options.BaseURL,
+			CheckLinks: options.CheckLinks,
+			Project:    options.Project,
+		})
+	} else {
+		result, err = c.quickCrawl(options.URL, options.Project, options.CheckLinks)
+	}
+	verdict.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		verdict.Reasons = append(verdict.Reasons, err.Error())
+		return verdict
+	}
+	pageURL := options.URL
+	if options.HTML != nil {
+		pageURL = options.BaseURL
+	}
+	result.Findings = append(result.Findings, customRuleFindings(options.Project, pageURL, result)...)
+	verdict.Result = result
+	verdict.BudgetStatus = result.BudgetStatus
+	verdict.BrokenLinks = result.InaccessibleLinks
+
+	threshold := severityRank[verdict.FailOn]
+	for _, finding := range result.Findings {
+		if severityRank[finding.Severity] >= threshold {
+			verdict.Failures = append(verdict.Failures, finding)
+		}
+	}
+	if len(verdict.Failures) > 0 {
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("%d finding(s) with severity %s or higher", len(verdict.Failures), verdict.FailOn))
+	}
+	if result.BudgetStatus == models.BudgetFail {
+		verdict.Reasons = append(verdict.Reasons, "page exceeds the project budget")
+	}
+	if options.CheckLinks && options.MaxBrokenLinks != nil && result.InaccessibleLinks > *options.MaxBrokenLinks {
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("%d broken link(s), at most %d allowed", result.InaccessibleLinks, *options.MaxBrokenLinks))
+	}
+
+	verdict.Passed = len(verdict.Reasons) == 0
+	return verdict
+}
+
+// quickCrawl fetches a single page and runs the analyzers of a crawl that need nothing but the page
+func (c *CrawlerService) quickCrawl(targetURL string, project *models.Project, checkLinks bool) (*models.CrawlResult, error) {
+	if c.mock != nil {
+		return c.mockCrawl(targetURL, project)
+	}
+
+	// CI checks aren't stored, so there is no URL to stop them by
+	ctx := context.Background()
+	settings := c.settings.Get()
+	tracker := newThrottleTracker()
+	page, err := c.fetchPage(ctx, targetURL, settings, models.CrawlConfig{}, tracker)
+	if err != nil {
+		return nil, err
+	}
+	if page.Bot != "" {
+		return nil, &BotProtectionError{Vendor: page.Bot, StatusCode: page.StatusCode}
+	}
+	if page.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", page.StatusCode, http.StatusText(page.StatusCode))
+	}
+
+	result := &models.CrawlResult{
+		CrawledAt:       time.Now(),
+		AnalyzerVersion: AnalyzerVersion,
+	}
+	c.analyzeDocument(result, page.Doc, targetURL, page, models.CrawlConfig{}, project)
+	if checkLinks {
+		c.checkDocumentLinks(ctx, result, settings, tracker)
+	}
+	tracker.apply(&result.Diagnostics)
+
+	return result, nil
+}
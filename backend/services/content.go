@@ -0,0 +1,231 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// boilerplateElements are page chrome that is not part of the main content
+var boilerplateElements = map[string]bool{
+	"nav":    true,
+	"header": true,
+	"footer": true,
+	"aside":  true,
+	"form":   true,
+}
+
+// languageStopwords are frequent function words used to guess the language of a text
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "it", "with", "are", "this", "you", "on"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "ein", "eine", "zu", "sie", "auf", "für"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pour", "dans", "que", "pas", "sur", "vous", "avec"},
+	"es": {"el", "la", "los", "las", "y", "que", "es", "una", "para", "por", "con", "del", "se", "como"},
+	"it": {"il", "la", "che", "di", "è", "per", "una", "con", "non", "sono", "gli", "della", "del", "le"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "voor", "met", "zijn", "te", "ook"},
+}
+
+// sentenceEnd splits text into sentences
+var sentenceEnd = regexp.MustCompile(`[.!?]+(\s|$)`)
+
+// analyzeContent extracts the main text of the page, detects its language, and scores its readability
+func analyzeContent(doc *html.Node) models.ContentAnalysis {
+	text := mainContentText(doc)
+	words := splitWords(text)
+
+	analysis := models.ContentAnalysis{
+		WordCount: len(words),
+	}
+	analysis.Language, analysis.LanguageSource = detectLanguage(doc, words)
+
+	if len(words) == 0 {
+		return analysis
+	}
+
+	sentences := 0
+	for _, sentence := range sentenceEnd.Split(text, -1) {
+		if strings.TrimSpace(sentence) != "" {
+			sentences++
+		}
+	}
+	if sentences == 0 {
+		sentences = 1
+	}
+
+	syllables := 0
+	for _, word := range words {
+		syllables += countSyllables(word, analysis.Language)
+	}
+
+	wordsPerSentence := float64(len(words)) / float64(sentences)
+	syllablesPerWord := float64(syllables) / float64(len(words))
+
+	analysis.SentenceCount = sentences
+	analysis.AvgWordsPerSentence = round2(wordsPerSentence)
+	analysis.AvgSyllablesPerWord = round2(syllablesPerWord)
+
+	// German text needs the Amstad adaptation, other languages use the original Flesch formulas
+	if analysis.Language == "de" {
+		analysis.ReadabilityFormula = "flesch-amstad"
+		analysis.ReadingEase = round2(180 - wordsPerSentence - 58.5*syllablesPerWord)
+	} else {
+		analysis.ReadabilityFormula = "flesch-kincaid"
+		analysis.ReadingEase = round2(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+	}
+	analysis.GradeLevel = round2(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+
+	return analysis
+}
+
+// mainContentText returns the visible text of the main content, leaving out navigation and other boilerplate
+// It prefers <main>, role="main", or <article>, and falls back to the whole body
+func mainContentText(doc *html.Node) string {
+	root := findMainElement(doc)
+	if root == nil {
+		root = doc
+	}
+
+	var builder strings.Builder
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && (nonVisibleElements[n.Data] || boilerplateElements[n.Data]) {
+			return
+		}
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			builder.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(root)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// findMainElement returns the element holding the main content, if the page marks one
+func findMainElement(doc *html.Node) *html.Node {
+	var article *html.Node
+
+	var traverse func(*html.Node) *html.Node
+	traverse = func(n *html.Node) *html.Node {
+		if n.Type == html.ElementNode {
+			if n.Data == "main" || attrValue(n, "role") == "main" {
+				return n
+			}
+			if n.Data == "article" && article == nil {
+				article = n
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if found := traverse(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	if main := traverse(doc); main != nil {
+		return main
+	}
+	return article
+}
+
+// detectLanguage uses the declared <html lang> and otherwise guesses from stopword frequencies
+func detectLanguage(doc *html.Node, words []string) (string, string) {
+	var htmlLang string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "html" {
+			htmlLang = attrValue(n, "lang")
+			return
+		}
+		for child := n.FirstChild; child != nil && htmlLang == ""; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	if htmlLang != "" {
+		// "en-US" -> "en"
+		return strings.ToLower(strings.SplitN(htmlLang, "-", 2)[0]), "html_lang"
+	}
+
+	counts := make(map[string]int)
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		for language, stopwords := range languageStopwords {
+			if containsString(stopwords, lower) {
+				counts[language]++
+			}
+		}
+	}
+
+	best, bestCount := "", 0
+	for language, count := range counts {
+		if count > bestCount || (count == bestCount && language < best) {
+			best, bestCount = language, count
+		}
+	}
+	if bestCount < 3 {
+		return "", ""
+	}
+	return best, "detected"
+}
+
+// splitWords returns the words of text without surrounding punctuation
+func splitWords(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// countSyllables approximates the syllables of a word by counting vowel groups
+func countSyllables(word, language string) int {
+	word = strings.ToLower(word)
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouyäöüéèêàâîôûíóúáœæ", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+
+	// A trailing silent "e" is not a syllable in English ("make", "page")
+	if language != "de" && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// attrValue returns the value of the named attribute of an element
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+// round2 rounds to two decimals for stable, readable scores
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// maxCrawlPriority is the highest priority a URL can be crawled with
+const maxCrawlPriority = 10
+
+// ValidateCrawlConfig checks that the per-URL crawl overrides are within sensible limits
+func ValidateCrawlConfig(config models.CrawlConfig) error {
+	if config.TimeoutSeconds < 0 || config.TimeoutSeconds > 300 {
+		return fmt.Errorf("timeout_seconds must be between 0 and 300")
+	}
+	if len(config.UserAgent) > 512 {
+		return fmt.Errorf("user_agent must be at most 512 characters")
+	}
+	if config.MaxPages < 0 || config.MaxPages > maxSitePages {
+		return fmt.Errorf("max_pages must be between 0 and %d", maxSitePages)
+	}
+	if config.MaxDepth < 0 || config.MaxDepth > maxSiteDepth {
+		return fmt.Errorf("max_depth must be between 0 and %d", maxSiteDepth)
+	}
+	if config.Priority < 0 || config.Priority > maxCrawlPriority {
+		return fmt.Errorf("priority must be between 0 and %d", maxCrawlPriority)
+	}
+	if err := validateCrawlRegions(config.Regions); err != nil {
+		return err
+	}
+	if err := validateCrawlProfiles(config.Profiles); err != nil {
+		return err
+	}
+	if config.Scope != nil {
+		if err := ValidateLinkScope(*config.Scope); err != nil {
+			return fmt.Errorf("scope.%v", err) // Field names such as scope.path_prefix
+		}
+	}
+	return nil
+}
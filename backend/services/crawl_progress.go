@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+// progressSaveInterval bounds how often link check progress is written to the URL row
+const progressSaveInterval = time.Second
+
+// crawlProgress records the phase and link check counts of a running crawl on its URL row,
+// so clients polling the URL see how far the crawl got and not just that it is running.
+// A nil progress ignores all updates, for crawls that aren't stored such as CI checks.
+type crawlProgress struct {
+	urls repository.URLRepository
+
+	mu      sync.Mutex
+	url     models.URL
+	savedAt time.Time
+}
+
+// newCrawlProgress reports the progress of the crawl of urlID
+func newCrawlProgress(urls repository.URLRepository, urlID uint) *crawlProgress {
+	return &crawlProgress{urls: urls, url: models.URL{ID: urlID}}
+}
+
+// setPhase starts a new phase of the crawl and saves it right away
+func (p *crawlProgress) setPhase(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.url.CrawlPhase = phase
+	p.save()
+}
+
+// addLinks adds links about to be checked to the total
+func (p *crawlProgress) addLinks(count int) {
+	if p == nil || count == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.url.LinksTotal += count
+	p.save()
+}
+
+// linkChecked counts a checked link; the count is saved at most once per progressSaveInterval
+// and always once every link is checked
+func (p *crawlProgress) linkChecked() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.url.LinksChecked++
+	if p.url.LinksChecked >= p.url.LinksTotal || time.Since(p.savedAt) >= progressSaveInterval {
+		p.save()
+	}
+}
+
+// save writes the progress columns; failures only cost the client an update, so they are ignored
+func (p *crawlProgress) save() {
+	p.savedAt = time.Now()
+	url := p.url
+	p.urls.Update(&url, "crawl_phase", "links_checked", "links_total")
+}
+
+// progressColumns resets the progress of url once its crawl ended and returns the columns to save with it
+func progressColumns(url *models.URL) []string {
+	url.CrawlPhase = ""
+	url.LinksChecked = 0
+	url.LinksTotal = 0
+	return []string{"crawl_phase", "links_checked", "links_total"}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// ErrQueueFull is returned when a crawl can't be admitted because every worker is busy and the queue is full
+var ErrQueueFull = errors.New("crawl queue is full")
+
+const (
+	queueHeartbeatInterval = 30 * time.Second
+	queueStaleAfter        = 2 * time.Minute // Crawls of a server silent for that long are taken over
+)
+
+// QueueStats describes how many crawls are running and waiting for a worker
+type QueueStats struct {
+	Running  int `json:"running"`
+	Queued   int `json:"queued"`
+	Workers  int `json:"workers"`
+	Capacity int `json:"capacity"` // Maximum number of queued crawls
+	Deferred int `json:"deferred"` // Batch crawls waiting for their project's crawl window, not counted above
+
+	Paused bool `json:"paused"` // Queued crawls are held, see models.Settings.CrawlingPaused
+}
+
+// crawlQueue counts admitted crawls so new ones can be refused instead of piling up goroutines
+// Started crawls are also stored as models.QueuedCrawl, so they are resumed after a restart
+type crawlQueue struct {
+	mu       sync.Mutex
+	pending  int    // Admitted crawls that haven't finished, running or waiting
+	deferred int    // Admitted crawls waiting for a crawl window, see waitForCrawlWindow
+	owner    string // Identifies this server in the stored queue; a new one is picked on every start
+}
+
+// newQueueOwner picks the random ID a server holds its stored crawls under
+func newQueueOwner() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// CrawlReservation holds admitted slots in the crawl queue
+// Unused slots must be given back with Release
+type CrawlReservation struct {
+	crawler   *CrawlerService
+	remaining int
+	Position  int  // Queue position of the first reserved crawl, 0 when it starts right away
+	JobID     uint // Batch job the started crawls belong to, used to schedule batches fairly
+}
+
+// Reserve admits n crawls at once, or none of them with ErrQueueFull
+func (c *CrawlerService) Reserve(n int) (*CrawlReservation, error) {
+	settings := c.settings.Get()
+
+	c.queue.mu.Lock()
+	defer c.queue.mu.Unlock()
+	waiting := c.queue.pending - c.queue.deferred
+	if waiting+n > settings.WorkerCount+settings.MaxQueuedCrawls {
+		return nil, ErrQueueFull
+	}
+
+	// While crawling is paused, every admitted crawl waits behind the ones already queued
+	position := waiting + 1 - settings.WorkerCount
+	if settings.CrawlingPaused {
+		position = waiting - c.workers.Active() + 1
+	}
+	if position < 0 {
+		position = 0
+	}
+	c.queue.pending += n
+
+	return &CrawlReservation{crawler: c, remaining: n, Position: position}, nil
+}
+
+// QueueStats returns a snapshot of the crawl queue
+func (c *CrawlerService) QueueStats() QueueStats {
+	settings := c.settings.Get()
+
+	c.queue.mu.Lock()
+	pending := c.queue.pending - c.queue.deferred
+	deferred := c.queue.deferred
+	c.queue.mu.Unlock()
+
+	running := pending
+	if running > settings.WorkerCount {
+		running = settings.WorkerCount
+	}
+	if settings.CrawlingPaused {
+		running = c.workers.Active()
+	}
+	return QueueStats{
+		Running:  running,
+		Queued:   pending - running,
+		Workers:  settings.WorkerCount,
+		Capacity: settings.MaxQueuedCrawls,
+		Deferred: deferred,
+		Paused:   settings.CrawlingPaused,
+	}
+}
+
+// RetryAfter estimates when a refused crawl is worth retrying
+// A running crawl takes at most about one crawl timeout to fetch its page, which frees a slot
+func (c *CrawlerService) RetryAfter() time.Duration {
+	return time.Duration(c.settings.Get().CrawlTimeoutSeconds) * time.Second
+}
+
+// Start crawls the URL in the background using one reserved slot
+// done is called with the crawl outcome and may be nil
+func (r *CrawlReservation) Start(urlID uint, done func(error)) {
+	if r.remaining == 0 {
+		return
+	}
+	r.remaining--
+
+	crawl := models.QueuedCrawl{URLID: urlID, JobID: r.JobID, Owner: r.crawler.queue.owner, HeartbeatAt: time.Now()}
+	if err := r.crawler.store.CrawlQueue().Enqueue(&crawl); err != nil {
+		// The crawl runs all the same, it is only lost on a restart
+		log.Printf("Failed to store the queued crawl of URL %d: %v", urlID, err)
+		crawl.CreatedAt = time.Now()
+	}
+	r.crawler.runQueued(crawl, done)
+}
+
+// runQueued crawls a queued crawl in the background and removes it from the stored queue once it ended
+func (c *CrawlerService) runQueued(crawl models.QueuedCrawl, done func(error)) {
+	go func() {
+		err := c.crawlURL(crawl.URLID, crawl.JobID, crawl.CreatedAt)
+		if crawl.ID != 0 {
+			if err := c.store.CrawlQueue().Dequeue(crawl.ID); err != nil {
+				log.Printf("Failed to remove the crawl of URL %d from the stored queue: %v", crawl.URLID, err)
+			}
+		}
+		c.finishCrawl(1)
+		if done != nil {
+			done(err)
+		}
+	}()
+}
+
+// ResumeQueuedCrawls keeps the stored crawls of this server alive and resumes those of servers that stopped
+// sending heartbeats, this one before a restart included, in the order they were queued
+// Resumed crawls are admitted even beyond the queue capacity; done is called with the outcome of each
+func (c *CrawlerService) ResumeQueuedCrawls(done func(crawl models.QueuedCrawl, err error)) {
+	go func() {
+		c.claimQueuedCrawls(done)
+		ticker := time.NewTicker(queueHeartbeatInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := c.store.CrawlQueue().Heartbeat(c.queue.owner); err != nil {
+				log.Printf("Failed to send the crawl queue heartbeat: %v", err)
+			}
+			c.claimQueuedCrawls(done)
+		}
+	}()
+}
+
+// claimQueuedCrawls takes over the stored crawls of stopped servers and starts them
+func (c *CrawlerService) claimQueuedCrawls(done func(crawl models.QueuedCrawl, err error)) {
+	crawls, err := c.store.CrawlQueue().Claim(c.queue.owner, time.Now().Add(-queueStaleAfter))
+	if err != nil {
+		log.Printf("Failed to claim stored crawls: %v", err)
+	}
+	if len(crawls) == 0 {
+		return
+	}
+	log.Printf("Resuming %d stored crawl(s)", len(crawls))
+
+	c.queue.mu.Lock()
+	c.queue.pending += len(crawls)
+	c.queue.mu.Unlock()
+	for _, queued := range crawls {
+		crawl := queued
+		c.runQueued(crawl, func(err error) { done(crawl, err) })
+	}
+}
+
+// Release gives back the slots that weren't started
+func (r *CrawlReservation) Release() {
+	if r == nil || r.remaining == 0 {
+		return
+	}
+	r.crawler.finishCrawl(r.remaining)
+	r.remaining = 0
+}
+
+// finishCrawl frees n admitted slots
+func (c *CrawlerService) finishCrawl(n int) {
+	c.queue.mu.Lock()
+	defer c.queue.mu.Unlock()
+	c.queue.pending -= n
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrCrawlStopped is the cause of a crawl cancelled with StopCrawl
+var ErrCrawlStopped = errors.New("crawl stopped")
+
+// crawlRegistry holds the cancel functions of the crawls in progress, from admission until they end,
+// so stopping a URL cancels its fetches and link checks instead of only resetting its status
+type crawlRegistry struct {
+	mu      sync.Mutex
+	next    uint64
+	running map[uint]map[uint64]context.CancelCauseFunc // By URL ID; a URL can be crawled twice at once
+}
+
+// start registers a crawl of urlID and returns its context; done must be called when the crawl ends
+func (r *crawlRegistry) start(urlID uint) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[uint]map[uint64]context.CancelCauseFunc)
+	}
+	if r.running[urlID] == nil {
+		r.running[urlID] = make(map[uint64]context.CancelCauseFunc)
+	}
+	r.next++
+	id := r.next
+	r.running[urlID][id] = cancel
+
+	return ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.running[urlID], id)
+		if len(r.running[urlID]) == 0 {
+			delete(r.running, urlID)
+		}
+		cancel(nil)
+	}
+}
+
+// stop cancels every crawl of urlID and reports whether there was one
+func (r *crawlRegistry) stop(urlID uint) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.running[urlID] {
+		cancel(ErrCrawlStopped)
+	}
+	return len(r.running[urlID]) > 0
+}
+
+// active reports whether a crawl of urlID is in progress
+func (r *crawlRegistry) active(urlID uint) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.running[urlID]) > 0
+}
+
+// Crawling reports whether a crawl of the URL is in progress on this server or stored for any server to run
+// It doesn't go by the URL's status, which stays running when a server stopped without finishing the crawl
+func (c *CrawlerService) Crawling(urlID uint) (bool, error) {
+	if c.crawls.active(urlID) {
+		return true, nil
+	}
+	return c.store.CrawlQueue().HasURL(urlID)
+}
+
+// StopCrawl cancels the crawls of the URL in progress, including those waiting for a worker or a crawl window
+// The stopped crawls store nothing and leave the status of the URL alone; it reports whether one was running
+// Stored crawls of the URL are dropped too, so a server that went down doesn't resume them
+func (c *CrawlerService) StopCrawl(urlID uint) bool {
+	if err := c.store.CrawlQueue().DequeueURL(urlID); err != nil {
+		log.Printf("Failed to remove the crawls of URL %d from the stored queue: %v", urlID, err)
+	}
+	return c.crawls.stop(urlID)
+}
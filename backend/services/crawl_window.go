@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// maxCrawlWindows is the number of crawl windows a project may define
+const maxCrawlWindows = 24
+
+// ValidateCrawlSchedule checks a project's time zone and crawl windows
+func ValidateCrawlSchedule(schedule models.CrawlSchedule) error {
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("unknown time zone %q", schedule.Timezone)
+	}
+	if len(schedule.Windows) > maxCrawlWindows {
+		return fmt.Errorf("at most %d windows are allowed", maxCrawlWindows)
+	}
+	for _, window := range schedule.Windows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("window %s-%s is empty", window.Start, window.End)
+		}
+	}
+	return nil
+}
+
+// parseClock returns the minutes since midnight of an HH:MM time
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("time %q must be HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// crawlWindowWait returns how long until one of the project's crawl windows is open, 0 when one is open now
+// Projects without windows, and URLs without a project, may be crawled at any time
+func crawlWindowWait(project *models.Project, now time.Time) time.Duration {
+	if project == nil || len(project.CrawlSchedule.Windows) == 0 {
+		return 0
+	}
+	loc, err := time.LoadLocation(project.CrawlSchedule.Timezone)
+	if err != nil {
+		return 0
+	}
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	minute := now.Hour()*60 + now.Minute()
+
+	var wait time.Duration
+	for _, window := range project.CrawlSchedule.Windows {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return 0
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return 0
+		}
+		if start < end && minute >= start && minute < end || start > end && (minute >= start || minute < end) {
+			return 0
+		}
+
+		// Dates are built from clock times so the daylight saving time shifts of the zone are respected
+		opens := time.Date(midnight.Year(), midnight.Month(), midnight.Day(), start/60, start%60, 0, 0, loc)
+		if !opens.After(now) {
+			opens = time.Date(midnight.Year(), midnight.Month(), midnight.Day()+1, start/60, start%60, 0, 0, loc)
+		}
+		if until := opens.Sub(now); wait == 0 || until < wait {
+			wait = until
+		}
+	}
+	return wait
+}
+
+// waitForCrawlWindow defers a batch crawl until a crawl window of the project opens
+// Crawls outside of batch jobs, i.e. started by hand for one URL, aren't deferred
+// A deferred crawl doesn't count against the queue capacity, its URL stays queued
+// It returns ctx's error when the crawl was stopped while deferred
+func (c *CrawlerService) waitForCrawlWindow(ctx context.Context, project *models.Project, url models.URL, jobID uint) error {
+	if jobID == 0 {
+		return nil
+	}
+	for {
+		wait := crawlWindowWait(project, time.Now())
+		if wait <= 0 {
+			return nil
+		}
+		log.Printf("Deferring crawl of URL %d by %s until the crawl window of project %d opens", url.ID, wait.Round(time.Second), project.ID)
+
+		c.queue.mu.Lock()
+		c.queue.deferred++
+		c.queue.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+
+		c.queue.mu.Lock()
+		c.queue.deferred--
+		c.queue.mu.Unlock()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
@@ -1,39 +1,117 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
 	"golang.org/x/net/html"
-	"gorm.io/gorm"
 )
 
+const (
+	linkProbeBodyLimit = 8192 // Body bytes read when a link is checked with GET, enough to see the title
+	maxLinkRedirects   = 10   // Redirects followed per link check, matching Go's default client
+)
+
+// AnalyzerVersion identifies the generation of the analysis logic that produced a crawl result
+// Bump it when a change alters the results of pages that haven't changed, e.g. improved HTML version detection
+const AnalyzerVersion = 11
+
 // CrawlerService handles website crawling and analysis operations
 type CrawlerService struct {
-	db     *gorm.DB
-	client *http.Client
+	store     repository.Store
+	client    *http.Client
+	noFollow  *http.Client // Link checks and probes that record every redirect themselves
+	plugins   *http.Client // Analyzer plugins are services of the operator, so they bypass the crawl transport
+	transport *HTTPTransport
+	settings  *SettingsService
+	workers   *crawlScheduler
+	queue     crawlQueue
+	crawls    crawlRegistry // Crawls in progress, see StopCrawl
+	metrics   *HostMetrics
+	spell     *SpellChecker
+	observers []CrawlObserver
+	mock      *MockCrawl         // Set in mock mode, see EnableMock
+	agents    *FetchAgentService // Fetch agents of other regions, see UseFetchAgents
+	billing   *BillingService    // Plan limits of hosted mode, see UseBilling
+}
+
+// CrawlObserver is told about every crawl of a URL, e.g. to report its outcome to an external system
+// It is called on the crawl's goroutine, so slow work must be done in the background
+// CrawlFinished is called once the outcome is stored, with the saved result or the error that ended the crawl;
+// crawls stopped with StopCrawl store nothing and aren't reported
+type CrawlObserver interface {
+	CrawlStarted(project *models.Project, url models.URL)
+	CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error)
 }
 
 // NewCrawlerService creates a new crawler service instance with configured HTTP client
-func NewCrawlerService(db *gorm.DB) *CrawlerService {
-	return &CrawlerService{
-		db: db,
-		client: &http.Client{
-			Timeout: 30 * time.Second, // Set reasonable timeout for HTTP requests
+// Timeouts and concurrency are read from the runtime settings so changes apply without a restart
+func NewCrawlerService(store repository.Store, settings *SettingsService, metrics *HostMetrics, spell *SpellChecker, transport *HTTPTransport) *CrawlerService {
+	current := settings.Get()
+	c := &CrawlerService{
+		store:     store,
+		client:    &http.Client{Transport: transport}, // Timeouts are applied per request from the runtime settings
+		transport: transport,
+		settings:  settings,
+		queue:     crawlQueue{owner: newQueueOwner()},
+		workers:   newCrawlScheduler(current.WorkerCount, current.SchedulingMode, priorityAging(current)),
+		metrics:   metrics,
+		spell:     spell,
+		plugins:   &http.Client{}, // Plugin calls time out with their context, see callPlugin
+
+		// Redirects are followed manually so the initial and final status can be recorded separately
+		noFollow: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
 		},
 	}
+
+	// Resize the worker limit, switch the scheduling policy, and pause or resume whenever the settings change
+	c.workers.Pause(current.CrawlingPaused)
+	settings.OnChange(func(s models.Settings) {
+		c.workers.Configure(s.WorkerCount, s.SchedulingMode, priorityAging(s))
+		c.workers.Pause(s.CrawlingPaused)
+	})
+
+	return c
+}
+
+// Paused reports whether crawling is paused, see models.Settings.CrawlingPaused
+func (c *CrawlerService) Paused() bool {
+	return c.settings.Get().CrawlingPaused
+}
+
+// Observe registers an observer of every crawl; it must be called before crawls start
+func (c *CrawlerService) Observe(observer CrawlObserver) {
+	c.observers = append(c.observers, observer)
 }
 
 // CrawlURL orchestrates the complete crawling process for a given URL
 // It handles status updates, performs the actual crawl, and saves results
 func (c *CrawlerService) CrawlURL(urlID uint) error {
+	return c.crawlURL(urlID, 0, time.Now())
+}
+
+// crawlURL crawls the URL as part of the batch job jobID, 0 when it isn't part of a batch
+// The project, or else the batch job, is the group the fair scheduler rotates between; within it,
+// crawls wait for a worker in the order they were queued
+func (c *CrawlerService) crawlURL(urlID uint, jobID uint, queued time.Time) error {
 	// Retrieve the URL record to check current status
-	var urlModel models.URL
-	if err := c.db.First(&urlModel, urlID).Error; err != nil {
+	urlModel, err := c.store.URLs().Get(urlID)
+	if err != nil {
 		return fmt.Errorf("failed to find URL with ID %d: %v", urlID, err)
 	}
 
@@ -42,93 +120,466 @@ func (c *CrawlerService) CrawlURL(urlID uint) error {
 		return nil // Already completed, no action needed
 	}
 
+	// Register the crawl so stopping the URL cancels it, wherever it is
+	ctx, finished := c.crawls.start(urlID)
+	defer finished()
+
+	// Load the project whose rules apply to the crawl
+	var project *models.Project
+	if urlModel.ProjectID != nil {
+		if loaded, err := c.store.Projects().Get(*urlModel.ProjectID); err == nil {
+			project = &loaded
+		}
+	}
+
+	// Batch crawls only start within the project's crawl windows
+	if err := c.waitForCrawlWindow(ctx, project, urlModel, jobID); err != nil {
+		return c.crawlStopped(ctx, &urlModel)
+	}
+
+	// In hosted mode, the plan of the user who added the URL bounds its crawls
+	if c.billing != nil {
+		if err := c.billing.AllowCrawl(urlModel.CreatedBy); err != nil {
+			return c.crawlRefused(&urlModel, err)
+		}
+		release, err := c.billing.AcquireWorker(ctx, urlModel.CreatedBy)
+		if err == nil {
+			defer release()
+		}
+		if ctx.Err() != nil {
+			return c.crawlStopped(ctx, &urlModel)
+		}
+		if err != nil {
+			return c.crawlRefused(&urlModel, err)
+		}
+	}
+
 	// Update status to running only if not already in progress
 	if urlModel.Status != "running" {
-		if err := c.db.Model(&urlModel).Update("status", "running").Error; err != nil {
+		if err := c.store.URLs().SetStatus("running", urlID); err != nil {
 			return fmt.Errorf("failed to update URL status to running: %v", err)
 		}
 	}
+	progress := newCrawlProgress(c.store.URLs(), urlID)
+	progress.setPhase(models.CrawlPhaseWaiting)
+
+	// Wait for a free worker slot so the number of concurrent crawls stays bounded
+	// The window may have closed while the crawl waited, then it is deferred again
+	for {
+		if err := c.workers.Acquire(ctx, schedulingGroup(urlModel, jobID), urlModel.CrawlConfig.Priority, queued); err != nil {
+			return c.crawlStopped(ctx, &urlModel)
+		}
+		if jobID == 0 || crawlWindowWait(project, time.Now()) == 0 {
+			break
+		}
+		c.workers.Release()
+		if err := c.waitForCrawlWindow(ctx, project, urlModel, jobID); err != nil {
+			return c.crawlStopped(ctx, &urlModel)
+		}
+	}
+	defer c.workers.Release()
+
+	// Charge the crawl only once it has a worker, so a crawl stopped while it waited isn't billed
+	// Other crawls of the user may have used up the quota in the meantime
+	if c.billing != nil {
+		if err := c.billing.ChargeCrawl(urlModel.CreatedBy); err != nil {
+			return c.crawlRefused(&urlModel, err)
+		}
+	}
+	for _, observer := range c.observers {
+		observer.CrawlStarted(project, urlModel)
+	}
 
 	// Execute the actual crawling and analysis
-	result, err := c.performCrawl(urlModel.URL)
+	result, err := c.performCrawl(ctx, urlModel.URL, urlModel.CrawlConfig, project, progress)
+	if ctx.Err() != nil {
+		// Whatever the crawl got before it was stopped is incomplete
+		result, err = nil, context.Cause(ctx)
+	}
+	if err == nil {
+		result.Findings = append(result.Findings, c.duplicateDescriptionFindings(project, urlModel, result)...)
+		result.Findings = append(result.Findings, c.pluginFindings(ctx, project, urlModel, result)...)
+		result.Findings = append(result.Findings, customRuleFindings(project, urlModel.URL, result)...)
+	}
+	// A crawl stopped while plugins or rules ran is dropped as well, before anyone is told about it
+	if ctx.Err() != nil {
+		return c.crawlStopped(ctx, &urlModel)
+	}
 	if err != nil {
-		// Update status to error and return the error
-		c.db.Model(&models.URL{}).Where("id = ?", urlID).Update("status", "error")
+		// Bot protection is its own outcome, so users can tell it apart from broken sites
+		status := "error"
+		var botErr *BotProtectionError
+		if errors.As(err, &botErr) {
+			status = "blocked"
+		}
+		message := err.Error()
+		if len(message) > 1024 {
+			message = message[:1024]
+		}
+		urlModel.Status = status
+		urlModel.LastError = message
+		c.store.URLs().Update(&urlModel, append(progressColumns(&urlModel), "status", "last_error")...)
+		c.crawlFinished(project, urlModel, nil, err)
 		return fmt.Errorf("crawling failed for URL %s: %v", urlModel.URL, err)
 	}
 
 	// Associate the crawl result with the URL
+	progress.setPhase(models.CrawlPhaseSaving)
 	result.URLID = urlID
-	if err := c.db.Create(result).Error; err != nil {
+	c.trackFindingLifecycle(urlID, result)
+	if err := c.store.CrawlResults().Create(result); err != nil {
 		// Update status to error if we can't save results
-		c.db.Model(&models.URL{}).Where("id = ?", urlID).Update("status", "error")
-		return fmt.Errorf("failed to save crawl results: %v", err)
+		urlModel.Status = "error"
+		c.store.URLs().Update(&urlModel, append(progressColumns(&urlModel), "status")...)
+		err = fmt.Errorf("failed to save crawl results: %v", err)
+		c.crawlFinished(project, urlModel, nil, err)
+		return err
 	}
 
 	// Mark URL as completed
-	if err := c.db.Model(&models.URL{}).Where("id = ?", urlID).Update("status", "completed").Error; err != nil {
+	urlModel.Status = "completed"
+	urlModel.LastError = ""
+	err = c.store.URLs().Update(&urlModel, append(progressColumns(&urlModel), "status", "last_error")...)
+	c.crawlFinished(project, urlModel, result, nil)
+	if err != nil {
 		return fmt.Errorf("failed to update URL status to completed: %v", err)
 	}
 
 	return nil
 }
 
+// crawlFinished tells the observers about the stored outcome of a crawl
+func (c *CrawlerService) crawlFinished(project *models.Project, urlModel models.URL, result *models.CrawlResult, err error) {
+	for _, observer := range c.observers {
+		observer.CrawlFinished(project, urlModel, result, err)
+	}
+}
+
+// crawlStopped ends a crawl cancelled before it was saved; the status set by whoever stopped it is kept
+func (c *CrawlerService) crawlStopped(ctx context.Context, urlModel *models.URL) error {
+	c.store.URLs().Update(urlModel, progressColumns(urlModel)...)
+	return fmt.Errorf("crawling stopped for URL %s: %w", urlModel.URL, context.Cause(ctx))
+}
+
+// crawlRefused ends a crawl the plan of the URL doesn't allow, recording why on the URL
+func (c *CrawlerService) crawlRefused(urlModel *models.URL, err error) error {
+	urlModel.Status = "error"
+	urlModel.LastError = err.Error()
+	c.store.URLs().Update(urlModel, append(progressColumns(urlModel), "status", "last_error")...)
+	return fmt.Errorf("crawl of URL %s refused: %v", urlModel.URL, err)
+}
+
 // performCrawl executes the actual website analysis and data extraction
 // It fetches the webpage, parses HTML, and extracts all relevant information
-func (c *CrawlerService) performCrawl(targetURL string) (*models.CrawlResult, error) {
-	// Fetch the webpage using configured HTTP client
-	resp, err := c.client.Get(targetURL)
+// project is nil when the URL does not belong to a project, progress when the crawl isn't reported
+// Cancelling ctx cancels the requests in flight; the result is then incomplete
+func (c *CrawlerService) performCrawl(ctx context.Context, targetURL string, config models.CrawlConfig, project *models.Project, progress *crawlProgress) (*models.CrawlResult, error) {
+	if c.mock != nil {
+		return c.mockCrawl(targetURL, project)
+	}
+
+	start := time.Now()
+	settings := c.settings.Get()
+	progress.setPhase(models.CrawlPhaseFetching)
+
+	// Fetch the webpage, backing off when the target throttles us
+	tracker := newThrottleTracker()
+	page, err := c.fetchPage(ctx, targetURL, settings, config, tracker)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %v", err)
+		return nil, err
+	}
+
+	// Retry with alternate user agents when bot protection blocked the page and the URL opted in
+	rotatedUserAgent := ""
+	if page.Bot != "" && config.RotateUserAgents {
+		for _, userAgent := range alternateUserAgents {
+			retryConfig := config
+			retryConfig.UserAgent = userAgent
+			retry, err := c.fetchPage(ctx, targetURL, settings, retryConfig, tracker)
+			if err == nil && retry.Bot == "" {
+				page, config = retry, retryConfig
+				rotatedUserAgent = userAgent
+				break
+			}
+		}
+	}
+
+	// Follow meta refresh redirects when the URL opted in, so the analysis reflects the destination page
+	var refresh *models.MetaRefresh
+	analyzedURL := targetURL
+	if config.FollowMetaRefresh {
+		if page, refresh, err = c.followMetaRefresh(ctx, page, settings, config, tracker); err != nil {
+			return nil, err
+		}
+		if refresh != nil {
+			analyzedURL = page.FinalURL
+		}
+	}
+
+	if page.Bot != "" {
+		return nil, &BotProtectionError{Vendor: page.Bot, StatusCode: page.StatusCode}
 	}
-	defer resp.Body.Close()
 
 	// Check for successful HTTP response
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	if page.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", page.StatusCode, http.StatusText(page.StatusCode))
 	}
+	doc := page.Doc
 
-	// Parse the HTML document
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	// Merge the content of same-origin frames when the URL opted in, for legacy sites that put everything in frames
+	var frames []models.Frame
+	if config.IncludeFrames {
+		frames = c.includeFrames(ctx, page, settings, config, tracker)
 	}
 
 	// Initialize crawl result with timestamp
+	progress.setPhase(models.CrawlPhaseAnalyzing)
 	result := &models.CrawlResult{
-		CrawledAt: time.Now(),
+		CrawledAt:       time.Now(),
+		AnalyzerVersion: AnalyzerVersion,
+	}
+	result.Diagnostics.RotatedUserAgent = rotatedUserAgent
+	result.Frames = frames
+	result.ContentHash = page.Hash
+
+	// Run the analyzers that only need the document, keeping it so they can be re-run later
+	c.analyzeDocument(result, doc, analyzedURL, page, config, project)
+
+	// Fetch and analyze the page as the device profiles of the config, while the result only holds the analysis
+	c.crawlProfiles(ctx, result, doc, analyzedURL, page, settings, config, project, tracker)
+	if refresh != nil {
+		result.MetaRefresh = refresh
+		result.Findings = append(result.Findings, metaRefreshFindings(refresh)...)
+	}
+	if page.Body != nil {
+		result.Snapshot = &models.PageSnapshot{FinalURL: page.FinalURL, Header: page.Header, HTML: page.Body}
 	}
 
-	// Extract various pieces of information from the HTML document
-	c.extractTitle(doc, result)            // Page title
-	c.extractHTMLVersion(doc, result)      // HTML version detection
-	c.extractHeadingCounts(doc, result)    // H1-H6 heading counts
-	c.extractLinks(doc, result, targetURL) // Internal/external links
-	c.checkLoginForm(doc, result)          // Login form detection
+	// Check HSTS and its preload eligibility
+	hsts, hstsFindings := c.checkHSTS(ctx, page.FinalURL, page.Header, settings, tracker)
+	result.Security.HSTS = hsts
+	result.Findings = append(result.Findings, hstsFindings...)
+
+	// Report when HTTPS exists but the submitted HTTP URL doesn't use it, or HTTP doesn't redirect to it
+	https, httpsFindings := c.checkHTTPS(ctx, targetURL, page.FinalURL, hsts, settings, tracker)
+	result.Security.HTTPS = https
+	result.Findings = append(result.Findings, httpsFindings...)
+
+	// Probe security.txt, robots.txt, and humans.txt of the domain
+	wellKnown, wellKnownFindings := c.checkWellKnownFiles(ctx, page.FinalURL, settings, tracker)
+	result.WellKnown = wellKnown
+	result.Findings = append(result.Findings, wellKnownFindings...)
+
+	// Record the pages of the site's sitemaps for the watch report of a competitor's URL
+	if project != nil && project.WatchGroup {
+		result.Sitemap = c.discoverSitemap(ctx, page.FinalURL, wellKnown.RobotsTxt.Sitemaps, settings, tracker)
+	}
+
+	// Fetch the page through the agents of other regions and compare what they get
+	c.compareRegions(ctx, result, page, targetURL, settings, config)
 
 	// Perform link accessibility check (may take additional time)
-	c.checkLinkAccessibility(result)
+	progress.setPhase(models.CrawlPhaseCheckingLinks)
+	c.checkLinkAccessibility(ctx, result, settings, tracker, progress)
+
+	// Verify that print stylesheets and other alternate representations resolve
+	c.checkAlternates(ctx, result, analyzedURL, settings, tracker)
+
+	// Follow internal links when the URL is configured for a site crawl
+	if config.MaxPages > 1 {
+		progress.setPhase(models.CrawlPhaseCrawlingSite)
+		c.crawlSite(ctx, targetURL, result, settings, config, project, tracker, progress)
+	}
+
+	// Record soft 404 links as findings
+	for _, link := range result.Links {
+		if link.Soft404 {
+			result.Findings = append(result.Findings, soft404Finding(link.URL, link.Soft404Reasons))
+		}
+	}
+
+	// Report whether the hosts contacted were reachable over IPv4 and IPv6
+	contacted := []string{targetURL, page.FinalURL}
+	for _, link := range result.Links {
+		contacted = append(contacted, link.URL)
+	}
+	result.Diagnostics.AddressFamilies = c.transport.AddressFamilies(contacted)
+	result.Findings = append(result.Findings, ipv6Findings(result.Diagnostics.AddressFamilies, contacted)...)
+
+	// Record which hosts throttled the crawl, and what it cost
+	tracker.apply(&result.Diagnostics)
+	result.Requests, result.BytesDownloaded = tracker.usage()
+	result.DurationMS = time.Since(start).Milliseconds()
 
 	return result, nil
 }
 
+// analyzeDocument runs the analyzers that need nothing but the parsed page, filling result
+// targetURL is the URL the page was requested as; page.FinalURL the one it was served from
+func (c *CrawlerService) analyzeDocument(result *models.CrawlResult, doc *html.Node, targetURL string, page *fetchedPage, config models.CrawlConfig, project *models.Project) {
+	// Extract various pieces of information from the HTML document
+	c.extractTitle(doc, result) // Page title
+	result.MetaDescription = findMetaDescription(doc)
+	c.extractHTMLVersion(doc, result)                                                // HTML version detection
+	c.extractHeadingCounts(doc, result)                                              // H1-H6 heading counts
+	c.extractLinks(doc, result, targetURL, newLinkScope(targetURL, config, project)) // Internal/external links
+	c.checkLoginForm(doc, result)                                                    // Login form detection
+	result.Pagination = detectPagination(doc, targetURL)
+	result.MetaRefresh = detectMetaRefresh(doc, targetURL, page.FinalURL)
+	result.Findings = append(result.Findings, metaRefreshFindings(result.MetaRefresh)...)
+	result.Findings = append(result.Findings, jsRedirectFindings(doc, targetURL, page.FinalURL)...)
+	result.Alternates = detectAlternates(doc, page.FinalURL)
+	result.Content = analyzeContent(doc) // Main content, language, and readability
+
+	// Flag the page itself when it is a "not found" page served with 200
+	if signals := detectSoft404(doc, false); signals.IsSoft404() {
+		result.Findings = append(result.Findings, soft404Finding(targetURL, signals.Reasons()))
+	}
+
+	// Spell check the visible text when the URL opted in and a dictionary exists for its language
+	if config.SpellCheck {
+		if misspellings, ok := c.spell.Check(visibleText(doc), result.Content.Language); ok {
+			result.Findings = append(result.Findings, misspellingFindings(targetURL, result.Content.Language, misspellings)...)
+		}
+	}
+
+	// Check the page text against the project's policy word list
+	result.Findings = append(result.Findings, policyFindings(targetURL, visibleText(doc), project)...)
+
+	// Flag missing meta descriptions and ones search engines would truncate
+	result.Findings = append(result.Findings, metaDescriptionFindings(targetURL, result.MetaDescription)...)
+
+	// Check the title and H1s against the project's length and keyword rules
+	result.Findings = append(result.Findings, headingFindings(targetURL, result.Title, headingTexts(doc), project)...)
+
+	// Measure the page weight and check it against the project budget
+	result.Weight = measurePageWeight(doc, targetURL, page.Size)
+	result.Weight.Protocol = detectProtocol(page.Protocol, page.Header)
+	status, budgetFindings := evaluateBudget(targetURL, result.Weight, project)
+	result.BudgetStatus = status
+	result.Findings = append(result.Findings, budgetFindings...)
+
+	// Simulate the Content Security Policy against the page's resources
+	result.Findings = append(result.Findings, cspFindings(page.FinalURL, page.Header, doc)...)
+
+	// Check that third-party scripts and styles are pinned with Subresource Integrity
+	sriAudit, sriFindings := auditSRI(doc, page.FinalURL)
+	result.Security.SRI = sriAudit
+	result.Findings = append(result.Findings, sriFindings...)
+
+	// Split the links and resources into first and third party
+	measurePartySplit(result, doc, targetURL)
+
+	// Fingerprint the software the page is built and served with
+	result.Technologies = detectTechnologies(doc, page.Header)
+
+	// Check the viewport, font sizes, and tap targets a phone shows the page with
+	mobileAudit, mobileIssues := auditMobile(doc, targetURL)
+	result.Mobile = mobileAudit
+	result.Findings = append(result.Findings, mobileIssues...)
+}
+
+// fetchedPage is a fetched webpage; Doc is only set for successful HTML responses
+type fetchedPage struct {
+	StatusCode int
+	Header     http.Header
+	FinalURL   string
+	Protocol   string // Protocol the response came over, e.g. HTTP/2.0
+	Size       int64  // Bytes of the HTML document
+	Body       []byte // HTML document, unless it is larger than a snapshot may be
+	Hash       string // Hex SHA-256 of the HTML document
+	Bot        string // Vendor of the bot challenge served instead of the page
+	Doc        *html.Node
+}
+
+// fetchPage downloads and parses a webpage within the configured crawl timeout
+// Non-200 responses are returned without a document rather than as an error
+func (c *CrawlerService) fetchPage(ctx context.Context, targetURL string, settings models.Settings, config models.CrawlConfig, tracker *throttleTracker) (*fetchedPage, error) {
+	// Bound the page fetch by the configured crawl timeout, unless the URL overrides it
+	timeout := time.Duration(settings.CrawlTimeoutSeconds) * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, http.MethodGet, targetURL, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	if config.UserAgent != "" {
+		req.Header.Set("User-Agent", config.UserAgent)
+	}
+
+	resp, err := c.doWithBackoff(c.client, req, tracker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	page := &fetchedPage{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		FinalURL:   resp.Request.URL.String(),
+		Protocol:   resp.Proto,
+	}
+	head := &headBuffer{limit: botChallengeBodyLimit}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(head, io.LimitReader(resp.Body, botChallengeBodyLimit))
+		page.Bot = detectBotChallenge(resp.StatusCode, resp.Header, head.data)
+		return page, nil
+	}
+
+	// Parse the HTML document, measuring its size and keeping its start for bot challenge detection
+	snapshot := &headBuffer{limit: maxSnapshotBytes}
+	hash := sha256.New()
+	body := &countingReader{reader: io.TeeReader(resp.Body, io.MultiWriter(head, snapshot, hash))}
+	page.Doc, err = html.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	page.Size = body.n
+	page.Hash = hex.EncodeToString(hash.Sum(nil))
+	if page.Size <= maxSnapshotBytes {
+		page.Body = snapshot.data
+	}
+	page.Bot = detectBotChallenge(resp.StatusCode, resp.Header, head.data)
+
+	return page, nil
+}
+
+// do sends a request and records its latency and outcome in the per-host metrics
+func (c *CrawlerService) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	c.metrics.Record(req.URL.String(), time.Since(start), statusCode, err)
+
+	return resp, err
+}
+
 // extractTitle extracts the page title from the HTML document
 func (c *CrawlerService) extractTitle(doc *html.Node, result *models.CrawlResult) {
-	var findTitle func(*html.Node) string
-	findTitle = func(n *html.Node) string {
-		if n.Type == html.ElementNode && n.Data == "title" {
-			if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
-				return strings.TrimSpace(n.FirstChild.Data)
-			}
+	result.Title = findTitle(doc)
+}
+
+// findTitle returns the text of the first title element in the document
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "title" {
+		if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+			return strings.TrimSpace(n.FirstChild.Data)
 		}
-		for child := n.FirstChild; child != nil; child = child.NextSibling {
-			if title := findTitle(child); title != "" {
-				return title
-			}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if title := findTitle(child); title != "" {
+			return title
 		}
-		return ""
 	}
-	result.Title = findTitle(doc)
+	return ""
 }
 
 // Extract HTML version (simple detection)
@@ -181,8 +632,8 @@ func (c *CrawlerService) extractHeadingCounts(doc *html.Node, result *models.Cra
 	traverse(doc)
 }
 
-// Extract all links and categorize them
-func (c *CrawlerService) extractLinks(doc *html.Node, result *models.CrawlResult, baseURL string) {
+// Extract all links and categorize them by the scope of the crawl
+func (c *CrawlerService) extractLinks(doc *html.Node, result *models.CrawlResult, baseURL string, scope linkScope) {
 	var links []models.Link
 	parsedBaseURL, _ := url.Parse(baseURL)
 
@@ -196,15 +647,16 @@ func (c *CrawlerService) extractLinks(doc *html.Node, result *models.CrawlResult
 						continue
 					}
 
-					// Resolve relative URLs
+					// Resolve relative URLs, comparing internationalized hosts in their punycode form
 					absoluteURL := parsedBaseURL.ResolveReference(linkURL)
+					absoluteURL.Host = asciiHost(absoluteURL.Host)
 
 					link := models.Link{
 						URL: absoluteURL.String(),
 					}
 
 					// Determine if internal or external
-					if absoluteURL.Host == parsedBaseURL.Host || absoluteURL.Host == "" {
+					if scope.internal(absoluteURL) {
 						link.Type = "internal"
 						result.InternalLinks++
 					} else {
@@ -262,40 +714,213 @@ func (c *CrawlerService) checkLoginForm(doc *html.Node, result *models.CrawlResu
 }
 
 // Check accessibility of links (finds broken links)
-func (c *CrawlerService) checkLinkAccessibility(result *models.CrawlResult) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+// Links are checked in parallel, bounded by the configured link check concurrency
+func (c *CrawlerService) checkLinkAccessibility(ctx context.Context, result *models.CrawlResult, settings models.Settings, tracker *throttleTracker, progress *crawlProgress) {
+	c.checkLinks(ctx, result.Links, settings, tracker, progress)
 
+	// Throttled links are not counted as broken since their real status is unknown
 	inaccessibleCount := 0
+	permanentRedirects := 0
+	for _, link := range result.Links {
+		if !link.IsAccessible && !link.Throttled {
+			inaccessibleCount++
+		}
+		if link.PermanentRedirect {
+			permanentRedirects++
+		}
+	}
 
-	for i := range result.Links {
-		link := &result.Links[i]
+	result.InaccessibleLinks = inaccessibleCount
+	result.PermanentRedirects = permanentRedirects
+}
 
-		// Skip checking very long URLs or non-HTTP schemes
-		if len(link.URL) > 2000 || (!strings.HasPrefix(link.URL, "http://") && !strings.HasPrefix(link.URL, "https://")) {
-			link.StatusCode = 0
-			link.IsAccessible = false
-			inaccessibleCount++
-			continue
+// checkLinks checks the given links in parallel, bounded by the configured link check concurrency
+// With a link check rate, checks are started no faster than that; they wait instead of failing
+func (c *CrawlerService) checkLinks(ctx context.Context, links []models.Link, settings models.Settings, tracker *throttleTracker, progress *crawlProgress) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	progress.addLinks(len(links))
+
+	for w := 0; w < settings.LinkCheckConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				c.checkLink(ctx, settings, tracker, &links[i])
+				progress.linkChecked()
+			}
+		}()
+	}
+
+	var pace <-chan time.Time
+	if settings.LinkChecksPerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(settings.LinkChecksPerSecond))
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+	// A stopped crawl leaves the remaining links unchecked
+feed:
+	for i := range links {
+		if pace != nil && i > 0 {
+			select {
+			case <-pace:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// checkLink determines the status code and accessibility of a single link
+// It tries a cheap HEAD request first and falls back to a ranged GET, since many servers reject HEAD
+// Internal links are checked with the ranged GET alone, whose first bytes are looked at for soft 404s
+func (c *CrawlerService) checkLink(ctx context.Context, settings models.Settings, tracker *throttleTracker, link *models.Link) {
+	// Skip checking very long URLs or non-HTTP schemes
+	if len(link.URL) > 2000 || (!strings.HasPrefix(link.URL, "http://") && !strings.HasPrefix(link.URL, "https://")) {
+		link.StatusCode = 0
+		link.IsAccessible = false
+		return
+	}
+
+	// Make HEAD request to check if link is accessible, or the ranged GET for internal links
+	method := http.MethodHead
+	if link.Type == "internal" {
+		method = http.MethodGet
+	}
+	probe, err := c.probeLink(ctx, method, link.URL, settings, tracker)
+	link.CheckMethod = method
+
+	// Retry with GET when HEAD failed or was rejected; throttled responses are already retried
+	if method == http.MethodHead && (err != nil || (probe.finalStatus >= 400 && !isThrottleStatus(probe.finalStatus))) {
+		if getProbe, getErr := c.probeLink(ctx, http.MethodGet, link.URL, settings, tracker); getErr == nil {
+			probe, err = getProbe, nil
+			link.CheckMethod = http.MethodGet
+		}
+	}
+
+	if err != nil {
+		link.StatusCode = 0
+		link.IsAccessible = false
+		return
+	}
+
+	link.StatusCode = probe.finalStatus
+	link.InitialStatusCode = probe.initialStatus
+	link.FinalURL = probe.finalURL
+	link.RedirectCount = probe.redirects
+	// Only a redirect to a working page can be fixed by updating the link; one ending in an error is
+	// reported as the broken link it is
+	link.PermanentRedirect = probe.redirects > 0 &&
+		(probe.initialStatus == http.StatusMovedPermanently || probe.initialStatus == http.StatusPermanentRedirect) &&
+		probe.finalStatus >= 200 && probe.finalStatus < 300
+
+	// 416 only means our Range header didn't fit the resource, which itself exists
+	link.IsAccessible = probe.finalStatus < 400 || probe.finalStatus == http.StatusRequestedRangeNotSatisfiable
+	link.Throttled = isThrottleStatus(probe.finalStatus)
+
+	if probe.finalStatus == http.StatusOK && len(probe.body) > 0 && link.Type == "internal" {
+		if signals := detectSoft404Snippet(probe.body); signals.IsSoft404() {
+			link.Soft404 = true
+			link.Soft404Reasons = signals.Reasons()
 		}
+	}
+}
+
+// linkProbe is the outcome of requesting a link and following its redirects
+type linkProbe struct {
+	initialStatus int
+	finalStatus   int
+	finalURL      string
+	redirects     int
+	body          []byte // First bytes of the final response body, GET only
+}
 
-		// Make HEAD request to check if link is accessible
-		resp, err := client.Head(link.URL)
+// probeLink requests a link with the given method, following up to maxLinkRedirects redirects
+// Every hop is bounded by the link check timeout; GET requests only ask for and read the first bytes of the body
+func (c *CrawlerService) probeLink(ctx context.Context, method, linkURL string, settings models.Settings, tracker *throttleTracker) (linkProbe, error) {
+	probe := linkProbe{finalURL: linkURL}
+	visited := map[string]bool{linkURL: true}
+
+	for {
+		resp, body, err := c.probeHop(ctx, method, probe.finalURL, settings, tracker)
 		if err != nil {
-			link.StatusCode = 0
-			link.IsAccessible = false
-			inaccessibleCount++
-			continue
+			return probe, err
+		}
+		if method == http.MethodGet {
+			probe.body = body
 		}
 
-		link.StatusCode = resp.StatusCode
-		link.IsAccessible = resp.StatusCode < 400
+		if probe.redirects == 0 {
+			probe.initialStatus = resp.StatusCode
+		}
+		probe.finalStatus = resp.StatusCode
 
-		if !link.IsAccessible {
-			inaccessibleCount++
+		location, err := resp.Location()
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || err != nil {
+			return probe, nil
+		}
+		if probe.redirects >= maxLinkRedirects {
+			return probe, fmt.Errorf("stopped after %d redirects", maxLinkRedirects)
+		}
+
+		probe.redirects++
+		probe.finalURL = location.String()
+
+		// Redirects leaving HTTP or coming back to an earlier URL can't succeed however often they're followed
+		if location.Scheme != "http" && location.Scheme != "https" {
+			return probe, fmt.Errorf("redirected to unsupported scheme %q", location.Scheme)
+		}
+		if visited[probe.finalURL] {
+			return probe, fmt.Errorf("redirect loop at %s", probe.finalURL)
+		}
+		visited[probe.finalURL] = true
+
+		// 303 See Other switches to GET like browsers do
+		if resp.StatusCode == http.StatusSeeOther {
+			method = http.MethodGet
 		}
 	}
+}
 
-	result.InaccessibleLinks = inaccessibleCount
+// probeHop sends one request of a link check without following redirects
+// It returns the response with its body already read and closed, so the request context can end with it
+func (c *CrawlerService) probeHop(ctx context.Context, method, targetURL string, settings models.Settings, tracker *throttleTracker) (*http.Response, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(settings.LinkCheckTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, method, targetURL, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", linkProbeBodyLimit-1))
+	}
+
+	resp, err := c.doWithBackoff(c.noFollow, req, tracker)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// Servers that ignore Range would otherwise stream the whole body
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, linkProbeBodyLimit))
+	return resp, body, nil
+}
+
+// newCrawlRequest builds a request carrying the configured crawler headers
+func newCrawlRequest(ctx context.Context, method, targetURL string, settings models.Settings) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", crawlerUserAgent(settings))
+	req.Header.Set("Accept", settings.AcceptHeader)
+	return req, nil
 }
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	egressIPTimeout = 10 * time.Second
+	egressIPMaxAge  = 5 * time.Minute // Egress IPs rarely change, so the checker isn't asked on every request
+)
+
+// crawlerUserAgent returns the configured User-Agent with the crawler info URL, if one is set
+// The URL goes inside the trailing comment as crawlers customarily do, e.g. "Bot/1.0 (compatible; +https://...)"
+func crawlerUserAgent(settings models.Settings) string {
+	userAgent := settings.UserAgent
+	if settings.CrawlerInfoURL == "" || strings.Contains(userAgent, settings.CrawlerInfoURL) {
+		return userAgent
+	}
+	if strings.HasSuffix(userAgent, ")") {
+		return strings.TrimSuffix(userAgent, ")") + "; +" + settings.CrawlerInfoURL + ")"
+	}
+	return userAgent + " (+" + settings.CrawlerInfoURL + ")"
+}
+
+// CrawlerIdentity tells site owners how to recognize the crawler, so they can allow it through firewalls and bot protection
+type CrawlerIdentity struct {
+	UserAgent         string     `json:"user_agent"` // Sent with every crawl unless a URL overrides it
+	AcceptHeader      string     `json:"accept_header"`
+	InfoURL           string     `json:"info_url,omitempty"`
+	EgressIP          string     `json:"egress_ip,omitempty"`
+	EgressIPCheckedAt *time.Time `json:"egress_ip_checked_at,omitempty"`
+	EgressIPError     string     `json:"egress_ip_error,omitempty"` // Why the egress IP is unknown
+}
+
+// CrawlerIdentityService reports the crawler's identity, asking an external checker for the egress IP
+type CrawlerIdentityService struct {
+	settings *SettingsService
+	client   *http.Client
+	checkURL string
+
+	mu        sync.Mutex
+	egressIP  string
+	checkedAt time.Time
+}
+
+// NewCrawlerIdentityService creates an identity service
+// The checker is asked through the crawler's transport, so the answer is the address crawled sites see
+func NewCrawlerIdentityService(settings *SettingsService, transport *HTTPTransport, checkURL string) *CrawlerIdentityService {
+	return &CrawlerIdentityService{
+		settings: settings,
+		client:   &http.Client{Transport: transport, Timeout: egressIPTimeout},
+		checkURL: checkURL,
+	}
+}
+
+// Identity returns the User-Agent and the egress IP of the crawler
+func (s *CrawlerIdentityService) Identity() CrawlerIdentity {
+	settings := s.settings.Get()
+	identity := CrawlerIdentity{
+		UserAgent:    crawlerUserAgent(settings),
+		AcceptHeader: settings.AcceptHeader,
+		InfoURL:      settings.CrawlerInfoURL,
+	}
+
+	ip, checkedAt, err := s.egress()
+	if err != nil {
+		identity.EgressIPError = err.Error()
+		return identity
+	}
+	identity.EgressIP = ip
+	identity.EgressIPCheckedAt = &checkedAt
+	return identity
+}
+
+// egress returns the cached egress IP, asking the checker when it is missing or stale
+func (s *CrawlerIdentityService) egress() (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.egressIP != "" && time.Since(s.checkedAt) < egressIPMaxAge {
+		return s.egressIP, s.checkedAt, nil
+	}
+	if s.checkURL == "" {
+		return "", time.Time{}, fmt.Errorf("no egress IP checker is configured")
+	}
+
+	ip, err := s.check()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	s.egressIP, s.checkedAt = ip, time.Now().UTC()
+	return s.egressIP, s.checkedAt, nil
+}
+
+// check asks the checker for the address the request came from; it must answer with the bare IP
+func (s *CrawlerIdentityService) check() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), egressIPTimeout)
+	defer cancel()
+	req, err := newCrawlRequest(ctx, http.MethodGet, s.checkURL, s.settings.Get())
+	if err != nil {
+		return "", fmt.Errorf("failed to build egress IP request: %v", err)
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("egress IP checker is unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("egress IP checker answered %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read egress IP: %v", err)
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return "", fmt.Errorf("egress IP checker answered no IP address")
+	}
+	return ip.String(), nil
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// maxCSPBlockedFindings caps the external resources reported as blocked per page
+const maxCSPBlockedFindings = 50
+
+// cspPolicy maps directive names to their source lists; the first occurrence of a directive wins
+type cspPolicy map[string][]string
+
+// parseCSP parses every enforced policy of a page from its headers and <meta http-equiv> tags
+func parseCSP(header http.Header, doc *html.Node) []cspPolicy {
+	var raw []string
+	for _, value := range header.Values("Content-Security-Policy") {
+		// A comma separates several policies combined into one header
+		raw = append(raw, strings.Split(value, ",")...)
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(attrValue(n, "http-equiv"), "content-security-policy") {
+			raw = append(raw, attrValue(n, "content"))
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	var policies []cspPolicy
+	for _, text := range raw {
+		policy := make(cspPolicy)
+		for _, directive := range strings.Split(text, ";") {
+			tokens := strings.Fields(directive)
+			if len(tokens) == 0 {
+				continue
+			}
+			name := strings.ToLower(tokens[0])
+			if _, seen := policy[name]; !seen {
+				policy[name] = tokens[1:]
+			}
+		}
+		if len(policy) > 0 {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// sources returns the source list that governs a fetch, following the directive fallback chain
+func (p cspPolicy) sources(chain ...string) (string, []string, bool) {
+	for _, directive := range chain {
+		if sources, ok := p[directive]; ok {
+			return directive, sources, true
+		}
+	}
+	return "", nil, false
+}
+
+// Directive fallback chains, most specific first
+var (
+	cspScriptElem = []string{"script-src-elem", "script-src", "default-src"}
+	cspScriptAttr = []string{"script-src-attr", "script-src", "default-src"}
+	cspStyleElem  = []string{"style-src-elem", "style-src", "default-src"}
+	cspStyleAttr  = []string{"style-src-attr", "style-src", "default-src"}
+	cspFrame      = []string{"frame-src", "child-src", "default-src"}
+)
+
+// cspResource is something on the page a policy may block
+type cspResource struct {
+	kind   string // script, style, frame, inline-script, inline-style, style-attribute, event-handler
+	url    *url.URL
+	body   string // Content of inline code, used for hash sources
+	nonce  string
+	chain  []string
+	inline bool
+	attr   bool
+}
+
+// cspFindings simulates the page's Content Security Policy against its scripts, styles, and frames
+// It reports resources the policy would block and directives that allow more than they should
+func cspFindings(pageURL string, header http.Header, doc *html.Node) []models.Finding {
+	policies := parseCSP(header, doc)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	self, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	// Inline code is reported once per kind and directive, in the order it was first seen
+	type inlineKey struct{ kind, directive string }
+	inlineBlocked := make(map[inlineKey]int)
+	var inlineOrder []inlineKey
+
+	var findings []models.Finding
+	blockedExternal := 0
+
+	for _, resource := range collectCSPResources(doc, self) {
+		for _, policy := range policies {
+			directive, sources, ok := policy.sources(resource.chain...)
+			if !ok || cspAllows(sources, resource, self) {
+				continue
+			}
+
+			if resource.inline {
+				key := inlineKey{resource.kind, directive}
+				if inlineBlocked[key] == 0 {
+					inlineOrder = append(inlineOrder, key)
+				}
+				inlineBlocked[key]++
+			} else if blockedExternal < maxCSPBlockedFindings {
+				blockedExternal++
+				findings = append(findings, models.Finding{
+					Type:     models.FindingCSPBlocked,
+					Category: models.CategorySecurity,
+					Severity: models.SeverityWarning,
+					URL:      pageURL,
+					Message:  fmt.Sprintf("%s %s would be blocked by %s", resource.kind, resource.url, directive),
+					Details: map[string]interface{}{
+						"kind":      resource.kind,
+						"resource":  resource.url.String(),
+						"directive": directive,
+					},
+				})
+			}
+			break
+		}
+	}
+
+	for _, key := range inlineOrder {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingCSPBlocked,
+			Category: models.CategorySecurity,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("%d %s(s) would be blocked by %s", inlineBlocked[key], key.kind, key.directive),
+			Details: map[string]interface{}{
+				"kind":      key.kind,
+				"count":     inlineBlocked[key],
+				"directive": key.directive,
+			},
+		})
+	}
+
+	for _, policy := range policies {
+		findings = append(findings, cspPermissiveFindings(pageURL, policy)...)
+	}
+
+	return findings
+}
+
+// collectCSPResources lists the scripts, styles, frames, and inline code of a page
+func collectCSPResources(doc *html.Node, base *url.URL) []cspResource {
+	var resources []cspResource
+
+	external := func(kind, value, nonce string, chain []string) {
+		if resolved, err := base.Parse(value); err == nil {
+			resources = append(resources, cspResource{kind: kind, url: resolved, nonce: nonce, chain: chain})
+		}
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			nonce := attrValue(n, "nonce")
+
+			switch n.Data {
+			case "script":
+				if src := attrValue(n, "src"); src != "" {
+					external("script", src, nonce, cspScriptElem)
+				} else if isExecutableScript(attrValue(n, "type")) {
+					resources = append(resources, cspResource{kind: "inline-script", body: nodeText(n), nonce: nonce, chain: cspScriptElem, inline: true})
+				}
+			case "style":
+				resources = append(resources, cspResource{kind: "inline-style", body: nodeText(n), nonce: nonce, chain: cspStyleElem, inline: true})
+			case "link":
+				if href := attrValue(n, "href"); href != "" && containsString(strings.Fields(strings.ToLower(attrValue(n, "rel"))), "stylesheet") {
+					external("style", href, nonce, cspStyleElem)
+				}
+			case "iframe", "frame":
+				if src := attrValue(n, "src"); src != "" {
+					external("frame", src, "", cspFrame)
+				}
+			}
+
+			for _, attr := range n.Attr {
+				switch {
+				case attr.Key == "style" && attr.Val != "":
+					resources = append(resources, cspResource{kind: "style-attribute", body: attr.Val, chain: cspStyleAttr, inline: true, attr: true})
+				case strings.HasPrefix(attr.Key, "on") && attr.Val != "":
+					resources = append(resources, cspResource{kind: "event-handler", body: attr.Val, chain: cspScriptAttr, inline: true, attr: true})
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	return resources
+}
+
+// nodeText returns the raw text content of an element
+func nodeText(n *html.Node) string {
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			builder.WriteString(child.Data)
+		}
+	}
+	return builder.String()
+}
+
+// cspAllows reports whether a source list permits the resource
+func cspAllows(sources []string, resource cspResource, self *url.URL) bool {
+	hasNonceOrHash, strictDynamic, unsafeInline, unsafeHashes := false, false, false, false
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		switch {
+		case strings.HasPrefix(lower, "'nonce-"), strings.HasPrefix(lower, "'sha256-"), strings.HasPrefix(lower, "'sha384-"), strings.HasPrefix(lower, "'sha512-"):
+			hasNonceOrHash = true
+		case lower == "'strict-dynamic'":
+			strictDynamic = true
+		case lower == "'unsafe-inline'":
+			unsafeInline = true
+		case lower == "'unsafe-hashes'":
+			unsafeHashes = true
+		}
+	}
+	isScript := strings.HasPrefix(resource.chain[0], "script")
+
+	// Nonces apply to elements, hashes to inline content (attributes only with 'unsafe-hashes')
+	if resource.nonce != "" && !resource.attr && containsString(sources, "'nonce-"+resource.nonce+"'") {
+		return true
+	}
+	if resource.inline && (!resource.attr || unsafeHashes) && cspHashMatches(sources, resource.body) {
+		return true
+	}
+
+	if resource.inline {
+		// 'unsafe-inline' is ignored when the list has a nonce or hash, and for scripts under 'strict-dynamic'
+		return unsafeInline && !hasNonceOrHash && !(isScript && strictDynamic)
+	}
+
+	// 'strict-dynamic' ignores host and scheme sources for scripts
+	if isScript && strictDynamic {
+		return false
+	}
+
+	for _, source := range sources {
+		if cspSourceMatches(source, resource.url, self) {
+			return true
+		}
+	}
+	return false
+}
+
+// cspHashMatches reports whether a hash source matches the inline content
+func cspHashMatches(sources []string, body string) bool {
+	sum256 := sha256.Sum256([]byte(body))
+	sum384 := sha512.Sum384([]byte(body))
+	sum512 := sha512.Sum512([]byte(body))
+	hashes := []string{
+		"'sha256-" + base64.StdEncoding.EncodeToString(sum256[:]) + "'",
+		"'sha384-" + base64.StdEncoding.EncodeToString(sum384[:]) + "'",
+		"'sha512-" + base64.StdEncoding.EncodeToString(sum512[:]) + "'",
+	}
+	for _, hash := range hashes {
+		if containsString(sources, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// cspSourceMatches matches a URL against a single source expression
+func cspSourceMatches(source string, target, self *url.URL) bool {
+	lower := strings.ToLower(source)
+	scheme := strings.ToLower(target.Scheme)
+
+	switch {
+	case lower == "*":
+		// The wildcard does not cover data:, blob: and filesystem: URLs
+		return scheme == "http" || scheme == "https" || scheme == self.Scheme
+	case lower == "'self'":
+		return cspSchemeMatches(self.Scheme, scheme) && strings.EqualFold(target.Host, self.Host)
+	case strings.HasPrefix(lower, "'"):
+		return false // Keywords, nonces, and hashes don't match URLs
+	case strings.HasSuffix(lower, ":") && !strings.Contains(lower, "/"):
+		return cspSchemeMatches(strings.TrimSuffix(lower, ":"), scheme)
+	}
+
+	// Host source: [scheme://]host[:port][/path]
+	sourceScheme := ""
+	rest := lower
+	if i := strings.Index(rest, "://"); i >= 0 {
+		sourceScheme, rest = rest[:i], rest[i+3:]
+	}
+	path := ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest, path = rest[:i], rest[i:]
+	}
+	host, port := rest, ""
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		host, port = rest[:i], rest[i+1:]
+	}
+
+	if sourceScheme == "" {
+		sourceScheme = self.Scheme
+	}
+	if !cspSchemeMatches(sourceScheme, scheme) {
+		return false
+	}
+
+	targetHost := strings.ToLower(target.Hostname())
+	if strings.HasPrefix(host, "*.") {
+		if !strings.HasSuffix(targetHost, host[1:]) {
+			return false
+		}
+	} else if host != targetHost {
+		return false
+	}
+
+	if port != "*" {
+		targetPort := target.Port()
+		if targetPort == "" {
+			targetPort = defaultPort(scheme)
+		}
+		if port == "" {
+			port = defaultPort(sourceScheme)
+		}
+		// An http source on the default port also allows https on its default port
+		if port != targetPort && !(port == "80" && targetPort == "443") {
+			return false
+		}
+	}
+
+	if path != "" {
+		if strings.HasSuffix(path, "/") {
+			return strings.HasPrefix(target.Path, path)
+		}
+		return target.Path == path
+	}
+	return true
+}
+
+// cspSchemeMatches reports whether a source scheme allows the target scheme; http also allows https
+func cspSchemeMatches(sourceScheme, targetScheme string) bool {
+	sourceScheme = strings.ToLower(sourceScheme)
+	return sourceScheme == targetScheme || (sourceScheme == "http" && targetScheme == "https")
+}
+
+// defaultPort returns the default port of a scheme
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// cspPermissiveFindings reports directives that weaken a policy
+func cspPermissiveFindings(pageURL string, policy cspPolicy) []models.Finding {
+	var findings []models.Finding
+	permissive := func(severity, directive, source, reason string) {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingCSPPermissive,
+			Category: models.CategorySecurity,
+			Severity: severity,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("%s allows %s: %s", directive, source, reason),
+			Details: map[string]interface{}{
+				"directive": directive,
+				"source":    source,
+			},
+		})
+	}
+
+	scriptDirective, scriptSources, ok := policy.sources("script-src", "default-src")
+	if !ok {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingCSPPermissive,
+			Category: models.CategorySecurity,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  "Policy sets neither script-src nor default-src, so scripts are not restricted",
+		})
+	} else {
+		hasNonceOrHash := false
+		for _, source := range scriptSources {
+			lower := strings.ToLower(source)
+			if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha") {
+				hasNonceOrHash = true
+			}
+		}
+		for _, source := range scriptSources {
+			switch strings.ToLower(source) {
+			case "'unsafe-inline'":
+				if !hasNonceOrHash {
+					permissive(models.SeverityWarning, scriptDirective, source, "inline scripts and event handlers can run")
+				}
+			case "'unsafe-eval'":
+				permissive(models.SeverityWarning, scriptDirective, source, "strings can be evaluated as code")
+			case "*", "http:", "https:", "data:":
+				permissive(models.SeverityWarning, scriptDirective, source, "scripts can be loaded from anywhere")
+			}
+		}
+	}
+
+	if styleDirective, styleSources, ok := policy.sources("style-src", "default-src"); ok {
+		for _, source := range styleSources {
+			if strings.ToLower(source) == "'unsafe-inline'" {
+				permissive(models.SeverityInfo, styleDirective, source, "inline styles are allowed")
+			}
+		}
+	}
+
+	if objectDirective, objectSources, ok := policy.sources("object-src", "default-src"); ok {
+		for _, source := range objectSources {
+			if source == "*" {
+				permissive(models.SeverityWarning, objectDirective, source, "plugins can be loaded from anywhere")
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxCustomRules          = 50   // Rules per project
+	maxCustomRuleName       = 100  // Characters per name
+	maxCustomRuleExpression = 2000 // Characters per expression
+	maxCustomRuleMessage    = 500  // Characters per message
+)
+
+// customRuleCategories are the finding categories a custom rule may report under
+var customRuleCategories = []string{models.CategoryContent, models.CategoryPerformance, models.CategorySecurity, models.CategoryNetwork}
+
+// ValidateCustomRules checks a project's custom rules and that their expressions parse
+func ValidateCustomRules(rules []models.CustomRule) error {
+	if len(rules) > maxCustomRules {
+		return fmt.Errorf("at most %d custom rules are allowed", maxCustomRules)
+	}
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" || utf8.RuneCountInString(rule.Name) > maxCustomRuleName {
+			return fmt.Errorf("rule names must be between 1 and %d characters", maxCustomRuleName)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Expression == "" || len(rule.Expression) > maxCustomRuleExpression {
+			return fmt.Errorf("rule %q: expression must be between 1 and %d characters", rule.Name, maxCustomRuleExpression)
+		}
+		if _, err := parseRule(rule.Expression); err != nil {
+			return fmt.Errorf("rule %q: %v", rule.Name, err)
+		}
+		if rule.Severity != "" && !ValidSeverity(rule.Severity) {
+			return fmt.Errorf("rule %q: severity must be one of info, warning, error", rule.Name)
+		}
+		if rule.Category != "" && !containsString(customRuleCategories, rule.Category) {
+			return fmt.Errorf("rule %q: category must be one of %s", rule.Name, strings.Join(customRuleCategories, ", "))
+		}
+		if utf8.RuneCountInString(rule.Message) > maxCustomRuleMessage {
+			return fmt.Errorf("rule %q: message must be at most %d characters", rule.Name, maxCustomRuleMessage)
+		}
+	}
+	return nil
+}
+
+// customRuleFindings evaluates the project's custom rules over the crawl result of pageURL
+// The rules see the result as its JSON, with the findings reported so far, as the variable result,
+// and the crawled URL as url; a rule that evaluates to false or fails to evaluate gets a finding
+func customRuleFindings(project *models.Project, pageURL string, result *models.CrawlResult) []models.Finding {
+	if project == nil || len(project.CustomRules) == 0 {
+		return nil
+	}
+	vars, err := customRuleVars(pageURL, result)
+	if err != nil {
+		log.Printf("Failed to prepare custom rules for %s: %v", pageURL, err)
+		return nil
+	}
+
+	var findings []models.Finding
+	for _, rule := range project.CustomRules {
+		passed, err := evalCustomRule(rule, vars)
+		if err == nil && passed {
+			continue
+		}
+
+		finding := models.Finding{
+			Type:     models.FindingCustomRule,
+			Category: rule.Category,
+			Severity: rule.Severity,
+			URL:      pageURL,
+			Message:  rule.Message,
+			Details: map[string]interface{}{
+				"rule":       rule.Name,
+				"expression": rule.Expression,
+			},
+		}
+		if finding.Category == "" {
+			finding.Category = models.CategoryContent
+		}
+		if finding.Severity == "" {
+			finding.Severity = models.SeverityWarning
+		}
+		if finding.Message == "" {
+			finding.Message = fmt.Sprintf("Custom rule %q failed", rule.Name)
+		}
+		if err != nil {
+			finding.Type = models.FindingCustomRuleError
+			finding.Message = fmt.Sprintf("Custom rule %q could not be evaluated: %v", rule.Name, err)
+			finding.Details["error"] = err.Error()
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// customRuleVars decodes the crawl result into the generic values expressions work on
+func customRuleVars(pageURL string, result *models.CrawlResult) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": decoded, "url": pageURL}, nil
+}
+
+// evalCustomRule evaluates a rule, which must produce a bool
+func evalCustomRule(rule models.CustomRule, vars map[string]interface{}) (bool, error) {
+	expr, err := parseRule(rule.Expression)
+	if err != nil {
+		return false, err
+	}
+	value, err := evalRule(expr, vars)
+	if err != nil {
+		return false, err
+	}
+	passed, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must evaluate to a bool, not %s", ruleTypeName(value))
+	}
+	return passed, nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// deviceUserAgents are the browsers the device profiles of CrawlConfig.Profiles fetch the page as
+// The crawler has no rendering browser, so a profile is its user agent: sites that serve phones other
+// HTML, redirect them to an m. site or block them show up, layout that only differs in CSS doesn't
+var deviceUserAgents = map[string]string{
+	models.ProfileDesktop: alternateUserAgents[0],
+	models.ProfileMobile:  "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+}
+
+// Fields a profile can differ from the crawl in
+const (
+	ProfileDiffStatusCode      = "status_code"
+	ProfileDiffFinalURL        = "final_url"
+	ProfileDiffTitle           = "title"
+	ProfileDiffMetaDescription = "meta_description"
+	ProfileDiffViewport        = "viewport"
+	ProfileDiffH1Count         = "h1_count"
+	ProfileDiffInternalLinks   = "internal_links"
+	ProfileDiffExternalLinks   = "external_links"
+)
+
+// ProfileComparison is how the page as a device profile got it differs from the crawl
+type ProfileComparison struct {
+	Profile       string               `json:"profile"`
+	Result        models.ProfileResult `json:"result"`
+	Differences   []string             `json:"differences"`
+	OnlyInProfile []models.Finding     `json:"only_in_profile"` // Findings the crawl doesn't have, e.g. mobile-only issues
+	OnlyInCrawl   []models.Finding     `json:"only_in_crawl"`   // Findings of the crawl the profile doesn't have
+}
+
+// validateCrawlProfiles checks the device profiles of a crawl config
+func validateCrawlProfiles(profiles []string) error {
+	seen := make(map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		if _, ok := deviceUserAgents[profile]; !ok || seen[profile] {
+			return fmt.Errorf("profiles must be distinct values of %s, %s", models.ProfileDesktop, models.ProfileMobile)
+		}
+		seen[profile] = true
+	}
+	return nil
+}
+
+// crawlProfiles fetches and analyzes the page as every device profile of the config, recording the crawl's
+// analysis first so they can be compared; result must only hold the output of analyzeDocument so far
+func (c *CrawlerService) crawlProfiles(ctx context.Context, result *models.CrawlResult, doc *html.Node, targetURL string, page *fetchedPage, settings models.Settings, config models.CrawlConfig, project *models.Project, tracker *throttleTracker) {
+	if len(config.Profiles) == 0 {
+		return
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = crawlerUserAgent(settings)
+	}
+	crawl := profileResult(models.ProfileCrawl, userAgent, page, doc, result)
+	result.Profiles = append(result.Profiles, crawl)
+
+	for _, profile := range config.Profiles {
+		profileConfig := config
+		profileConfig.UserAgent = deviceUserAgents[profile]
+		fetched, err := c.fetchPage(ctx, targetURL, settings, profileConfig, tracker)
+		switch {
+		case err != nil:
+			result.Profiles = append(result.Profiles, models.ProfileResult{Profile: profile, UserAgent: profileConfig.UserAgent, Error: err.Error()})
+		case fetched.Doc == nil || fetched.Bot != "":
+			result.Profiles = append(result.Profiles, profileResult(profile, profileConfig.UserAgent, fetched, nil, nil))
+		default:
+			analyzed := &models.CrawlResult{}
+			c.analyzeDocument(analyzed, fetched.Doc, targetURL, fetched, profileConfig, project)
+			result.Profiles = append(result.Profiles, profileResult(profile, profileConfig.UserAgent, fetched, fetched.Doc, analyzed))
+		}
+	}
+}
+
+// profileResult summarizes a fetched page and its analysis; doc and analyzed are nil when it wasn't an HTML page
+func profileResult(profile, userAgent string, page *fetchedPage, doc *html.Node, analyzed *models.CrawlResult) models.ProfileResult {
+	result := models.ProfileResult{
+		Profile:    profile,
+		UserAgent:  userAgent,
+		StatusCode: page.StatusCode,
+		FinalURL:   page.FinalURL,
+		Size:       page.Size,
+		Bot:        page.Bot,
+	}
+	if doc == nil || analyzed == nil {
+		return result
+	}
+	result.Title = analyzed.Title
+	result.MetaDescription = analyzed.MetaDescription
+	result.Viewport = findMetaViewport(doc)
+	result.H1Count = analyzed.H1Count
+	result.InternalLinks = analyzed.InternalLinks
+	result.ExternalLinks = analyzed.ExternalLinks
+	result.Findings = append([]models.Finding(nil), analyzed.Findings...)
+	return result
+}
+
+// CompareProfiles compares every device profile of a crawl result with the crawl itself
+// Findings are matched by FindingTarget, so the same issue on both counts once
+func CompareProfiles(profiles []models.ProfileResult) []ProfileComparison {
+	var crawl *models.ProfileResult
+	for i := range profiles {
+		if profiles[i].Profile == models.ProfileCrawl {
+			crawl = &profiles[i]
+			break
+		}
+	}
+	comparisons := []ProfileComparison{}
+	if crawl == nil {
+		return comparisons
+	}
+
+	crawlTargets := findingTargets(crawl.Findings)
+	for _, profile := range profiles {
+		if profile.Profile == models.ProfileCrawl {
+			continue
+		}
+		comparison := ProfileComparison{
+			Profile:       profile.Profile,
+			Result:        profile,
+			Differences:   profileDifferences(*crawl, profile),
+			OnlyInProfile: []models.Finding{},
+			OnlyInCrawl:   []models.Finding{},
+		}
+		// A profile that didn't get the page has no analysis to compare findings with
+		if profile.Error == "" && profile.StatusCode == crawl.StatusCode && profile.Bot == "" {
+			profileTargets := findingTargets(profile.Findings)
+			for _, finding := range profile.Findings {
+				if !crawlTargets[FindingTarget(finding)] {
+					comparison.OnlyInProfile = append(comparison.OnlyInProfile, finding)
+				}
+			}
+			for _, finding := range crawl.Findings {
+				if !profileTargets[FindingTarget(finding)] {
+					comparison.OnlyInCrawl = append(comparison.OnlyInCrawl, finding)
+				}
+			}
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// profileDifferences lists the fields a profile differs from the crawl in
+func profileDifferences(crawl, profile models.ProfileResult) []string {
+	differences := []string{}
+	if profile.Error != "" || profile.StatusCode != crawl.StatusCode {
+		return append(differences, ProfileDiffStatusCode)
+	}
+	fields := []struct {
+		name    string
+		differs bool
+	}{
+		{ProfileDiffFinalURL, profile.FinalURL != crawl.FinalURL},
+		{ProfileDiffTitle, profile.Title != crawl.Title},
+		{ProfileDiffMetaDescription, profile.MetaDescription != crawl.MetaDescription},
+		{ProfileDiffViewport, profile.Viewport != crawl.Viewport},
+		{ProfileDiffH1Count, profile.H1Count != crawl.H1Count},
+		{ProfileDiffInternalLinks, profile.InternalLinks != crawl.InternalLinks},
+		{ProfileDiffExternalLinks, profile.ExternalLinks != crawl.ExternalLinks},
+	}
+	for _, field := range fields {
+		if field.differs {
+			differences = append(differences, field.name)
+		}
+	}
+	return differences
+}
+
+// findingTargets indexes findings by FindingTarget
+func findingTargets(findings []models.Finding) map[string]bool {
+	targets := make(map[string]bool, len(findings))
+	for _, finding := range findings {
+		targets[FindingTarget(finding)] = true
+	}
+	return targets
+}
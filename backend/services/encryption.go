@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"gorm.io/gorm"
+)
+
+// reencryptBatchSize is the number of snapshots loaded at once while re-encrypting
+const reencryptBatchSize = 50
+
+// EncryptionStatus describes the encryption of sensitive columns
+type EncryptionStatus struct {
+	Enabled          bool     `json:"enabled"`
+	ActiveKey        string   `json:"active_key,omitempty"` // ID of the key new values are encrypted with
+	Keys             []string `json:"keys"`                 // IDs of the keys values can be decrypted with
+	EncryptSnapshots bool     `json:"encrypt_snapshots"`
+}
+
+// ReencryptResult counts the rows rewritten by Reencrypt
+type ReencryptResult struct {
+	Projects    int `json:"projects"`
+	FetchAgents int `json:"fetch_agents"`
+	Snapshots   int `json:"snapshots"`
+}
+
+// EncryptionService reports and rotates the keys encrypting stored credentials and snapshots
+type EncryptionService struct {
+	db        *gorm.DB
+	cipher    *repository.FieldCipher
+	snapshots bool
+}
+
+// NewEncryptionService creates an encryption service; cipher is nil when no keys are configured
+func NewEncryptionService(db *gorm.DB, cipher *repository.FieldCipher, encryptSnapshots bool) *EncryptionService {
+	return &EncryptionService{db: db, cipher: cipher, snapshots: encryptSnapshots}
+}
+
+// Status returns the configured keys, never the key material
+func (s *EncryptionService) Status() EncryptionStatus {
+	if s.cipher == nil {
+		return EncryptionStatus{Keys: []string{}}
+	}
+	return EncryptionStatus{
+		Enabled:          true,
+		ActiveKey:        s.cipher.ActiveKeyID(),
+		Keys:             s.cipher.KeyIDs(),
+		EncryptSnapshots: s.snapshots,
+	}
+}
+
+// Reencrypt rewrites every encrypted column with the active key, so retired keys can be removed afterwards
+// Without keys it writes the values back in plaintext, which must happen while the old keys are still configured
+func (s *EncryptionService) Reencrypt() (ReencryptResult, error) {
+	var result ReencryptResult
+
+	var projects []models.Project
+	if err := s.db.Unscoped().Find(&projects).Error; err != nil {
+		return result, fmt.Errorf("failed to load projects: %v", err)
+	}
+	for i := range projects {
+		if err := s.db.Unscoped().Model(&projects[i]).Select("github_token", "issue_tracker_token", "plugin_secret").Updates(&projects[i]).Error; err != nil {
+			return result, fmt.Errorf("failed to re-encrypt project %d: %v", projects[i].ID, err)
+		}
+		result.Projects++
+	}
+
+	var agents []models.FetchAgent
+	if err := s.db.Find(&agents).Error; err != nil {
+		return result, fmt.Errorf("failed to load fetch agents: %v", err)
+	}
+	for i := range agents {
+		if err := s.db.Model(&agents[i]).Select("token").Updates(&agents[i]).Error; err != nil {
+			return result, fmt.Errorf("failed to re-encrypt fetch agent %d: %v", agents[i].ID, err)
+		}
+		result.FetchAgents++
+	}
+
+	// Only snapshots stored before the bodies were deduplicated keep their HTML themselves
+	var snapshots []models.PageSnapshot
+	err := s.db.Where("body_hash = ''").FindInBatches(&snapshots, reencryptBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range snapshots {
+			if err := s.db.Model(&snapshots[i]).Select("html").Updates(&snapshots[i]).Error; err != nil {
+				return fmt.Errorf("failed to re-encrypt snapshot %d: %v", snapshots[i].ID, err)
+			}
+			result.Snapshots++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return result, err
+	}
+
+	var bodies []models.SnapshotBody
+	err = s.db.FindInBatches(&bodies, reencryptBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range bodies {
+			if err := s.db.Model(&bodies[i]).Select("data").Updates(&bodies[i]).Error; err != nil {
+				return fmt.Errorf("failed to re-encrypt snapshot body %s: %v", bodies[i].Hash, err)
+			}
+			result.Snapshots++
+		}
+		return nil
+	}).Error
+	return result, err
+}
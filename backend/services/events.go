@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	brokerTimeout = 5 * time.Second
+
+	// eventQueueSize bounds the events waiting for the broker; crawls never wait on it, events beyond it are dropped
+	eventQueueSize = 1000
+)
+
+// Stream event names
+const (
+	StreamEventCrawlStarted  = "crawl.started"
+	StreamEventCrawlFinished = "crawl.finished"
+	StreamEventFinding       = "finding"
+)
+
+// EventStreamConfig selects the broker crawl events are published to
+type EventStreamConfig struct {
+	Broker string // nats, kafka (through a Kafka REST proxy); empty disables publishing
+	URL    string // nats://[user:password@]host:4222, or the base URL of the REST proxy
+
+	// Topics (NATS subjects) of the events
+	CrawlStartedTopic  string
+	CrawlFinishedTopic string
+	FindingTopic       string
+}
+
+// StreamFindingData describes a finding of finding events
+type StreamFindingData struct {
+	URLID     uint           `json:"url_id"`
+	URL       string         `json:"url"`
+	ProjectID *uint          `json:"project_id"`
+	Finding   models.Finding `json:"finding"`
+}
+
+// broker publishes messages to a topic of a message broker
+type broker interface {
+	Publish(topic, key string, message []byte) error
+}
+
+type streamMessage struct {
+	topic, key string
+	message    []byte
+}
+
+// EventPublisher publishes crawl lifecycle events and findings to a message broker
+// Messages use the envelope of REST hooks, so consumers of both can share their parsing
+type EventPublisher struct {
+	config  EventStreamConfig
+	broker  broker
+	queue   chan streamMessage
+	dropped atomic.Int64
+}
+
+// NewEventPublisher creates a publisher for the configured broker; it returns nil when publishing is disabled
+func NewEventPublisher(config EventStreamConfig) (*EventPublisher, error) {
+	var b broker
+	switch config.Broker {
+	case "":
+		return nil, nil
+	case "nats":
+		nats, err := newNATSBroker(config.URL)
+		if err != nil {
+			return nil, err
+		}
+		b = nats
+	case "kafka":
+		if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+			return nil, fmt.Errorf("kafka events are published through a REST proxy, its URL must start with http:// or https://")
+		}
+		b = &kafkaRESTBroker{baseURL: strings.TrimRight(config.URL, "/"), client: &http.Client{Timeout: brokerTimeout}}
+	default:
+		return nil, fmt.Errorf("unknown event broker %q, expected nats or kafka", config.Broker)
+	}
+
+	p := &EventPublisher{config: config, broker: b, queue: make(chan streamMessage, eventQueueSize)}
+	go p.run()
+	return p, nil
+}
+
+// CrawlStarted publishes a crawl.started event
+func (p *EventPublisher) CrawlStarted(project *models.Project, url models.URL) {
+	data := HookURLData{URLID: url.ID, URL: url.URL, ProjectID: url.ProjectID, Status: "running"}
+	p.enqueue(p.config.CrawlStartedTopic, url.ID, newHookPayload(StreamEventCrawlStarted, data))
+}
+
+// CrawlFinished publishes a crawl.finished event, followed by a finding event for every finding of the crawl
+func (p *EventPublisher) CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) {
+	p.enqueue(p.config.CrawlFinishedTopic, url.ID, newHookPayload(StreamEventCrawlFinished, newHookURLData(url, result, crawlErr)))
+	if crawlErr != nil {
+		return
+	}
+	for _, finding := range result.Findings {
+		data := StreamFindingData{URLID: url.ID, URL: url.URL, ProjectID: url.ProjectID, Finding: finding}
+		p.enqueue(p.config.FindingTopic, url.ID, newHookPayload(StreamEventFinding, data))
+	}
+}
+
+// enqueue hands an event to the publishing goroutine; events are keyed by URL so a partitioned topic keeps their order
+func (p *EventPublisher) enqueue(topic string, urlID uint, event HookPayload) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Event, err)
+		return
+	}
+
+	select {
+	case p.queue <- streamMessage{topic: topic, key: strconv.FormatUint(uint64(urlID), 10), message: message}:
+	default:
+		if dropped := p.dropped.Add(1); dropped%100 == 1 {
+			log.Printf("Event queue is full, dropped %d event(s) so far", dropped)
+		}
+	}
+}
+
+// run publishes the queued events, retrying each once since the broker connection may have been reset
+func (p *EventPublisher) run() {
+	for m := range p.queue {
+		err := p.broker.Publish(m.topic, m.key, m.message)
+		if err != nil {
+			err = p.broker.Publish(m.topic, m.key, m.message)
+		}
+		if err != nil {
+			log.Printf("Failed to publish event to %s: %v", m.topic, err)
+		}
+	}
+}
+
+// natsBroker publishes with the NATS client protocol; it connects lazily and reconnects after errors
+type natsBroker struct {
+	address string
+	connect []byte // CONNECT line, carrying the credentials of the URL
+
+	mu     sync.Mutex
+	conn   net.Conn
+	writer *bufio.Writer
+}
+
+func newNATSBroker(rawURL string) (*natsBroker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("NATS URL must have the form nats://host:port")
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "sykell-url-analyzer", "lang": "go"}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"], options["pass"] = u.User.Username(), password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+
+	return &natsBroker{address: address, connect: append(append([]byte("CONNECT "), connect...), "\r\n"...)}, nil
+}
+
+func (b *natsBroker) Publish(topic, key string, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		if err := b.dial(); err != nil {
+			return err
+		}
+	}
+	b.conn.SetWriteDeadline(time.Now().Add(brokerTimeout))
+	fmt.Fprintf(b.writer, "PUB %s %d\r\n", topic, len(message))
+	b.writer.Write(message)
+	b.writer.WriteString("\r\n")
+	if err := b.writer.Flush(); err != nil {
+		b.conn.Close()
+		b.conn = nil
+		return fmt.Errorf("NATS publish: %v", err)
+	}
+	return nil
+}
+
+// dial connects and sends CONNECT; it must be called with mu held
+func (b *natsBroker) dial() error {
+	conn, err := net.DialTimeout("tcp", b.address, brokerTimeout)
+	if err != nil {
+		return fmt.Errorf("NATS connect: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO before it accepts CONNECT
+	conn.SetReadDeadline(time.Now().Add(brokerTimeout))
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("NATS connect: no INFO from %s", b.address)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	b.conn = conn
+	b.writer = bufio.NewWriter(conn)
+	b.writer.Write(b.connect)
+	go b.read(conn, reader)
+	return nil
+}
+
+// read answers the server's keep-alive PINGs and drops the connection on errors, so the next publish reconnects
+func (b *natsBroker) read(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			b.mu.Lock()
+			if b.conn == conn {
+				b.writer.WriteString("PONG\r\n")
+				b.writer.Flush()
+			}
+			b.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("NATS server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+
+	b.mu.Lock()
+	if b.conn == conn {
+		b.conn = nil
+	}
+	b.mu.Unlock()
+	conn.Close()
+}
+
+// kafkaRESTBroker produces to Kafka through the v2 API of a Kafka REST proxy
+type kafkaRESTBroker struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (b *kafkaRESTBroker) Publish(topic, key string, message []byte) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": key, "value": json.RawMessage(message)}},
+	})
+	req, err := http.NewRequest(http.MethodPost, b.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
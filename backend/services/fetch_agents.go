@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+// maxCrawlRegions bounds the regions a crawl fetches its page from
+const maxCrawlRegions = 10
+
+var regionPattern = regexp.MustCompile(`^[a-z0-9-]{1,64}$`)
+
+// FetchAgentService keeps the fetch agents crawls can fetch their page through from other regions
+// Agents are plain HTTP forward proxies, e.g. Squid or tinyproxy on a small VM in the region,
+// that accept the username "agent" and the agent's token as the password
+type FetchAgentService struct {
+	db *gorm.DB
+}
+
+// NewFetchAgentService creates a fetch agent service
+func NewFetchAgentService(db *gorm.DB) *FetchAgentService {
+	return &FetchAgentService{db: db}
+}
+
+// ValidateFetchAgent checks the region and proxy URL of an agent
+func ValidateFetchAgent(agent models.FetchAgent) error {
+	if !regionPattern.MatchString(agent.Region) {
+		return fmt.Errorf("region must be 1 to 64 lowercase letters, digits or -")
+	}
+	proxy, err := url.Parse(agent.ProxyURL)
+	if err != nil || (proxy.Scheme != "http" && proxy.Scheme != "https") || proxy.Host == "" || proxy.User != nil {
+		return fmt.Errorf("proxy_url must be an absolute http or https URL without credentials")
+	}
+	if len(agent.ProxyURL) > 2048 || len(agent.Token) > 512 {
+		return fmt.Errorf("proxy_url must be at most 2048 and token at most 512 characters")
+	}
+	return nil
+}
+
+// validateCrawlRegions checks the regions of a crawl config; whether agents exist for them is checked when crawling
+func validateCrawlRegions(regions []string) error {
+	if len(regions) > maxCrawlRegions {
+		return fmt.Errorf("at most %d regions are allowed", maxCrawlRegions)
+	}
+	for _, region := range regions {
+		if !regionPattern.MatchString(region) {
+			return fmt.Errorf("regions must be 1 to 64 lowercase letters, digits or -")
+		}
+	}
+	return nil
+}
+
+// List returns every agent, ordered by region
+func (s *FetchAgentService) List() ([]models.FetchAgent, error) {
+	var agents []models.FetchAgent
+	if err := s.db.Order("region").Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list fetch agents: %v", err)
+	}
+	return agents, nil
+}
+
+// Register creates the agent of its region, or replaces the proxy URL and token of the existing one
+// The agent must have been validated
+func (s *FetchAgentService) Register(agent *models.FetchAgent) error {
+	var existing models.FetchAgent
+	err := s.db.Where("region = ?", agent.Region).First(&existing).Error
+	switch {
+	case err == nil:
+		agent.ID = existing.ID
+		agent.CreatedAt = existing.CreatedAt
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to look up fetch agent: %v", err)
+	}
+	if err := s.db.Save(agent).Error; err != nil {
+		return fmt.Errorf("failed to save fetch agent: %v", err)
+	}
+	agent.TokenSet = agent.Token != ""
+	return nil
+}
+
+// Delete removes an agent
+func (s *FetchAgentService) Delete(id uint) error {
+	result := s.db.Delete(&models.FetchAgent{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete fetch agent %d: %v", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// byRegion loads the agents of the given regions
+func (s *FetchAgentService) byRegion(regions []string) (map[string]models.FetchAgent, error) {
+	var agents []models.FetchAgent
+	if err := s.db.Where("region IN ?", regions).Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("failed to load fetch agents: %v", err)
+	}
+	byRegion := make(map[string]models.FetchAgent, len(agents))
+	for _, agent := range agents {
+		byRegion[agent.Region] = agent
+	}
+	return byRegion, nil
+}
+
+// UseFetchAgents lets crawls fetch their page through the agents of CrawlConfig.Regions
+// It must be called before crawls start
+func (c *CrawlerService) UseFetchAgents(agents *FetchAgentService) {
+	c.agents = agents
+}
+
+// compareRegions fetches the page through the agent of every region of the config and compares what the
+// agents got with the page the crawl analyzed, reporting pages the regions can't get or get differently
+func (c *CrawlerService) compareRegions(ctx context.Context, result *models.CrawlResult, page *fetchedPage, targetURL string, settings models.Settings, config models.CrawlConfig) {
+	if len(config.Regions) == 0 || c.agents == nil {
+		return
+	}
+	agents, err := c.agents.byRegion(config.Regions)
+	if err != nil {
+		log.Printf("Failed to compare %s across regions: %v", targetURL, err)
+		return
+	}
+
+	contentHash := ""
+	if page.Body != nil {
+		sum := sha256.Sum256(page.Body)
+		contentHash = hex.EncodeToString(sum[:])
+	}
+	for _, region := range config.Regions {
+		agent, ok := agents[region]
+		if !ok {
+			result.Regions = append(result.Regions, models.RegionFetch{Region: region, Error: "no fetch agent is registered for the region"})
+			continue
+		}
+		fetch := c.fetchThroughAgent(ctx, agent, targetURL, settings, config)
+
+		switch {
+		case fetch.StatusCode != page.StatusCode:
+			fetch.Differences = append(fetch.Differences, models.RegionDiffStatusCode)
+		case !regionBlocked(page, fetch):
+			if fetch.FinalURL != page.FinalURL {
+				fetch.Differences = append(fetch.Differences, models.RegionDiffFinalURL)
+			}
+			if fetch.Title != result.Title {
+				fetch.Differences = append(fetch.Differences, models.RegionDiffTitle)
+			}
+			if contentHash != "" && fetch.ContentHash != contentHash {
+				fetch.Differences = append(fetch.Differences, models.RegionDiffContent)
+			}
+		}
+		result.Regions = append(result.Regions, fetch)
+		if finding := regionFinding(targetURL, page, fetch); finding != nil {
+			result.Findings = append(result.Findings, *finding)
+		}
+	}
+}
+
+// regionBlocked reports whether a region didn't get the page the crawl got
+func regionBlocked(page *fetchedPage, fetch models.RegionFetch) bool {
+	return fetch.Error != "" || fetch.Bot != "" || fetch.StatusCode != page.StatusCode
+}
+
+// regionFinding reports a region that couldn't get the page, or got another final URL or title
+func regionFinding(pageURL string, page *fetchedPage, fetch models.RegionFetch) *models.Finding {
+	details := map[string]interface{}{
+		"region":      fetch.Region,
+		"status_code": fetch.StatusCode,
+		"differences": fetch.Differences,
+	}
+	switch {
+	case regionBlocked(page, fetch):
+		reason := fetch.Error
+		switch {
+		case fetch.Bot != "":
+			reason = fmt.Sprintf("blocked by %s", fetch.Bot)
+			details["bot"] = fetch.Bot
+		case reason == "":
+			reason = fmt.Sprintf("HTTP %d instead of %d", fetch.StatusCode, page.StatusCode)
+		}
+		if fetch.Error != "" {
+			details["error"] = fetch.Error
+		}
+		return &models.Finding{
+			Type:     models.FindingGeoBlocked,
+			Category: models.CategoryNetwork,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("The page couldn't be fetched from region %s: %s", fetch.Region, reason),
+			Details:  details,
+		}
+	case containsString(fetch.Differences, models.RegionDiffFinalURL) || containsString(fetch.Differences, models.RegionDiffTitle):
+		details["final_url"] = fetch.FinalURL
+		details["title"] = fetch.Title
+		return &models.Finding{
+			Type:     models.FindingGeoVariant,
+			Category: models.CategoryContent,
+			Severity: models.SeverityInfo,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("Region %s gets a different version of the page", fetch.Region),
+			Details:  details,
+		}
+	}
+	return nil
+}
+
+// fetchThroughAgent fetches the page through the forward proxy of an agent, with the timeout and
+// user agent of the crawl; the connection isn't pooled, since it is used once per crawl
+func (c *CrawlerService) fetchThroughAgent(ctx context.Context, agent models.FetchAgent, targetURL string, settings models.Settings, config models.CrawlConfig) models.RegionFetch {
+	fetch := models.RegionFetch{Region: agent.Region}
+
+	proxy, err := url.Parse(agent.ProxyURL)
+	if err != nil {
+		fetch.Error = fmt.Sprintf("invalid proxy URL: %v", err)
+		return fetch
+	}
+	if agent.Token != "" {
+		proxy.User = url.UserPassword("agent", agent.Token)
+	}
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:               http.ProxyURL(proxy),
+		DisableKeepAlives:   true,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}}
+
+	timeout := time.Duration(settings.CrawlTimeoutSeconds) * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, http.MethodGet, targetURL, settings)
+	if err != nil {
+		fetch.Error = fmt.Sprintf("failed to build request: %v", err)
+		return fetch
+	}
+	if config.UserAgent != "" {
+		req.Header.Set("User-Agent", config.UserAgent)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		fetch.DurationMS = time.Since(start).Milliseconds()
+		fetch.Error = err.Error()
+		return fetch
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSnapshotBytes))
+	fetch.DurationMS = time.Since(start).Milliseconds()
+	fetch.StatusCode = resp.StatusCode
+	fetch.FinalURL = resp.Request.URL.String()
+	if err != nil {
+		fetch.Error = fmt.Sprintf("failed to read the page: %v", err)
+		return fetch
+	}
+	fetch.Size = int64(len(body))
+	sum := sha256.Sum256(body)
+	fetch.ContentHash = hex.EncodeToString(sum[:])
+	head := body
+	if len(head) > botChallengeBodyLimit {
+		head = head[:botChallengeBodyLimit]
+	}
+	fetch.Bot = detectBotChallenge(resp.StatusCode, resp.Header, head)
+	if resp.StatusCode == http.StatusOK {
+		if doc, err := html.Parse(bytes.NewReader(body)); err == nil {
+			fetch.Title = findTitle(doc)
+		}
+	}
+	return fetch
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+// markFindingLifecycle marks the findings of result as new or persisting, matching them with the findings
+// of the previous crawl like annotations do, and counts the previous findings that are gone as resolved
+func markFindingLifecycle(result *models.CrawlResult, previous []models.Finding) {
+	before := make(map[string]bool, len(previous))
+	for _, finding := range previous {
+		before[FindingTarget(finding)] = true
+	}
+
+	result.FindingsNew, result.FindingsPersisting = 0, 0
+	current := make(map[string]bool, len(result.Findings))
+	for i := range result.Findings {
+		target := FindingTarget(result.Findings[i])
+		current[target] = true
+		if before[target] {
+			result.Findings[i].Lifecycle = models.FindingPersisting
+			result.FindingsPersisting++
+		} else {
+			result.Findings[i].Lifecycle = models.FindingNew
+			result.FindingsNew++
+		}
+	}
+
+	result.FindingsResolved = 0
+	for target := range before {
+		if !current[target] {
+			result.FindingsResolved++
+		}
+	}
+}
+
+// ResolvedFindings returns the findings of the previous crawl that the current crawl no longer has
+func ResolvedFindings(previous, current []models.Finding) []models.Finding {
+	now := make(map[string]bool, len(current))
+	for _, finding := range current {
+		now[FindingTarget(finding)] = true
+	}
+	resolved := []models.Finding{}
+	for _, finding := range previous {
+		target := FindingTarget(finding)
+		if now[target] {
+			continue
+		}
+		now[target] = true // A finding reported twice is resolved once
+		finding.Lifecycle = models.FindingResolved
+		resolved = append(resolved, finding)
+	}
+	return resolved
+}
+
+// trackFindingLifecycle compares the findings of a new crawl with the URL's latest stored crawl;
+// it runs before result is saved, so Latest is the previous crawl
+func (c *CrawlerService) trackFindingLifecycle(urlID uint, result *models.CrawlResult) {
+	previous, err := c.previousFindings(urlID, 0)
+	if err != nil {
+		log.Printf("Failed to compare the findings of URL %d with its previous crawl: %v", urlID, err)
+	}
+	markFindingLifecycle(result, previous)
+}
+
+// previousFindings loads the findings of the crawl of the URL before resultID, or of its latest crawl when
+// resultID is 0; a URL without such a crawl has none
+func (c *CrawlerService) previousFindings(urlID, resultID uint) ([]models.Finding, error) {
+	var previousID uint
+	var err error
+	if resultID == 0 {
+		previousID, err = c.store.CrawlResults().LatestID(urlID)
+	} else {
+		previousID, err = c.store.CrawlResults().PreviousID(urlID, resultID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the previous crawl: %v", err)
+	}
+	if previousID == 0 {
+		return nil, nil
+	}
+	findings, err := c.store.CrawlResults().Findings(previousID, repository.FindingFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the findings of crawl %d: %v", previousID, err)
+	}
+	return findings, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// maxIncludedFrames bounds the frames fetched per page
+const maxIncludedFrames = 10
+
+// Reasons a frame was not included
+const (
+	frameSkippedCrossOrigin = "cross_origin"
+	frameSkippedLimit       = "limit"
+	frameSkippedFailed      = "failed"
+)
+
+// urlAttributes hold URLs that are rewritten to absolute ones when a frame's content is merged into its page
+var urlAttributes = []string{"href", "src", "action", "poster"}
+
+// includeFrames fetches the same-origin frames and iframes of the page and merges their body into
+// the frame element, so analyzers see legacy framed sites as the browser shows them.
+// Frames of frames are not followed. Relative URLs of the frame content are made absolute first,
+// so links resolve against the frame and not against the page.
+func (c *CrawlerService) includeFrames(ctx context.Context, page *fetchedPage, settings models.Settings, config models.CrawlConfig, tracker *throttleTracker) []models.Frame {
+	base, err := url.Parse(page.FinalURL)
+	if err != nil {
+		return nil
+	}
+
+	var frames []models.Frame
+	fetched := 0
+	for _, element := range frameElements(page.Doc) {
+		target := resolveHref(base, attrValue(element, "src"))
+		if !isHTTPURL(target) {
+			continue
+		}
+		frame := models.Frame{URL: target}
+
+		targetURL, _ := url.Parse(target)
+		switch {
+		case !sameOrigin(base, targetURL):
+			frame.Skipped = frameSkippedCrossOrigin
+		case fetched >= maxIncludedFrames:
+			frame.Skipped = frameSkippedLimit
+		default:
+			fetched++
+			framePage, err := c.fetchPage(ctx, target, settings, config, tracker)
+			if err == nil {
+				frame.StatusCode = framePage.StatusCode
+			}
+			if err != nil || framePage.Doc == nil {
+				frame.Skipped = frameSkippedFailed
+				break
+			}
+			mergeFrame(element, framePage.Doc, framePage.FinalURL)
+			frame.Included = true
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// frameElements returns the <frame> and <iframe> elements with a src attribute, in document order
+func frameElements(doc *html.Node) []*html.Node {
+	var elements []*html.Node
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "frame" || n.Data == "iframe") && attrValue(n, "src") != "" {
+			elements = append(elements, n)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+	return elements
+}
+
+// mergeFrame replaces the children of the frame element, an iframe's fallback content, with the body of the frame document
+func mergeFrame(element, frameDoc *html.Node, frameURL string) {
+	body := findElement(frameDoc, "body")
+	if body == nil {
+		// Framesets have no body; merge the whole document element instead
+		body = findElement(frameDoc, "html")
+	}
+	if body == nil {
+		return
+	}
+
+	for element.FirstChild != nil {
+		element.RemoveChild(element.FirstChild)
+	}
+	base, _ := url.Parse(frameURL)
+	for child := body.FirstChild; child != nil; {
+		next := child.NextSibling
+		body.RemoveChild(child)
+		absolutizeURLs(child, base)
+		element.AppendChild(child)
+		child = next
+	}
+}
+
+// absolutizeURLs resolves the URL attributes of n and its descendants against base
+func absolutizeURLs(n *html.Node, base *url.URL) {
+	if n.Type == html.ElementNode && base != nil {
+		for i, attr := range n.Attr {
+			if !containsString(urlAttributes, attr.Key) {
+				continue
+			}
+			value := strings.TrimSpace(attr.Val)
+			if value == "" || strings.HasPrefix(value, "#") {
+				continue
+			}
+			if ref, err := url.Parse(value); err == nil {
+				n.Attr[i].Val = base.ResolveReference(ref).String()
+			}
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		absolutizeURLs(child, base)
+	}
+}
+
+// sameOrigin reports whether both URLs have the same scheme and host, including the port
+func sameOrigin(a, b *url.URL) bool {
+	return a != nil && b != nil && strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// findElement returns the first element with the tag name in document order
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	defaultGitHubContext = "sykell-url-analyzer"
+	githubTimeout        = 10 * time.Second
+)
+
+// GitHub commit status states
+const (
+	GitHubStatePending = "pending"
+	GitHubStateSuccess = "success"
+	GitHubStateFailure = "failure" // The page was analyzed and has broken links or exceeds the budget
+	GitHubStateError   = "error"   // The page couldn't be crawled
+)
+
+var (
+	githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	commitSHAPattern  = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+)
+
+// ValidateGitHubIntegration checks the repository name of a project's GitHub integration
+func ValidateGitHubIntegration(github models.GitHubIntegration) error {
+	if github.Repo != "" && !githubRepoPattern.MatchString(github.Repo) {
+		return fmt.Errorf("repo must have the form owner/name")
+	}
+	if len(github.Context) > 255 {
+		return fmt.Errorf("context must be at most 255 characters")
+	}
+	return nil
+}
+
+// ValidateCommitSHA checks that sha looks like a git commit hash; empty clears it
+func ValidateCommitSHA(sha string) error {
+	if sha != "" && !commitSHAPattern.MatchString(sha) {
+		return fmt.Errorf("commit_sha must be a hexadecimal commit hash")
+	}
+	return nil
+}
+
+// GitHubNotifier posts crawl outcomes as commit statuses to the repositories of projects
+type GitHubNotifier struct {
+	apiURL      string // https://api.github.com, or the API of a GitHub Enterprise server
+	frontendURL string // Base URL of the frontend, statuses link to the URL's page when set
+	client      *http.Client
+}
+
+// NewGitHubNotifier creates a notifier posting to the given GitHub API
+func NewGitHubNotifier(apiURL, frontendURL string) *GitHubNotifier {
+	return &GitHubNotifier{
+		apiURL:      strings.TrimRight(apiURL, "/"),
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		client:      &http.Client{Timeout: githubTimeout},
+	}
+}
+
+// enabled reports whether crawls of url are posted to GitHub
+func (g *GitHubNotifier) enabled(project *models.Project, url models.URL) bool {
+	return project != nil && project.GitHub.Repo != "" && project.GitHubToken != "" && url.CommitSHA != ""
+}
+
+// CrawlStarted marks the commit of url as pending while it is crawled
+func (g *GitHubNotifier) CrawlStarted(project *models.Project, url models.URL) {
+	if !g.enabled(project, url) {
+		return
+	}
+	go g.post(project, url, GitHubStatePending, "Crawl in progress")
+}
+
+// CrawlFinished posts the outcome of a crawl of url; crawlErr is set when the crawl failed
+// Broken links and budget failures fail the status, so they show up on the pull request of the commit
+func (g *GitHubNotifier) CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) {
+	if !g.enabled(project, url) {
+		return
+	}
+
+	state, description := GitHubStateSuccess, "No broken links"
+	switch {
+	case crawlErr != nil:
+		state, description = GitHubStateError, "Crawl failed: "+crawlErr.Error()
+	case result.InaccessibleLinks > 0 && result.BudgetStatus == models.BudgetFail:
+		state, description = GitHubStateFailure, fmt.Sprintf("%d broken link(s), page exceeds the budget", result.InaccessibleLinks)
+	case result.InaccessibleLinks > 0:
+		state, description = GitHubStateFailure, fmt.Sprintf("%d broken link(s)", result.InaccessibleLinks)
+	case result.BudgetStatus == models.BudgetFail:
+		state, description = GitHubStateFailure, "Page exceeds the budget"
+	}
+	go g.post(project, url, state, description)
+}
+
+// post creates a commit status; failures are logged since no request is waiting on them
+func (g *GitHubNotifier) post(project *models.Project, url models.URL, state, description string) {
+	context := project.GitHub.Context
+	if context == "" {
+		context = defaultGitHubContext
+	}
+	// GitHub rejects descriptions longer than 140 characters
+	if len(description) > 140 {
+		description = description[:137] + "..."
+	}
+
+	payload := map[string]string{
+		"state":       state,
+		"description": description,
+		"context":     context,
+	}
+	if g.frontendURL != "" {
+		payload["target_url"] = fmt.Sprintf("%s/url/%d", g.frontendURL, url.ID)
+	}
+	body, _ := json.Marshal(payload)
+
+	endpoint := fmt.Sprintf("%s/repos/%s/statuses/%s", g.apiURL, project.GitHub.Repo, url.CommitSHA)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("GitHub status for URL %d: %v", url.ID, err)
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+project.GitHubToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		log.Printf("GitHub status for URL %d: %v", url.ID, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		log.Printf("GitHub status for URL %d on %s@%s: HTTP %d: %s", url.ID, project.GitHub.Repo, url.CommitSHA, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+}
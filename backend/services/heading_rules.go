@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxHeadingRuleLength = 1000 // Characters a length rule may require
+	maxHeadingKeywords   = 50   // Target keywords per project
+	maxHeadingKeyword    = 100  // Characters per keyword
+)
+
+// ValidateHeadingRules checks a project's title and H1 rules
+func ValidateHeadingRules(rules models.HeadingRules) error {
+	lengths := []struct {
+		name     string
+		min, max int
+	}{
+		{"title", rules.TitleMinLength, rules.TitleMaxLength},
+		{"h1", rules.H1MinLength, rules.H1MaxLength},
+	}
+	for _, length := range lengths {
+		if length.min < 0 || length.min > maxHeadingRuleLength {
+			return fmt.Errorf("%s_min_length must be between 0 and %d", length.name, maxHeadingRuleLength)
+		}
+		if length.max < 0 || length.max > maxHeadingRuleLength {
+			return fmt.Errorf("%s_max_length must be between 0 and %d", length.name, maxHeadingRuleLength)
+		}
+		if length.max > 0 && length.min > length.max {
+			return fmt.Errorf("%s_min_length must not exceed %s_max_length", length.name, length.name)
+		}
+	}
+
+	if len(rules.Keywords) > maxHeadingKeywords {
+		return fmt.Errorf("at most %d keywords are allowed", maxHeadingKeywords)
+	}
+	for _, keyword := range rules.Keywords {
+		if len(splitWords(keyword)) == 0 || utf8.RuneCountInString(keyword) > maxHeadingKeyword {
+			return fmt.Errorf("keyword %q must contain a word and be at most %d characters", keyword, maxHeadingKeyword)
+		}
+	}
+
+	if rules.LengthSeverity != "" && !ValidSeverity(rules.LengthSeverity) {
+		return fmt.Errorf("length_severity must be one of info, warning, error")
+	}
+	if rules.KeywordSeverity != "" && !ValidSeverity(rules.KeywordSeverity) {
+		return fmt.Errorf("keyword_severity must be one of info, warning, error")
+	}
+	return nil
+}
+
+// headingTexts returns the whitespace-normalized text of every H1 of the document
+func headingTexts(doc *html.Node) []string {
+	var texts []string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "h1" {
+			texts = append(texts, visibleText(n))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+	return texts
+}
+
+// headingFindings checks the page title and H1s against the project's heading rules
+// A title or H1 that is required by a minimum length but missing altogether is an error
+func headingFindings(pageURL, title string, h1s []string, project *models.Project) []models.Finding {
+	if project == nil {
+		return nil
+	}
+	rules := project.HeadingRules
+	lengthSeverity := rules.LengthSeverity
+	if lengthSeverity == "" {
+		lengthSeverity = models.SeverityWarning
+	}
+	keywordSeverity := rules.KeywordSeverity
+	if keywordSeverity == "" {
+		keywordSeverity = models.SeverityWarning
+	}
+
+	var findings []models.Finding
+	checkLength := func(findingType, element, text string, min, max int) {
+		length := utf8.RuneCountInString(text)
+		var message string
+		severity := lengthSeverity
+		switch {
+		case length == 0 && min > 0:
+			message = fmt.Sprintf("Page has no %s", element)
+			severity = models.SeverityError
+		case min > 0 && length < min:
+			message = fmt.Sprintf("%s is too short: %d < %d characters", element, length, min)
+		case max > 0 && length > max:
+			message = fmt.Sprintf("%s is too long: %d > %d characters", element, length, max)
+		default:
+			return
+		}
+		findings = append(findings, models.Finding{
+			Type:     findingType,
+			Category: models.CategoryContent,
+			Severity: severity,
+			URL:      pageURL,
+			Message:  message,
+			Details: map[string]interface{}{
+				"text":       text,
+				"length":     length,
+				"min_length": min,
+				"max_length": max,
+			},
+		})
+	}
+
+	checkLength(models.FindingTitleLength, "Title", title, rules.TitleMinLength, rules.TitleMaxLength)
+	if len(h1s) == 0 {
+		checkLength(models.FindingH1Length, "H1 heading", "", rules.H1MinLength, rules.H1MaxLength)
+	}
+	for _, h1 := range h1s {
+		checkLength(models.FindingH1Length, "H1 heading", h1, rules.H1MinLength, rules.H1MaxLength)
+	}
+
+	if len(rules.Keywords) > 0 {
+		checkKeywords := func(element string, texts ...string) {
+			for _, text := range texts {
+				if containsKeyword(text, rules.Keywords) {
+					return
+				}
+			}
+			findings = append(findings, models.Finding{
+				Type:     models.FindingKeywordMissing,
+				Category: models.CategoryContent,
+				Severity: keywordSeverity,
+				URL:      pageURL,
+				Message:  fmt.Sprintf("%s contains none of the target keywords", element),
+				Details: map[string]interface{}{
+					"element":  strings.ToLower(element),
+					"keywords": rules.Keywords,
+				},
+			})
+		}
+		checkKeywords("Title", title)
+		checkKeywords("H1", h1s...)
+	}
+	return findings
+}
+
+// containsKeyword reports whether text contains one of the keywords as whole words, ignoring case
+func containsKeyword(text string, keywords []string) bool {
+	normalized := " " + normalizeWords(text) + " "
+	for _, keyword := range keywords {
+		if strings.Contains(normalized, " "+normalizeWords(keyword)+" ") {
+			return true
+		}
+	}
+	return false
+}
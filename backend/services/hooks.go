@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	hookTimeout  = 10 * time.Second
+	hookAttempts = 3 // Deliveries are retried with growing delays before they are dropped
+
+	// maxNewBrokenLinkEvents bounds the link.broken.new events of one crawl
+	maxNewBrokenLinkEvents = 50
+)
+
+// HookEvents lists the events that can be subscribed to
+var HookEvents = []string{models.HookEventURLCompleted, models.HookEventURLFailed, models.HookEventLinkBrokenNew, models.HookEventBudgetFailed}
+
+// HookPayload is the body POSTed to subscribers
+type HookPayload struct {
+	ID        string      `json:"id"` // Unique per event, so receivers can drop duplicate deliveries
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// HookURLData describes the URL of url.completed and url.failed events
+type HookURLData struct {
+	URLID        uint       `json:"url_id"`
+	URL          string     `json:"url"`
+	ProjectID    *uint      `json:"project_id"`
+	Status       string     `json:"status"` // completed, error, blocked
+	Error        string     `json:"error,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	LinksCount   int        `json:"links_count"`
+	BrokenLinks  int        `json:"broken_links"`
+	BudgetStatus string     `json:"budget_status,omitempty"`
+	CrawledAt    *time.Time `json:"crawled_at,omitempty"`
+}
+
+// HookLinkData describes the link of link.broken.new events
+type HookLinkData struct {
+	URLID      uint   `json:"url_id"`
+	URL        string `json:"url"` // Page the link was found on
+	ProjectID  *uint  `json:"project_id"`
+	Link       string `json:"link"`
+	LinkType   string `json:"link_type"` // internal, external
+	StatusCode int    `json:"status_code"`
+}
+
+// HookBudgetData describes the page of budget.failed events and the budget limits it exceeds
+type HookBudgetData struct {
+	URLID      uint                  `json:"url_id"`
+	URL        string                `json:"url"`
+	ProjectID  *uint                 `json:"project_id"`
+	Violations []HookBudgetViolation `json:"violations"`
+}
+
+// HookBudgetViolation is one exceeded limit of a page budget
+type HookBudgetViolation struct {
+	Metric string `json:"metric"` // html_bytes, scripts, third_party_domains
+	Actual int64  `json:"actual"`
+	Limit  int64  `json:"limit"`
+}
+
+// HookService manages REST hook subscriptions and delivers crawl events to them
+type HookService struct {
+	db     *gorm.DB
+	store  repository.Store
+	client *http.Client
+}
+
+// NewHookService creates a hook service
+func NewHookService(db *gorm.DB, store repository.Store) *HookService {
+	return &HookService{
+		db:     db,
+		store:  store,
+		client: &http.Client{Timeout: hookTimeout},
+	}
+}
+
+// ValidateHookSubscription checks the event and target of a new subscription
+func ValidateHookSubscription(event, targetURL string) error {
+	known := false
+	for _, e := range HookEvents {
+		known = known || e == event
+	}
+	if !known {
+		return fmt.Errorf("event must be one of %v", HookEvents)
+	}
+
+	target, err := url.Parse(targetURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("target_url must be an absolute http or https URL")
+	}
+	if len(targetURL) > 2048 {
+		return fmt.Errorf("target_url must be at most 2048 characters")
+	}
+	return nil
+}
+
+// Subscribe creates a subscription of targetURL to event
+func (s *HookService) Subscribe(event, targetURL, createdBy string) (*models.HookSubscription, error) {
+	subscription := &models.HookSubscription{Event: event, TargetURL: targetURL, CreatedBy: createdBy}
+	if err := s.db.Create(subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to create hook subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+// Unsubscribe deletes a subscription, returning false when it doesn't exist
+func (s *HookService) Unsubscribe(id uint) (bool, error) {
+	result := s.db.Delete(&models.HookSubscription{}, id)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete hook subscription %d: %v", id, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// List returns every subscription, oldest first
+func (s *HookService) List() ([]models.HookSubscription, error) {
+	var subscriptions []models.HookSubscription
+	if err := s.db.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list hook subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// Sample returns example payloads of event, which Zapier shows while a zap is set up
+func (s *HookService) Sample(event string) []HookPayload {
+	crawledAt := time.Now().UTC().Truncate(time.Second)
+	projectID := uint(1)
+
+	var data interface{}
+	switch event {
+	case models.HookEventURLCompleted:
+		data = HookURLData{URLID: 1, URL: "https://example.com/", ProjectID: &projectID, Status: "completed", Title: "Example Domain",
+			LinksCount: 12, BrokenLinks: 1, BudgetStatus: models.BudgetPass, CrawledAt: &crawledAt}
+	case models.HookEventURLFailed:
+		data = HookURLData{URLID: 1, URL: "https://example.com/", ProjectID: &projectID, Status: "error", Error: "HTTP 503: Service Unavailable"}
+	case models.HookEventLinkBrokenNew:
+		data = HookLinkData{URLID: 1, URL: "https://example.com/", ProjectID: &projectID, Link: "https://example.com/missing",
+			LinkType: "internal", StatusCode: http.StatusNotFound}
+	case models.HookEventBudgetFailed:
+		data = HookBudgetData{URLID: 1, URL: "https://example.com/", ProjectID: &projectID,
+			Violations: []HookBudgetViolation{{Metric: "scripts", Actual: 24, Limit: 20}}}
+	default:
+		return []HookPayload{}
+	}
+	return []HookPayload{{ID: "sample", Event: event, CreatedAt: crawledAt, Data: data}}
+}
+
+// CrawlStarted does nothing; events are only sent for finished crawls
+func (s *HookService) CrawlStarted(project *models.Project, url models.URL) {}
+
+// CrawlFinished sends url.completed or url.failed, and link.broken.new for links the previous crawl didn't find broken
+// The subscriptions are loaded in the background, so the crawl doesn't wait for the database
+func (s *HookService) CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) {
+	go s.crawlFinished(url, result, crawlErr)
+}
+
+// crawlFinished sends the events of a finished crawl to their subscriptions
+func (s *HookService) crawlFinished(url models.URL, result *models.CrawlResult, crawlErr error) {
+	var subscriptions []models.HookSubscription
+	if err := s.db.Find(&subscriptions).Error; err != nil {
+		log.Printf("Failed to load hook subscriptions: %v", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+	subscribed := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		subscribed[subscription.Event] = true
+	}
+
+	var events []HookPayload
+	data := newHookURLData(url, result, crawlErr)
+	if crawlErr != nil {
+		events = append(events, newHookPayload(models.HookEventURLFailed, data))
+	} else {
+		events = append(events, newHookPayload(models.HookEventURLCompleted, data))
+
+		if subscribed[models.HookEventLinkBrokenNew] {
+			events = append(events, s.newBrokenLinkEvents(url, result)...)
+		}
+		if subscribed[models.HookEventBudgetFailed] && result.BudgetStatus == models.BudgetFail {
+			events = append(events, s.budgetFailedEvents(url, result)...)
+		}
+	}
+
+	for _, event := range events {
+		for _, subscription := range subscriptions {
+			if subscription.Event == event.Event {
+				go s.deliver(subscription, event)
+			}
+		}
+	}
+}
+
+// newBrokenLinkEvents returns a link.broken.new event for every broken link of result that was fine,
+// or not linked at all, in the crawl before the saved result
+func (s *HookService) newBrokenLinkEvents(url models.URL, result *models.CrawlResult) []HookPayload {
+	previouslyBroken := make(map[string]bool)
+	previousID, err := s.store.CrawlResults().PreviousID(url.ID, result.ID)
+	if err != nil {
+		log.Printf("Failed to find the crawl of URL %d before crawl %d: %v", url.ID, result.ID, err)
+	}
+	if previousID != 0 {
+		links, err := s.store.CrawlResults().Links(previousID)
+		if err != nil {
+			log.Printf("Failed to load the links of crawl %d: %v", previousID, err)
+		}
+		for _, link := range links {
+			if !link.IsAccessible && !link.Throttled {
+				previouslyBroken[link.URL] = true
+			}
+		}
+	}
+
+	var events []HookPayload
+	for _, link := range result.Links {
+		if link.IsAccessible || link.Throttled || previouslyBroken[link.URL] {
+			continue
+		}
+		previouslyBroken[link.URL] = true // Report links found on the page more than once only once
+		events = append(events, newHookPayload(models.HookEventLinkBrokenNew, HookLinkData{
+			URLID:      url.ID,
+			URL:        url.URL,
+			ProjectID:  url.ProjectID,
+			Link:       link.URL,
+			LinkType:   link.Type,
+			StatusCode: link.StatusCode,
+		}))
+		if len(events) == maxNewBrokenLinkEvents {
+			break
+		}
+	}
+	return events
+}
+
+// budgetFailedEvents returns a budget.failed event when the page of result exceeds its budget and the
+// crawl before it didn't, so subscribers are alerted once when the budget is reached rather than on every crawl
+func (s *HookService) budgetFailedEvents(url models.URL, result *models.CrawlResult) []HookPayload {
+	previousID, err := s.store.CrawlResults().PreviousID(url.ID, result.ID)
+	if err != nil {
+		log.Printf("Failed to find the crawl of URL %d before crawl %d: %v", url.ID, result.ID, err)
+	}
+	if previousID != 0 {
+		previous, err := s.store.CrawlResults().Get(previousID)
+		if err != nil {
+			log.Printf("Failed to load crawl %d: %v", previousID, err)
+		}
+		if previous.BudgetStatus == models.BudgetFail {
+			return nil
+		}
+	}
+
+	data := HookBudgetData{URLID: url.ID, URL: url.URL, ProjectID: url.ProjectID, Violations: []HookBudgetViolation{}}
+	for _, finding := range result.Findings {
+		if finding.Type != models.FindingBudgetExceeded {
+			continue
+		}
+		metric, _ := finding.Details["metric"].(string)
+		actual, _ := finding.Details["actual"].(int64)
+		limit, _ := finding.Details["limit"].(int64)
+		data.Violations = append(data.Violations, HookBudgetViolation{Metric: metric, Actual: actual, Limit: limit})
+	}
+	return []HookPayload{newHookPayload(models.HookEventBudgetFailed, data)}
+}
+
+// newHookURLData describes the outcome of a crawl of url; crawlErr is set when the crawl failed
+func newHookURLData(url models.URL, result *models.CrawlResult, crawlErr error) HookURLData {
+	data := HookURLData{URLID: url.ID, URL: url.URL, ProjectID: url.ProjectID}
+	if crawlErr != nil {
+		data.Status = "error"
+		var botErr *BotProtectionError
+		if errors.As(crawlErr, &botErr) {
+			data.Status = "blocked"
+		}
+		data.Error = crawlErr.Error()
+		return data
+	}
+
+	crawledAt := result.CrawledAt
+	data.Status = "completed"
+	data.Title = result.Title
+	data.LinksCount = len(result.Links)
+	data.BrokenLinks = result.InaccessibleLinks
+	data.BudgetStatus = result.BudgetStatus
+	data.CrawledAt = &crawledAt
+	return data
+}
+
+func newHookPayload(event string, data interface{}) HookPayload {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return HookPayload{ID: hex.EncodeToString(id), Event: event, CreatedAt: time.Now().UTC(), Data: data}
+}
+
+// deliver POSTs the event to the subscriber, retrying failures
+// A 410 Gone answer means the subscriber is gone for good, so the subscription is deleted as REST hooks specify
+func (s *HookService) deliver(subscription models.HookSubscription, event HookPayload) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Event, err)
+		return
+	}
+
+	delay := time.Second
+	for attempt := 1; attempt <= hookAttempts; attempt++ {
+		resp, err := s.client.Post(subscription.TargetURL, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusGone:
+				if _, err := s.Unsubscribe(subscription.ID); err != nil {
+					log.Printf("Failed to remove gone hook subscription %d: %v", subscription.ID, err)
+				}
+				return
+			case resp.StatusCode >= 200 && resp.StatusCode < 300:
+				return
+			}
+			err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+
+		if attempt == hookAttempts {
+			log.Printf("Dropped %s event for hook subscription %d after %d attempts: %v", event.Event, subscription.ID, hookAttempts, err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 4
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HostStats summarizes the requests the crawler made to a single target host
+type HostStats struct {
+	Host            string     `json:"host"`
+	Requests        int64      `json:"requests"`
+	Errors          int64      `json:"errors"` // Transport failures and 4xx/5xx responses
+	ErrorRate       float64    `json:"error_rate"`
+	AvgLatencyMs    float64    `json:"avg_latency_ms"`
+	LastStatusCode  int        `json:"last_status_code"`
+	Last429At       *time.Time `json:"last_429_at"`
+	Last403At       *time.Time `json:"last_403_at"`
+	LastRequestedAt time.Time  `json:"last_requested_at"`
+
+	totalLatency time.Duration
+}
+
+// HostMetrics is an in-memory store of per-host request statistics
+// It lets operators see which target sites are slow or throttling the crawler
+type HostMetrics struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+}
+
+// NewHostMetrics creates an empty host metrics store
+func NewHostMetrics() *HostMetrics {
+	return &HostMetrics{hosts: make(map[string]*HostStats)}
+}
+
+// Record adds the outcome of one request; statusCode is ignored when err is non-nil
+func (m *HostMetrics) Record(rawURL string, latency time.Duration, statusCode int, err error) {
+	parsed, parseErr := url.Parse(rawURL)
+	if parseErr != nil || parsed.Host == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.hosts[parsed.Host]
+	if !ok {
+		stats = &HostStats{Host: parsed.Host}
+		m.hosts[parsed.Host] = stats
+	}
+
+	now := time.Now()
+	stats.Requests++
+	stats.totalLatency += latency
+	stats.LastRequestedAt = now
+
+	if err != nil {
+		stats.Errors++
+		return
+	}
+
+	stats.LastStatusCode = statusCode
+	if statusCode >= 400 {
+		stats.Errors++
+	}
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		stats.Last429At = &now
+	case http.StatusForbidden:
+		stats.Last403At = &now
+	}
+}
+
+// Snapshot returns a copy of all host stats, most requested hosts first
+func (m *HostMetrics) Snapshot() []HostStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]HostStats, 0, len(m.hosts))
+	for _, stats := range m.hosts {
+		s := *stats
+		if s.Requests > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+			s.AvgLatencyMs = float64(s.totalLatency.Microseconds()) / float64(s.Requests) / 1000
+		}
+		snapshot = append(snapshot, s)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Requests != snapshot[j].Requests {
+			return snapshot[i].Requests > snapshot[j].Requests
+		}
+		return snapshot[i].Host < snapshot[j].Host
+	})
+
+	return snapshot
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// hstsPreloadMinMaxAge is the shortest max-age accepted by the HSTS preload list (one year)
+const hstsPreloadMinMaxAge = 31536000
+
+// checkHSTS parses the Strict-Transport-Security header of an HTTPS page and checks preload eligibility
+// Preloading also requires plain HTTP on the same host to redirect to HTTPS, which is probed separately
+func (c *CrawlerService) checkHSTS(ctx context.Context, pageURL string, header http.Header, settings models.Settings, tracker *throttleTracker) (models.HSTSCheck, []models.Finding) {
+	var check models.HSTSCheck
+
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return check, nil
+	}
+
+	if page.Scheme != "https" {
+		check.Problems = append(check.Problems, "Page is not served over HTTPS")
+	} else if check.Header = header.Get("Strict-Transport-Security"); check.Header == "" {
+		check.Problems = append(check.Problems, "Strict-Transport-Security header is missing")
+	} else {
+		parseHSTS(&check)
+		if check.MaxAge < hstsPreloadMinMaxAge {
+			check.Problems = append(check.Problems, fmt.Sprintf("max-age must be at least %d seconds", hstsPreloadMinMaxAge))
+		}
+		if !check.IncludeSubDomains {
+			check.Problems = append(check.Problems, "includeSubDomains directive is missing")
+		}
+		if !check.Preload {
+			check.Problems = append(check.Problems, "preload directive is missing")
+		}
+	}
+
+	if page.Scheme == "https" {
+		if redirects, ok := c.httpRedirectsToHTTPS(ctx, page, settings, tracker); ok {
+			check.HTTPRedirectsToHTTPS = &redirects
+			if !redirects {
+				check.Problems = append(check.Problems, "HTTP does not redirect to HTTPS on the same host")
+			}
+		}
+	}
+
+	check.PreloadEligible = len(check.Problems) == 0
+
+	if check.PreloadEligible {
+		return check, nil
+	}
+
+	severity := models.SeverityInfo
+	message := "Site is not eligible for HSTS preloading"
+	if check.Header == "" {
+		severity = models.SeverityWarning
+		message = "Site does not enforce HTTPS with HSTS"
+	}
+	return check, []models.Finding{{
+		Type:     models.FindingHSTS,
+		Category: models.CategorySecurity,
+		Severity: severity,
+		URL:      pageURL,
+		Message:  message,
+		Details: map[string]interface{}{
+			"problems": check.Problems,
+		},
+	}}
+}
+
+// parseHSTS reads the directives of the Strict-Transport-Security header
+func parseHSTS(check *models.HSTSCheck) {
+	for _, directive := range strings.Split(check.Header, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64); err == nil {
+				check.MaxAge = maxAge
+			}
+		case "includesubdomains":
+			check.IncludeSubDomains = true
+		case "preload":
+			check.Preload = true
+		}
+	}
+}
+
+// httpRedirectsToHTTPS requests the plain HTTP version of the page's host and reports whether it redirects to HTTPS
+// ok is false when the host could not be reached over HTTP
+func (c *CrawlerService) httpRedirectsToHTTPS(ctx context.Context, page *url.URL, settings models.Settings, tracker *throttleTracker) (redirects bool, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(settings.LinkCheckTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, http.MethodGet, "http://"+page.Hostname()+"/", settings)
+	if err != nil {
+		return false, false
+	}
+
+	resp, err := c.doWithBackoff(c.noFollow, req, tracker)
+	if err != nil {
+		return false, false
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		return false, true
+	}
+	return location.Scheme == "https" && strings.EqualFold(location.Hostname(), page.Hostname()), true
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// checkHTTPS compares the scheme the URL was submitted with to the page it ended up on
+// An HTTP page gets its HTTPS variant probed and is reported when that answers, since visitors could be on HTTPS.
+// An HTTPS page is reported when plain HTTP doesn't redirect to it; the HSTS check already probed that.
+func (c *CrawlerService) checkHTTPS(ctx context.Context, submittedURL, finalURL string, hsts models.HSTSCheck, settings models.Settings, tracker *throttleTracker) (models.HTTPSCheck, []models.Finding) {
+	var check models.HTTPSCheck
+	submitted, err := url.Parse(submittedURL)
+	if err != nil {
+		return check, nil
+	}
+	page, err := url.Parse(finalURL)
+	if err != nil {
+		return check, nil
+	}
+	check.SubmittedScheme = submitted.Scheme
+	check.ServedOverHTTPS = page.Scheme == "https"
+
+	if check.ServedOverHTTPS {
+		check.HTTPRedirectsToHTTPS = hsts.HTTPRedirectsToHTTPS
+		if redirects := check.HTTPRedirectsToHTTPS; redirects == nil || *redirects {
+			return check, nil
+		}
+		return check, []models.Finding{{
+			Type:     models.FindingHTTPSRedirectMissing,
+			Category: models.CategorySecurity,
+			Severity: models.SeverityWarning,
+			URL:      finalURL,
+			Message:  fmt.Sprintf("http://%s/ doesn't redirect to HTTPS, so visitors following http:// links stay on plain HTTP", page.Hostname()),
+		}}
+	}
+
+	// The page was fetched over HTTP all the way, so HTTP evidently doesn't redirect
+	redirects := false
+	check.HTTPRedirectsToHTTPS = &redirects
+
+	variant := *page
+	variant.Scheme = "https"
+	available := c.httpsAvailable(ctx, variant.String(), settings, tracker)
+	check.HTTPSAvailable = &available
+	if !available {
+		return check, nil
+	}
+	return check, []models.Finding{{
+		Type:     models.FindingHTTPSNotUsed,
+		Category: models.CategorySecurity,
+		Severity: models.SeverityWarning,
+		URL:      finalURL,
+		Message:  "HTTPS is available but not used: the page is served over HTTP without redirecting to HTTPS",
+		Details: map[string]interface{}{
+			"https_url": variant.String(),
+		},
+	}}
+}
+
+// httpsAvailable reports whether the HTTPS URL answers without an error and without sending the client back to HTTP
+func (c *CrawlerService) httpsAvailable(ctx context.Context, httpsURL string, settings models.Settings, tracker *throttleTracker) bool {
+	probe, err := c.probeLink(ctx, http.MethodHead, httpsURL, settings, tracker)
+	if err != nil || probe.finalStatus >= 400 {
+		probe, err = c.probeLink(ctx, http.MethodGet, httpsURL, settings, tracker)
+	}
+	if err != nil || probe.finalStatus >= 400 {
+		return false
+	}
+	final, err := url.Parse(probe.finalURL)
+	return err == nil && final.Scheme == "https"
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxNewIssuesPerCrawl keeps a page full of broken links from flooding the tracker;
+	// the remaining links get their tickets on later crawls
+	maxNewIssuesPerCrawl = 10
+
+	issueTrackerTimeout = 10 * time.Second
+	linearAPIURL        = "https://api.linear.app/graphql"
+)
+
+// ValidateIssueTracker checks the settings of a project's issue tracker integration
+func ValidateIssueTracker(tracker models.IssueTrackerIntegration) error {
+	switch tracker.Provider {
+	case "":
+		return nil
+	case models.IssueProviderJira:
+		base, err := url.Parse(tracker.BaseURL)
+		if err != nil || base.Scheme != "https" || base.Host == "" {
+			return fmt.Errorf("base_url must be the https URL of the Jira site")
+		}
+		if tracker.Email == "" || tracker.ProjectKey == "" {
+			return fmt.Errorf("email and project_key are required for Jira")
+		}
+	case models.IssueProviderLinear:
+		if tracker.TeamID == "" {
+			return fmt.Errorf("team_id is required for Linear")
+		}
+	default:
+		return fmt.Errorf("provider must be jira or linear")
+	}
+	return nil
+}
+
+// IssueService opens tickets for new broken links and failed crawls in the issue tracker of a project
+// Every problem is fingerprinted, so a recurring one updates its ticket instead of opening another
+type IssueService struct {
+	db          *gorm.DB
+	frontendURL string
+	client      *http.Client
+
+	// mu guards the tracked issues and opening, so concurrent crawls don't open the same ticket twice
+	// It isn't held during tracker requests, so one slow tracker doesn't hold up every other sync
+	mu      sync.Mutex
+	opening map[string]bool // Issues whose ticket a sync is opening, by issueKey
+}
+
+// NewIssueService creates an issue service; frontendURL is linked from tickets when set
+func NewIssueService(db *gorm.DB, frontendURL string) *IssueService {
+	return &IssueService{
+		db:          db,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		client:      &http.Client{Timeout: issueTrackerTimeout},
+		opening:     make(map[string]bool),
+	}
+}
+
+// List returns the tickets opened for a project, most recently seen first
+func (s *IssueService) List(projectID uint) ([]models.TrackedIssue, error) {
+	var issues []models.TrackedIssue
+	if err := s.db.Where("project_id = ?", projectID).Order("last_seen_at desc, id desc").Find(&issues).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tracked issues: %v", err)
+	}
+	return issues, nil
+}
+
+// CrawlStarted does nothing; tickets are only opened for finished crawls
+func (s *IssueService) CrawlStarted(project *models.Project, url models.URL) {}
+
+// CrawlFinished syncs the problems seen by a crawl with the project's tickets in the background
+func (s *IssueService) CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) {
+	if project == nil || project.IssueTracker.Provider == "" || project.IssueTrackerToken == "" {
+		return
+	}
+	go func() {
+		if err := s.sync(project, url, result, crawlErr); err != nil {
+			log.Printf("Issue tracker sync for URL %d: %v", url.ID, err)
+		}
+	}()
+}
+
+// problem is something a ticket is opened for
+type problem struct {
+	kind, subject      string
+	title, description string
+	recurred           string // Comment added when the problem comes back after it was resolved
+}
+
+func (p problem) fingerprint() string {
+	sum := sha256.Sum256([]byte(p.kind + "\n" + p.subject))
+	return hex.EncodeToString(sum[:])
+}
+
+// sync opens tickets for new problems of the crawl, updates the ones seen before,
+// and marks those the crawl no longer sees as resolved
+func (s *IssueService) sync(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) error {
+	tracker := s.tracker(project)
+	page := url.URL
+	if s.frontendURL != "" {
+		page = fmt.Sprintf("%s (%s/url/%d)", url.URL, s.frontendURL, url.ID)
+	}
+
+	var problems []problem
+	if crawlErr != nil {
+		problems = append(problems, problem{
+			kind:        models.IssueKindCrawlFailed,
+			subject:     url.URL,
+			title:       "Crawl failed: " + url.URL,
+			description: fmt.Sprintf("Crawling %s failed:\n\n%v", page, crawlErr),
+			recurred:    fmt.Sprintf("Crawling %s failed again: %v", url.URL, crawlErr),
+		})
+	} else {
+		seen := make(map[string]bool)
+		for _, link := range result.Links {
+			if link.IsAccessible || link.Throttled || seen[link.URL] {
+				continue
+			}
+			seen[link.URL] = true
+			problems = append(problems, problem{
+				kind:        models.IssueKindBrokenLink,
+				subject:     link.URL,
+				title:       "Broken link: " + link.URL,
+				description: fmt.Sprintf("%s links to %s, which is broken (HTTP status %d).", page, link.URL, link.StatusCode),
+				recurred:    fmt.Sprintf("%s is broken again, linked from %s (HTTP status %d).", link.URL, url.URL, link.StatusCode),
+			})
+		}
+	}
+
+	now := time.Now()
+	current := make(map[string]bool)
+	created := 0
+	for _, p := range problems {
+		fingerprint := p.fingerprint()
+		current[fingerprint] = true
+
+		issue, recurred, open, err := s.recordSighting(project.ID, url.ID, fingerprint, now, created < maxNewIssuesPerCrawl)
+		if err != nil {
+			return err
+		}
+		if recurred {
+			if err := tracker.comment(issue, p.recurred); err != nil {
+				log.Printf("Failed to comment on %s: %v", issue.ExternalKey, err)
+			}
+		}
+		if !open {
+			continue
+		}
+
+		ticket, err := tracker.create(p.title, p.description)
+		if err != nil {
+			s.stopOpening(project.ID, fingerprint)
+			return fmt.Errorf("failed to create %s issue: %v", project.IssueTracker.Provider, err)
+		}
+		created++
+
+		issue = models.TrackedIssue{
+			ProjectID:   project.ID,
+			Fingerprint: fingerprint,
+			Kind:        p.kind,
+			Subject:     p.subject,
+			URLID:       url.ID,
+			Provider:    project.IssueTracker.Provider,
+			ExternalID:  ticket.id,
+			ExternalKey: ticket.key,
+			ExternalURL: ticket.url,
+			Occurrences: 1,
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+		}
+		if err := s.saveOpened(&issue); err != nil {
+			return fmt.Errorf("failed to save tracked issue for %s: %v", ticket.key, err)
+		}
+	}
+
+	// Problems last seen on this URL that the crawl didn't see again are resolved
+	// A failed crawl says nothing about the links, so it only keeps its own ticket open
+	kinds := []string{models.IssueKindCrawlFailed}
+	if crawlErr == nil {
+		kinds = append(kinds, models.IssueKindBrokenLink)
+	}
+	resolved, err := s.resolveUnseen(project.ID, url.ID, kinds, current, now)
+	if err != nil {
+		return err
+	}
+	for _, issue := range resolved {
+		message := fmt.Sprintf("%s is no longer broken on %s.", issue.Subject, url.URL)
+		if issue.Kind == models.IssueKindCrawlFailed {
+			message = fmt.Sprintf("%s was crawled successfully again.", url.URL)
+		}
+		if err := tracker.comment(issue, message); err != nil {
+			log.Printf("Failed to comment on %s: %v", issue.ExternalKey, err)
+		}
+	}
+
+	return nil
+}
+
+// issueKey identifies the issue of a problem in IssueService.opening
+func issueKey(projectID uint, fingerprint string) string {
+	return fmt.Sprintf("%d:%s", projectID, fingerprint)
+}
+
+// recordSighting counts another occurrence of the problem on its issue and reports whether it had been resolved
+// When there is no issue yet and canOpen is set, the problem is reserved for the caller, reported by open, who
+// opens the ticket and then calls saveOpened or stopOpening; a problem another sync is opening is left to it
+func (s *IssueService) recordSighting(projectID, urlID uint, fingerprint string, now time.Time, canOpen bool) (issue models.TrackedIssue, recurred, open bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.db.Where("project_id = ? AND fingerprint = ?", projectID, fingerprint).First(&issue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		key := issueKey(projectID, fingerprint)
+		if !canOpen || s.opening[key] {
+			return issue, false, false, nil
+		}
+		s.opening[key] = true
+		return issue, false, true, nil
+	}
+	if err != nil {
+		return issue, false, false, fmt.Errorf("failed to look up tracked issue: %v", err)
+	}
+
+	recurred = issue.ResolvedAt != nil
+	issue.ResolvedAt = nil
+	issue.URLID = urlID
+	issue.Occurrences++
+	issue.LastSeenAt = now
+	if err := s.db.Save(&issue).Error; err != nil {
+		return issue, false, false, fmt.Errorf("failed to update tracked issue %d: %v", issue.ID, err)
+	}
+	return issue, recurred, false, nil
+}
+
+// saveOpened stores the issue whose ticket was opened for a problem reserved by recordSighting
+func (s *IssueService) saveOpened(issue *models.TrackedIssue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.opening, issueKey(issue.ProjectID, issue.Fingerprint))
+
+	// Another server may have opened a ticket for the problem during the request; the row keeps the first one
+	var existing models.TrackedIssue
+	err := s.db.Where("project_id = ? AND fingerprint = ?", issue.ProjectID, issue.Fingerprint).First(&existing).Error
+	if err == nil {
+		log.Printf("Opened %s for a problem that is tracked as %s already", issue.ExternalKey, existing.ExternalKey)
+		return s.db.Model(&existing).Updates(map[string]interface{}{
+			"occurrences":  gorm.Expr("occurrences + 1"),
+			"last_seen_at": issue.LastSeenAt,
+			"url_id":       issue.URLID,
+		}).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.db.Create(issue).Error
+}
+
+// stopOpening gives up the reservation of a problem whose ticket couldn't be opened
+func (s *IssueService) stopOpening(projectID uint, fingerprint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.opening, issueKey(projectID, fingerprint))
+}
+
+// resolveUnseen marks the open issues of the kinds last seen on the URL that aren't in current as resolved
+// and returns them, so their tickets can be commented on
+func (s *IssueService) resolveUnseen(projectID, urlID uint, kinds []string, current map[string]bool, now time.Time) ([]models.TrackedIssue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var open []models.TrackedIssue
+	if err := s.db.Where("project_id = ? AND url_id = ? AND kind IN ? AND resolved_at IS NULL", projectID, urlID, kinds).
+		Find(&open).Error; err != nil {
+		return nil, fmt.Errorf("failed to list open tracked issues: %v", err)
+	}
+	var resolved []models.TrackedIssue
+	for _, issue := range open {
+		if current[issue.Fingerprint] {
+			continue
+		}
+		if err := s.db.Model(&issue).Update("resolved_at", now).Error; err != nil {
+			return resolved, fmt.Errorf("failed to resolve tracked issue %d: %v", issue.ID, err)
+		}
+		resolved = append(resolved, issue)
+	}
+	return resolved, nil
+}
+
+// ticket identifies an issue created in a tracker
+type ticket struct {
+	id, key, url string
+}
+
+// issueTracker creates and comments on issues of one provider
+type issueTracker interface {
+	create(title, description string) (ticket, error)
+	comment(issue models.TrackedIssue, body string) error
+}
+
+// tracker returns the client of the project's issue tracker
+func (s *IssueService) tracker(project *models.Project) issueTracker {
+	if project.IssueTracker.Provider == models.IssueProviderLinear {
+		return &linearTracker{client: s.client, config: project.IssueTracker, token: project.IssueTrackerToken}
+	}
+	return &jiraTracker{client: s.client, config: project.IssueTracker, token: project.IssueTrackerToken}
+}
+
+// sendJSON sends payload as the JSON body of req and decodes the response into out, unless out is nil
+func sendJSON(client *http.Client, req *http.Request, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jiraTracker talks to the REST API of a Jira Cloud site, authenticating with an account's API token
+type jiraTracker struct {
+	client *http.Client
+	config models.IssueTrackerIntegration
+	token  string
+}
+
+func (j *jiraTracker) request(path string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(j.config.BaseURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(j.config.Email, j.token)
+	return req, nil
+}
+
+func (j *jiraTracker) create(title, description string) (ticket, error) {
+	issueType := j.config.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	req, err := j.request("/rest/api/2/issue")
+	if err != nil {
+		return ticket{}, err
+	}
+
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	err = sendJSON(j.client, req, map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.config.ProjectKey},
+			"issuetype":   map[string]string{"name": issueType},
+			"summary":     truncate(title, 255),
+			"description": description,
+		},
+	}, &created)
+	if err != nil {
+		return ticket{}, err
+	}
+	return ticket{id: created.ID, key: created.Key, url: strings.TrimRight(j.config.BaseURL, "/") + "/browse/" + created.Key}, nil
+}
+
+func (j *jiraTracker) comment(issue models.TrackedIssue, body string) error {
+	req, err := j.request("/rest/api/2/issue/" + url.PathEscape(issue.ExternalKey) + "/comment")
+	if err != nil {
+		return err
+	}
+	return sendJSON(j.client, req, map[string]string{"body": body}, nil)
+}
+
+// linearTracker talks to the GraphQL API of Linear, authenticating with a personal API key
+type linearTracker struct {
+	client *http.Client
+	config models.IssueTrackerIntegration
+	token  string
+}
+
+// query runs a GraphQL mutation, failing on HTTP and GraphQL errors alike
+func (l *linearTracker) query(query string, variables map[string]interface{}, out interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, linearAPIURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", l.token)
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := sendJSON(l.client, req, map[string]interface{}{"query": query, "variables": variables}, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return errors.New(response.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, out)
+}
+
+func (l *linearTracker) create(title, description string) (ticket, error) {
+	var data struct {
+		IssueCreate struct {
+			Issue struct {
+				ID         string `json:"id"`
+				Identifier string `json:"identifier"`
+				URL        string `json:"url"`
+			} `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	err := l.query(`mutation($input: IssueCreateInput!) { issueCreate(input: $input) { issue { id identifier url } } }`,
+		map[string]interface{}{"input": map[string]string{
+			"teamId":      l.config.TeamID,
+			"title":       truncate(title, 255),
+			"description": description,
+		}}, &data)
+	if err != nil {
+		return ticket{}, err
+	}
+	issue := data.IssueCreate.Issue
+	return ticket{id: issue.ID, key: issue.Identifier, url: issue.URL}, nil
+}
+
+func (l *linearTracker) comment(issue models.TrackedIssue, body string) error {
+	return l.query(`mutation($input: CommentCreateInput!) { commentCreate(input: $input) { success } }`,
+		map[string]interface{}{"input": map[string]string{
+			"issueId": issue.ExternalID,
+			"body":    body,
+		}}, nil)
+}
+
+// truncate shortens s to at most limit bytes, marking the cut
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit-3] + "..."
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// jsRedirectPatterns match the common ways scripts send the browser elsewhere; the group captures the new location
+// Comparisons such as location.href == "..." don't match, since the assignment must not be followed by another =
+var jsRedirectPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|[^\w$.])(?:(?:window|document|self|top|parent)\.)?location(?:\.href)?\s*=\s*([^=\s][^;\n]*)`),
+	regexp.MustCompile(`(?:^|[^\w$.])(?:(?:window|document|self|top|parent)\.)?location\.(?:replace|assign)\s*\(\s*([^)\n]*)`),
+}
+
+// maxRedirectExpression bounds the script expression kept in the details of a finding
+const maxRedirectExpression = 100
+
+// jsRedirectFindings flags inline scripts at the start of the document that change window.location
+// Browsers run them before the visitor sees the page, so what they show differs from the static analysis.
+// Only scripts in the head and in the body before any visible text count; later ones usually react to clicks.
+// pageURL is the page the finding is for, base the URL relative targets are resolved against
+func jsRedirectFindings(doc *html.Node, pageURL, base string) []models.Finding {
+	position, expression := findJSRedirect(doc)
+	if expression == "" {
+		return nil
+	}
+
+	finding := models.Finding{
+		Type:     models.FindingJSRedirect,
+		Category: models.CategoryContent,
+		Severity: models.SeverityInfo,
+		URL:      pageURL,
+		Message:  "Inline script may redirect the page, which the static analysis doesn't follow",
+		Details: map[string]interface{}{
+			"position":   position,
+			"expression": expression,
+		},
+	}
+	if target := redirectTarget(expression, base); target != "" {
+		finding.Message = fmt.Sprintf("Inline script redirects to %s, which the static analysis doesn't follow", target)
+		finding.Details["target"] = target
+	}
+	return []models.Finding{finding}
+}
+
+// findJSRedirect returns where the first redirecting script at the start of the document is (head or body)
+// and the location it assigns
+func findJSRedirect(doc *html.Node) (string, string) {
+	var position, expression string
+	seenText := false
+
+	var traverse func(n *html.Node, inHead bool)
+	traverse = func(n *html.Node, inHead bool) {
+		if expression != "" || (seenText && !inHead) {
+			return
+		}
+		switch {
+		case n.Type == html.ElementNode && n.Data == "script":
+			if attrValue(n, "src") != "" || n.FirstChild == nil {
+				return
+			}
+			for _, pattern := range jsRedirectPatterns {
+				if match := pattern.FindStringSubmatch(n.FirstChild.Data); match != nil {
+					expression = strings.TrimSpace(match[1])
+					if len(expression) > maxRedirectExpression {
+						expression = expression[:maxRedirectExpression]
+					}
+					position = "body"
+					if inHead {
+						position = "head"
+					}
+					return
+				}
+			}
+			return
+		case n.Type == html.ElementNode && nonVisibleElements[n.Data] && n.Data != "head":
+			return
+		case n.Type == html.TextNode && !inHead && strings.TrimSpace(n.Data) != "":
+			seenText = true
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child, inHead || (n.Type == html.ElementNode && n.Data == "head"))
+		}
+	}
+	traverse(doc, false)
+	return position, expression
+}
+
+// redirectTarget returns the absolute URL of a quoted string literal, or "" for any other expression
+func redirectTarget(expression, base string) string {
+	expression = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(expression), ")"))
+	if len(expression) < 2 {
+		return ""
+	}
+	quote := expression[0]
+	if (quote != '"' && quote != '\'' && quote != '`') || expression[len(expression)-1] != quote {
+		return ""
+	}
+	literal := expression[1 : len(expression)-1]
+	if strings.ContainsAny(literal, "'\"`+") || strings.Contains(literal, "${") {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	if target := resolveHref(baseURL, literal); isHTTPURL(target) {
+		return target
+	}
+	return ""
+}
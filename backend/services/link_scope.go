@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// linkScope decides which links of a crawl are internal, relative to the URL being crawled
+// Internal links are followed by site crawls and get the extra soft 404 check
+type linkScope struct {
+	root              *url.URL
+	registrableDomain bool
+	pathPrefix        string
+}
+
+// newLinkScope returns the scope of a crawl of rootURL
+// The URL's own scope wins over the project's; without either only links to the exact host are internal
+func newLinkScope(rootURL string, config models.CrawlConfig, project *models.Project) linkScope {
+	root, err := url.Parse(rootURL)
+	if err != nil {
+		root = &url.URL{}
+	}
+	root.Host = asciiHost(root.Host)
+
+	rules := models.LinkScope{}
+	switch {
+	case config.Scope != nil:
+		rules = *config.Scope
+	case project != nil:
+		rules = project.LinkScope
+	}
+	return linkScope{
+		root:              root,
+		registrableDomain: rules.Domain == models.LinkScopeRegistrableDomain,
+		pathPrefix:        rules.PathPrefix,
+	}
+}
+
+// internal reports whether the absolute link target is within the scope
+// Targets without a host, such as mailto: links, have always counted as internal
+func (s linkScope) internal(target *url.URL) bool {
+	if target.Host == "" {
+		return true
+	}
+	if !s.sameSite(target) {
+		return false
+	}
+	return s.pathPrefix == "" || strings.HasPrefix(target.Path, s.pathPrefix)
+}
+
+// sameSite compares the hosts, or their registrable domains, which include the subdomains of a site
+// Hosts without a registrable domain, such as IP addresses, must match exactly
+func (s linkScope) sameSite(target *url.URL) bool {
+	if strings.EqualFold(target.Host, s.root.Host) {
+		return true
+	}
+	return s.registrableDomain && isSameSite(target.Hostname(), s.root.Hostname())
+}
+
+// ValidateLinkScope checks the scope rules of a URL or project
+func ValidateLinkScope(scope models.LinkScope) error {
+	if scope.Domain != "" && scope.Domain != models.LinkScopeHost && scope.Domain != models.LinkScopeRegistrableDomain {
+		return fmt.Errorf("domain must be one of %s", strings.Join([]string{models.LinkScopeHost, models.LinkScopeRegistrableDomain}, ", "))
+	}
+	if scope.PathPrefix != "" && !strings.HasPrefix(scope.PathPrefix, "/") {
+		return fmt.Errorf("path_prefix must start with /")
+	}
+	if len(scope.PathPrefix) > 2048 {
+		return fmt.Errorf("path_prefix must be at most 2048 characters")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// loginLimiterSweepSize is the number of tracked keys above which stale entries are swept
+const loginLimiterSweepSize = 10000
+
+// loginFailures tracks the recent failed logins of one IP or username
+type loginFailures struct {
+	times       []time.Time
+	lockedUntil time.Time
+	pending     int // Attempts allowed whose password is still being checked
+}
+
+// LoginLimiter throttles login attempts per client IP and per username
+// After too many failures within the window the IP or username is locked out for the lockout duration
+type LoginLimiter struct {
+	mu         sync.Mutex
+	maxPerUser int
+	maxPerIP   int
+	window     time.Duration
+	lockout    time.Duration
+	failures   map[string]*loginFailures
+}
+
+// NewLoginLimiter creates a limiter; failures are counted within window and lock the key out for lockout
+func NewLoginLimiter(maxPerUser, maxPerIP int, window, lockout time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		maxPerUser: maxPerUser,
+		maxPerIP:   maxPerIP,
+		window:     window,
+		lockout:    lockout,
+		failures:   make(map[string]*loginFailures),
+	}
+}
+
+// LoginAttempt is a login the limiter allowed; exactly one of its methods must be called once the
+// password was checked
+type LoginAttempt struct {
+	limiter      *LoginLimiter
+	ip, username string
+}
+
+// Allow reserves a login attempt from the IP for the username, or reports when to retry if none is left
+// Attempts in progress count as failures until they end, so concurrent guesses can't exceed the limits
+func (l *LoginLimiter) Allow(ip, username string) (*LoginAttempt, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.failures) > loginLimiterSweepSize {
+		l.sweep(now)
+	}
+
+	var retryAfter time.Duration
+	keys := []string{"ip:" + ip, "user:" + username}
+	for i, max := range []int{l.maxPerIP, l.maxPerUser} {
+		entry, ok := l.failures[keys[i]]
+		if !ok {
+			continue
+		}
+		wait := time.Duration(0)
+		if entry.lockedUntil.After(now) {
+			wait = entry.lockedUntil.Sub(now)
+		} else if len(l.recent(entry, now))+entry.pending >= max {
+			wait = time.Second // The attempts in progress may still succeed
+		}
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return nil, retryAfter, false
+	}
+
+	for _, key := range keys {
+		l.entry(key).pending++
+	}
+	return &LoginAttempt{limiter: l, ip: ip, username: username}, 0, true
+}
+
+// Failed counts the attempt as a failed login and reports whether it locked the IP or username out
+func (a *LoginAttempt) Failed() bool {
+	l := a.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	locked := l.fail("ip:"+a.ip, l.maxPerIP, now)
+	if l.fail("user:"+a.username, l.maxPerUser, now) {
+		locked = true
+	}
+	return locked
+}
+
+// Succeeded clears the failures of the IP and username after a successful login
+func (a *LoginAttempt) Succeeded() {
+	l := a.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range []string{"ip:" + a.ip, "user:" + a.username} {
+		entry := l.entry(key)
+		entry.pending--
+		entry.times, entry.lockedUntil = nil, time.Time{}
+	}
+}
+
+// Cancel gives the attempt back when the password couldn't be checked
+func (a *LoginAttempt) Cancel() {
+	l := a.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entry("ip:"+a.ip).pending--
+	l.entry("user:"+a.username).pending--
+}
+
+// entry returns the failures of key, adding them when there are none yet
+func (l *LoginLimiter) entry(key string) *loginFailures {
+	entry, ok := l.failures[key]
+	if !ok {
+		entry = &loginFailures{}
+		l.failures[key] = entry
+	}
+	return entry
+}
+
+// recent drops the failures of entry outside the window and returns the others
+func (l *LoginLimiter) recent(entry *loginFailures, now time.Time) []time.Time {
+	recent := entry.times[:0]
+	for _, t := range entry.times {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+	entry.times = recent
+	return recent
+}
+
+// fail ends a pending attempt of key as a failure and locks key once max failures fall within the window
+func (l *LoginLimiter) fail(key string, max int, now time.Time) bool {
+	entry := l.entry(key)
+	entry.pending--
+	entry.times = append(l.recent(entry, now), now)
+
+	if len(entry.times) >= max {
+		entry.lockedUntil = now.Add(l.lockout)
+		entry.times = nil
+		return true
+	}
+	return false
+}
+
+// sweep drops entries that are neither locked, in use by an attempt, nor hold failures inside the window
+func (l *LoginLimiter) sweep(now time.Time) {
+	for key, entry := range l.failures {
+		stale := entry.lockedUntil.Before(now) && entry.pending == 0
+		for _, t := range entry.times {
+			if now.Sub(t) < l.window {
+				stale = false
+				break
+			}
+		}
+		if stale {
+			delete(l.failures, key)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLimiterReservesAttempts(t *testing.T) {
+	limiter := NewLoginLimiter(3, 10, time.Minute, time.Minute)
+
+	// Attempts still being checked count against the limit, so the fourth concurrent guess is refused
+	var attempts []*LoginAttempt
+	for i := 0; i < 3; i++ {
+		attempt, _, ok := limiter.Allow("192.0.2.1", "admin")
+		if !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if _, _, ok := limiter.Allow("192.0.2.2", "admin"); ok {
+		t.Fatal("fourth concurrent attempt allowed")
+	}
+
+	// An attempt given back frees its place
+	attempts[0].Cancel()
+	fourth, _, ok := limiter.Allow("192.0.2.2", "admin")
+	if !ok {
+		t.Fatal("attempt after Cancel refused")
+	}
+	attempts[1].Failed()
+	if locked := attempts[2].Failed(); locked {
+		t.Fatal("locked after 2 failures")
+	}
+	if _, retryAfter, ok := limiter.Allow("192.0.2.3", "admin"); ok || retryAfter != time.Second {
+		t.Errorf("Allow with an attempt in progress = %v, %v, want a retry after a second", ok, retryAfter)
+	}
+
+	// The third failure locks the username out
+	if locked := fourth.Failed(); !locked {
+		t.Fatal("not locked after 3 failures")
+	}
+	if _, retryAfter, ok := limiter.Allow("192.0.2.3", "admin"); ok || retryAfter <= time.Second {
+		t.Errorf("Allow after the lockout = %v, %v, want a retry after the lockout", ok, retryAfter)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"golang.org/x/net/html"
+)
+
+// Meta description lengths in characters; search engines truncate longer descriptions in their results
+const (
+	metaDescriptionMinLength = 50
+	metaDescriptionMaxLength = 160
+
+	// metaDescriptionVersion is the analyzer version that started extracting meta descriptions
+	metaDescriptionVersion = 3
+)
+
+// findMetaDescription returns the whitespace-normalized content of the first <meta name="description">
+func findMetaDescription(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "meta" {
+		var name, content string
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "name":
+				name = attr.Val
+			case "content":
+				content = attr.Val
+			}
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "description") {
+			return strings.Join(strings.Fields(content), " ")
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if description := findMetaDescription(child); description != "" {
+			return description
+		}
+	}
+	return ""
+}
+
+// metaDescriptionFindings reports a missing meta description, or one that is too short or too long
+func metaDescriptionFindings(pageURL, description string) []models.Finding {
+	length := utf8.RuneCountInString(description)
+
+	finding := models.Finding{
+		Type:     models.FindingMetaDescriptionLength,
+		Category: models.CategoryContent,
+		Severity: models.SeverityWarning,
+		URL:      pageURL,
+		Details: map[string]interface{}{
+			"meta_description": description,
+			"length":           length,
+		},
+	}
+	switch {
+	case length == 0:
+		finding.Type = models.FindingMetaDescriptionMissing
+		finding.Message = "Page has no meta description"
+		finding.Details = nil
+	case length < metaDescriptionMinLength:
+		finding.Severity = models.SeverityInfo
+		finding.Message = fmt.Sprintf("Meta description is too short: %d < %d characters", length, metaDescriptionMinLength)
+	case length > metaDescriptionMaxLength:
+		finding.Message = fmt.Sprintf("Meta description is too long: %d > %d characters", length, metaDescriptionMaxLength)
+	default:
+		return nil
+	}
+	return []models.Finding{finding}
+}
+
+// duplicateDescriptionFindings reports the other URLs of the project whose latest crawl has the same meta description
+// Only the page being crawled gets the finding; the others get it when they are crawled again
+func (c *CrawlerService) duplicateDescriptionFindings(project *models.Project, url models.URL, result *models.CrawlResult) []models.Finding {
+	if project == nil || result.MetaDescription == "" {
+		return nil
+	}
+	pages, err := c.store.CrawlResults().ProjectMetaDescriptions(project.ID, result.MetaDescription)
+	if err != nil {
+		log.Printf("Failed to look up duplicate meta descriptions of URL %d: %v", url.ID, err)
+		return nil
+	}
+
+	var duplicates []string
+	for _, page := range pages {
+		if page.URLID != url.ID {
+			duplicates = append(duplicates, page.URL)
+		}
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return []models.Finding{{
+		Type:     models.FindingMetaDescriptionDuplicate,
+		Category: models.CategoryContent,
+		Severity: models.SeverityWarning,
+		URL:      url.URL,
+		Message:  fmt.Sprintf("Meta description is also used by %d other page(s) of the project", len(duplicates)),
+		Details: map[string]interface{}{
+			"meta_description": result.MetaDescription,
+			"urls":             duplicates,
+		},
+	}}
+}
+
+// MetaDescriptionReport summarizes the meta descriptions of the latest crawls of a project's URLs
+type MetaDescriptionReport struct {
+	ProjectID  uint                             `json:"project_id"`
+	Pages      int                              `json:"pages"`    // URLs with a crawl
+	Outdated   int                              `json:"outdated"` // Crawled before meta descriptions were extracted, not included below
+	Missing    []repository.PageMetaDescription `json:"missing"`
+	TooShort   []repository.PageMetaDescription `json:"too_short"`
+	TooLong    []repository.PageMetaDescription `json:"too_long"`
+	Duplicates []MetaDescriptionDuplicate       `json:"duplicates"` // Most used first
+	MinLength  int                              `json:"min_length"`
+	MaxLength  int                              `json:"max_length"`
+}
+
+// MetaDescriptionDuplicate is a meta description shared by several URLs
+type MetaDescriptionDuplicate struct {
+	MetaDescription string                           `json:"meta_description"`
+	Pages           []repository.PageMetaDescription `json:"pages"`
+}
+
+// BuildMetaDescriptionReport groups the pages by the problems of their meta descriptions
+// Descriptions differing only in case count as duplicates, like in the database lookup at crawl time
+func BuildMetaDescriptionReport(projectID uint, pages []repository.PageMetaDescription) MetaDescriptionReport {
+	report := MetaDescriptionReport{
+		ProjectID:  projectID,
+		Pages:      len(pages),
+		Missing:    []repository.PageMetaDescription{},
+		TooShort:   []repository.PageMetaDescription{},
+		TooLong:    []repository.PageMetaDescription{},
+		Duplicates: []MetaDescriptionDuplicate{},
+		MinLength:  metaDescriptionMinLength,
+		MaxLength:  metaDescriptionMaxLength,
+	}
+
+	groups := make(map[string][]repository.PageMetaDescription)
+	var order []string
+	for _, page := range pages {
+		if page.AnalyzerVersion < metaDescriptionVersion {
+			report.Outdated++
+			continue
+		}
+		length := utf8.RuneCountInString(page.MetaDescription)
+		switch {
+		case length == 0:
+			report.Missing = append(report.Missing, page)
+			continue
+		case length < metaDescriptionMinLength:
+			report.TooShort = append(report.TooShort, page)
+		case length > metaDescriptionMaxLength:
+			report.TooLong = append(report.TooLong, page)
+		}
+		key := strings.ToLower(page.MetaDescription)
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], page)
+	}
+
+	for _, key := range order {
+		if group := groups[key]; len(group) > 1 {
+			report.Duplicates = append(report.Duplicates, MetaDescriptionDuplicate{MetaDescription: group[0].MetaDescription, Pages: group})
+		}
+	}
+	sort.SliceStable(report.Duplicates, func(i, j int) bool {
+		return len(report.Duplicates[i].Pages) > len(report.Duplicates[j].Pages)
+	})
+	return report
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// maxMetaRefreshHops bounds the meta refreshes followed one after another, so refresh loops end
+const maxMetaRefreshHops = 5
+
+// detectMetaRefresh returns the first <meta http-equiv="refresh"> of the document, or nil without one
+// pageURL is the page carrying it, base the URL relative targets are resolved against
+func detectMetaRefresh(doc *html.Node, pageURL, base string) *models.MetaRefresh {
+	var content string
+	found := false
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			var equiv, value string
+			for _, attr := range n.Attr {
+				switch attr.Key {
+				case "http-equiv":
+					equiv = attr.Val
+				case "content":
+					value = attr.Val
+				}
+			}
+			if strings.EqualFold(strings.TrimSpace(equiv), "refresh") {
+				content, found = value, true
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+	if !found {
+		return nil
+	}
+
+	delay, target, ok := parseMetaRefresh(content)
+	if !ok {
+		return nil
+	}
+	refresh := &models.MetaRefresh{URL: pageURL, Delay: delay}
+	if target != "" {
+		baseURL, _ := url.Parse(base)
+		if resolved := resolveHref(baseURL, target); isHTTPURL(resolved) && resolved != normalizePageURL(base) {
+			refresh.Target = resolved
+		}
+	}
+	return refresh
+}
+
+// parseMetaRefresh splits a refresh content value such as `0; url='/new'` into the delay and the target
+// It follows the HTML standard's parsing loosely: fractions of the delay are dropped and quotes are optional
+func parseMetaRefresh(content string) (int, string, bool) {
+	content = strings.TrimSpace(content)
+	digits := 0
+	for digits < len(content) && content[digits] >= '0' && content[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return 0, "", false
+	}
+	delay, err := strconv.Atoi(content[:digits])
+	if err != nil {
+		return 0, "", false
+	}
+
+	rest := strings.TrimLeft(content[digits:], "0123456789.")
+	rest = strings.TrimLeft(rest, " \t\n\r;,")
+	if len(rest) >= 3 && strings.EqualFold(rest[:3], "url") {
+		if after := strings.TrimLeft(rest[3:], " \t\n\r"); strings.HasPrefix(after, "=") {
+			rest = strings.TrimLeft(after[1:], " \t\n\r")
+		}
+	}
+	if len(rest) > 0 && (rest[0] == '\'' || rest[0] == '"') {
+		quote := rest[0]
+		rest = rest[1:]
+		if end := strings.IndexByte(rest, quote); end >= 0 {
+			rest = rest[:end]
+		}
+	}
+	return delay, strings.TrimSpace(rest), true
+}
+
+// metaRefreshFindings reports a meta refresh redirect; a delayed one is a warning, since visitors
+// see the page before being sent away and search engines may not treat it as a redirect
+func metaRefreshFindings(refresh *models.MetaRefresh) []models.Finding {
+	if refresh == nil || refresh.Target == "" {
+		return nil
+	}
+	finding := models.Finding{
+		Type:     models.FindingMetaRefresh,
+		Category: models.CategoryContent,
+		Severity: models.SeverityInfo,
+		URL:      refresh.URL,
+		Message:  fmt.Sprintf("Page redirects to %s with a meta refresh", refresh.Target),
+		Details: map[string]interface{}{
+			"target":   refresh.Target,
+			"delay":    refresh.Delay,
+			"followed": refresh.Followed,
+		},
+	}
+	if refresh.Delay > 0 {
+		finding.Severity = models.SeverityWarning
+		finding.Message = fmt.Sprintf("Page redirects to %s with a meta refresh after %d second(s)", refresh.Target, refresh.Delay)
+	}
+	if refresh.Followed {
+		finding.Details["final_url"] = refresh.FinalURL
+	}
+	return []models.Finding{finding}
+}
+
+// followMetaRefresh fetches the destinations of meta refresh redirects until a page has none
+// It returns the last page fetched and the refresh of the first page, nil when it had none to follow
+func (c *CrawlerService) followMetaRefresh(ctx context.Context, page *fetchedPage, settings models.Settings, config models.CrawlConfig, tracker *throttleTracker) (*fetchedPage, *models.MetaRefresh, error) {
+	var first *models.MetaRefresh
+	visited := map[string]bool{normalizePageURL(page.FinalURL): true}
+
+	for hop := 1; hop <= maxMetaRefreshHops && page.Doc != nil; hop++ {
+		refresh := detectMetaRefresh(page.Doc, page.FinalURL, page.FinalURL)
+		if refresh == nil || refresh.Target == "" || visited[refresh.Target] {
+			break
+		}
+		next, err := c.fetchPage(ctx, refresh.Target, settings, config, tracker)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to follow meta refresh to %s: %v", refresh.Target, err)
+		}
+		visited[refresh.Target] = true
+		visited[normalizePageURL(next.FinalURL)] = true
+
+		if first == nil {
+			first = refresh
+		}
+		first.Followed = true
+		first.Hops = hop
+		first.FinalURL = next.FinalURL
+		page = next
+	}
+	return page, first, nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// Thresholds of the mobile-friendliness checks, in CSS pixels
+const (
+	minLegibleFontPx  = 12  // Smaller text needs zooming on a phone
+	minTapTargetPx    = 24  // WCAG 2.2 minimum target size
+	minZoomScale      = 5.0 // A lower maximum-scale keeps visitors with poor sight from zooming in
+	maxMobileExamples = 5   // Offending elements listed per finding
+)
+
+var (
+	cssFontSizePattern  = regexp.MustCompile(`(?i)font-size\s*:\s*([0-9]*\.?[0-9]+)\s*(px|pt)\b`)
+	cssDimensionPattern = regexp.MustCompile(`(?i)(?:^|[;\s])(width|height)\s*:\s*([0-9]*\.?[0-9]+)\s*px\b`)
+)
+
+// tapTargetElements are the elements visitors tap; hidden inputs aren't shown
+var tapTargetElements = map[string]bool{"a": true, "button": true, "input": true, "select": true, "textarea": true}
+
+// auditMobile checks the page for what makes it hard to use on a phone: a missing or fixed-width viewport,
+// disabled zoom, small font sizes, and small tap targets
+// Without a browser only the page's own markup counts: style attributes, <style> blocks, and width and
+// height attributes; external stylesheets aren't fetched, so a site styling everything through them passes
+func auditMobile(doc *html.Node, pageURL string) (models.MobileAudit, []models.Finding) {
+	audit := models.MobileAudit{Viewport: findMetaViewport(doc)}
+	viewport := parseViewport(audit.Viewport)
+	audit.Responsive = viewport["width"] == "device-width"
+	audit.ZoomDisabled = zoomDisabled(viewport)
+
+	var smallFonts, smallTargets []string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "style":
+				if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+					smallFonts = append(smallFonts, smallFontSizes(n.FirstChild.Data)...)
+				}
+			case "font":
+				// size="1" is 10px and size="-2" makes text two steps smaller
+				if size := attrValue(n, "size"); size == "1" || size == "-2" || size == "-3" {
+					smallFonts = append(smallFonts, fmt.Sprintf(`<font size="%s">`, size))
+				}
+			}
+			smallFonts = append(smallFonts, smallFontSizes(attrValue(n, "style"))...)
+
+			if tapTargetElements[n.Data] && !strings.EqualFold(attrValue(n, "type"), "hidden") {
+				if width, height := tapTargetSize(n); (width > 0 && width < minTapTargetPx) || (height > 0 && height < minTapTargetPx) {
+					smallTargets = append(smallTargets, tapTargetLabel(n, width, height))
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	audit.SmallFonts = len(smallFonts)
+	audit.SmallTapTargets = len(smallTargets)
+	audit.Friendly = audit.Viewport != "" && audit.Responsive && !audit.ZoomDisabled && audit.SmallFonts == 0 && audit.SmallTapTargets == 0
+	return audit, mobileFindings(pageURL, audit, smallFonts, smallTargets)
+}
+
+// findMetaViewport returns the whitespace-normalized content of the first <meta name="viewport">
+func findMetaViewport(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "meta" {
+		var name, content string
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "name":
+				name = attr.Val
+			case "content":
+				content = attr.Val
+			}
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "viewport") {
+			return strings.Join(strings.Fields(content), " ")
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if viewport := findMetaViewport(child); viewport != "" {
+			return viewport
+		}
+	}
+	return ""
+}
+
+// parseViewport splits viewport content such as "width=device-width, initial-scale=1" into lowercase keys and values
+// Browsers accept semicolons as separators too
+func parseViewport(content string) map[string]string {
+	values := make(map[string]string)
+	for _, part := range strings.FieldsFunc(content, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, _ := strings.Cut(part, "=")
+		values[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+	}
+	return values
+}
+
+// zoomDisabled reports a viewport that keeps visitors from zooming in
+func zoomDisabled(viewport map[string]string) bool {
+	if scalable, ok := viewport["user-scalable"]; ok && (scalable == "no" || scalable == "0") {
+		return true
+	}
+	if maximum, err := strconv.ParseFloat(viewport["maximum-scale"], 64); err == nil && maximum < minZoomScale {
+		return true
+	}
+	return false
+}
+
+// smallFontSizes returns the font-size declarations of CSS that are below the legible size
+func smallFontSizes(css string) []string {
+	var small []string
+	for _, match := range cssFontSizePattern.FindAllStringSubmatch(css, -1) {
+		size, err := strconv.ParseFloat(match[1], 64)
+		if err != nil || size == 0 {
+			continue
+		}
+		if strings.EqualFold(match[2], "pt") {
+			size = size * 4 / 3
+		}
+		if size < minLegibleFontPx {
+			small = append(small, strings.Join(strings.Fields(match[0]), " "))
+		}
+	}
+	return small
+}
+
+// tapTargetSize returns the width and height an element declares in its style or attributes, 0 when unknown
+// A link wrapping nothing but an image is as large as the image
+func tapTargetSize(n *html.Node) (width, height float64) {
+	width, height = declaredSize(n)
+	if width == 0 && height == 0 && (n.Data == "a" || n.Data == "button") {
+		if image := onlyImageChild(n); image != nil {
+			width, height = declaredSize(image)
+		}
+	}
+	return width, height
+}
+
+// declaredSize reads the pixel width and height of an element, preferring its style over its attributes
+func declaredSize(n *html.Node) (width, height float64) {
+	width, _ = strconv.ParseFloat(attrValue(n, "width"), 64)
+	height, _ = strconv.ParseFloat(attrValue(n, "height"), 64)
+	for _, match := range cssDimensionPattern.FindAllStringSubmatch(attrValue(n, "style"), -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(match[1], "width") {
+			width = value
+		} else {
+			height = value
+		}
+	}
+	return width, height
+}
+
+// onlyImageChild returns the <img> that is the only content of an element, ignoring whitespace
+func onlyImageChild(n *html.Node) *html.Node {
+	var image *html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode && strings.TrimSpace(child.Data) == "":
+		case child.Type == html.ElementNode && child.Data == "img" && image == nil:
+			image = child
+		default:
+			return nil
+		}
+	}
+	return image
+}
+
+// tapTargetLabel describes a small tap target for a finding, e.g. <a href="/cart"> 16x16px
+func tapTargetLabel(n *html.Node, width, height float64) string {
+	label := "<" + n.Data
+	for _, key := range []string{"href", "name", "id"} {
+		if value := attrValue(n, key); value != "" {
+			label += fmt.Sprintf(" %s=%q", key, value)
+			break
+		}
+	}
+	return fmt.Sprintf("%s> %gx%gpx", label, width, height)
+}
+
+// mobileFindings reports each mobile-friendliness problem once per page, with examples of the offending elements
+func mobileFindings(pageURL string, audit models.MobileAudit, smallFonts, smallTargets []string) []models.Finding {
+	var findings []models.Finding
+	details := map[string]interface{}{"viewport": audit.Viewport}
+	switch {
+	case audit.Viewport == "":
+		findings = append(findings, models.Finding{
+			Type:     models.FindingViewportMissing,
+			Category: models.CategoryContent,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  "The page has no viewport meta tag, so phones show it zoomed out at desktop width",
+			Details:  details,
+		})
+	case !audit.Responsive:
+		findings = append(findings, models.Finding{
+			Type:     models.FindingViewportNotResponsive,
+			Category: models.CategoryContent,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  "The viewport meta tag doesn't set width=device-width, so the page doesn't adapt to the screen",
+			Details:  details,
+		})
+	}
+	if audit.ZoomDisabled {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingViewportZoomDisabled,
+			Category: models.CategoryContent,
+			Severity: models.SeverityWarning,
+			URL:      pageURL,
+			Message:  "The viewport meta tag keeps visitors from zooming in",
+			Details:  details,
+		})
+	}
+	if len(smallFonts) > 0 {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingSmallFontSize,
+			Category: models.CategoryContent,
+			Severity: models.SeverityInfo,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("%d font sizes are below %dpx, which is hard to read on a phone", len(smallFonts), minLegibleFontPx),
+			Details: map[string]interface{}{
+				"count":    len(smallFonts),
+				"examples": firstStrings(smallFonts, maxMobileExamples),
+			},
+		})
+	}
+	if len(smallTargets) > 0 {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingSmallTapTarget,
+			Category: models.CategoryContent,
+			Severity: models.SeverityInfo,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("%d links or controls are smaller than %dpx, which is hard to tap", len(smallTargets), minTapTargetPx),
+			Details: map[string]interface{}{
+				"count":    len(smallTargets),
+				"examples": firstStrings(smallTargets, maxMobileExamples),
+			},
+		})
+	}
+	return findings
+}
+
+// firstStrings returns at most limit values
+func firstStrings(values []string, limit int) []string {
+	if len(values) > limit {
+		return values[:limit]
+	}
+	return values
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// Crawler modes
+const (
+	CrawlerModeLive = "live" // Fetch pages over the network
+	CrawlerModeMock = "mock" // Return synthetic results without network access
+)
+
+// MockCrawl configures the mock crawler mode
+// Results are derived from a hash of the URL, so the same URL always gets the same result
+type MockCrawl struct {
+	Latency        time.Duration // Time every mock crawl takes
+	FailurePercent int           // 0-100, share of URLs whose crawl fails
+}
+
+// EnableMock makes the crawler return synthetic results instead of fetching pages,
+// for fast and hermetic local and CI runs
+func (c *CrawlerService) EnableMock(mock MockCrawl) {
+	c.mock = &mock
+}
+
+// mockCrawl builds a deterministic page for the URL and runs the analyzers that don't need the network
+func (c *CrawlerService) mockCrawl(targetURL string, project *models.Project) (*models.CrawlResult, error) {
+	time.Sleep(c.mock.Latency)
+
+	seed := mockHash(targetURL)
+	if int(seed%100) < c.mock.FailurePercent {
+		return nil, fmt.Errorf("HTTP %d: %s (mock)", http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+	}
+
+	page := mockPage(targetURL, seed)
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mock page: %v", err)
+	}
+
+	result := &models.CrawlResult{
+		CrawledAt:       time.Now(),
+		AnalyzerVersion: AnalyzerVersion,
+	}
+	c.extractTitle(doc, result)
+	result.MetaDescription = findMetaDescription(doc)
+	c.extractHTMLVersion(doc, result)
+	c.extractHeadingCounts(doc, result)
+	c.extractLinks(doc, result, targetURL, newLinkScope(targetURL, models.CrawlConfig{}, project))
+	c.checkLoginForm(doc, result)
+	result.Content = analyzeContent(doc)
+	result.Findings = append(result.Findings, policyFindings(targetURL, visibleText(doc), project)...)
+	result.Findings = append(result.Findings, metaDescriptionFindings(targetURL, result.MetaDescription)...)
+	result.Findings = append(result.Findings, headingFindings(targetURL, result.Title, headingTexts(doc), project)...)
+
+	result.Weight = measurePageWeight(doc, targetURL, int64(len(page)))
+	status, budgetFindings := evaluateBudget(targetURL, result.Weight, project)
+	result.BudgetStatus = status
+	result.Findings = append(result.Findings, budgetFindings...)
+
+	sriAudit, sriFindings := auditSRI(doc, targetURL)
+	result.Security.SRI = sriAudit
+	result.Findings = append(result.Findings, sriFindings...)
+	measurePartySplit(result, doc, targetURL)
+	result.Technologies = detectTechnologies(doc, nil)
+
+	// Every tenth link is broken and every seventh permanently redirects
+	for i := range result.Links {
+		link := &result.Links[i]
+		linkSeed := mockHash(link.URL)
+		link.CheckMethod = http.MethodHead
+		link.InitialStatusCode = http.StatusOK
+		link.StatusCode = http.StatusOK
+		link.FinalURL = link.URL
+		switch {
+		case linkSeed%10 == 0:
+			link.InitialStatusCode = http.StatusNotFound
+			link.StatusCode = http.StatusNotFound
+			result.InaccessibleLinks++
+		case linkSeed%7 == 0:
+			link.InitialStatusCode = http.StatusMovedPermanently
+			link.FinalURL = strings.TrimSuffix(link.URL, "/") + "/new"
+			link.RedirectCount = 1
+			link.PermanentRedirect = true
+			result.PermanentRedirects++
+		}
+		link.IsAccessible = link.StatusCode < 400
+	}
+
+	return result, nil
+}
+
+// mockPage renders the synthetic HTML page of a URL; seed varies its structure
+func mockPage(targetURL string, seed uint32) string {
+	host := targetURL
+	if parsed, err := url.Parse(targetURL); err == nil {
+		host = parsed.Hostname()
+	}
+
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html>\n<html lang=\"en\"><head>")
+	fmt.Fprintf(&page, "<title>Mock page of %s</title>", html.EscapeString(host))
+	page.WriteString(`<script src="https://cdn.example/app.js"></script>`)
+	page.WriteString("</head><body><main>")
+	fmt.Fprintf(&page, "<h1>%s</h1>", html.EscapeString(host))
+
+	sections := 2 + int(seed%4)
+	for i := 1; i <= sections; i++ {
+		fmt.Fprintf(&page, "<h2>Section %d</h2>", i)
+		page.WriteString("<p>This page was generated by the mock crawler. It has a few short sentences so the readability analysis has something to measure. The links below point to pages of the same site and to other sites.</p>")
+	}
+
+	internal := 2 + int(seed%5)
+	for i := 1; i <= internal; i++ {
+		fmt.Fprintf(&page, `<a href="/page-%d">Page %d</a>`, i, i)
+	}
+	external := 1 + int(seed/7%3)
+	for i := 1; i <= external; i++ {
+		fmt.Fprintf(&page, `<a href="https://external-%d.example/">External %d</a>`, i, i)
+	}
+
+	if seed%3 == 0 {
+		page.WriteString(`<form><input type="email" name="email"><input type="password" name="password"></form>`)
+	}
+	page.WriteString("</main></body></html>")
+	return page.String()
+}
+
+// mockHash returns a stable hash of s
+func mockHash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// resourceAttributes names the attribute holding the URL of a resource the browser downloads
+var resourceAttributes = map[string]string{
+	"script": "src",
+	"link":   "href",
+	"img":    "src",
+	"iframe": "src",
+	"video":  "src",
+	"audio":  "src",
+	"source": "src",
+	"embed":  "src",
+}
+
+// isExecutableScript reports whether a script type runs as JavaScript; data blocks such as JSON-LD don't
+func isExecutableScript(scriptType string) bool {
+	switch strings.ToLower(strings.TrimSpace(strings.SplitN(scriptType, ";", 2)[0])) {
+	case "", "text/javascript", "application/javascript", "module", "text/ecmascript", "application/ecmascript":
+		return true
+	}
+	return false
+}
+
+// textSize returns the byte length of an element's text content
+func textSize(n *html.Node) int64 {
+	var size int64
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			size += int64(len(child.Data))
+		}
+	}
+	return size
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// measurePageWeight counts the scripts and styles of a page and the third-party domains it loads resources from
+func measurePageWeight(doc *html.Node, pageURL string, htmlBytes int64) models.PageWeight {
+	weight := models.PageWeight{HTMLBytes: htmlBytes}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return weight
+	}
+	site := registrableDomain(base.Hostname())
+
+	domains := make(map[string]bool) // Registrable domains, so cdn1.ads.example and cdn2.ads.example count once
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script":
+				weight.ScriptCount++
+				if attrValue(n, "src") != "" {
+					weight.ExternalScripts++
+				} else if isExecutableScript(attrValue(n, "type")) {
+					weight.InlineScripts++
+					weight.InlineScriptBytes += textSize(n)
+				}
+			case "style":
+				weight.InlineStyles++
+				weight.InlineStyleBytes += textSize(n)
+			case "link":
+				if containsString(strings.Fields(strings.ToLower(attrValue(n, "rel"))), "stylesheet") {
+					weight.ExternalStyles++
+				}
+			}
+			if attrValue(n, "style") != "" {
+				weight.InlineStyleAttributes++
+			}
+			if attribute, ok := resourceAttributes[n.Data]; ok {
+				if value := attrValue(n, attribute); value != "" {
+					if resource, err := base.Parse(value); err == nil && isHTTPURL(resource.String()) {
+						if domain := registrableDomain(resource.Hostname()); domain != site {
+							domains[domain] = true
+						}
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	weight.ThirdPartyDomains = make([]string, 0, len(domains))
+	for domain := range domains {
+		weight.ThirdPartyDomains = append(weight.ThirdPartyDomains, domain)
+	}
+	sort.Strings(weight.ThirdPartyDomains)
+
+	return weight
+}
+
+// isSameSite reports whether both hosts belong to the same registrable domain, e.g. shop.example.co.uk and example.co.uk
+func isSameSite(host, site string) bool {
+	return registrableDomain(host) == registrableDomain(site)
+}
+
+// registrableDomain returns the domain a host is registered under according to the public suffix list,
+// so example.co.uk for shop.example.co.uk and user.github.io for itself.
+// Hosts without one, such as IP addresses and localhost, are returned as they are.
+func registrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// ValidatePageBudget checks that no budget limit is negative
+func ValidatePageBudget(budget models.PageBudget) error {
+	if budget.MaxHTMLBytes < 0 || budget.MaxScripts < 0 || budget.MaxThirdPartyDomains < 0 {
+		return fmt.Errorf("budget limits must not be negative")
+	}
+	return nil
+}
+
+// evaluateBudget checks a page against the project budget
+// The status is empty when the project does not set a budget
+func evaluateBudget(pageURL string, weight models.PageWeight, project *models.Project) (string, []models.Finding) {
+	if project == nil || project.Budget == (models.PageBudget{}) {
+		return "", nil
+	}
+	budget := project.Budget
+
+	var findings []models.Finding
+	exceeded := func(metric string, actual, limit int64) {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingBudgetExceeded,
+			Category: models.CategoryPerformance,
+			Severity: models.SeverityError,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("Page exceeds the %s budget: %d > %d", metric, actual, limit),
+			Details: map[string]interface{}{
+				"metric": metric,
+				"actual": actual,
+				"limit":  limit,
+			},
+		})
+	}
+
+	if budget.MaxHTMLBytes > 0 && weight.HTMLBytes > budget.MaxHTMLBytes {
+		exceeded("html_bytes", weight.HTMLBytes, budget.MaxHTMLBytes)
+	}
+	if budget.MaxScripts > 0 && weight.ScriptCount > budget.MaxScripts {
+		exceeded("scripts", int64(weight.ScriptCount), int64(budget.MaxScripts))
+	}
+	if budget.MaxThirdPartyDomains > 0 && len(weight.ThirdPartyDomains) > budget.MaxThirdPartyDomains {
+		exceeded("third_party_domains", int64(len(weight.ThirdPartyDomains)), int64(budget.MaxThirdPartyDomains))
+	}
+
+	if len(findings) > 0 {
+		return models.BudgetFail, findings
+	}
+	return models.BudgetPass, nil
+}
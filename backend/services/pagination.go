@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// pageNumberPlaceholder replaces the page number in the URL of a paginated series
+const pageNumberPlaceholder = "{page}"
+
+// paginationQueryParams are query parameters commonly carrying the page number
+var paginationQueryParams = []string{"page", "p", "pg", "paged", "seite", "pagina"}
+
+// paginationPathPattern matches page numbers in the path, e.g. /blog/page/2/
+var paginationPathPattern = regexp.MustCompile(`/(?:page|p|seite|pagina)/(\d+)/?$`)
+
+// infiniteScrollAttributes mark elements of common infinite scroll and "load more" scripts
+var infiniteScrollAttributes = []string{"data-infinite-scroll", "data-next-page", "data-next-url", "data-load-more"}
+
+// paginationKey returns the series of a URL with a page number, together with the number
+func paginationKey(rawURL string) (string, int, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", 0, false
+	}
+	base := u.Scheme + "://" + u.Host
+
+	if match := paginationPathPattern.FindStringSubmatchIndex(u.Path); match != nil {
+		number, _ := strconv.Atoi(u.Path[match[2]:match[3]])
+		key := base + u.Path[:match[2]] + pageNumberPlaceholder + u.Path[match[3]:]
+		if u.RawQuery != "" {
+			key += "?" + sortedQuery(u.Query())
+		}
+		return key, number, true
+	}
+
+	query := u.Query()
+	for _, param := range paginationQueryParams {
+		number, err := strconv.Atoi(query.Get(param))
+		if err != nil || number < 1 {
+			continue
+		}
+		query.Set(param, pageNumberPlaceholder)
+		return base + u.Path + "?" + sortedQuery(query), number, true
+	}
+	return "", 0, false
+}
+
+// sortedQuery encodes query sorted by parameter, keeping the page number placeholder readable
+func sortedQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), url.QueryEscape(pageNumberPlaceholder), pageNumberPlaceholder)
+}
+
+// detectPagination finds the rel=prev/next links and infinite scroll markers of a page and places it in its series
+// A first page usually has no number in its URL, so it joins the series of its rel=next page
+func detectPagination(doc *html.Node, pageURL string) models.Pagination {
+	var pagination models.Pagination
+	base, _ := url.Parse(pageURL)
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data == "link" || n.Data == "a" {
+				var rel, href string
+				for _, attr := range n.Attr {
+					switch attr.Key {
+					case "rel":
+						rel = strings.ToLower(attr.Val)
+					case "href":
+						href = attr.Val
+					}
+				}
+				if target := resolveHref(base, href); target != "" {
+					for _, token := range strings.Fields(rel) {
+						switch {
+						case token == "next" && pagination.Next == "":
+							pagination.Next = target
+						case (token == "prev" || token == "previous") && pagination.Prev == "":
+							pagination.Prev = target
+						}
+					}
+				}
+			}
+			if !pagination.InfiniteScroll && isInfiniteScrollElement(n) {
+				pagination.InfiniteScroll = true
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	if key, number, ok := paginationKey(pageURL); ok {
+		pagination.Series, pagination.Page = key, number
+	} else if key, number, ok := paginationKey(pagination.Next); ok && number == 2 {
+		pagination.Series, pagination.Page = key, 1
+	} else if key, number, ok := paginationKey(pagination.Prev); ok {
+		pagination.Series, pagination.Page = key, number+1
+	}
+	return pagination
+}
+
+func resolveHref(base *url.URL, href string) string {
+	if base == nil || href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return normalizePageURL(base.ResolveReference(ref).String())
+}
+
+func isInfiniteScrollElement(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if containsString(infiniteScrollAttributes, attr.Key) {
+			return true
+		}
+		if attr.Key == "class" {
+			for _, class := range strings.Fields(strings.ToLower(attr.Val)) {
+				if strings.Contains(class, "infinite-scroll") || strings.Contains(class, "load-more") || strings.Contains(class, "loadmore") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// groupingDetails tell apart findings of one type that are about different things, e.g. different policy terms
+var groupingDetails = []string{"term", "metric", "element"}
+
+// seriesGrouper merges the findings of the pages of a paginated series, so a 50 page archive reports each
+// problem once, on its first page, with the pages it affects in the details
+type seriesGrouper struct {
+	result  *models.CrawlResult
+	merged  map[string]int // Series, type, and grouping details -> index in result.Findings
+	members map[string][]string
+}
+
+func newSeriesGrouper(result *models.CrawlResult) *seriesGrouper {
+	return &seriesGrouper{result: result, merged: make(map[string]int), members: make(map[string][]string)}
+}
+
+// adopt indexes the findings already in the result, those of the root page, as the first page of its series
+func (g *seriesGrouper) adopt(pageURL string, pagination models.Pagination) {
+	findings := g.result.Findings
+	g.result.Findings = nil
+	g.add(pageURL, pagination, findings)
+}
+
+// add records the findings of a page, merging them into earlier findings of the same series
+func (g *seriesGrouper) add(pageURL string, pagination models.Pagination, findings []models.Finding) {
+	if pagination.Series != "" && !containsString(g.members[pagination.Series], pageURL) {
+		g.members[pagination.Series] = append(g.members[pagination.Series], pageURL)
+	}
+
+	for _, finding := range findings {
+		// Findings about the page's links stay as they are
+		if pagination.Series == "" || normalizePageURL(finding.URL) != pageURL {
+			g.result.Findings = append(g.result.Findings, finding)
+			continue
+		}
+
+		key := pagination.Series + "|" + finding.Type
+		for _, detail := range groupingDetails {
+			if value, ok := finding.Details[detail]; ok {
+				key += "|" + detail + "=" + fmt.Sprint(value)
+			}
+		}
+		index, seen := g.merged[key]
+		if !seen {
+			if finding.Details == nil {
+				finding.Details = make(map[string]interface{})
+			}
+			finding.Details["pagination_series"] = pagination.Series
+			finding.Details["series_urls"] = []string{finding.URL}
+			g.merged[key] = len(g.result.Findings)
+			g.result.Findings = append(g.result.Findings, finding)
+			continue
+		}
+		existing := g.result.Findings[index]
+		urls := existing.Details["series_urls"].([]string)
+		if !containsString(urls, finding.URL) {
+			existing.Details["series_urls"] = append(urls, finding.URL)
+		}
+	}
+}
+
+// seriesOf returns the paginated series seen by the crawl with at least two pages, largest first
+func (g *seriesGrouper) seriesOf() []models.PaginatedSeries {
+	var series []models.PaginatedSeries
+	for key, urls := range g.members {
+		if len(urls) > 1 {
+			series = append(series, models.PaginatedSeries{Series: key, Pages: len(urls), URLs: urls})
+		}
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Pages != series[j].Pages {
+			return series[i].Pages > series[j].Pages
+		}
+		return series[i].Series < series[j].Series
+	})
+	return series
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	pluginTimeout       = 30 * time.Second
+	maxPlugins          = 10      // Plugins per project
+	maxPluginResponse   = 1 << 20 // Bytes read from a plugin's answer
+	maxPluginFindings   = 200     // Findings kept per plugin and crawl
+	maxPluginMessage    = 1000    // Characters per finding message
+	pluginSignatureName = "X-Analyzer-Signature"
+)
+
+var (
+	pluginNamePattern        = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+	pluginFindingTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+)
+
+// PluginRequest is the body POSTed to an analyzer plugin
+type PluginRequest struct {
+	Plugin     string              `json:"plugin"`
+	URLID      uint                `json:"url_id"`
+	URL        string              `json:"url"`
+	ProjectID  *uint               `json:"project_id"`
+	FinalURL   string              `json:"final_url,omitempty"`
+	Header     map[string][]string `json:"header,omitempty"`
+	HTML       string              `json:"html,omitempty"` // Missing for pages too large to keep a snapshot of
+	Result     *models.CrawlResult `json:"result"`         // Crawl result with its links and core findings
+	AnalyzedAt time.Time           `json:"analyzed_at"`
+}
+
+// PluginResponse is what a plugin answers with
+type PluginResponse struct {
+	Findings []PluginFinding `json:"findings"`
+}
+
+// PluginFinding is a finding reported by a plugin; URL defaults to the page,
+// Category to content and Severity to warning
+type PluginFinding struct {
+	Type     string                 `json:"type"`
+	Category string                 `json:"category"`
+	Severity string                 `json:"severity"`
+	URL      string                 `json:"url"`
+	Message  string                 `json:"message"`
+	Details  map[string]interface{} `json:"details"`
+}
+
+// ValidateAnalyzerPlugins checks the plugins of a project
+func ValidateAnalyzerPlugins(plugins []models.AnalyzerPlugin) error {
+	if len(plugins) > maxPlugins {
+		return fmt.Errorf("at most %d plugins are allowed", maxPlugins)
+	}
+	names := make(map[string]bool, len(plugins))
+	for _, plugin := range plugins {
+		if !pluginNamePattern.MatchString(plugin.Name) {
+			return fmt.Errorf("plugin names must be 1 to 50 lowercase letters, digits, - or _")
+		}
+		if names[plugin.Name] {
+			return fmt.Errorf("plugin %q is defined more than once", plugin.Name)
+		}
+		names[plugin.Name] = true
+		target, err := url.Parse(plugin.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" || len(plugin.URL) > 2048 {
+			return fmt.Errorf("plugin %q: url must be an absolute http or https URL of at most 2048 characters", plugin.Name)
+		}
+	}
+	return nil
+}
+
+// pluginFindings sends the crawled page to every plugin of the project and returns the findings they answer with
+// A plugin that fails gets a plugin_failed finding, so a broken integration doesn't go unnoticed
+// Cancelling ctx cancels the calls in flight; the findings are then incomplete
+func (c *CrawlerService) pluginFindings(ctx context.Context, project *models.Project, url models.URL, result *models.CrawlResult) []models.Finding {
+	if project == nil || len(project.Plugins.Plugins) == 0 {
+		return nil
+	}
+
+	request := PluginRequest{
+		URLID:      url.ID,
+		URL:        url.URL,
+		ProjectID:  url.ProjectID,
+		Result:     result,
+		AnalyzedAt: time.Now().UTC(),
+	}
+	if result.Snapshot != nil {
+		request.FinalURL = result.Snapshot.FinalURL
+		request.Header = result.Snapshot.Header
+		request.HTML = string(result.Snapshot.HTML)
+	}
+
+	// The plugins are called at once, so together they take at most pluginTimeout
+	answers := make([][]models.Finding, len(project.Plugins.Plugins))
+	var wg sync.WaitGroup
+	for i, plugin := range project.Plugins.Plugins {
+		wg.Add(1)
+		go func(i int, plugin models.AnalyzerPlugin, request PluginRequest) {
+			defer wg.Done()
+			request.Plugin = plugin.Name
+			answers[i] = c.pluginAnswer(ctx, plugin, project.PluginSecret, url, request)
+		}(i, plugin, request)
+	}
+	wg.Wait()
+
+	var findings []models.Finding
+	for _, answer := range answers {
+		findings = append(findings, answer...)
+	}
+	return findings
+}
+
+// pluginAnswer calls one plugin and returns its findings, or a plugin_failed finding when the call failed
+func (c *CrawlerService) pluginAnswer(ctx context.Context, plugin models.AnalyzerPlugin, secret string, url models.URL, request PluginRequest) []models.Finding {
+	reported, err := c.callPlugin(ctx, plugin, secret, request)
+	if err != nil {
+		return []models.Finding{{
+			Type:     models.FindingPluginFailed,
+			Category: models.CategoryNetwork,
+			Severity: models.SeverityInfo,
+			URL:      url.URL,
+			Message:  fmt.Sprintf("Plugin %q failed: %v", plugin.Name, err),
+			Details: map[string]interface{}{
+				"plugin": plugin.Name,
+				"error":  err.Error(),
+			},
+		}}
+	}
+	findings := make([]models.Finding, 0, len(reported))
+	for _, finding := range reported {
+		findings = append(findings, pluginFinding(plugin, url.URL, finding))
+	}
+	return findings
+}
+
+// callPlugin POSTs the request to a plugin, signing it with an HMAC-SHA256 of the body when a secret is set
+// The call gets pluginTimeout within ctx
+func (c *CrawlerService) callPlugin(ctx context.Context, plugin models.AnalyzerPlugin, secret string, request PluginRequest) ([]PluginFinding, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, plugin.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set(pluginSignatureName, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.plugins.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var response PluginResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPluginResponse)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	for _, finding := range response.Findings {
+		if !pluginFindingTypePattern.MatchString(finding.Type) {
+			return nil, fmt.Errorf("finding type %q must be 1 to 64 lowercase letters, digits or _", finding.Type)
+		}
+		if finding.Severity != "" && !ValidSeverity(finding.Severity) {
+			return nil, fmt.Errorf("finding severity must be one of info, warning, error")
+		}
+		if finding.Category != "" && !containsString(customRuleCategories, finding.Category) {
+			return nil, fmt.Errorf("finding category must be one of %s", strings.Join(customRuleCategories, ", "))
+		}
+	}
+	if len(response.Findings) > maxPluginFindings {
+		response.Findings = response.Findings[:maxPluginFindings]
+	}
+	return response.Findings, nil
+}
+
+// pluginFinding turns a validated plugin finding into a stored one, recording the plugin in its details
+// The type is namespaced as plugin:<name>:<type>, so plugins can't pass their findings off as built-in ones
+func pluginFinding(plugin models.AnalyzerPlugin, pageURL string, reported PluginFinding) models.Finding {
+	finding := models.Finding{
+		Type:     "plugin:" + plugin.Name + ":" + reported.Type,
+		Category: reported.Category,
+		Severity: reported.Severity,
+		URL:      reported.URL,
+		Message:  reported.Message,
+		Details:  reported.Details,
+	}
+	if finding.Category == "" {
+		finding.Category = models.CategoryContent
+	}
+	if finding.Severity == "" {
+		finding.Severity = models.SeverityWarning
+	}
+	if finding.URL == "" {
+		finding.URL = pageURL
+	}
+	if utf8.RuneCountInString(finding.Message) > maxPluginMessage {
+		finding.Message = string([]rune(finding.Message)[:maxPluginMessage])
+	}
+	if finding.Details == nil {
+		finding.Details = map[string]interface{}{}
+	}
+	finding.Details["plugin"] = plugin.Name
+	return finding
+}
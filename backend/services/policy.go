@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxPolicyTerms      = 200 // Terms per project
+	maxPolicyTermLength = 200 // Characters per term
+)
+
+// ValidatePolicyTerms checks a project's policy word list
+func ValidatePolicyTerms(terms []models.PolicyTerm) error {
+	if len(terms) > maxPolicyTerms {
+		return fmt.Errorf("at most %d policy terms are allowed", maxPolicyTerms)
+	}
+	for _, term := range terms {
+		if len(splitWords(term.Term)) == 0 || len(term.Term) > maxPolicyTermLength {
+			return fmt.Errorf("policy term %q must contain a word and be at most %d characters", term.Term, maxPolicyTermLength)
+		}
+		if term.Rule != models.PolicyRuleRequired && term.Rule != models.PolicyRuleForbidden {
+			return fmt.Errorf("policy term %q must have rule %q or %q", term.Term, models.PolicyRuleRequired, models.PolicyRuleForbidden)
+		}
+	}
+	return nil
+}
+
+// policyFindings reports forbidden terms found in and required terms missing from the page text
+// Terms match whole words case-insensitively, so "free" does not match "freedom"
+func policyFindings(pageURL, text string, project *models.Project) []models.Finding {
+	if project == nil || len(project.PolicyTerms) == 0 {
+		return nil
+	}
+
+	words := lowerWords(text)
+
+	var findings []models.Finding
+	for _, term := range project.PolicyTerms {
+		count := countPhrase(words, lowerWords(term.Term))
+
+		switch {
+		case term.Rule == models.PolicyRuleForbidden && count > 0:
+			findings = append(findings, models.Finding{
+				Type:     models.FindingPolicyForbidden,
+				Category: models.CategoryContent,
+				Severity: models.SeverityError,
+				URL:      pageURL,
+				Message:  fmt.Sprintf("Forbidden term %q appears %d times", term.Term, count),
+				Details: map[string]interface{}{
+					"term":  term.Term,
+					"count": count,
+				},
+			})
+		case term.Rule == models.PolicyRuleRequired && count == 0:
+			findings = append(findings, models.Finding{
+				Type:     models.FindingPolicyMissing,
+				Category: models.CategoryContent,
+				Severity: models.SeverityWarning,
+				URL:      pageURL,
+				Message:  fmt.Sprintf("Required term %q is missing", term.Term),
+				Details: map[string]interface{}{
+					"term": term.Term,
+				},
+			})
+		}
+	}
+	return findings
+}
+
+// normalizeWords lowercases text and joins its words with single spaces
+func normalizeWords(text string) string {
+	return strings.ToLower(strings.Join(splitWords(text), " "))
+}
+
+// lowerWords returns the lowercased words of text
+func lowerWords(text string) []string {
+	words := splitWords(text)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// countPhrase counts where phrase occurs in words; occurrences may overlap, so "spam spam" holds "spam" twice
+func countPhrase(words, phrase []string) int {
+	if len(phrase) == 0 {
+		return 0
+	}
+	count := 0
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(phrase)], phrase) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+func TestPolicyFindingsCountsRepeats(t *testing.T) {
+	project := &models.Project{PolicyTerms: []models.PolicyTerm{
+		{Term: "spam", Rule: models.PolicyRuleForbidden},
+		{Term: "buy now", Rule: models.PolicyRuleForbidden},
+	}}
+
+	findings := policyFindings("https://example.com/", "Spam spam, SPAM! Buy now buy now now.", project)
+	if len(findings) != 2 {
+		t.Fatalf("policyFindings = %+v, want 2 findings", findings)
+	}
+	for i, want := range []int{3, 2} {
+		if count := findings[i].Details["count"]; count != want {
+			t.Errorf("count of %q = %v, want %d", findings[i].Details["term"], count, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// detectProtocol reports the protocol the page was fetched over and the ones the site advertises in Alt-Svc
+// The crawler speaks HTTP/1.1 and HTTP/2, so HTTP/3 support is only known from the advertisement.
+func detectProtocol(negotiated string, header http.Header) models.HTTPProtocol {
+	protocol := models.HTTPProtocol{
+		Negotiated: negotiated,
+		AltSvc:     strings.Join(header.Values("Alt-Svc"), ", "),
+	}
+	protocol.HTTP2 = strings.HasPrefix(negotiated, "HTTP/2")
+	for _, id := range altSvcProtocols(protocol.AltSvc) {
+		switch {
+		case id == "h2":
+			protocol.HTTP2 = true
+		case id == "h3" || strings.HasPrefix(id, "h3-"): // h3-29 and the other drafts
+			protocol.HTTP3 = true
+		}
+	}
+	return protocol
+}
+
+// altSvcProtocols returns the protocol IDs of an Alt-Svc header value such as `h3=":443"; ma=86400, h3-29=":443"`
+// "clear" withdraws every advertisement and yields none
+func altSvcProtocols(value string) []string {
+	var ids []string
+	for _, entry := range strings.Split(value, ",") {
+		id, _, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			continue
+		}
+		ids = append(ids, strings.ToLower(strings.TrimSpace(id)))
+	}
+	return ids
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// reachabilityTimeout bounds the whole check so submitting a URL stays quick
+const reachabilityTimeout = 5 * time.Second
+
+// VerifyReachable checks that the URL's host resolves and answers HTTP at all
+// Any HTTP response counts, since the crawl reports status codes itself; this only catches typos and dead hosts
+func (c *CrawlerService) VerifyReachable(rawURL string) error {
+	// Mock mode never touches the network
+	if c.mock != nil {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reachabilityTimeout)
+	defer cancel()
+
+	if _, err := c.transport.dns.resolver.LookupHost(ctx, parsedURL.Hostname()); err != nil {
+		return fmt.Errorf("host %s does not resolve", parsedURL.Hostname())
+	}
+
+	req, err := newCrawlRequest(ctx, http.MethodHead, rawURL, c.settings.Get())
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	resp, err := c.do(c.client, req)
+	if err != nil {
+		return fmt.Errorf("host %s did not answer: %v", parsedURL.Host, err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
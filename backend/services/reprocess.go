@@ -0,0 +1,349 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"golang.org/x/net/html"
+)
+
+// maxSnapshotBytes bounds the pages whose HTML is kept for reprocessing; larger pages are re-crawled instead
+const maxSnapshotBytes = 5 << 20
+
+// documentAnalyzer is an analyzer that can be re-run over a page snapshot
+// apply copies its output from a fresh analysis into the stored result
+type documentAnalyzer struct {
+	name         string
+	columns      []string
+	findingTypes []string
+	apply        func(stored, fresh *models.CrawlResult)
+}
+
+// documentAnalyzers lists the analyzers reprocessing can re-run; link checks and the other
+// analyzers that contact the site need a re-crawl
+var documentAnalyzers = []documentAnalyzer{
+	{name: "title", columns: []string{"title"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Title = fresh.Title
+	}},
+	{name: "meta_description", columns: []string{"meta_description"}, findingTypes: []string{models.FindingMetaDescriptionMissing, models.FindingMetaDescriptionLength}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.MetaDescription = fresh.MetaDescription
+	}},
+	{name: "html_version", columns: []string{"html_version"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.HTMLVersion = fresh.HTMLVersion
+	}},
+	{name: "headings", columns: []string{"h1_count", "h2_count", "h3_count", "h4_count", "h5_count", "h6_count"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.H1Count, stored.H2Count, stored.H3Count = fresh.H1Count, fresh.H2Count, fresh.H3Count
+		stored.H4Count, stored.H5Count, stored.H6Count = fresh.H4Count, fresh.H5Count, fresh.H6Count
+	}},
+	{name: "pagination", columns: []string{"pagination"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Pagination = fresh.Pagination
+	}},
+	{name: "meta_refresh", columns: []string{"meta_refresh"}, findingTypes: []string{models.FindingMetaRefresh}, apply: func(stored, fresh *models.CrawlResult) {
+		// A followed refresh was on a page before the snapshot, which only holds the destination
+		if stored.MetaRefresh != nil && stored.MetaRefresh.Followed {
+			fresh.Findings = append(fresh.Findings, metaRefreshFindings(stored.MetaRefresh)...)
+			return
+		}
+		stored.MetaRefresh = fresh.MetaRefresh
+	}},
+	{name: "js_redirect", findingTypes: []string{models.FindingJSRedirect}},
+	{name: "alternates", columns: []string{"alternates"}, apply: func(stored, fresh *models.CrawlResult) {
+		// Only crawls request alternates, so the ones the crawl checked keep their status and their findings
+		checked := make(map[string]models.Alternate)
+		for _, alternate := range stored.Alternates {
+			if alternate.Checked {
+				checked[alternate.Kind+" "+alternate.URL] = alternate
+			}
+		}
+		for i, alternate := range fresh.Alternates {
+			if previous, ok := checked[alternate.Kind+" "+alternate.URL]; ok {
+				fresh.Alternates[i].Checked, fresh.Alternates[i].StatusCode, fresh.Alternates[i].Resolves = true, previous.StatusCode, previous.Resolves
+			}
+		}
+		stored.Alternates = fresh.Alternates
+	}},
+	{name: "login_form", columns: []string{"has_login_form"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.HasLoginForm = fresh.HasLoginForm
+	}},
+	{name: "content", columns: []string{"content"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Content = fresh.Content
+	}},
+	{name: "spell_check", findingTypes: []string{models.FindingMisspelling}},
+	{name: "policy", findingTypes: []string{models.FindingPolicyForbidden, models.FindingPolicyMissing}},
+	{name: "heading_rules", findingTypes: []string{models.FindingTitleLength, models.FindingH1Length, models.FindingKeywordMissing}},
+	{name: "weight", columns: []string{"weight", "budget_status"}, findingTypes: []string{models.FindingBudgetExceeded}, apply: func(stored, fresh *models.CrawlResult) {
+		// Snapshots don't record the protocol the page came over, so the crawl's protocol stays
+		fresh.Weight.Protocol = stored.Weight.Protocol
+		stored.Weight = fresh.Weight
+		stored.BudgetStatus = fresh.BudgetStatus
+	}},
+	{name: "csp", findingTypes: []string{models.FindingCSPBlocked, models.FindingCSPPermissive}},
+	{name: "sri", columns: []string{"security"}, findingTypes: []string{models.FindingSRIMissing}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Security.SRI = fresh.Security.SRI
+	}},
+	{name: "third_parties", columns: []string{"first_party_links", "third_party_links", "first_party_resources", "third_party_resources", "third_party_domain_count"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.FirstPartyLinks, stored.ThirdPartyLinks = fresh.FirstPartyLinks, fresh.ThirdPartyLinks
+		stored.FirstPartyResources, stored.ThirdPartyResources = fresh.FirstPartyResources, fresh.ThirdPartyResources
+		stored.ThirdPartyDomainCount = fresh.ThirdPartyDomainCount
+	}},
+	{name: "technologies", columns: []string{"technologies"}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Technologies = fresh.Technologies
+	}},
+	{name: "mobile", columns: []string{"mobile"}, findingTypes: []string{models.FindingViewportMissing, models.FindingViewportNotResponsive, models.FindingViewportZoomDisabled, models.FindingSmallFontSize, models.FindingSmallTapTarget}, apply: func(stored, fresh *models.CrawlResult) {
+		stored.Mobile = fresh.Mobile
+	}},
+}
+
+// ReprocessAnalyzers lists the names of the analyzers reprocessing can re-run
+func ReprocessAnalyzers() []string {
+	names := make([]string, len(documentAnalyzers))
+	for i, analyzer := range documentAnalyzers {
+		names[i] = analyzer.name
+	}
+	return names
+}
+
+// ReprocessFilter selects the URLs to reprocess; empty fields don't filter
+type ReprocessFilter struct {
+	IDs       []uint
+	ProjectID *uint
+	Tag       string
+	Status    string
+	Outdated  bool // Only URLs whose latest crawl was analyzed by an older analyzer version
+}
+
+// ReprocessService re-runs analyzers over the stored crawls of many URLs as a batch job
+type ReprocessService struct {
+	store   repository.Store
+	crawler *CrawlerService
+	jobs    *BatchJobService
+}
+
+// NewReprocessService creates a reprocess service
+func NewReprocessService(store repository.Store, crawler *CrawlerService, jobs *BatchJobService) *ReprocessService {
+	return &ReprocessService{store: store, crawler: crawler, jobs: jobs}
+}
+
+// ValidateAnalyzers checks that every name is a known analyzer
+func ValidateAnalyzers(names []string) error {
+	for _, name := range names {
+		if selectAnalyzers([]string{name}) == nil {
+			return fmt.Errorf("unknown analyzer %q, expected one of %v", name, ReprocessAnalyzers())
+		}
+	}
+	return nil
+}
+
+// selectAnalyzers returns the named analyzers in their run order; no names selects all of them
+func selectAnalyzers(names []string) []documentAnalyzer {
+	if len(names) == 0 {
+		return documentAnalyzers
+	}
+	var selected []documentAnalyzer
+	for _, analyzer := range documentAnalyzers {
+		for _, name := range names {
+			if analyzer.name == name {
+				selected = append(selected, analyzer)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// Start creates a reprocess job for the URLs matching filter and runs it in the background
+// It returns a nil job when no URL matches
+func (s *ReprocessService) Start(filter ReprocessFilter, analyzers []string) (*models.BatchJob, error) {
+	ids, err := s.match(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	job, err := s.jobs.CreateJob("reprocess", len(ids))
+	if err != nil {
+		return nil, err
+	}
+	go s.run(job.ID, ids, selectAnalyzers(analyzers))
+	return job, nil
+}
+
+// match returns the IDs of the URLs matching filter, skipping URLs that are being crawled
+func (s *ReprocessService) match(filter ReprocessFilter) ([]uint, error) {
+	urls, err := s.store.URLs().List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URLs: %v", err)
+	}
+
+	var outdated map[uint]bool
+	if filter.Outdated {
+		ids, err := s.store.URLs().OutdatedIDs(AnalyzerVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find outdated URLs: %v", err)
+		}
+		outdated = make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			outdated[id] = true
+		}
+	}
+	wanted := make(map[uint]bool, len(filter.IDs))
+	for _, id := range filter.IDs {
+		wanted[id] = true
+	}
+
+	var ids []uint
+	for _, url := range urls {
+		switch {
+		case url.Status == "running":
+		case len(wanted) > 0 && !wanted[url.ID]:
+		case filter.ProjectID != nil && (url.ProjectID == nil || *url.ProjectID != *filter.ProjectID):
+		case filter.Tag != "" && !hasTag(url.Tags, filter.Tag):
+		case filter.Status != "" && url.Status != filter.Status:
+		case outdated != nil && !outdated[url.ID]:
+		default:
+			ids = append(ids, url.ID)
+		}
+	}
+	return ids, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// run reprocesses the URLs one after another, so a large backfill doesn't crowd out regular crawls
+func (s *ReprocessService) run(jobID uint, ids []uint, analyzers []documentAnalyzer) {
+	for _, id := range ids {
+		err := s.reprocessURL(jobID, id, analyzers)
+		if err != nil {
+			log.Printf("Failed to reprocess URL %d: %v", id, err)
+		}
+		if err := s.jobs.RecordResult(jobID, err); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// reprocessURL re-runs the analyzers over the snapshot of the URL's latest crawl, or crawls it again without one
+func (s *ReprocessService) reprocessURL(jobID, urlID uint, analyzers []documentAnalyzer) error {
+	url, err := s.store.URLs().Get(urlID)
+	if err != nil {
+		return fmt.Errorf("failed to find URL: %v", err)
+	}
+
+	// The snapshot only holds the page itself, not the content of its frames, so those pages are crawled again
+	result, err := s.store.CrawlResults().Latest(urlID, repository.LoadOptions{})
+	if url.CrawlConfig.IncludeFrames {
+		err = repository.ErrNotFound
+	}
+	if err == nil {
+		snapshot, err := s.store.CrawlResults().Snapshot(result.ID)
+		if err == nil {
+			return s.reanalyze(url, &result, snapshot, analyzers)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("failed to load snapshot: %v", err)
+		}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to load latest crawl: %v", err)
+	}
+
+	if err := s.store.URLs().SetStatus("running", urlID); err != nil {
+		return fmt.Errorf("failed to update URL status to running: %v", err)
+	}
+	return s.crawler.crawlURL(urlID, jobID, time.Now())
+}
+
+// reanalyze updates the stored result with the output of the analyzers run over the snapshot
+// The analyzer version is only updated when every analyzer was re-run
+func (s *ReprocessService) reanalyze(url models.URL, result *models.CrawlResult, snapshot models.PageSnapshot, analyzers []documentAnalyzer) error {
+	doc, err := html.Parse(bytes.NewReader(snapshot.HTML))
+	if err != nil {
+		return fmt.Errorf("failed to parse snapshot: %v", err)
+	}
+
+	var project *models.Project
+	if url.ProjectID != nil {
+		if loaded, err := s.store.Projects().Get(*url.ProjectID); err == nil {
+			project = &loaded
+		}
+	}
+
+	page := &fetchedPage{
+		StatusCode: http.StatusOK,
+		Header:     http.Header(snapshot.Header),
+		FinalURL:   snapshot.FinalURL,
+		Size:       int64(len(snapshot.HTML)),
+		Doc:        doc,
+	}
+	fresh := &models.CrawlResult{}
+	s.crawler.analyzeDocument(fresh, doc, url.URL, page, url.CrawlConfig, project)
+
+	var columns, findingTypes []string
+	for _, analyzer := range analyzers {
+		if analyzer.apply != nil {
+			analyzer.apply(result, fresh)
+		}
+		columns = append(columns, analyzer.columns...)
+		findingTypes = append(findingTypes, analyzer.findingTypes...)
+	}
+	result.Findings = nil
+	for _, finding := range fresh.Findings {
+		for _, findingType := range findingTypes {
+			if finding.Type == findingType {
+				result.Findings = append(result.Findings, finding)
+				break
+			}
+		}
+	}
+	if len(findingTypes) > 0 {
+		if err := s.markReanalyzedLifecycle(url.ID, result, findingTypes); err != nil {
+			return err
+		}
+		columns = append(columns, "findings_new", "findings_persisting", "findings_resolved")
+	}
+	if len(analyzers) == len(documentAnalyzers) {
+		result.AnalyzerVersion = AnalyzerVersion
+		columns = append(columns, "analyzer_version")
+	}
+
+	return s.store.CrawlResults().UpdateAnalysis(result, columns, findingTypes)
+}
+
+// markReanalyzedLifecycle compares the replacement findings in result.Findings with the crawl before result,
+// counting them together with the stored findings of the types that aren't replaced
+func (s *ReprocessService) markReanalyzedLifecycle(urlID uint, result *models.CrawlResult, findingTypes []string) error {
+	stored, err := s.store.CrawlResults().Findings(result.ID, repository.FindingFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to load findings: %v", err)
+	}
+	previous, err := s.crawler.previousFindings(urlID, result.ID)
+	if err != nil {
+		return err
+	}
+
+	replaced := len(result.Findings)
+	all := &models.CrawlResult{Findings: result.Findings}
+	for _, finding := range stored {
+		if !containsString(findingTypes, finding.Type) {
+			all.Findings = append(all.Findings, finding)
+		}
+	}
+	markFindingLifecycle(all, previous)
+	result.Findings = all.Findings[:replaced]
+	result.FindingsNew, result.FindingsPersisting, result.FindingsResolved = all.FindingsNew, all.FindingsPersisting, all.FindingsResolved
+	return nil
+}
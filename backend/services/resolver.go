@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dohTimeout bounds a single DNS-over-HTTPS query
+const dohTimeout = 5 * time.Second
+
+// hostResolver looks up the addresses of a host; *net.Resolver satisfies it
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// newResolver picks the resolver of the crawler: DNS-over-HTTPS, specific DNS servers, or the system resolver
+// The returned name identifies the resolver in stats and error messages
+func newResolver(config TransportConfig) (hostResolver, string) {
+	switch {
+	case config.DoHURL != "":
+		return newDoHResolver(config.DoHURL), "doh " + config.DoHURL
+	case len(config.DNSServers) > 0:
+		servers := make([]string, 0, len(config.DNSServers))
+		for _, server := range config.DNSServers {
+			if server = strings.TrimSpace(server); server == "" {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(server); err != nil {
+				server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+			}
+			servers = append(servers, server)
+		}
+		if len(servers) > 0 {
+			return serverResolver(servers), "servers " + strings.Join(servers, ",")
+		}
+	}
+	return net.DefaultResolver, "system"
+}
+
+// serverResolver sends queries to the given DNS servers instead of the ones in resolv.conf
+// Each query goes to the next server, so the Go resolver's retries move on to another server
+func serverResolver(servers []string) *net.Resolver {
+	var next atomic.Uint64
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[(next.Add(1)-1)%uint64(len(servers))]
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// dohResolver resolves hosts with DNS-over-HTTPS (RFC 8484), for networks that block plain DNS
+// The host of the endpoint itself is resolved by the system resolver, unless the URL uses an IP address
+type dohResolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newDoHResolver(endpoint string) *dohResolver {
+	return &dohResolver{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: dohTimeout},
+	}
+}
+
+// LookupHost queries the A and AAAA records of host in parallel
+// Like the Go resolver, it succeeds when either query returns addresses
+func (r *dohResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	type answer struct {
+		addrs []string
+		err   error
+	}
+
+	types := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	answers := make(chan answer, len(types))
+	for _, qtype := range types {
+		go func(qtype dnsmessage.Type) {
+			addrs, err := r.query(ctx, host, qtype)
+			answers <- answer{addrs, err}
+		}(qtype)
+	}
+
+	var addrs []string
+	var firstErr error
+	for range types {
+		a := <-answers
+		addrs = append(addrs, a.addrs...)
+		if a.err != nil && firstErr == nil {
+			firstErr = a.err
+		}
+	}
+
+	if len(addrs) > 0 {
+		return addrs, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, r.dnsError(host, "no such host", true, false)
+}
+
+// query sends one question for host and returns the addresses in the answer
+func (r *dohResolver) query(ctx context.Context, host string, qtype dnsmessage.Type) ([]string, error) {
+	fqdn := host
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	name, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, r.dnsError(host, "invalid host name", true, false)
+	}
+
+	// ID 0 is recommended for DoH, since HTTP already matches answers to questions
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, r.dnsError(host, err.Error(), false, false)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, r.dnsError(host, err.Error(), false, false)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		timeout := errors.As(err, &netErr) && netErr.Timeout()
+		return nil, r.dnsError(host, err.Error(), false, timeout)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, r.dnsError(host, fmt.Sprintf("DoH server returned HTTP %d", resp.StatusCode), false, false)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, r.dnsError(host, err.Error(), false, false)
+	}
+
+	var reply dnsmessage.Message
+	if err := reply.Unpack(body); err != nil {
+		return nil, r.dnsError(host, "invalid DoH answer: "+err.Error(), false, false)
+	}
+	switch reply.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, r.dnsError(host, "no such host", true, false)
+	default:
+		return nil, r.dnsError(host, "server misbehaving: "+reply.RCode.String(), false, false)
+	}
+
+	// CNAME records are followed by the server, so only the addresses matter
+	var addrs []string
+	for _, res := range reply.Answers {
+		switch body := res.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, net.IP(body.AAAA[:]).String())
+		}
+	}
+	return addrs, nil
+}
+
+// dnsError reports a failed lookup the way the Go resolver does, naming the DoH server
+func (r *dohResolver) dnsError(host, message string, notFound, timeout bool) error {
+	return &net.DNSError{
+		Err:         message,
+		Name:        host,
+		Server:      r.endpoint,
+		IsNotFound:  notFound,
+		IsTimeout:   timeout,
+		IsTemporary: !notFound,
+	}
+}
@@ -0,0 +1,872 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Custom rules are written in a subset of CEL, the Common Expression Language: literals, lists, field access
+// and indexing, the usual operators, has(), size(), the string functions contains, startsWith, endsWith and
+// matches, and the all, exists, exists_one, filter and map macros. Expressions can't loop forever or touch
+// anything but the variables they are given: every evaluation step is counted against maxRuleSteps, and every
+// list element and string byte they build against maxRuleAllocation.
+// Numbers are float64 like in the decoded JSON they are evaluated over, so 1 == 1.0.
+
+const (
+	maxRuleDepth = 50     // Nesting of a parsed expression
+	maxRuleSteps = 100000 // Evaluation steps of one expression, bounding comprehensions over large lists
+
+	maxRuleAllocation = 1 << 20 // List elements and string bytes built by one expression, bounding + and map()
+)
+
+// ruleMacros are the comprehensions over lists and maps; their first argument names the element
+var ruleMacros = map[string]bool{"all": true, "exists": true, "exists_one": true, "filter": true, "map": true}
+
+// ruleExpr is a parsed expression
+type ruleExpr interface{}
+
+type (
+	ruleLiteral struct{ value interface{} }
+	ruleIdent   struct{ name string }
+	ruleSelect  struct {
+		operand ruleExpr
+		field   string
+	}
+	ruleIndex struct{ operand, index ruleExpr }
+	ruleCall  struct {
+		target  ruleExpr // Receiver of x.f(...), nil for f(...)
+		name    string
+		args    []ruleExpr
+		pattern *regexp.Regexp // Compiled when the expression is parsed, for matches() of a string literal
+	}
+	ruleUnary struct {
+		op      string
+		operand ruleExpr
+	}
+	ruleBinary struct {
+		op          string
+		left, right ruleExpr
+	}
+	ruleConditional struct{ cond, then, otherwise ruleExpr }
+	ruleList        struct{ items []ruleExpr }
+)
+
+// ruleToken is a lexed token; kind is number, string, ident, or the operator itself
+type ruleToken struct {
+	kind  string
+	text  string
+	value interface{}
+	pos   int
+}
+
+// ruleOperators are matched longest first
+var ruleOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "(", ")", "[", "]", ".", ",", "?", ":", "!", "-", "+", "*", "/", "%", "<", ">"}
+
+// lexRule splits an expression into tokens
+func lexRule(source string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for pos := 0; pos < len(source); {
+		r, width := utf8.DecodeRuneInString(source[pos:])
+		switch {
+		case unicode.IsSpace(r):
+			pos += width
+		case r >= '0' && r <= '9':
+			end := pos
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.') {
+				end++
+			}
+			value, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", source[pos:end], pos)
+			}
+			tokens = append(tokens, ruleToken{kind: "number", text: source[pos:end], value: value, pos: pos})
+			pos = end
+		case r == '"' || r == '\'':
+			value, end, err := lexRuleString(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, ruleToken{kind: "string", text: source[pos:end], value: value, pos: pos})
+			pos = end
+		case r == '_' || unicode.IsLetter(r):
+			end := pos
+			for end < len(source) {
+				next, size := utf8.DecodeRuneInString(source[end:])
+				if next != '_' && !unicode.IsLetter(next) && !unicode.IsDigit(next) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, ruleToken{kind: "ident", text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			matched := ""
+			for _, op := range ruleOperators {
+				if strings.HasPrefix(source[pos:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+			}
+			tokens = append(tokens, ruleToken{kind: matched, text: matched, pos: pos})
+			pos += len(matched)
+		}
+	}
+	return append(tokens, ruleToken{kind: "end", pos: len(source)}), nil
+}
+
+// lexRuleString reads the quoted string starting at pos, returning its value and the position behind it
+func lexRuleString(source string, pos int) (string, int, error) {
+	quote := source[pos]
+	var value strings.Builder
+	for i := pos + 1; i < len(source); i++ {
+		switch c := source[i]; {
+		case c == quote:
+			return value.String(), i + 1, nil
+		case c == '\\' && i+1 < len(source):
+			i++
+			switch source[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(source[i]) // \\, \", \' and \. for regular expressions
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", pos)
+}
+
+// ruleParser is a recursive descent parser over the tokens of an expression
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+	depth  int
+}
+
+// parseRule parses an expression
+func parseRule(source string) (ruleExpr, error) {
+	tokens, err := lexRule(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &ruleParser{tokens: tokens}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != "end" {
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+	}
+	return expr, nil
+}
+
+func (p *ruleParser) peek() ruleToken { return p.tokens[p.pos] }
+
+func (p *ruleParser) next() ruleToken {
+	token := p.tokens[p.pos]
+	if token.kind != "end" {
+		p.pos++
+	}
+	return token
+}
+
+// accept consumes the next token when it is one of kinds
+func (p *ruleParser) accept(kinds ...string) (ruleToken, bool) {
+	token := p.peek()
+	for _, kind := range kinds {
+		if token.kind == kind || token.kind == "ident" && token.text == kind {
+			return p.next(), true
+		}
+	}
+	return token, false
+}
+
+func (p *ruleParser) expect(kind string) error {
+	if token, ok := p.accept(kind); !ok {
+		if token.kind == "end" {
+			return fmt.Errorf("expected %q at the end of the expression", kind)
+		}
+		return fmt.Errorf("expected %q at position %d, found %q", kind, token.pos, token.text)
+	}
+	return nil
+}
+
+// expression parses a conditional, the operator with the lowest precedence
+func (p *ruleParser) expression() (ruleExpr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxRuleDepth {
+		return nil, fmt.Errorf("expression is nested more than %d levels deep", maxRuleDepth)
+	}
+
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return ruleConditional{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// ruleBinaryLevels lists the binary operators from the lowest precedence to the highest
+var ruleBinaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// binary parses the left-associative binary operators of a precedence level and the levels above it
+func (p *ruleParser) binary(level int) (ruleExpr, error) {
+	if level == len(ruleBinaryLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := p.accept(ruleBinaryLevels[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = ruleBinary{op: token.text, left: left, right: right}
+	}
+}
+
+func (p *ruleParser) unary() (ruleExpr, error) {
+	if token, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxRuleDepth {
+			return nil, fmt.Errorf("expression is nested more than %d levels deep", maxRuleDepth)
+		}
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return ruleUnary{op: token.text, operand: operand}, nil
+	}
+	return p.member()
+}
+
+// member parses a primary expression followed by field selections, indexes, and method calls
+func (p *ruleParser) member() (ruleExpr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.peek().kind == ".":
+			p.next()
+			field := p.next()
+			if field.kind != "ident" {
+				return nil, fmt.Errorf("expected a field name at position %d", field.pos)
+			}
+			if _, ok := p.accept("("); ok {
+				args, err := p.arguments(")")
+				if err != nil {
+					return nil, err
+				}
+				call, err := checkRuleCall(ruleCall{target: expr, name: field.text, args: args})
+				if err != nil {
+					return nil, err
+				}
+				expr = call
+			} else {
+				expr = ruleSelect{operand: expr, field: field.text}
+			}
+		case p.peek().kind == "[":
+			p.next()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = ruleIndex{operand: expr, index: index}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *ruleParser) primary() (ruleExpr, error) {
+	token := p.next()
+	switch token.kind {
+	case "number", "string":
+		return ruleLiteral{value: token.value}, nil
+	case "(":
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	case "[":
+		items, err := p.arguments("]")
+		if err != nil {
+			return nil, err
+		}
+		return ruleList{items: items}, nil
+	case "ident":
+		switch token.text {
+		case "true":
+			return ruleLiteral{value: true}, nil
+		case "false":
+			return ruleLiteral{value: false}, nil
+		case "null":
+			return ruleLiteral{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			args, err := p.arguments(")")
+			if err != nil {
+				return nil, err
+			}
+			return checkRuleCall(ruleCall{name: token.text, args: args})
+		}
+		return ruleIdent{name: token.text}, nil
+	case "end":
+		return nil, fmt.Errorf("unexpected end of the expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.pos)
+}
+
+// arguments parses a comma separated list of expressions up to the closing token
+func (p *ruleParser) arguments(closing string) ([]ruleExpr, error) {
+	var args []ruleExpr
+	if _, ok := p.accept(closing); ok {
+		return args, nil
+	}
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.accept(closing); ok {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkRuleCall rejects calls of unknown functions and with the wrong number of arguments when they are parsed,
+// and compiles the regular expression of matches() when it is a literal
+func checkRuleCall(call ruleCall) (ruleCall, error) {
+	want := -1
+	switch {
+	case ruleMacros[call.name] && call.target != nil:
+		if len(call.args) != 2 {
+			return call, fmt.Errorf("%s() takes a variable and an expression", call.name)
+		}
+		if _, ok := call.args[0].(ruleIdent); !ok {
+			return call, fmt.Errorf("the first argument of %s() must be a variable name", call.name)
+		}
+		return call, nil
+	case call.name == "has" && call.target == nil:
+		if len(call.args) != 1 {
+			return call, fmt.Errorf("has() takes one field selection")
+		}
+		if _, ok := call.args[0].(ruleSelect); !ok {
+			return call, fmt.Errorf("the argument of has() must be a field selection, e.g. has(result.meta_refresh)")
+		}
+		return call, nil
+	case call.name == "size" && call.target == nil:
+		want = 1
+	case call.name == "size":
+		want = 0
+	case call.target != nil && (call.name == "contains" || call.name == "startsWith" || call.name == "endsWith" || call.name == "matches"):
+		want = 1
+	}
+	if want < 0 {
+		return call, fmt.Errorf("unknown function %s()", call.name)
+	}
+	if len(call.args) != want {
+		return call, fmt.Errorf("%s() takes %d argument(s)", call.name, want)
+	}
+	if call.name != "matches" {
+		return call, nil
+	}
+	if literal, ok := call.args[0].(ruleLiteral); ok {
+		if source, ok := literal.value.(string); ok {
+			pattern, err := regexp.Compile(source)
+			if err != nil {
+				return call, fmt.Errorf("invalid regular expression %q: %v", source, err)
+			}
+			call.pattern = pattern
+		}
+	}
+	return call, nil
+}
+
+// ruleScope binds the variables of an evaluation; comprehensions add their element on top
+type ruleScope struct {
+	name   string
+	value  interface{}
+	parent *ruleScope
+}
+
+func (s *ruleScope) lookup(name string) (interface{}, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if scope.name == name {
+			return scope.value, true
+		}
+	}
+	return nil, false
+}
+
+// ruleEval evaluates one expression, counting its steps and what it builds
+type ruleEval struct {
+	steps     int
+	allocated int
+	patterns  map[string]*regexp.Regexp // Regular expressions computed while evaluating, compiled once
+}
+
+// evalRule evaluates a parsed expression with the given variables, whose values must be
+// decoded JSON: nil, bool, float64, string, []interface{}, or map[string]interface{}
+func evalRule(expr ruleExpr, vars map[string]interface{}) (interface{}, error) {
+	var scope *ruleScope
+	for name, value := range vars {
+		scope = &ruleScope{name: name, value: value, parent: scope}
+	}
+	return (&ruleEval{}).eval(expr, scope)
+}
+
+func (e *ruleEval) eval(expr ruleExpr, scope *ruleScope) (interface{}, error) {
+	e.steps++
+	if e.steps > maxRuleSteps {
+		return nil, fmt.Errorf("expression took more than %d steps", maxRuleSteps)
+	}
+
+	switch expr := expr.(type) {
+	case ruleLiteral:
+		return expr.value, nil
+	case ruleIdent:
+		if value, ok := scope.lookup(expr.name); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("undeclared variable %s", expr.name)
+	case ruleList:
+		if err := e.allocate(len(expr.items)); err != nil {
+			return nil, err
+		}
+		items := make([]interface{}, 0, len(expr.items))
+		for _, item := range expr.items {
+			value, err := e.eval(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case ruleSelect:
+		operand, err := e.eval(expr.operand, scope)
+		if err != nil {
+			return nil, err
+		}
+		object, ok := operand.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("can't select field %s of %s", expr.field, ruleTypeName(operand))
+		}
+		value, ok := object[expr.field]
+		if !ok {
+			return nil, fmt.Errorf("no such field %s", expr.field)
+		}
+		return value, nil
+	case ruleIndex:
+		return e.evalIndex(expr, scope)
+	case ruleCall:
+		return e.evalCall(expr, scope)
+	case ruleUnary:
+		operand, err := e.eval(expr.operand, scope)
+		if err != nil {
+			return nil, err
+		}
+		if expr.op == "!" {
+			value, ok := operand.(bool)
+			if !ok {
+				return nil, fmt.Errorf("! needs a bool, not %s", ruleTypeName(operand))
+			}
+			return !value, nil
+		}
+		value, ok := operand.(float64)
+		if !ok {
+			return nil, fmt.Errorf("- needs a number, not %s", ruleTypeName(operand))
+		}
+		return -value, nil
+	case ruleBinary:
+		return e.evalBinary(expr, scope)
+	case ruleConditional:
+		cond, err := e.evalBool(expr.cond, scope, "?:")
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return e.eval(expr.then, scope)
+		}
+		return e.eval(expr.otherwise, scope)
+	}
+	return nil, fmt.Errorf("unsupported expression")
+}
+
+// allocate counts size list elements or string bytes against maxRuleAllocation before they are built
+func (e *ruleEval) allocate(size int) error {
+	e.allocated += size
+	if e.allocated > maxRuleAllocation {
+		return fmt.Errorf("expression built more than %d list elements and string bytes", maxRuleAllocation)
+	}
+	return nil
+}
+
+// evalBool evaluates an expression that must produce a bool
+func (e *ruleEval) evalBool(expr ruleExpr, scope *ruleScope, op string) (bool, error) {
+	value, err := e.eval(expr, scope)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs a bool, not %s", op, ruleTypeName(value))
+	}
+	return result, nil
+}
+
+func (e *ruleEval) evalIndex(expr ruleIndex, scope *ruleScope) (interface{}, error) {
+	operand, err := e.eval(expr.operand, scope)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.eval(expr.index, scope)
+	if err != nil {
+		return nil, err
+	}
+	switch operand := operand.(type) {
+	case []interface{}:
+		position, ok := index.(float64)
+		if !ok || position != math.Trunc(position) {
+			return nil, fmt.Errorf("list index must be a whole number, not %s", ruleTypeName(index))
+		}
+		if position < 0 || int(position) >= len(operand) {
+			return nil, fmt.Errorf("index %d out of range for a list of size %d", int(position), len(operand))
+		}
+		return operand[int(position)], nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, not %s", ruleTypeName(index))
+		}
+		value, ok := operand[key]
+		if !ok {
+			return nil, fmt.Errorf("no such key %q", key)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("can't index %s", ruleTypeName(operand))
+}
+
+func (e *ruleEval) evalBinary(expr ruleBinary, scope *ruleScope) (interface{}, error) {
+	// && and || only evaluate their right side when the left side doesn't decide the result
+	if expr.op == "&&" || expr.op == "||" {
+		left, err := e.evalBool(expr.left, scope, expr.op)
+		if err != nil {
+			return nil, err
+		}
+		if left == (expr.op == "||") {
+			return left, nil
+		}
+		return e.evalBool(expr.right, scope, expr.op)
+	}
+
+	left, err := e.eval(expr.left, scope)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.eval(expr.right, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "in":
+		switch container := right.(type) {
+		case []interface{}:
+			for _, item := range container {
+				if reflect.DeepEqual(left, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key must be a string, not %s", ruleTypeName(left))
+			}
+			_, found := container[key]
+			return found, nil
+		}
+		return nil, fmt.Errorf("in needs a list or map, not %s", ruleTypeName(right))
+	case "+":
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				if err := e.allocate(len(l) + len(r)); err != nil {
+					return nil, err
+				}
+				return l + r, nil
+			}
+		case []interface{}:
+			if r, ok := right.([]interface{}); ok {
+				if err := e.allocate(len(l) + len(r)); err != nil {
+					return nil, err
+				}
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch expr.op {
+			case "<":
+				return l < r, nil
+			case "<=":
+				return l <= r, nil
+			case ">":
+				return l > r, nil
+			case ">=":
+				return l >= r, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s can't be applied to %s and %s", expr.op, ruleTypeName(left), ruleTypeName(right))
+	}
+	switch expr.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if expr.op == "/" {
+			return l / r, nil
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", expr.op)
+}
+
+func (e *ruleEval) evalCall(call ruleCall, scope *ruleScope) (interface{}, error) {
+	if call.name == "has" {
+		selection := call.args[0].(ruleSelect)
+		operand, err := e.eval(selection.operand, scope)
+		if err != nil {
+			return nil, err
+		}
+		object, ok := operand.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("has() needs a field of a map, not of %s", ruleTypeName(operand))
+		}
+		value, found := object[selection.field]
+		return found && value != nil, nil
+	}
+
+	var target interface{}
+	if call.target != nil {
+		var err error
+		if target, err = e.eval(call.target, scope); err != nil {
+			return nil, err
+		}
+	}
+	if ruleMacros[call.name] {
+		return e.evalMacro(call, target, scope)
+	}
+
+	args := make([]interface{}, 0, len(call.args))
+	for _, arg := range call.args {
+		value, err := e.eval(arg, scope)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	if call.name == "size" {
+		if call.target == nil {
+			target = args[0]
+		}
+		switch value := target.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(value)), nil
+		case []interface{}:
+			return float64(len(value)), nil
+		case map[string]interface{}:
+			return float64(len(value)), nil
+		}
+		return nil, fmt.Errorf("size() needs a string, list, or map, not %s", ruleTypeName(target))
+	}
+
+	text, ok := target.(string)
+	argument, argOK := args[0].(string)
+	if !ok || !argOK {
+		return nil, fmt.Errorf("%s() needs strings, not %s and %s", call.name, ruleTypeName(target), ruleTypeName(args[0]))
+	}
+	switch call.name {
+	case "contains":
+		return strings.Contains(text, argument), nil
+	case "startsWith":
+		return strings.HasPrefix(text, argument), nil
+	case "endsWith":
+		return strings.HasSuffix(text, argument), nil
+	}
+	// Go regular expressions run in linear time, so a pattern can't stall the evaluation
+	pattern := call.pattern
+	if pattern == nil {
+		if pattern = e.patterns[argument]; pattern == nil {
+			var err error
+			if pattern, err = regexp.Compile(argument); err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %v", argument, err)
+			}
+			if e.patterns == nil {
+				e.patterns = make(map[string]*regexp.Regexp)
+			}
+			e.patterns[argument] = pattern
+		}
+	}
+	return pattern.MatchString(text), nil
+}
+
+// evalMacro runs a comprehension over the elements of a list or the keys of a map
+func (e *ruleEval) evalMacro(call ruleCall, target interface{}, scope *ruleScope) (interface{}, error) {
+	var elements []interface{}
+	switch value := target.(type) {
+	case []interface{}:
+		elements = value
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // Keys in a stable order, so filter() and map() results don't change between runs
+		for _, key := range keys {
+			elements = append(elements, key)
+		}
+	default:
+		return nil, fmt.Errorf("%s() needs a list or map, not %s", call.name, ruleTypeName(target))
+	}
+
+	name := call.args[0].(ruleIdent).name
+	matches := 0
+	results := []interface{}{}
+	for _, element := range elements {
+		inner := &ruleScope{name: name, value: element, parent: scope}
+		if call.name == "map" || call.name == "filter" {
+			if err := e.allocate(1); err != nil {
+				return nil, err
+			}
+		}
+		if call.name == "map" {
+			value, err := e.eval(call.args[1], inner)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, value)
+			continue
+		}
+		match, err := e.evalBool(call.args[1], inner, call.name+"()")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case call.name == "all" && !match:
+			return false, nil
+		case call.name == "exists" && match:
+			return true, nil
+		case match:
+			matches++
+			results = append(results, element)
+		}
+	}
+
+	switch call.name {
+	case "all":
+		return true, nil
+	case "exists":
+		return false, nil
+	case "exists_one":
+		return matches == 1, nil
+	}
+	return results, nil
+}
+
+// ruleTypeName names the type of a value in error messages
+func ruleTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRuleErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"1 +", "unexpected end of the expression"},
+		{"(1 + 2", `expected ")" at the end of the expression`},
+		{"1 2", `unexpected "2" at position 2`},
+		{"'open", "unterminated string at position 0"},
+		{"1 # 2", "unexpected character '#' at position 2"},
+		{"1.2.3", `invalid number "1.2.3" at position 0`},
+		{"unknown(1)", "unknown function unknown()"},
+		{"url.contains()", "contains() takes 1 argument(s)"},
+		{"has(url)", "the argument of has() must be a field selection"},
+		{"[1].all(1, true)", "the first argument of all() must be a variable name"},
+		{"url.matches('(')", "invalid regular expression"},
+		{strings.Repeat("(", maxRuleDepth+1) + "1" + strings.Repeat(")", maxRuleDepth+1), "nested more than"},
+	}
+	for _, test := range tests {
+		_, err := parseRule(test.expression)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("parseRule(%q) = %v, want an error containing %q", test.expression, err, test.err)
+		}
+	}
+}
+
+func TestEvalRule(t *testing.T) {
+	vars := map[string]interface{}{
+		"url": "https://example.com/shop",
+		"result": map[string]interface{}{
+			"title": "Shop",
+			"links": []interface{}{
+				map[string]interface{}{"url": "https://example.com/a", "is_accessible": true},
+				map[string]interface{}{"url": "https://other.com/b", "is_accessible": false},
+			},
+			"meta_refresh": nil,
+		},
+	}
+	tests := []struct {
+		expression string
+		want       interface{}
+	}{
+		// Precedence and associativity
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"-2 * 3", -6.0},
+		{"7 % 4", 3.0},
+		{"1 < 2 == true", true},
+		{"true || false && false", true},
+		{"!true || true", true},
+		{"1 == 1 ? 'yes' : 'no'", "yes"},
+		{"false ? 1 : true ? 2 : 3", 2.0},
+		// Values, functions and macros
+		{"1 == 1.0", true},
+		{"'a' + 'b'", "ab"},
+		{"[1] + [2]", []interface{}{1.0, 2.0}},
+		{"2 in [1, 2]", true},
+		{"'title' in result", true},
+		{"size(result.links)", 2.0},
+		{"result.title.size()", 4.0},
+		{"url.startsWith('https://') && url.endsWith('/shop')", true},
+		{"url.matches('^https://[a-z]+\\\\.com/')", true},
+		{"url.matches('^' + 'https')", true},
+		{"has(result.title)", true},
+		{"has(result.meta_refresh)", false},
+		{"result.links.all(l, l.url.startsWith('https://'))", true},
+		{"result.links.exists(l, !l.is_accessible)", true},
+		{"result.links.exists_one(l, l.is_accessible)", true},
+		{"result.links.filter(l, l.is_accessible).map(l, l.url)", []interface{}{"https://example.com/a"}},
+		{"result.links[1]['url']", "https://other.com/b"},
+		// && and || don't evaluate their right side when the left side decides
+		{"false && missing", false},
+		{"true || 1 / 0 == 1", true},
+	}
+	for _, test := range tests {
+		expr, err := parseRule(test.expression)
+		if err != nil {
+			t.Errorf("parseRule(%q): %v", test.expression, err)
+			continue
+		}
+		got, err := evalRule(expr, vars)
+		if err != nil {
+			t.Errorf("evalRule(%q): %v", test.expression, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("evalRule(%q) = %#v, want %#v", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestEvalRuleErrors(t *testing.T) {
+	vars := map[string]interface{}{
+		"url":    "https://example.com/",
+		"result": map[string]interface{}{"links": []interface{}{1.0}},
+	}
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		// Type errors
+		{"1 + 'a'", "+ can't be applied to number and string"},
+		{"!1", "! needs a bool, not number"},
+		{"-'a'", "- needs a number, not string"},
+		{"1 && true", "&& needs a bool, not number"},
+		{"1 ? 2 : 3", "?: needs a bool, not number"},
+		{"url.contains(1)", "contains() needs strings, not string and number"},
+		{"size(1)", "size() needs a string, list, or map, not number"},
+		{"url.all(x, true)", "all() needs a list or map, not string"},
+		{"result.links.all(x, x)", "all() needs a bool, not number"},
+		{"url.title", "can't select field title of string"},
+		{"result.links['a']", "list index must be a whole number, not string"},
+		{"result.links[1]", "index 1 out of range for a list of size 1"},
+		{"result.missing", "no such field missing"},
+		{"missing", "undeclared variable missing"},
+		{"1 / 0", "division by zero"},
+		{"1 in 2", "in needs a list or map, not number"},
+		{"url.matches('(' + '')", "invalid regular expression"},
+		// Limits
+		{"[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(a, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(b, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(c, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(d, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].all(e, true)))))", "more than 100000 steps"},
+		{doublingList(21), "expression built more than"},
+		{doublingString(21), "expression built more than"},
+	}
+	for _, test := range tests {
+		expr, err := parseRule(test.expression)
+		if err != nil {
+			t.Errorf("parseRule(%q): %v", test.expression, err)
+			continue
+		}
+		_, err = evalRule(expr, vars)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("evalRule(%q) = %v, want an error containing %q", test.expression, err, test.err)
+		}
+	}
+}
+
+// doublingList returns an expression that doubles a list n times in few evaluation steps
+func doublingList(n int) string {
+	expr := "[0]"
+	for i := 0; i < n; i++ {
+		expr = "[" + expr + "].map(x, x + x)[0]"
+	}
+	return expr
+}
+
+// doublingString returns an expression that doubles a string n times in few evaluation steps
+func doublingString(n int) string {
+	expr := "'ab'"
+	for i := 0; i < n; i++ {
+		expr = "[" + expr + "].map(s, s + s)[0]"
+	}
+	return expr
+}
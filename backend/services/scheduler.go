@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// Scheduling modes for crawls waiting for a worker
+const (
+	SchedulingFIFO = "fifo" // Crawls start in the order they were queued
+	SchedulingFair = "fair" // Groups of crawls take turns, see crawlScheduler
+)
+
+// crawlWaiter is a crawl waiting for a worker slot
+type crawlWaiter struct {
+	group    string
+	priority int
+	queued   time.Time // When the crawl was queued, which orders the waiting list
+	since    time.Time // When it started waiting for a slot, which its priority ages from
+	ready    chan struct{}
+}
+
+// crawlScheduler bounds the number of concurrent crawls and decides which waiting crawl starts next
+// In fair mode, groups (a project, a batch job, or ad-hoc crawls) take turns so a large batch
+// can't starve everyone else. Within a group, the crawl with the highest priority starts first and
+// waiting crawls gain one priority level per aging interval so low priorities still get their turn.
+// Unlike a buffered channel, its limit can be changed at runtime
+type crawlScheduler struct {
+	mu         sync.Mutex
+	limit      int
+	active     int
+	mode       string
+	aging      time.Duration
+	paused     bool // No slots are granted while paused; crawls that hold one finish
+	waiting    []*crawlWaiter
+	lastServed map[string]uint64 // Sequence number of the last slot granted to each group
+	served     uint64
+}
+
+// newCrawlScheduler creates a scheduler allowing up to limit concurrent crawls
+func newCrawlScheduler(limit int, mode string, aging time.Duration) *crawlScheduler {
+	return &crawlScheduler{
+		limit:      limit,
+		mode:       mode,
+		aging:      aging,
+		lastServed: make(map[string]uint64),
+	}
+}
+
+// Acquire blocks until the scheduler grants the crawl a slot, or until ctx is done
+// Crawls wait in the order they were queued, so crawls resumed after a restart go before newer ones
+// A crawl that stops waiting holds no slot, so it must not call Release
+func (s *crawlScheduler) Acquire(ctx context.Context, group string, priority int, queued time.Time) error {
+	waiter := &crawlWaiter{
+		group:    group,
+		priority: priority,
+		queued:   queued,
+		since:    time.Now(),
+		ready:    make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	i := len(s.waiting)
+	for i > 0 && s.waiting[i-1].queued.After(queued) {
+		i--
+	}
+	s.waiting = append(s.waiting, nil)
+	copy(s.waiting[i+1:], s.waiting[i:])
+	s.waiting[i] = waiter
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, waiting := range s.waiting {
+		if waiting == waiter {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// The slot was granted while ctx ended, so it is given back
+	s.active--
+	s.dispatch()
+	return ctx.Err()
+}
+
+// Release frees a slot granted by Acquire
+func (s *crawlScheduler) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	s.dispatch()
+}
+
+// Configure changes the limit and scheduling policy; running crawls are not interrupted when the limit shrinks
+func (s *crawlScheduler) Configure(limit int, mode string, aging time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.mode = mode
+	s.aging = aging
+	s.dispatch()
+}
+
+// Active returns the number of crawls holding a slot
+func (s *crawlScheduler) Active() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// Pause holds every waiting crawl until the scheduler is resumed; running crawls are not interrupted
+func (s *crawlScheduler) Pause(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+	s.dispatch()
+}
+
+// dispatch grants free slots to waiting crawls; the caller must hold the lock
+func (s *crawlScheduler) dispatch() {
+	for !s.paused && s.active < s.limit && len(s.waiting) > 0 {
+		i := s.next()
+		waiter := s.waiting[i]
+		s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+
+		s.served++
+		s.lastServed[waiter.group] = s.served
+		s.active++
+		close(waiter.ready)
+	}
+
+	// Forget groups that have nothing waiting so the map doesn't grow forever
+	if len(s.waiting) == 0 {
+		s.lastServed = make(map[string]uint64)
+	}
+}
+
+// next returns the index of the waiting crawl that should start next
+func (s *crawlScheduler) next() int {
+	if s.mode != SchedulingFair {
+		return 0
+	}
+
+	// The group served least recently takes its turn; the waiting list is in queue order,
+	// so the first waiter of a group decides ties between groups that were never served
+	group := s.waiting[0].group
+	for _, waiter := range s.waiting[1:] {
+		if s.lastServed[waiter.group] < s.lastServed[group] {
+			group = waiter.group
+		}
+	}
+
+	now := time.Now()
+	best, bestPriority := -1, 0
+	for i, waiter := range s.waiting {
+		if waiter.group != group {
+			continue
+		}
+		priority := waiter.priority
+		if s.aging > 0 {
+			priority += int(now.Sub(waiter.since) / s.aging)
+		}
+		if best == -1 || priority > bestPriority {
+			best, bestPriority = i, priority
+		}
+	}
+	return best
+}
+
+// schedulingGroup returns the group a crawl takes turns with in fair mode
+func schedulingGroup(urlModel models.URL, jobID uint) string {
+	if urlModel.ProjectID != nil {
+		return fmt.Sprintf("project:%d", *urlModel.ProjectID)
+	}
+	if jobID != 0 {
+		return fmt.Sprintf("job:%d", jobID)
+	}
+	return "adhoc"
+}
+
+// priorityAging returns the wait after which a queued crawl gains one priority level
+func priorityAging(settings models.Settings) time.Duration {
+	return time.Duration(settings.PriorityAgingSeconds) * time.Second
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"gorm.io/gorm"
+)
+
+// schedulePollInterval is how often due schedules are looked for; runs start at most this late
+const schedulePollInterval = time.Minute
+
+// Bounds of the recrawl intervals of adaptive schedules, in hours
+const (
+	DefaultMinIntervalHours = 1
+	DefaultMaxIntervalHours = 168
+	maxRecrawlIntervalHours = 720
+)
+
+// ScheduleService keeps the crawl schedules of projects and starts their runs as batch jobs
+type ScheduleService struct {
+	db      *gorm.DB
+	store   repository.Store
+	crawler *CrawlerService
+	jobs    *BatchJobService
+}
+
+// NewScheduleService creates a schedule service
+func NewScheduleService(db *gorm.DB, store repository.Store, crawler *CrawlerService, jobs *BatchJobService) *ScheduleService {
+	return &ScheduleService{db: db, store: store, crawler: crawler, jobs: jobs}
+}
+
+// ValidateSchedule checks the frequency, time, weekday, and time zone of a schedule,
+// or the interval bounds of an adaptive schedule
+func ValidateSchedule(schedule models.Schedule) error {
+	switch schedule.Frequency {
+	case models.ScheduleHourly, models.ScheduleDaily, models.ScheduleWeekly:
+	case models.ScheduleAdaptive:
+		if schedule.MinIntervalHours < 1 || schedule.MinIntervalHours > maxRecrawlIntervalHours {
+			return fmt.Errorf("min_interval_hours must be between %d and %d", 1, maxRecrawlIntervalHours)
+		}
+		if schedule.MaxIntervalHours < schedule.MinIntervalHours || schedule.MaxIntervalHours > maxRecrawlIntervalHours {
+			return fmt.Errorf("max_interval_hours must be between %d and %d", schedule.MinIntervalHours, maxRecrawlIntervalHours)
+		}
+		return nil
+	default:
+		return fmt.Errorf("frequency must be one of hourly, daily, weekly, adaptive")
+	}
+	if _, err := parseClock(schedule.Time); err != nil {
+		return err
+	}
+	if schedule.Weekday < 0 || schedule.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 and 6")
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("unknown time zone %q", schedule.Timezone)
+	}
+	return nil
+}
+
+// NextRun returns the first run of the schedule after the given time
+// Runs are computed from the local clock time, so a daily 03:00 run stays at 03:00 across daylight saving time changes.
+// A time skipped by the change runs when the clock continues, e.g. 02:30 runs at 03:30 on the spring forward day.
+func NextRun(schedule models.Schedule, after time.Time) time.Time {
+	// Adaptive schedules look for due URLs on every poll
+	if schedule.Frequency == models.ScheduleAdaptive {
+		return after.Add(schedulePollInterval)
+	}
+
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	minutes, _ := parseClock(schedule.Time)
+	local := after.In(loc)
+
+	if schedule.Frequency == models.ScheduleHourly {
+		next := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), minutes%60, 0, 0, loc)
+		for !next.After(after) {
+			next = next.Add(time.Hour)
+		}
+		return next
+	}
+
+	for day := 0; ; day++ {
+		next := time.Date(local.Year(), local.Month(), local.Day()+day, minutes/60, minutes%60, 0, 0, loc)
+		if schedule.Frequency == models.ScheduleWeekly && int(next.Weekday()) != schedule.Weekday {
+			continue
+		}
+		if next.After(after) {
+			return next
+		}
+	}
+}
+
+// Get returns the schedule of a project
+func (s *ScheduleService) Get(projectID uint) (*models.Schedule, error) {
+	var schedule models.Schedule
+	if err := s.db.Where("project_id = ?", projectID).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// Save creates or replaces the schedule of a project and computes its next run
+// The schedule must have been validated
+func (s *ScheduleService) Save(schedule *models.Schedule) error {
+	existing, err := s.Get(schedule.ProjectID)
+	switch {
+	case err == nil:
+		schedule.ID = existing.ID
+		schedule.LastRunAt = existing.LastRunAt
+		schedule.LastJobID = existing.LastJobID
+		schedule.CreatedAt = existing.CreatedAt
+	case !errors.Is(err, repository.ErrNotFound):
+		return err
+	}
+
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		next := NextRun(*schedule, time.Now()).UTC()
+		schedule.NextRunAt = &next
+	}
+	return s.db.Save(schedule).Error
+}
+
+// Delete removes the schedule of a project
+func (s *ScheduleService) Delete(projectID uint) error {
+	result := s.db.Where("project_id = ?", projectID).Delete(&models.Schedule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Start looks for due schedules every minute in the background
+func (s *ScheduleService) Start() {
+	go func() {
+		ticker := time.NewTicker(schedulePollInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			s.runDue(now)
+		}
+	}()
+}
+
+// runDue starts the runs of every schedule whose next run has come
+// During maintenance nothing starts and next_run_at stays, so the runs start once maintenance ends
+func (s *ScheduleService) runDue(now time.Time) {
+	if s.crawler.settings.Get().MaintenanceMode {
+		return
+	}
+	var schedules []models.Schedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now.UTC()).Find(&schedules).Error; err != nil {
+		log.Printf("Failed to find due schedules: %v", err)
+		return
+	}
+	for _, schedule := range schedules {
+		if err := s.run(schedule, now); err != nil {
+			log.Printf("Scheduled run of project %d failed: %v", schedule.ProjectID, err)
+		}
+	}
+}
+
+// run crawls the monitored URLs of the schedule's project as a batch job and moves the schedule to its next run
+// Runs missed while the server was down are not caught up, the schedule continues with its next run
+func (s *ScheduleService) run(schedule models.Schedule, now time.Time) error {
+	urls, err := s.monitoredURLs(schedule.ProjectID)
+	if err != nil {
+		return err
+	}
+	// Adaptive schedules only crawl the URLs whose recrawl interval has passed
+	adaptive := schedule.Frequency == models.ScheduleAdaptive
+	var due []models.URL
+	var ids []uint
+	for _, url := range urls {
+		if !adaptive || url.NextRecrawlAt == nil || !url.NextRecrawlAt.After(now) {
+			due = append(due, url)
+			ids = append(ids, url.ID)
+		}
+	}
+
+	// Admit the crawls before claiming the run, so a full queue retries on the next poll
+	var reservation *CrawlReservation
+	if len(ids) > 0 {
+		if reservation, err = s.crawler.Reserve(len(ids)); err != nil {
+			return err
+		}
+	}
+
+	// Claim the run by moving next_run_at, so a second server polling the same database doesn't start it again
+	next := NextRun(schedule, now).UTC()
+	updates := map[string]interface{}{"next_run_at": next, "last_run_at": now.UTC()}
+	if adaptive && len(ids) == 0 {
+		delete(updates, "last_run_at") // A poll that found nothing due isn't a run
+	}
+	claim := s.db.Model(&models.Schedule{}).
+		Where("id = ? AND next_run_at = ?", schedule.ID, schedule.NextRunAt).
+		Updates(updates)
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		reservation.Release()
+		return claim.Error
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Completed URLs are queued again so the crawler doesn't skip them; their earlier crawls are kept
+	if err := s.store.URLs().SetStatus("queued", ids...); err != nil {
+		reservation.Release()
+		return fmt.Errorf("failed to queue URLs: %v", err)
+	}
+	// Until their crawls finish and learn the next interval, the next polls must not start them again
+	if adaptive {
+		for _, url := range due {
+			nextRecrawl := now.Add(recrawlInterval(url, schedule)).UTC()
+			url.NextRecrawlAt = &nextRecrawl
+			if err := s.store.URLs().Update(&url, "next_recrawl_at"); err != nil {
+				log.Printf("Failed to postpone the next recrawl of URL %d: %v", url.ID, err)
+			}
+		}
+	}
+	job, err := s.jobs.CreateJob("scheduled", len(ids))
+	if err != nil {
+		reservation.Release()
+		return err
+	}
+	reservation.JobID = job.ID
+	if err := s.db.Model(&models.Schedule{}).Where("id = ?", schedule.ID).Update("last_job_id", job.ID).Error; err != nil {
+		log.Printf("Failed to record the job of schedule %d: %v", schedule.ID, err)
+	}
+
+	for _, id := range ids {
+		urlID := id
+		reservation.Start(urlID, func(crawlErr error) {
+			if crawlErr != nil {
+				log.Printf("Scheduled crawl of URL %d failed: %v", urlID, crawlErr)
+			}
+			if err := s.jobs.RecordResult(job.ID, crawlErr); err != nil {
+				log.Print(err)
+			}
+		})
+	}
+	log.Printf("Started scheduled run of project %d with %d URL(s), next run at %s", schedule.ProjectID, len(ids), next.Format(time.RFC3339))
+	return nil
+}
+
+// monitoredURLs returns the URLs of the project that opted into monitoring and aren't being crawled
+// Every URL of a watch group is monitored
+func (s *ScheduleService) monitoredURLs(projectID uint) ([]models.URL, error) {
+	project, err := s.store.Projects().Get(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project: %v", err)
+	}
+	urls, err := s.store.URLs().List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URLs: %v", err)
+	}
+	var monitored []models.URL
+	for _, url := range urls {
+		if (url.MonitorEnabled || project.WatchGroup) && url.ProjectID != nil && *url.ProjectID == projectID && url.Status != "running" {
+			monitored = append(monitored, url)
+		}
+	}
+	return monitored, nil
+}
+
+// CrawlStarted implements CrawlObserver; adaptive schedules only learn from finished crawls
+func (s *ScheduleService) CrawlStarted(project *models.Project, url models.URL) {}
+
+// CrawlFinished adapts the recrawl interval of a URL of an adaptive schedule to whether its page changed since
+// the previous crawl; crawls of every kind count, so a manual crawl postpones the next scheduled one as well
+// The schedule and the previous crawl are loaded in the background, so the crawl doesn't wait for the database
+func (s *ScheduleService) CrawlFinished(project *models.Project, url models.URL, result *models.CrawlResult, crawlErr error) {
+	if project == nil || result == nil || crawlErr != nil {
+		return
+	}
+	go s.adaptRecrawl(project, url, result)
+}
+
+// adaptRecrawl compares the saved result with the crawl before it and sets the next recrawl of the URL
+func (s *ScheduleService) adaptRecrawl(project *models.Project, url models.URL, result *models.CrawlResult) {
+	schedule, err := s.Get(project.ID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Failed to load the schedule of project %d: %v", project.ID, err)
+		}
+		return
+	}
+	if schedule.Frequency != models.ScheduleAdaptive {
+		return
+	}
+
+	interval := recrawlInterval(url, *schedule)
+	previousID, err := s.store.CrawlResults().PreviousID(url.ID, result.ID)
+	if err != nil {
+		log.Printf("Failed to find the crawl of URL %d before crawl %d: %v", url.ID, result.ID, err)
+		return
+	}
+	if previousID != 0 {
+		previous, err := s.store.CrawlResults().Get(previousID)
+		if err != nil {
+			log.Printf("Failed to load the previous crawl of URL %d: %v", url.ID, err)
+			return
+		}
+		if previous.ContentHash != "" && result.ContentHash != "" {
+			interval = adaptRecrawlInterval(interval, previous.ContentHash != result.ContentHash, *schedule)
+		}
+	}
+
+	url.RecrawlIntervalMinutes = int(interval / time.Minute)
+	nextRecrawl := time.Now().Add(interval).UTC()
+	url.NextRecrawlAt = &nextRecrawl
+	if err := s.store.URLs().Update(&url, "recrawl_interval_minutes", "next_recrawl_at"); err != nil {
+		log.Printf("Failed to update the recrawl interval of URL %d: %v", url.ID, err)
+	}
+}
+
+// recrawlInterval returns the recrawl interval of a URL within the bounds of the schedule;
+// URLs without one start at the shortest interval
+func recrawlInterval(url models.URL, schedule models.Schedule) time.Duration {
+	return clampRecrawlInterval(time.Duration(url.RecrawlIntervalMinutes)*time.Minute, schedule)
+}
+
+// adaptRecrawlInterval halves the interval of a page that changed since its previous crawl,
+// and makes it half as long again when the page didn't change
+func adaptRecrawlInterval(interval time.Duration, changed bool, schedule models.Schedule) time.Duration {
+	if changed {
+		interval /= 2
+	} else {
+		interval += interval / 2
+	}
+	return clampRecrawlInterval(interval, schedule)
+}
+
+// clampRecrawlInterval keeps an interval within the bounds of the schedule
+func clampRecrawlInterval(interval time.Duration, schedule models.Schedule) time.Duration {
+	minimum := time.Duration(schedule.MinIntervalHours) * time.Hour
+	maximum := time.Duration(schedule.MaxIntervalHours) * time.Hour
+	if interval < minimum {
+		return minimum
+	}
+	if interval > maximum {
+		return maximum
+	}
+	return interval
+}
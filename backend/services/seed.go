@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+// demoProjectName is the project the demo URLs are assigned to
+const demoProjectName = "Demo"
+
+// SeedResult reports what SeedDemoData created
+type SeedResult struct {
+	ProjectID   uint     `json:"project_id"`
+	Created     []string `json:"created"`
+	Skipped     []string `json:"skipped"` // URLs that already existed
+	CrawlsAdded int      `json:"crawls_added"`
+}
+
+// SeedService fills an empty development database with demo data
+// Frontend development and end-to-end tests can then run against realistic results without real crawls
+type SeedService struct {
+	store repository.Store
+}
+
+// NewSeedService creates a new seed service
+func NewSeedService(store repository.Store) *SeedService {
+	return &SeedService{store: store}
+}
+
+// demoURL is a seeded URL with the crawl result it gets, nil for URLs that haven't been crawled
+type demoURL struct {
+	url       models.URL
+	result    *models.CrawlResult
+	inProject bool
+}
+
+// SeedDemoData creates the demo project and URLs; URLs that already exist are left untouched,
+// so seeding twice is harmless
+func (s *SeedService) SeedDemoData() (SeedResult, error) {
+	var result SeedResult
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		project, err := tx.Projects().FindByName(demoProjectName, 0)
+		if errors.Is(err, repository.ErrNotFound) {
+			project = models.Project{
+				Name: demoProjectName,
+				PolicyTerms: []models.PolicyTerm{
+					{Term: "lorem ipsum", Rule: models.PolicyRuleForbidden},
+				},
+				Budget: models.PageBudget{MaxHTMLBytes: 100000, MaxScripts: 10},
+			}
+			err = tx.Projects().Create(&project)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create demo project: %v", err)
+		}
+		result.ProjectID = project.ID
+
+		for _, demo := range demoURLs(time.Now()) {
+			if _, err := tx.URLs().FindByURL(demo.url.URL, 0); err == nil {
+				result.Skipped = append(result.Skipped, demo.url.URL)
+				continue
+			}
+
+			if demo.inProject {
+				demo.url.ProjectID = &project.ID
+			}
+			if err := tx.URLs().Create(&demo.url); err != nil {
+				return fmt.Errorf("failed to create demo URL %s: %v", demo.url.URL, err)
+			}
+			result.Created = append(result.Created, demo.url.URL)
+
+			if demo.result == nil {
+				continue
+			}
+			demo.result.URLID = demo.url.ID
+			demo.result.AnalyzerVersion = AnalyzerVersion
+			if err := tx.CrawlResults().Create(demo.result); err != nil {
+				return fmt.Errorf("failed to create demo crawl of %s: %v", demo.url.URL, err)
+			}
+			result.CrawlsAdded++
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// demoURLs returns one URL for every status the frontend shows, using the reserved .example domain
+func demoURLs(now time.Time) []demoURL {
+	redirectsToHTTPS := true
+
+	return []demoURL{
+		{
+			inProject: true,
+			url: models.URL{
+				URL:            "https://shop.example/",
+				DisplayURL:     "https://shop.example/",
+				Status:         "completed",
+				Tags:           []string{"demo", "shop"},
+				MonitorEnabled: true,
+			},
+			result: &models.CrawlResult{
+				Title:              "Demo Shop - Home",
+				HTMLVersion:        "HTML5",
+				H1Count:            1,
+				H2Count:            4,
+				H3Count:            9,
+				InternalLinks:      4,
+				ExternalLinks:      2,
+				InaccessibleLinks:  1,
+				PermanentRedirects: 1,
+				HasLoginForm:       true,
+				CrawledAt:          now.Add(-2 * time.Hour),
+				BudgetStatus:       models.BudgetPass,
+				Content: models.ContentAnalysis{
+					Language:            "en",
+					LanguageSource:      "html_lang",
+					WordCount:           812,
+					SentenceCount:       54,
+					AvgWordsPerSentence: 15.04,
+					AvgSyllablesPerWord: 1.48,
+					ReadingEase:         62.3,
+					GradeLevel:          8.7,
+					ReadabilityFormula:  "flesch-kincaid",
+				},
+				Weight: models.PageWeight{HTMLBytes: 48210, ScriptCount: 6, ExternalScripts: 4, InlineScripts: 2},
+				Security: models.SecurityAnalysis{
+					HSTS: models.HSTSCheck{
+						Header:               "max-age=86400",
+						MaxAge:               86400,
+						HTTPRedirectsToHTTPS: &redirectsToHTTPS,
+						Problems:             []string{"max-age must be at least 31536000"},
+					},
+				},
+				Links: []models.Link{
+					{URL: "https://shop.example/products", Type: "internal", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://shop.example/products"},
+					{URL: "https://shop.example/cart", Type: "internal", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://shop.example/cart"},
+					{URL: "https://shop.example/old-sale", Type: "internal", StatusCode: 404, CheckMethod: "GET", InitialStatusCode: 404, FinalURL: "https://shop.example/old-sale"},
+					{URL: "https://shop.example/about-us", Type: "internal", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 301, FinalURL: "https://shop.example/about", RedirectCount: 1, PermanentRedirect: true},
+					{URL: "https://payments.example/checkout", Type: "external", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://payments.example/checkout"},
+					{URL: "https://social.example/demoshop", Type: "external", StatusCode: 429, Throttled: true, CheckMethod: "HEAD", InitialStatusCode: 429, FinalURL: "https://social.example/demoshop"},
+				},
+				Findings: []models.Finding{
+					{
+						Type:     models.FindingHSTS,
+						Category: models.CategorySecurity,
+						Severity: models.SeverityWarning,
+						URL:      "https://shop.example/",
+						Message:  "HSTS header does not meet the preload requirements",
+						Details:  map[string]interface{}{"problems": []string{"max-age must be at least 31536000"}},
+					},
+					{
+						Type:     models.FindingSRIMissing,
+						Category: models.CategorySecurity,
+						Severity: models.SeverityWarning,
+						URL:      "https://cdn.example/jquery.min.js",
+						Message:  "Third-party script is loaded without an integrity attribute",
+					},
+				},
+			},
+		},
+		{
+			inProject: true,
+			url: models.URL{
+				URL:        "https://blog.example/",
+				DisplayURL: "https://blog.example/",
+				Status:     "completed",
+				Tags:       []string{"demo", "blog"},
+			},
+			result: &models.CrawlResult{
+				Title:         "Demo Blog",
+				HTMLVersion:   "HTML5",
+				H1Count:       1,
+				H2Count:       12,
+				InternalLinks: 2,
+				ExternalLinks: 1,
+				CrawledAt:     now.Add(-26 * time.Hour),
+				BudgetStatus:  models.BudgetFail,
+				Content: models.ContentAnalysis{
+					Language:            "de",
+					LanguageSource:      "detected",
+					WordCount:           1930,
+					SentenceCount:       101,
+					AvgWordsPerSentence: 19.11,
+					AvgSyllablesPerWord: 1.71,
+					ReadingEase:         56.2,
+					GradeLevel:          11.4,
+					ReadabilityFormula:  "flesch-amstad",
+				},
+				Weight: models.PageWeight{HTMLBytes: 156400, ScriptCount: 14, ExternalScripts: 11, InlineScripts: 3, ThirdPartyDomains: []string{"ads.example", "analytics.example"}},
+				Links: []models.Link{
+					{URL: "https://blog.example/archive", Type: "internal", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://blog.example/archive"},
+					{URL: "https://blog.example/impressum", Type: "internal", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://blog.example/impressum"},
+					{URL: "https://news.example/", Type: "external", StatusCode: 200, IsAccessible: true, CheckMethod: "HEAD", InitialStatusCode: 200, FinalURL: "https://news.example/"},
+				},
+				Findings: []models.Finding{
+					{
+						Type:     models.FindingBudgetExceeded,
+						Category: models.CategoryPerformance,
+						Severity: models.SeverityError,
+						URL:      "https://blog.example/",
+						Message:  "Page exceeds the html_bytes budget: 156400 > 100000",
+						Details:  map[string]interface{}{"metric": "html_bytes", "actual": 156400, "limit": 100000},
+					},
+					{
+						Type:     models.FindingBudgetExceeded,
+						Category: models.CategoryPerformance,
+						Severity: models.SeverityError,
+						URL:      "https://blog.example/",
+						Message:  "Page exceeds the scripts budget: 14 > 10",
+						Details:  map[string]interface{}{"metric": "scripts", "actual": 14, "limit": 10},
+					},
+				},
+			},
+		},
+		{
+			url: models.URL{
+				URL:        "https://docs.example/getting-started",
+				DisplayURL: "https://docs.example/getting-started",
+				Status:     "queued",
+				Tags:       []string{"demo"},
+			},
+		},
+		{
+			url: models.URL{
+				URL:        "https://offline.example/",
+				DisplayURL: "https://offline.example/",
+				Status:     "error",
+				LastError:  "failed to fetch URL: dial tcp: lookup offline.example: no such host",
+				Tags:       []string{"demo"},
+			},
+		},
+		{
+			url: models.URL{
+				URL:        "https://protected.example/",
+				DisplayURL: "https://protected.example/",
+				Status:     "blocked",
+				LastError:  "page is protected by a bot challenge (cloudflare)",
+				Tags:       []string{"demo"},
+			},
+		},
+		{
+			url: models.URL{
+				URL:        "https://xn--mnchen-3ya.example/",
+				DisplayURL: "https://münchen.example/",
+				Status:     "queued",
+				Tags:       []string{"demo", "idn"},
+			},
+		},
+	}
+}
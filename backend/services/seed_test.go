@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+func TestSeedDemoData(t *testing.T) {
+	store := repository.NewMemoryStore()
+	seeder := NewSeedService(store)
+	demos := demoURLs(time.Now())
+
+	first, err := seeder.SeedDemoData()
+	if err != nil {
+		t.Fatalf("SeedDemoData: %v", err)
+	}
+	crawled := 0
+	for _, demo := range demos {
+		if demo.result != nil {
+			crawled++
+		}
+	}
+	if len(first.Created) != len(demos) || len(first.Skipped) != 0 || first.CrawlsAdded != crawled {
+		t.Errorf("first seed created %d, skipped %d, and crawled %d URLs, want %d, 0, and %d",
+			len(first.Created), len(first.Skipped), first.CrawlsAdded, len(demos), crawled)
+	}
+
+	// Seeding again leaves everything as it is
+	second, err := seeder.SeedDemoData()
+	if err != nil {
+		t.Fatalf("SeedDemoData again: %v", err)
+	}
+	if second.ProjectID != first.ProjectID || len(second.Created) != 0 || len(second.Skipped) != len(demos) || second.CrawlsAdded != 0 {
+		t.Errorf("second seed = %+v, want project %d and every URL skipped", second, first.ProjectID)
+	}
+
+	// The crawls are the latest of their URLs, whose counters describe them
+	for _, demo := range demos {
+		url, err := store.URLs().FindByURL(demo.url.URL, 0)
+		if err != nil {
+			t.Fatalf("FindByURL(%s): %v", demo.url.URL, err)
+		}
+		if (url.ProjectID != nil) != demo.inProject {
+			t.Errorf("%s: project %v, want in project %v", url.URL, url.ProjectID, demo.inProject)
+		}
+		if demo.result == nil {
+			if url.LatestCrawlID != nil {
+				t.Errorf("%s: latest crawl %d, want none", url.URL, *url.LatestCrawlID)
+			}
+			continue
+		}
+		latest, err := store.CrawlResults().Latest(url.ID, repository.LoadOptions{Links: true})
+		if err != nil {
+			t.Fatalf("Latest(%d): %v", url.ID, err)
+		}
+		if url.LatestCrawlID == nil || *url.LatestCrawlID != latest.ID {
+			t.Errorf("%s: latest crawl %v, want %d", url.URL, url.LatestCrawlID, latest.ID)
+		}
+		if url.LinksCount != len(demo.result.Links) || len(latest.Links) != len(demo.result.Links) {
+			t.Errorf("%s: %d links counted and %d stored, want %d", url.URL, url.LinksCount, len(latest.Links), len(demo.result.Links))
+		}
+	}
+}
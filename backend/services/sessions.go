@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval limits how often last-used times are written for a busy session
+const sessionTouchInterval = time.Minute
+
+// ErrSessionNotFound is returned for unknown, revoked, and expired sessions
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionService stores login sessions in the database so they survive restarts and are shared between instances
+type SessionService struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+// NewSessionService creates a session service; sessions expire after ttl without use
+func NewSessionService(db *gorm.DB, ttl time.Duration) *SessionService {
+	return &SessionService{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// Create starts a session for the user and returns its token
+// A session created with mustChangePassword may only change the password
+func (s *SessionService) Create(username, ip, userAgent string, mustChangePassword bool) (string, *models.Session, error) {
+	// Drop expired sessions while we're here
+	s.db.Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate session token: %v", err)
+	}
+
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		TokenHash:  hashToken(token),
+		Username:   username,
+		IP:         ip,
+		UserAgent:  userAgent,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+
+		MustChangePassword: mustChangePassword,
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to save session: %v", err)
+	}
+
+	return token, session, nil
+}
+
+// Validate returns the session of a token and extends its expiry
+func (s *SessionService) Validate(token string) (*models.Session, error) {
+	var session models.Session
+	err := s.db.Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	if now := time.Now(); now.Sub(session.LastUsedAt) > sessionTouchInterval {
+		session.LastUsedAt = now
+		session.ExpiresAt = now.Add(s.ttl)
+		s.db.Model(&session).Updates(map[string]interface{}{
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+		})
+	}
+
+	return &session, nil
+}
+
+// List returns the active sessions of a user, most recently used first
+func (s *SessionService) List(username string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.Where("username = ? AND expires_at > ?", username, time.Now()).
+		Order("last_used_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke ends the session of a token
+func (s *SessionService) Revoke(token string) error {
+	return s.db.Where("token_hash = ?", hashToken(token)).Delete(&models.Session{}).Error
+}
+
+// RevokeID ends one of the user's sessions by its ID
+func (s *SessionService) RevokeID(username string, id uint) error {
+	result := s.db.Where("id = ? AND username = ?", id, username).Delete(&models.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// PasswordChanged ends every other session of the user and lifts the password change restriction of the current one
+func (s *SessionService) PasswordChanged(username string, currentID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ? AND id <> ?", username, currentID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).Where("id = ?", currentID).Update("must_change_password", false).Error
+	})
+}
+
+// generateToken returns a random session token
+func generateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "auth_token_" + hex.EncodeToString(bytes), nil
+}
+
+// hashToken returns the hash a token is stored under, so a database leak doesn't leak usable tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"gorm.io/gorm"
+)
+
+// settingsRowID is the primary key of the single row holding runtime settings
+const settingsRowID = 1
+
+// Default request headers; many servers reject the bare Go user agent
+const (
+	defaultUserAgent    = "Mozilla/5.0 (compatible; SykellURLAnalyzer/1.0)"
+	defaultAcceptHeader = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+)
+
+// Default response compression; JSON covers the API, the others its exports
+const (
+	defaultCompressionMinBytes = 1024
+	defaultCompressionTypes    = "application/json,text/csv,text/plain"
+)
+
+// DefaultSettings returns the settings used when nothing has been persisted yet
+func DefaultSettings() models.Settings {
+	return models.Settings{
+		ID:                      settingsRowID,
+		WorkerCount:             5,
+		MaxQueuedCrawls:         100,
+		SchedulingMode:          SchedulingFair,
+		PriorityAgingSeconds:    60,
+		LinkCheckConcurrency:    10,
+		CrawlTimeoutSeconds:     30,
+		LinkCheckTimeoutSeconds: 10,
+		UserAgent:               defaultUserAgent,
+		AcceptHeader:            defaultAcceptHeader,
+		CompressionEnabled:      true,
+		CompressionMinBytes:     defaultCompressionMinBytes,
+		CompressionTypes:        defaultCompressionTypes,
+	}
+}
+
+// SettingsService keeps the runtime settings in memory and persists changes to the database
+// Services that depend on a setting register a listener and are notified on every change
+type SettingsService struct {
+	db        *gorm.DB
+	mu        sync.RWMutex
+	current   models.Settings
+	listeners []func(models.Settings)
+}
+
+// NewSettingsService creates a settings service and loads the persisted settings
+func NewSettingsService(db *gorm.DB) *SettingsService {
+	s := &SettingsService{
+		db:      db,
+		current: DefaultSettings(),
+	}
+
+	// Create the settings row with defaults on first start
+	settings := DefaultSettings()
+	if err := db.FirstOrCreate(&settings, settingsRowID).Error; err == nil {
+		s.current = withDefaults(settings)
+	}
+
+	return s
+}
+
+// withDefaults fills knobs that were added after the settings row was first created
+func withDefaults(settings models.Settings) models.Settings {
+	defaults := DefaultSettings()
+	if settings.UserAgent == "" {
+		settings.UserAgent = defaults.UserAgent
+	}
+	if settings.AcceptHeader == "" {
+		settings.AcceptHeader = defaults.AcceptHeader
+	}
+	if settings.MaxQueuedCrawls == 0 {
+		settings.MaxQueuedCrawls = defaults.MaxQueuedCrawls
+	}
+	if settings.SchedulingMode == "" {
+		settings.SchedulingMode = defaults.SchedulingMode
+		settings.PriorityAgingSeconds = defaults.PriorityAgingSeconds
+	}
+	if settings.CompressionTypes == "" {
+		settings.CompressionEnabled = defaults.CompressionEnabled
+		settings.CompressionMinBytes = defaults.CompressionMinBytes
+		settings.CompressionTypes = defaults.CompressionTypes
+	}
+	return settings
+}
+
+// Get returns a copy of the current settings
+func (s *SettingsService) Get() models.Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// OnChange registers a listener that is called whenever the settings change
+func (s *SettingsService) OnChange(listener func(models.Settings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Update validates and persists new settings, then applies them to all listeners
+func (s *SettingsService) Update(settings models.Settings) (models.Settings, error) {
+	if err := ValidateSettings(settings); err != nil {
+		return models.Settings{}, err
+	}
+
+	settings.ID = settingsRowID
+	if err := s.db.Save(&settings).Error; err != nil {
+		return models.Settings{}, fmt.Errorf("failed to save settings: %v", err)
+	}
+
+	s.mu.Lock()
+	s.current = settings
+	listeners := append([]func(models.Settings){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(settings)
+	}
+
+	return settings, nil
+}
+
+// ValidateSettings checks that every knob is within a sensible range
+func ValidateSettings(settings models.Settings) error {
+	if settings.WorkerCount < 1 || settings.WorkerCount > 100 {
+		return fmt.Errorf("worker_count must be between 1 and 100")
+	}
+	if settings.MaxQueuedCrawls < 1 || settings.MaxQueuedCrawls > 10000 {
+		return fmt.Errorf("max_queued_crawls must be between 1 and 10000")
+	}
+	if settings.SchedulingMode != SchedulingFair && settings.SchedulingMode != SchedulingFIFO {
+		return fmt.Errorf("scheduling_mode must be %q or %q", SchedulingFair, SchedulingFIFO)
+	}
+	if settings.PriorityAgingSeconds < 0 || settings.PriorityAgingSeconds > 86400 {
+		return fmt.Errorf("priority_aging_seconds must be between 0 and 86400")
+	}
+	if settings.LinkCheckConcurrency < 1 || settings.LinkCheckConcurrency > 100 {
+		return fmt.Errorf("link_check_concurrency must be between 1 and 100")
+	}
+	if settings.LinkChecksPerSecond < 0 || settings.LinkChecksPerSecond > 1000 {
+		return fmt.Errorf("link_checks_per_second must be between 0 and 1000")
+	}
+	if settings.CrawlTimeoutSeconds < 1 || settings.CrawlTimeoutSeconds > 300 {
+		return fmt.Errorf("crawl_timeout_seconds must be between 1 and 300")
+	}
+	if settings.LinkCheckTimeoutSeconds < 1 || settings.LinkCheckTimeoutSeconds > 120 {
+		return fmt.Errorf("link_check_timeout_seconds must be between 1 and 120")
+	}
+	if settings.UserAgent == "" || len(settings.UserAgent) > 512 {
+		return fmt.Errorf("user_agent must be between 1 and 512 characters")
+	}
+	if settings.AcceptHeader == "" || len(settings.AcceptHeader) > 512 {
+		return fmt.Errorf("accept_header must be between 1 and 512 characters")
+	}
+	if settings.CrawlerInfoURL != "" {
+		info, err := url.Parse(settings.CrawlerInfoURL)
+		if err != nil || (info.Scheme != "http" && info.Scheme != "https") || info.Host == "" {
+			return fmt.Errorf("crawler_info_url must be an absolute http or https URL")
+		}
+		if len(settings.CrawlerInfoURL) > 255 {
+			return fmt.Errorf("crawler_info_url must be at most 255 characters")
+		}
+	}
+	if settings.CompressionMinBytes < 0 || settings.CompressionMinBytes > 10<<20 {
+		return fmt.Errorf("compression_min_bytes must be between 0 and 10485760")
+	}
+	if len(settings.CompressionTypes) > 512 {
+		return fmt.Errorf("compression_types must be at most 512 characters")
+	}
+	for _, contentType := range strings.Split(settings.CompressionTypes, ",") {
+		if _, _, err := mime.ParseMediaType(strings.TrimSpace(contentType)); err != nil {
+			return fmt.Errorf("compression_types must be a comma-separated list of content types")
+		}
+	}
+	return nil
+}
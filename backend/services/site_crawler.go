@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxSitePages     = 500 // Upper bound for CrawlConfig.MaxPages
+	maxSiteDepth     = 10  // Upper bound for CrawlConfig.MaxDepth
+	defaultSiteDepth = 3   // Depth used when MaxDepth is not set
+)
+
+// sitePage is a page waiting to be visited by a site crawl
+type sitePage struct {
+	url   string
+	depth int
+}
+
+// crawlSite follows internal links breadth-first from the already analyzed root page
+// Every internal link target is verified, either as a crawled page or with a link check,
+// and the broken ones are reported together with the pages referencing them
+func (c *CrawlerService) crawlSite(ctx context.Context, rootURL string, result *models.CrawlResult, settings models.Settings, config models.CrawlConfig, project *models.Project, tracker *throttleTracker, progress *crawlProgress) {
+	maxPages := config.MaxPages
+	if maxPages > maxSitePages {
+		maxPages = maxSitePages
+	}
+	maxDepth := config.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultSiteDepth
+	}
+	scope := newLinkScope(rootURL, config, project)
+
+	root := normalizePageURL(rootURL)
+	crawled := map[string]int{root: http.StatusOK} // Status code of every visited page
+	referrers := make(map[string][]string)         // Internal link target -> pages linking to it
+	var targets []string                           // Targets in discovery order, for a stable report
+	var queue []sitePage
+
+	// addLinks records the internal links of a page and queues targets that haven't been seen yet
+	addLinks := func(source string, links []models.Link, depth int) {
+		for _, link := range links {
+			if link.Type != "internal" || !isHTTPURL(link.URL) {
+				continue
+			}
+			target := normalizePageURL(link.URL)
+			if _, seen := referrers[target]; !seen {
+				targets = append(targets, target)
+				if _, visited := crawled[target]; !visited && depth+1 <= maxDepth {
+					queue = append(queue, sitePage{url: target, depth: depth + 1})
+				}
+			}
+			if !containsString(referrers[target], source) {
+				referrers[target] = append(referrers[target], source)
+			}
+		}
+	}
+
+	addLinks(root, result.Links, 0)
+
+	// Pages of a paginated series share their problems, which are reported once per series
+	grouper := newSeriesGrouper(result)
+	grouper.adopt(root, result.Pagination)
+
+	// The root page counts towards the page limit; a stopped crawl visits no further pages
+	for len(queue) > 0 && len(result.Pages)+1 < maxPages && ctx.Err() == nil {
+		next := queue[0]
+		queue = queue[1:]
+		if _, visited := crawled[next.url]; visited {
+			continue
+		}
+
+		page := models.CrawlPage{
+			URL:       next.url,
+			Depth:     next.depth,
+			CrawledAt: time.Now(),
+		}
+
+		fetched, err := c.fetchPage(ctx, next.url, settings, config, tracker)
+		if err != nil {
+			page.Error = err.Error()
+		} else {
+			page.StatusCode = fetched.StatusCode
+			if fetched.Doc != nil {
+				pageResult := &models.CrawlResult{}
+				c.extractTitle(fetched.Doc, pageResult)
+				c.extractLinks(fetched.Doc, pageResult, next.url, scope)
+				page.Title = pageResult.Title
+				page.Pagination = detectPagination(fetched.Doc, next.url)
+				addLinks(next.url, pageResult.Links, next.depth)
+				// rel=next in the head isn't a link of the page, but the series continues there
+				if page.Pagination.Next != "" {
+					addLinks(next.url, []models.Link{{URL: page.Pagination.Next, Type: "internal"}}, next.depth)
+				}
+
+				var findings []models.Finding
+				if signals := detectSoft404(fetched.Doc, false); signals.IsSoft404() {
+					findings = append(findings, soft404Finding(next.url, signals.Reasons()))
+				}
+				findings = append(findings, policyFindings(next.url, visibleText(fetched.Doc), project)...)
+				findings = append(findings, metaDescriptionFindings(next.url, findMetaDescription(fetched.Doc))...)
+				findings = append(findings, headingFindings(next.url, pageResult.Title, headingTexts(fetched.Doc), project)...)
+
+				// A budget violation on any crawled page fails the crawl
+				status, budgetFindings := evaluateBudget(next.url, measurePageWeight(fetched.Doc, next.url, fetched.Size), project)
+				if status == models.BudgetFail {
+					result.BudgetStatus = status
+				}
+				findings = append(findings, budgetFindings...)
+				grouper.add(next.url, page.Pagination, findings)
+			}
+		}
+
+		crawled[next.url] = page.StatusCode
+		result.Pages = append(result.Pages, page)
+	}
+	result.PaginatedSeries = grouper.seriesOf()
+
+	// Reuse the link checks of the root page and check every other target that wasn't crawled
+	checked := make(map[string]models.Link)
+	for _, link := range result.Links {
+		checked[normalizePageURL(link.URL)] = link
+	}
+	var unchecked []models.Link
+	for _, target := range targets {
+		_, visited := crawled[target]
+		_, known := checked[target]
+		if !visited && !known {
+			unchecked = append(unchecked, models.Link{URL: target, Type: "internal"})
+		}
+	}
+	c.checkLinks(ctx, unchecked, settings, tracker, progress)
+	for _, link := range unchecked {
+		checked[link.URL] = link
+	}
+
+	for _, target := range targets {
+		entry := models.InternalBrokenLink{
+			URL:       target,
+			Referrers: referrers[target],
+		}
+
+		if status, visited := crawled[target]; visited {
+			entry.Crawled = true
+			entry.StatusCode = status
+			if status != 0 && status < 400 {
+				continue
+			}
+		} else {
+			link := checked[target]
+			if link.IsAccessible || link.Throttled {
+				continue
+			}
+			entry.StatusCode = link.StatusCode
+		}
+
+		result.InternalBrokenLinks = append(result.InternalBrokenLinks, entry)
+	}
+}
+
+// normalizePageURL drops the fragment so links to sections of a page count as the same page
+func normalizePageURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// isHTTPURL reports whether the URL uses the http or https scheme
+func isHTTPURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
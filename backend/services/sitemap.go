@@ -0,0 +1,133 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxSitemapFiles = 10       // Sitemap files read per crawl, including those of sitemap indexes
+	maxSitemapURLs  = 5000     // Pages recorded per crawl
+	maxSitemapBytes = 10 << 20 // Bytes read per sitemap file, uncompressed
+)
+
+// sitemapDocument is a <urlset> or a <sitemapindex>; the protocol uses the same <loc> in both
+type sitemapDocument struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// discoverSitemap reads the sitemaps robots.txt announces, or /sitemap.xml when it announces none, and
+// records the pages they list; sitemap indexes are followed until maxSitemapFiles files were read
+func (c *CrawlerService) discoverSitemap(ctx context.Context, pageURL string, announced []string, settings models.Settings, tracker *throttleTracker) *models.SitemapPages {
+	pages := &models.SitemapPages{}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		pages.Error = err.Error()
+		return pages
+	}
+
+	queue := announced
+	if len(queue) == 0 {
+		queue = []string{page.Scheme + "://" + page.Host + "/sitemap.xml"}
+	}
+	seen := make(map[string]bool)
+	urls := make(map[string]bool)
+	for len(queue) > 0 {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if seen[sitemapURL] {
+			continue
+		}
+		if len(pages.Sitemaps) == maxSitemapFiles {
+			pages.Truncated = true
+			break
+		}
+		seen[sitemapURL] = true
+		pages.Sitemaps = append(pages.Sitemaps, sitemapURL)
+
+		document, err := c.fetchSitemap(ctx, sitemapURL, settings, tracker)
+		if err != nil {
+			// The first failure is kept, so a broken index isn't hidden by its working children
+			if pages.Error == "" {
+				pages.Error = fmt.Sprintf("%s: %v", sitemapURL, err)
+			}
+			continue
+		}
+		for _, entry := range document.Sitemaps {
+			if loc := strings.TrimSpace(entry.Loc); isHTTPURL(loc) && !seen[loc] {
+				queue = append(queue, loc)
+			}
+		}
+		for _, entry := range document.URLs {
+			loc := strings.TrimSpace(entry.Loc)
+			if !isHTTPURL(loc) || urls[loc] {
+				continue
+			}
+			if len(urls) == maxSitemapURLs {
+				pages.Truncated = true
+				break
+			}
+			urls[loc] = true
+		}
+	}
+
+	pages.URLs = make([]string, 0, len(urls))
+	for loc := range urls {
+		pages.URLs = append(pages.URLs, loc)
+	}
+	sort.Strings(pages.URLs)
+	return pages
+}
+
+// fetchSitemap downloads and decodes a sitemap file, gunzipping .gz files
+func (c *CrawlerService) fetchSitemap(ctx context.Context, sitemapURL string, settings models.Settings, tracker *throttleTracker) (*sitemapDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(settings.CrawlTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, http.MethodGet, sitemapURL, settings)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/xml, text/xml;q=0.9, */*;q=0.5")
+
+	resp, err := c.doWithBackoff(c.client, req, tracker)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	// Go's transport already decodes Content-Encoding: gzip, so only gzipped files need it here
+	var body io.Reader = resp.Body
+	if strings.HasSuffix(strings.ToLower(resp.Request.URL.Path), ".gz") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip file: %v", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var document sitemapDocument
+	if err := xml.NewDecoder(io.LimitReader(body, maxSitemapBytes)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %v", err)
+	}
+	return &document, nil
+}
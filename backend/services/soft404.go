@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// soft404MaxWords is the word count below which a page counts as thin content
+const soft404MaxWords = 150
+
+// soft404TitlePattern matches titles of typical "not found" pages
+var soft404TitlePattern = regexp.MustCompile(`(?i)(\b404\b|not found|page missing|nicht gefunden|introuvable|no encontrada)`)
+
+// soft404Phrases are common sentences on "not found" pages, matched case-insensitively
+var soft404Phrases = []string{
+	"page not found",
+	"404 not found",
+	"error 404",
+	"page you requested could not be found",
+	"page you are looking for",
+	"page you were looking for",
+	"page doesn't exist",
+	"page does not exist",
+	"no longer available",
+	"nothing was found",
+	"couldn't find the page",
+	"could not find the page",
+	"seite wurde nicht gefunden",
+	"seite nicht gefunden",
+}
+
+// soft404Signals is the outcome of the soft 404 heuristics for one page
+type soft404Signals struct {
+	TitleMatch  bool
+	PhraseMatch bool
+	Thin        bool
+}
+
+// IsSoft404 requires at least two signals, one of which must be about the wording
+func (s soft404Signals) IsSoft404() bool {
+	return (s.TitleMatch && (s.PhraseMatch || s.Thin)) || (s.PhraseMatch && s.Thin)
+}
+
+// Reasons lists the signals that fired, for the finding details
+func (s soft404Signals) Reasons() []string {
+	var reasons []string
+	if s.TitleMatch {
+		reasons = append(reasons, "title looks like a not found page")
+	}
+	if s.PhraseMatch {
+		reasons = append(reasons, "content contains not found wording")
+	}
+	if s.Thin {
+		reasons = append(reasons, "thin content")
+	}
+	return reasons
+}
+
+// detectSoft404 evaluates the soft 404 heuristics on a parsed page
+// When the document is only the beginning of a page, thin content can't be judged
+func detectSoft404(doc *html.Node, truncated bool) soft404Signals {
+	title := findTitle(doc)
+	text := visibleText(doc)
+	lowerText := strings.ToLower(text)
+
+	signals := soft404Signals{
+		TitleMatch: soft404TitlePattern.MatchString(title),
+		Thin:       !truncated && wordCount(text) < soft404MaxWords,
+	}
+	for _, phrase := range soft404Phrases {
+		if strings.Contains(lowerText, phrase) {
+			signals.PhraseMatch = true
+			break
+		}
+	}
+
+	return signals
+}
+
+// detectSoft404Snippet evaluates the heuristics on the first bytes of a link's body
+func detectSoft404Snippet(body []byte) soft404Signals {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return soft404Signals{}
+	}
+	return detectSoft404(doc, true)
+}
+
+// soft404Finding builds the finding recorded for a page detected as soft 404
+func soft404Finding(pageURL string, reasons []string) models.Finding {
+	return models.Finding{
+		Type:     models.FindingSoft404,
+		Category: models.CategoryContent,
+		Severity: models.SeverityWarning,
+		URL:      pageURL,
+		Message:  "Page answers 200 OK but looks like a \"not found\" page",
+		Details: map[string]interface{}{
+			"reasons": reasons,
+		},
+	}
+}
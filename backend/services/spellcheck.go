@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+const (
+	maxReportedMisspellings = 20 // Misspelled words stored as findings per crawl
+	minSpellCheckWordLength = 3  // Shorter words are mostly abbreviations
+)
+
+// dictionaryLanguage accepts ISO 639-1 codes only, so a language never escapes the dictionary directory
+var dictionaryLanguage = regexp.MustCompile(`^[a-z]{2}$`)
+
+// SpellChecker looks up words in per-language word lists stored in a directory
+// Dictionaries are plain word lists or hunspell .dic files named after the language, e.g. en.dic or de.txt;
+// a .dic file is expanded with the prefix and suffix rules of the .aff file next to it, e.g. en.aff
+type SpellChecker struct {
+	dir          string
+	mu           sync.Mutex
+	dictionaries map[string]map[string]bool // nil entry: no dictionary for the language
+}
+
+// NewSpellChecker creates a spell checker reading dictionaries from dir; an empty dir disables it
+func NewSpellChecker(dir string) *SpellChecker {
+	return &SpellChecker{
+		dir:          dir,
+		dictionaries: make(map[string]map[string]bool),
+	}
+}
+
+// Misspelling is a word that was not found in the dictionary and how often it occurs
+type Misspelling struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Check returns the misspelled words of text, most frequent first
+// ok is false when there is no dictionary for the language
+func (s *SpellChecker) Check(text, language string) (misspellings []Misspelling, ok bool) {
+	dictionary := s.dictionary(language)
+	if dictionary == nil {
+		return nil, false
+	}
+
+	counts := make(map[string]int)
+	for _, word := range splitWords(text) {
+		if !spellCheckable(word) {
+			continue
+		}
+		lower := strings.ToLower(word)
+		if dictionary[word] || dictionary[lower] {
+			continue
+		}
+		counts[lower]++
+	}
+
+	for word, count := range counts {
+		misspellings = append(misspellings, Misspelling{Word: word, Count: count})
+	}
+	sort.Slice(misspellings, func(i, j int) bool {
+		if misspellings[i].Count != misspellings[j].Count {
+			return misspellings[i].Count > misspellings[j].Count
+		}
+		return misspellings[i].Word < misspellings[j].Word
+	})
+
+	return misspellings, true
+}
+
+// dictionary returns the word set for the language, loading it on first use
+func (s *SpellChecker) dictionary(language string) map[string]bool {
+	if s.dir == "" || !dictionaryLanguage.MatchString(language) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dictionary, loaded := s.dictionaries[language]; loaded {
+		return dictionary
+	}
+
+	dictionary, err := loadDictionary(s.dir, language)
+	if err != nil {
+		dictionary = nil
+	}
+	s.dictionaries[language] = dictionary
+	return dictionary
+}
+
+// loadDictionary reads the first dictionary file found for the language
+func loadDictionary(dir, language string) (map[string]bool, error) {
+	for _, name := range []string{language + ".dic", language + ".txt"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		// Without the affix file of a .dic file only the base forms of its words are known
+		var rules *affixes
+		if strings.HasSuffix(name, ".dic") {
+			rules, err = loadAffixes(filepath.Join(dir, language+".aff"))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+
+		dictionary := make(map[string]bool)
+		add := func(word string) { dictionary[word] = true }
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			// hunspell entries carry affix flags after a slash ("house/S") and may be followed by
+			// morphological fields; the first line is the entry count
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			word, flags, _ := strings.Cut(fields[0], "/")
+			if word == "" {
+				continue
+			}
+			if rules == nil || flags == "" {
+				add(word)
+				continue
+			}
+			rules.expand(word, flags, add)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read dictionary %s: %v", name, err)
+		}
+		return dictionary, nil
+	}
+	return nil, fmt.Errorf("no dictionary for language %q", language)
+}
+
+// spellCheckable skips words a dictionary cannot judge: short words, numbers, and acronyms
+func spellCheckable(word string) bool {
+	letters, upper := 0, 0
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return letters >= minSpellCheckWordLength && upper <= 1
+}
+
+// misspellingFindings turns the most frequent misspellings into findings
+func misspellingFindings(pageURL, language string, misspellings []Misspelling) []models.Finding {
+	if len(misspellings) > maxReportedMisspellings {
+		misspellings = misspellings[:maxReportedMisspellings]
+	}
+
+	findings := make([]models.Finding, 0, len(misspellings))
+	for _, misspelling := range misspellings {
+		findings = append(findings, models.Finding{
+			Type:     models.FindingMisspelling,
+			Category: models.CategoryContent,
+			Severity: models.SeverityInfo,
+			URL:      pageURL,
+			Message:  fmt.Sprintf("Possible misspelling %q (%d occurrences)", misspelling.Word, misspelling.Count),
+			Details: map[string]interface{}{
+				"word":     misspelling.Word,
+				"count":    misspelling.Count,
+				"language": language,
+			},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// affixRule is one PFX or SFX rule line of a hunspell .aff file: it removes strip from the word and adds add,
+// if the word matches condition
+type affixRule struct {
+	strip, add string
+	condition  *regexp.Regexp // nil matches every word
+}
+
+// affixClass holds the rules of one affix flag
+type affixClass struct {
+	prefix bool
+	cross  bool // Combines with affixes of the other kind, e.g. "un" + "do" + "ing"
+	rules  []affixRule
+}
+
+// affixes holds the affix classes of a hunspell .aff file by flag
+// Only what is needed to expand .dic entries into their word forms is read; compounding is not supported
+type affixes struct {
+	flagType string // FLAG of the file: empty for single characters, "long", "num", or "UTF-8"
+	classes  map[string]*affixClass
+}
+
+// loadAffixes reads the prefix and suffix rules of a hunspell .aff file, which must be UTF-8 encoded
+func loadAffixes(path string) (*affixes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	a := &affixes{classes: make(map[string]*affixClass)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "FLAG":
+			a.flagType = fields[1]
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				continue
+			}
+			class, defined := a.classes[fields[1]]
+			if !defined {
+				// The header line of the class: PFX flag cross_product count
+				a.classes[fields[1]] = &affixClass{prefix: fields[0] == "PFX", cross: fields[2] == "Y"}
+				continue
+			}
+			if rule, ok := parseAffixRule(fields, class.prefix); ok {
+				class.rules = append(class.rules, rule)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read affix file %s: %v", path, err)
+	}
+	return a, nil
+}
+
+// parseAffixRule parses the fields of a rule line: PFX flag strip add [condition [morphology...]]
+func parseAffixRule(fields []string, prefix bool) (affixRule, bool) {
+	rule := affixRule{strip: fields[2], add: strings.SplitN(fields[3], "/", 2)[0]} // Continuation flags are ignored
+	if rule.strip == "0" {
+		rule.strip = ""
+	}
+	if rule.add == "0" {
+		rule.add = ""
+	}
+	if len(fields) > 4 && fields[4] != "." {
+		pattern := "(?:" + fields[4] + ")$"
+		if prefix {
+			pattern = "^(?:" + fields[4] + ")"
+		}
+		condition, err := regexp.Compile(pattern)
+		if err != nil {
+			return affixRule{}, false
+		}
+		rule.condition = condition
+	}
+	return rule, true
+}
+
+// apply returns the word with the rule applied, or false when the rule doesn't apply to the word
+func (r affixRule) apply(word string, prefix bool) (string, bool) {
+	if r.condition != nil && !r.condition.MatchString(word) {
+		return "", false
+	}
+	if prefix {
+		if !strings.HasPrefix(word, r.strip) {
+			return "", false
+		}
+		return r.add + word[len(r.strip):], true
+	}
+	if !strings.HasSuffix(word, r.strip) {
+		return "", false
+	}
+	return word[:len(word)-len(r.strip)] + r.add, true
+}
+
+// splitFlags splits the affix flags of a .dic entry according to the FLAG type of the file
+func (a *affixes) splitFlags(flags string) []string {
+	var split []string
+	switch a.flagType {
+	case "num":
+		split = strings.Split(flags, ",")
+	case "long":
+		for len(flags) >= 2 {
+			_, first := utf8.DecodeRuneInString(flags)
+			_, second := utf8.DecodeRuneInString(flags[first:])
+			split = append(split, flags[:first+second])
+			flags = flags[first+second:]
+		}
+	default:
+		for _, flag := range flags {
+			split = append(split, string(flag))
+		}
+	}
+	return split
+}
+
+// expand calls add with every word form of a .dic entry: the word, the word with each of its affixes,
+// and the word with both a prefix and a suffix where both allow cross products
+func (a *affixes) expand(word, flags string, add func(string)) {
+	add(word)
+
+	var prefixes, suffixes []*affixClass
+	for _, flag := range a.splitFlags(flags) {
+		if class, ok := a.classes[flag]; ok {
+			if class.prefix {
+				prefixes = append(prefixes, class)
+			} else {
+				suffixes = append(suffixes, class)
+			}
+		}
+	}
+
+	var suffixed []string
+	for _, class := range suffixes {
+		for _, rule := range class.rules {
+			if form, ok := rule.apply(word, false); ok {
+				add(form)
+				if class.cross {
+					suffixed = append(suffixed, form)
+				}
+			}
+		}
+	}
+	for _, class := range prefixes {
+		for _, rule := range class.rules {
+			form, ok := rule.apply(word, true)
+			if !ok {
+				continue
+			}
+			add(form)
+			if !class.cross {
+				continue
+			}
+			// The condition holds for the word, and the suffixes only changed its end
+			for _, base := range suffixed {
+				if strings.HasPrefix(base, rule.strip) {
+					add(rule.add + base[len(rule.strip):])
+				}
+			}
+		}
+	}
+}
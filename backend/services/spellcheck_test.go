@@ -0,0 +1,44 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSpellCheckerAffixes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"en.dic": "6\nthe\nand\npage/S\ncrawl/DS\nlink/US\nquery/S\n",
+		"en.aff": `SET UTF-8
+
+PFX U Y 1
+PFX U 0 un .
+
+SFX S Y 3
+SFX S 0 s [^sxzhy]
+SFX S y ies [^aeiou]y
+SFX S 0 s [aeiou]y
+
+SFX D N 2
+SFX D 0 ed [^ey]
+SFX D 0 d e
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	// Inflected forms are expanded from the affix rules, other words are still reported
+	misspellings, ok := NewSpellChecker(dir).Check("Pages crawled the links, unlinks queries and crawlz unpages", "en")
+	if !ok {
+		t.Fatal("Check found no dictionary")
+	}
+	want := []Misspelling{{Word: "crawlz", Count: 1}, {Word: "unpages", Count: 1}}
+	if !reflect.DeepEqual(misspellings, want) {
+		t.Errorf("Check = %+v, want %+v", misspellings, want)
+	}
+}
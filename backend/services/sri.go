@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/net/html"
+)
+
+// maxSRIFindings caps the resources without integrity reported per page
+const maxSRIFindings = 50
+
+// auditSRI checks whether external scripts and stylesheets carry integrity attributes
+// Only third-party resources without integrity are flagged; first-party files are served by the site itself
+func auditSRI(doc *html.Node, pageURL string) (models.SRIAudit, []models.Finding) {
+	var audit models.SRIAudit
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return audit, nil
+	}
+	site := registrableDomain(base.Hostname())
+
+	var findings []models.Finding
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			kind, ref := "", ""
+			switch n.Data {
+			case "script":
+				kind, ref = "script", attrValue(n, "src")
+			case "link":
+				if containsString(strings.Fields(strings.ToLower(attrValue(n, "rel"))), "stylesheet") {
+					kind, ref = "style", attrValue(n, "href")
+				}
+			}
+
+			if ref != "" {
+				if resource, err := base.Parse(ref); err == nil && isHTTPURL(resource.String()) {
+					audit.ExternalResources++
+					if attrValue(n, "integrity") != "" {
+						audit.WithIntegrity++
+					} else if !isSameSite(resource.Hostname(), site) {
+						audit.ThirdPartyWithoutSRI++
+						audit.Missing = append(audit.Missing, models.SRIResource{Kind: kind, URL: resource.String()})
+						if len(findings) < maxSRIFindings {
+							findings = append(findings, sriFinding(pageURL, kind, resource.String()))
+						}
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	return audit, findings
+}
+
+// sriFinding reports a third-party resource loaded without integrity; scripts weigh more than styles
+func sriFinding(pageURL, kind, resource string) models.Finding {
+	severity := models.SeverityInfo
+	if kind == "script" {
+		severity = models.SeverityWarning
+	}
+	return models.Finding{
+		Type:     models.FindingSRIMissing,
+		Category: models.CategorySecurity,
+		Severity: severity,
+		URL:      pageURL,
+		Message:  fmt.Sprintf("Third-party %s %s is loaded without an integrity attribute", kind, resource),
+		Details: map[string]interface{}{
+			"kind":     kind,
+			"resource": resource,
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// technologyMarker identifies a technology by a fragment of the URLs of the page's scripts, styles, and frames
+type technologyMarker struct {
+	name   string
+	marker string
+}
+
+// resourceTechnologies are matched case-insensitively against resource URLs
+var resourceTechnologies = []technologyMarker{
+	{"WordPress", "/wp-content/"},
+	{"WordPress", "/wp-includes/"},
+	{"Shopify", "cdn.shopify.com"},
+	{"Wix", "static.wixstatic.com"},
+	{"Squarespace", "static1.squarespace.com"},
+	{"Webflow", "website-files.com"},
+	{"Drupal", "/sites/default/files/"},
+	{"Magento", "/static/version"},
+	{"Next.js", "/_next/"},
+	{"Nuxt", "/_nuxt/"},
+	{"Gatsby", "/page-data/"},
+	{"jQuery", "jquery"},
+	{"Bootstrap", "bootstrap"},
+	{"React", "react-dom"},
+	{"React", "react.production"},
+	{"Vue.js", "vue.js"},
+	{"Vue.js", "vue.min.js"},
+	{"Vue.js", "vue.global"},
+	{"AngularJS", "angular.js"},
+	{"AngularJS", "angular.min.js"},
+	{"Google Tag Manager", "googletagmanager.com/gtm.js"},
+	{"Google Analytics", "google-analytics.com"},
+	{"Google Analytics", "googletagmanager.com/gtag/js"},
+	{"Facebook Pixel", "connect.facebook.net"},
+	{"Hotjar", "static.hotjar.com"},
+	{"HubSpot", "js.hs-scripts.com"},
+	{"Intercom", "widget.intercom.io"},
+	{"Stripe", "js.stripe.com"},
+	{"Cloudflare", "cdnjs.cloudflare.com"},
+	{"reCAPTCHA", "google.com/recaptcha"},
+	{"Cookiebot", "consent.cookiebot.com"},
+	{"OneTrust", "cdn.cookielaw.org"},
+}
+
+// headerTechnologies are detected by the presence of a response header
+var headerTechnologies = map[string]string{
+	"Cf-Ray":              "Cloudflare",
+	"X-Amz-Cf-Id":         "Amazon CloudFront",
+	"X-Vercel-Id":         "Vercel",
+	"X-Nf-Request-Id":     "Netlify",
+	"X-Shopify-Stage":     "Shopify",
+	"X-Fastly-Request-Id": "Fastly",
+	"X-Drupal-Cache":      "Drupal",
+}
+
+// serverTechnologies are matched case-insensitively against the Server and X-Powered-By headers
+var serverTechnologies = []technologyMarker{
+	{"nginx", "nginx"},
+	{"Apache", "apache"},
+	{"Microsoft IIS", "microsoft-iis"},
+	{"LiteSpeed", "litespeed"},
+	{"Caddy", "caddy"},
+	{"PHP", "php"},
+	{"ASP.NET", "asp.net"},
+	{"Express", "express"},
+	{"Next.js", "next.js"},
+}
+
+// detectTechnologies names the software the page is built and served with, from its generator meta tag,
+// the URLs of its resources, a few framework markers, and its response headers
+// Like any fingerprinting it is a heuristic: bundled or self-hosted libraries under other names go unnoticed
+func detectTechnologies(doc *html.Node, header http.Header) []string {
+	found := make(map[string]bool)
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data == "meta" && strings.EqualFold(attrValue(n, "name"), "generator") {
+				if name := generatorName(attrValue(n, "content")); name != "" {
+					found[name] = true
+				}
+			}
+			if attribute, ok := resourceAttributes[n.Data]; ok {
+				if value := strings.ToLower(attrValue(n, attribute)); value != "" {
+					for _, technology := range resourceTechnologies {
+						if strings.Contains(value, technology.marker) {
+							found[technology.name] = true
+						}
+					}
+				}
+			}
+			switch {
+			case attrValue(n, "id") == "__NEXT_DATA__":
+				found["Next.js"] = true
+			case attrValue(n, "id") == "___gatsby":
+				found["Gatsby"] = true
+			case attrValue(n, "ng-version") != "":
+				found["Angular"] = true
+			case hasAttr(n, "data-reactroot"):
+				found["React"] = true
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	for name, technology := range headerTechnologies {
+		if header.Get(name) != "" {
+			found[technology] = true
+		}
+	}
+	served := strings.ToLower(header.Get("Server") + " " + header.Get("X-Powered-By"))
+	for _, technology := range serverTechnologies {
+		if strings.Contains(served, technology.marker) {
+			found[technology.name] = true
+		}
+	}
+
+	technologies := make([]string, 0, len(found))
+	for name := range found {
+		technologies = append(technologies, name)
+	}
+	sort.Strings(technologies)
+	return technologies
+}
+
+// generatorName returns the product of a generator meta tag without its version, e.g. WordPress for "WordPress 6.4.2"
+func generatorName(content string) string {
+	var name []string
+	for _, field := range strings.Fields(content) {
+		if isVersion(field) {
+			break
+		}
+		name = append(name, field)
+		if len(name) == 3 {
+			break
+		}
+	}
+	return strings.Trim(strings.Join(name, " "), " -;,")
+}
+
+// isVersion reports whether a word is a version number such as 6.4.2 or v2
+func isVersion(word string) bool {
+	word = strings.TrimPrefix(strings.ToLower(word), "v")
+	return word != "" && word[0] >= '0' && word[0] <= '9'
+}
+
+// hasAttr reports whether an element carries an attribute, even without a value
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
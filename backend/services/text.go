@@ -0,0 +1,43 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// nonVisibleElements hold text that is never rendered as page content
+var nonVisibleElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"head":     true,
+}
+
+// visibleText returns the whitespace-normalized text a visitor would see on the page
+func visibleText(doc *html.Node) string {
+	var builder strings.Builder
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && nonVisibleElements[n.Data] {
+			return
+		}
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			builder.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// wordCount returns the number of whitespace-separated words in text
+func wordCount(text string) int {
+	return len(strings.Fields(text))
+}
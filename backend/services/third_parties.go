@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"golang.org/x/net/html"
+)
+
+const maxPartyTrendMonths = 60
+
+// measurePartySplit counts the links and resources of a page that stay on its registrable domain and those
+// that leave it; unlike the internal/external split of links, the link scope of the URL doesn't matter
+// result.Links must hold the page's extracted links, and result.Weight its third-party domains
+func measurePartySplit(result *models.CrawlResult, doc *html.Node, pageURL string) {
+	result.FirstPartyLinks, result.ThirdPartyLinks = 0, 0
+	result.FirstPartyResources, result.ThirdPartyResources = 0, 0
+	result.ThirdPartyDomainCount = len(result.Weight.ThirdPartyDomains)
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+	site := registrableDomain(base.Hostname())
+
+	for _, link := range result.Links {
+		if target, err := url.Parse(link.URL); err == nil && isHTTPURL(link.URL) {
+			if registrableDomain(target.Hostname()) == site {
+				result.FirstPartyLinks++
+			} else {
+				result.ThirdPartyLinks++
+			}
+		}
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if attribute, ok := resourceAttributes[n.Data]; ok {
+				if value := attrValue(n, attribute); value != "" {
+					if resource, err := base.Parse(value); err == nil && isHTTPURL(resource.String()) {
+						if registrableDomain(resource.Hostname()) == site {
+							result.FirstPartyResources++
+						} else {
+							result.ThirdPartyResources++
+						}
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(doc)
+}
+
+// ValidatePartyTrendMonths checks the months a third-party trend covers
+func ValidatePartyTrendMonths(months int) error {
+	if months < 1 || months > maxPartyTrendMonths {
+		return fmt.Errorf("months must be between 1 and %d", maxPartyTrendMonths)
+	}
+	return nil
+}
+
+// PartyTrend is the first-party/third-party split of a project's pages month by month
+type PartyTrend struct {
+	ProjectID uint         `json:"project_id"`
+	URLID     uint         `json:"url_id,omitempty"` // Set when the trend covers a single URL
+	Since     time.Time    `json:"since"`
+	Outdated  int          `json:"outdated"` // Crawls from before the split was counted, not included below
+	Months    []PartyMonth `json:"months"`   // Oldest first; months without crawls are left out
+}
+
+// PartyMonth sums the latest crawl in the month of every URL crawled that month
+type PartyMonth struct {
+	Month               string  `json:"month"` // 2026-01, in UTC
+	Pages               int     `json:"pages"`
+	FirstPartyLinks     int     `json:"first_party_links"`
+	ThirdPartyLinks     int     `json:"third_party_links"`
+	FirstPartyResources int     `json:"first_party_resources"`
+	ThirdPartyResources int     `json:"third_party_resources"`
+	ThirdPartyDomains   float64 `json:"third_party_domains"`    // Average per page
+	ThirdPartyLinkShare float64 `json:"third_party_link_share"` // 0-1
+	ThirdPartyShare     float64 `json:"third_party_share"`      // Share of the resources, 0-1
+}
+
+// BuildPartyTrend groups the crawls of a project into months; splits must be ordered by crawl time
+func BuildPartyTrend(projectID, urlID uint, since time.Time, splits []repository.PartySplit) PartyTrend {
+	trend := PartyTrend{ProjectID: projectID, URLID: urlID, Since: since, Months: []PartyMonth{}}
+
+	// The latest crawl of each URL in each month, so URLs crawled daily don't outweigh monthly ones
+	latest := make(map[string]map[uint]repository.PartySplit)
+	for _, split := range splits {
+		if !split.Counted {
+			trend.Outdated++
+			continue
+		}
+		month := split.CrawledAt.UTC().Format("2006-01")
+		if latest[month] == nil {
+			latest[month] = make(map[uint]repository.PartySplit)
+		}
+		latest[month][split.URLID] = split
+	}
+
+	months := make([]string, 0, len(latest))
+	for month := range latest {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	for _, month := range months {
+		summary := PartyMonth{Month: month, Pages: len(latest[month])}
+		domains := 0
+		for _, split := range latest[month] {
+			summary.FirstPartyLinks += split.FirstPartyLinks
+			summary.ThirdPartyLinks += split.ThirdPartyLinks
+			summary.FirstPartyResources += split.FirstPartyResources
+			summary.ThirdPartyResources += split.ThirdPartyResources
+			domains += split.ThirdPartyDomainCount
+		}
+		summary.ThirdPartyDomains = round2(float64(domains) / float64(summary.Pages))
+		if links := summary.FirstPartyLinks + summary.ThirdPartyLinks; links > 0 {
+			summary.ThirdPartyLinkShare = round2(float64(summary.ThirdPartyLinks) / float64(links))
+		}
+		if resources := summary.FirstPartyResources + summary.ThirdPartyResources; resources > 0 {
+			summary.ThirdPartyShare = round2(float64(summary.ThirdPartyResources) / float64(resources))
+		}
+		trend.Months = append(trend.Months, summary)
+	}
+	return trend
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportConfig tunes the HTTP transport shared by every request the crawler makes
+type TransportConfig struct {
+	MaxIdleConns        int // Idle connections kept open across all hosts
+	MaxIdleConnsPerHost int // Idle connections kept open per host, Go's default of 2 is far below the link check concurrency
+	IdleConnTimeout     time.Duration
+	KeepAlives          bool          // false opens a new connection for every request
+	DNSCacheTTL         time.Duration // 0 resolves every dial
+	DNSServers          []string      // host[:port] of DNS servers to query instead of the system resolver
+	DoHURL              string        // DNS-over-HTTPS endpoint, takes precedence over DNSServers
+}
+
+// TransportStats reports the transport settings and how well the crawler reuses connections and DNS answers
+type TransportStats struct {
+	MaxIdleConns           int     `json:"max_idle_conns"`
+	MaxIdleConnsPerHost    int     `json:"max_idle_conns_per_host"`
+	IdleConnTimeoutSeconds int     `json:"idle_conn_timeout_seconds"`
+	KeepAlives             bool    `json:"keep_alives"`
+	DNSCacheSeconds        int     `json:"dns_cache_seconds"`
+	DNSResolver            string  `json:"dns_resolver"` // system, servers <list>, or doh <url>
+	Requests               int64   `json:"requests"`
+	NewConnections         int64   `json:"new_connections"`
+	ReusedConnections      int64   `json:"reused_connections"`
+	IdleReused             int64   `json:"idle_reused"` // Reused connections that had been idle in the pool
+	ReuseRate              float64 `json:"reuse_rate"`
+	DNSCacheHits           int64   `json:"dns_cache_hits"`
+	DNSCacheMisses         int64   `json:"dns_cache_misses"`
+	DNSErrors              int64   `json:"dns_errors"`
+	DNSNotFound            int64   `json:"dns_not_found"`         // Lookups answered with "no such host"
+	DNSResolverFailures    int64   `json:"dns_resolver_failures"` // Lookups the resolver failed to answer: timeouts, unreachable or misbehaving servers
+}
+
+// HTTPTransport is the instrumented transport of the crawler
+// It pools connections per host, caches DNS answers, and counts how often connections are reused
+type HTTPTransport struct {
+	config    TransportConfig
+	transport *http.Transport
+	dns       *dnsCache
+	families  *familyTracker
+
+	requests, newConns, reusedConns, idleReused atomic.Int64
+}
+
+// NewHTTPTransport creates the crawler transport with the given tuning
+func NewHTTPTransport(config TransportConfig) *HTTPTransport {
+	t := &HTTPTransport{
+		config:   config,
+		dns:      newDNSCache(config),
+		families: newFamilyTracker(),
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t.transport = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           t.dialContext(dialer),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		DisableKeepAlives:     !config.KeepAlives,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return t
+}
+
+// RoundTrip sends the request over the pooled transport, recording whether a connection was reused
+func (t *HTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if !info.Reused {
+				t.newConns.Add(1)
+				return
+			}
+			t.reusedConns.Add(1)
+			if info.WasIdle {
+				t.idleReused.Add(1)
+			}
+		},
+	}
+
+	return t.transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// Stats returns the connection reuse and DNS cache counters since startup
+func (t *HTTPTransport) Stats() TransportStats {
+	stats := TransportStats{
+		MaxIdleConns:           t.config.MaxIdleConns,
+		MaxIdleConnsPerHost:    t.config.MaxIdleConnsPerHost,
+		IdleConnTimeoutSeconds: int(t.config.IdleConnTimeout.Seconds()),
+		KeepAlives:             t.config.KeepAlives,
+		DNSCacheSeconds:        int(t.config.DNSCacheTTL.Seconds()),
+		DNSResolver:            t.dns.resolverName,
+		Requests:               t.requests.Load(),
+		NewConnections:         t.newConns.Load(),
+		ReusedConnections:      t.reusedConns.Load(),
+		IdleReused:             t.idleReused.Load(),
+		DNSCacheHits:           t.dns.hits.Load(),
+		DNSCacheMisses:         t.dns.misses.Load(),
+		DNSErrors:              t.dns.errors.Load(),
+		DNSNotFound:            t.dns.notFound.Load(),
+		DNSResolverFailures:    t.dns.errors.Load() - t.dns.notFound.Load(),
+	}
+	if total := stats.NewConnections + stats.ReusedConnections; total > 0 {
+		stats.ReuseRate = float64(stats.ReusedConnections) / float64(total)
+	}
+	return stats
+}
+
+// dnsCache remembers the addresses of recently dialed hosts, so thousands of link checks
+// against the same few hosts don't each wait for a lookup
+type dnsCache struct {
+	ttl          time.Duration
+	resolver     hostResolver
+	resolverName string
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+
+	hits, misses, errors, notFound atomic.Int64
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(config TransportConfig) *dnsCache {
+	resolver, name := newResolver(config)
+	return &dnsCache{
+		ttl:          config.DNSCacheTTL,
+		resolver:     resolver,
+		resolverName: name,
+		entries:      make(map[string]dnsEntry),
+	}
+}
+
+// lookup returns the addresses of host, from the cache while the entry is fresh
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	if d.ttl > 0 {
+		d.mu.Lock()
+		entry, ok := d.entries[host]
+		d.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			d.hits.Add(1)
+			return entry.addrs, nil
+		}
+	}
+
+	d.misses.Add(1)
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		// "No such host" is the domain's answer; anything else points at the resolver
+		d.errors.Add(1)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			d.notFound.Add(1)
+		}
+		return nil, err
+	}
+
+	if d.ttl > 0 {
+		d.mu.Lock()
+		d.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+		d.mu.Unlock()
+	}
+	return addrs, nil
+}
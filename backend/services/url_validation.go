@@ -2,8 +2,11 @@ package services
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
+
+	"golang.org/x/net/idna"
 )
 
 // URLValidationService handles URL validation and sanitization
@@ -14,13 +17,36 @@ func NewURLValidationService() *URLValidationService {
 	return &URLValidationService{}
 }
 
+// hostProfile converts hosts like idna.Lookup but without the STD3 rules, which would reject underscores
+// that internal hosts such as my_host.example.com commonly have
+var hostProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// NormalizedURL holds the two forms of a validated URL
+// ASCII is what gets crawled and stored as the unique key; Display is the Unicode form shown to users
+type NormalizedURL struct {
+	ASCII   string
+	Display string
+}
+
 // ValidateAndSanitizeURL validates and sanitizes a URL string with intelligent processing
+// It returns the ASCII form of the URL
 func (v *URLValidationService) ValidateAndSanitizeURL(rawURL string) (string, error) {
+	normalized, err := v.ValidateAndNormalizeURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return normalized.ASCII, nil
+}
+
+// ValidateAndNormalizeURL validates a URL and returns both its ASCII and display forms
+// Internationalized hosts are converted to punycode and non-ASCII paths and queries are percent-encoded,
+// so "münchen.de/straße" is stored as "https://www.xn--mnchen-3ya.de/stra%C3%9Fe"
+func (v *URLValidationService) ValidateAndNormalizeURL(rawURL string) (NormalizedURL, error) {
 	// Trim whitespace
 	rawURL = strings.TrimSpace(rawURL)
 
 	if rawURL == "" {
-		return "", fmt.Errorf("URL cannot be empty")
+		return NormalizedURL{}, fmt.Errorf("URL cannot be empty")
 	}
 
 	// Smart URL processing - handle various input formats
@@ -29,31 +55,166 @@ func (v *URLValidationService) ValidateAndSanitizeURL(rawURL string) (string, er
 	// Parse and validate URL
 	parsedURL, err := url.Parse(processedURL)
 	if err != nil {
-		return "", fmt.Errorf("invalid URL format: %v", err)
+		return NormalizedURL{}, fmt.Errorf("invalid URL format: %v", err)
 	}
 
 	// Check if host is provided
 	if parsedURL.Host == "" {
-		return "", fmt.Errorf("URL must include a valid host")
+		return NormalizedURL{}, fmt.Errorf("URL must include a valid host")
+	}
+
+	// Punycode the host and percent-encode what is left of the query; String() encodes the path
+	asciiHost, err := hostProfile.ToASCII(parsedURL.Hostname())
+	if err != nil {
+		return NormalizedURL{}, fmt.Errorf("invalid host %q: %v", parsedURL.Hostname(), err)
+	}
+	displayHost, err := hostProfile.ToUnicode(asciiHost)
+	if err != nil {
+		displayHost = asciiHost
+	}
+	parsedURL.Host = joinHostPort(asciiHost, parsedURL.Port())
+	parsedURL.RawQuery = encodeNonASCII(parsedURL.RawQuery)
+
+	// Return the cleaned URL in both forms
+	return NormalizedURL{
+		ASCII:   parsedURL.String(),
+		Display: displayURL(parsedURL, displayHost),
+	}, nil
+}
+
+// displayURL renders the URL with its Unicode host and decoded path and query
+func displayURL(parsedURL *url.URL, displayHost string) string {
+	display := parsedURL.Scheme + "://" + joinHostPort(displayHost, parsedURL.Port()) + parsedURL.Path
+	if parsedURL.RawQuery != "" {
+		query, err := url.PathUnescape(parsedURL.RawQuery)
+		if err != nil {
+			query = parsedURL.RawQuery
+		}
+		display += "?" + query
+	}
+	if parsedURL.Fragment != "" {
+		display += "#" + parsedURL.Fragment
 	}
+	return display
+}
 
-	// Return the cleaned URL
-	return parsedURL.String(), nil
+// asciiHost converts a host (with optional port) to its punycode form, leaving invalid hosts unchanged
+func asciiHost(host string) string {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+	ascii, err := hostProfile.ToASCII(hostname)
+	if err != nil {
+		return host
+	}
+	return joinHostPort(ascii, port)
+}
+
+// joinHostPort appends the port to the host when there is one
+func joinHostPort(host, port string) string {
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// encodeNonASCII percent-encodes the non-ASCII bytes of an already escaped URL component
+func encodeNonASCII(component string) string {
+	var builder strings.Builder
+	for i := 0; i < len(component); i++ {
+		if b := component[i]; b >= 0x80 || b == ' ' {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		} else {
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
 }
 
 // processURL intelligently processes raw URL input to create a valid URL
+// An explicit http:// is kept, so the crawl analyzes what was submitted and reports missing HTTPS as findings
 func (v *URLValidationService) processURL(rawURL string) string {
 	// Convert to lowercase for processing
 	processedURL := strings.ToLower(rawURL)
 
 	// Remove any existing protocol and www prefix to start clean
+	scheme := "https://"
+	if strings.HasPrefix(processedURL, "http://") {
+		scheme = "http://"
+	}
 	processedURL = strings.TrimPrefix(processedURL, "https://")
 	processedURL = strings.TrimPrefix(processedURL, "http://")
 	processedURL = strings.TrimPrefix(processedURL, "www.")
 
-	// Add https:// and www. for better compatibility
+	// Add https:// when no scheme was given, and www. for better compatibility
 	// Most modern websites support HTTPS and www redirects
-	return "https://www." + processedURL
+	return scheme + "www." + processedURL
+}
+
+// URLWarning describes a change validation makes to a URL as it was entered
+type URLWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Codes of URL warnings
+const (
+	URLWarningWhitespaceTrimmed = "whitespace_trimmed"
+	URLWarningSchemeAdded       = "scheme_added"
+	URLWarningWWWAdded          = "www_added"
+	URLWarningLowercased        = "lowercased"
+	URLWarningPunycode          = "punycode"
+	URLWarningPercentEncoded    = "percent_encoded"
+)
+
+// NormalizationWarnings lists the ways ValidateAndNormalizeURL rewrites rawURL, in the order they are applied
+// It doesn't validate the URL, so callers check it with ValidateAndNormalizeURL first
+func (v *URLValidationService) NormalizationWarnings(rawURL string) []URLWarning {
+	warnings := []URLWarning{}
+	trimmed := strings.TrimSpace(rawURL)
+	if trimmed != rawURL {
+		warnings = append(warnings, URLWarning{URLWarningWhitespaceTrimmed, "Leading and trailing whitespace will be removed"})
+	}
+
+	lower := strings.ToLower(trimmed)
+	rest := lower
+	switch {
+	case strings.HasPrefix(lower, "https://"):
+		rest = strings.TrimPrefix(lower, "https://")
+	case strings.HasPrefix(lower, "http://"):
+		rest = strings.TrimPrefix(lower, "http://")
+	default:
+		warnings = append(warnings, URLWarning{URLWarningSchemeAdded, "https:// will be added"})
+	}
+	if !strings.HasPrefix(rest, "www.") {
+		warnings = append(warnings, URLWarning{URLWarningWWWAdded, "www. will be added to the host"})
+	}
+	if lower != trimmed {
+		warnings = append(warnings, URLWarning{URLWarningLowercased, "The URL will be lowercased, including its path and query"})
+	}
+
+	host, remainder := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		host, remainder = rest[:i], rest[i:]
+	}
+	if hasNonASCII(host) {
+		warnings = append(warnings, URLWarning{URLWarningPunycode, "The internationalized host will be stored in punycode"})
+	}
+	if hasNonASCII(remainder) || strings.Contains(remainder, " ") {
+		warnings = append(warnings, URLWarning{URLWarningPercentEncoded, "Spaces and non-ASCII characters of the path and query will be percent-encoded"})
+	}
+	return warnings
+}
+
+// hasNonASCII reports whether s contains a byte outside of ASCII
+func hasNonASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return true
+		}
+	}
+	return false
 }
 
 // IsValidHTTPURL checks if the URL is a valid HTTP/HTTPS URL
@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestValidateAndNormalizeURL(t *testing.T) {
+	validator := NewURLValidationService()
+	tests := []struct {
+		raw, ascii, display string
+	}{
+		{"münchen.de/straße", "https://www.xn--mnchen-3ya.de/stra%C3%9Fe", "https://www.münchen.de/straße"},
+		{"http://example.com", "http://www.example.com", "http://www.example.com"},
+		// Underscores aren't valid in host names, but internal hosts have them
+		{"https://my_host.example.com/page", "https://www.my_host.example.com/page", "https://www.my_host.example.com/page"},
+	}
+	for _, test := range tests {
+		normalized, err := validator.ValidateAndNormalizeURL(test.raw)
+		if err != nil {
+			t.Errorf("ValidateAndNormalizeURL(%q): %v", test.raw, err)
+			continue
+		}
+		if normalized.ASCII != test.ascii || normalized.Display != test.display {
+			t.Errorf("ValidateAndNormalizeURL(%q) = %q, %q, want %q, %q", test.raw, normalized.ASCII, normalized.Display, test.ascii, test.display)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+const maxUsageMonths = 60
+
+// ValidateUsageMonths checks the months a usage report covers
+func ValidateUsageMonths(months int) error {
+	if months < 1 || months > maxUsageMonths {
+		return fmt.Errorf("months must be between 1 and %d", maxUsageMonths)
+	}
+	return nil
+}
+
+// UsageReport is what the crawls cost month by month, per project and per user
+type UsageReport struct {
+	Since      time.Time    `json:"since"`
+	Unmeasured int          `json:"unmeasured"` // Crawls stored before their cost was recorded, not included below
+	Months     []UsageMonth `json:"months"`     // Oldest first; months without crawls are left out
+}
+
+// UsageTotals sums the cost of a set of crawls
+type UsageTotals struct {
+	Crawls          int   `json:"crawls"`
+	Requests        int   `json:"requests"`
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+	DurationMS      int64 `json:"duration_ms"`
+}
+
+// UsageMonth is the cost of the crawls of a month, in total and split by project and by user
+type UsageMonth struct {
+	Month string `json:"month"` // 2026-01, in UTC
+	UsageTotals
+	Projects []ProjectUsage `json:"projects"` // Most requests first
+	Users    []UserUsage    `json:"users"`    // Most requests first
+}
+
+// ProjectUsage is the cost of a project's crawls; ProjectID is nil for the URLs outside projects
+type ProjectUsage struct {
+	ProjectID *uint `json:"project_id"`
+	UsageTotals
+}
+
+// UserUsage is the cost of the crawls of the URLs a user added; User is empty for URLs added before it was recorded
+type UserUsage struct {
+	User string `json:"user"`
+	UsageTotals
+}
+
+// add counts a group of crawls in the totals
+func (t *UsageTotals) add(usage repository.CrawlUsage) {
+	t.Crawls += usage.Crawls
+	t.Requests += usage.Requests
+	t.BytesDownloaded += usage.BytesDownloaded
+	t.DurationMS += usage.DurationMS
+}
+
+// BuildUsageReport splits the cost of the crawls since the given time, summed by month, project, and user,
+// into months
+func BuildUsageReport(since time.Time, crawls []repository.CrawlUsage) UsageReport {
+	report := UsageReport{Since: since, Months: []UsageMonth{}}
+
+	months := make(map[string]*UsageMonth)
+	projects := make(map[string]map[uint]*ProjectUsage) // 0 is the URLs outside projects
+	users := make(map[string]map[string]*UserUsage)
+	for _, usage := range crawls {
+		report.Unmeasured += usage.Unmeasured
+		if usage.Crawls == 0 {
+			continue
+		}
+		key := usage.Month
+		month, ok := months[key]
+		if !ok {
+			month = &UsageMonth{Month: key}
+			months[key] = month
+			projects[key] = make(map[uint]*ProjectUsage)
+			users[key] = make(map[string]*UserUsage)
+		}
+		month.add(usage)
+
+		projectID := uint(0)
+		if usage.ProjectID != nil {
+			projectID = *usage.ProjectID
+		}
+		project, ok := projects[key][projectID]
+		if !ok {
+			project = &ProjectUsage{ProjectID: usage.ProjectID}
+			projects[key][projectID] = project
+		}
+		project.add(usage)
+
+		user, ok := users[key][usage.CreatedBy]
+		if !ok {
+			user = &UserUsage{User: usage.CreatedBy}
+			users[key][usage.CreatedBy] = user
+		}
+		user.add(usage)
+	}
+
+	keys := make([]string, 0, len(months))
+	for key := range months {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		month := months[key]
+		month.Projects = make([]ProjectUsage, 0, len(projects[key]))
+		for _, project := range projects[key] {
+			month.Projects = append(month.Projects, *project)
+		}
+		sort.Slice(month.Projects, func(i, j int) bool {
+			a, b := month.Projects[i], month.Projects[j]
+			if a.Requests != b.Requests {
+				return a.Requests > b.Requests
+			}
+			return usageProjectID(a.ProjectID) < usageProjectID(b.ProjectID)
+		})
+		month.Users = make([]UserUsage, 0, len(users[key]))
+		for _, user := range users[key] {
+			month.Users = append(month.Users, *user)
+		}
+		sort.Slice(month.Users, func(i, j int) bool {
+			a, b := month.Users[i], month.Users[j]
+			if a.Requests != b.Requests {
+				return a.Requests > b.Requests
+			}
+			return a.User < b.User
+		})
+		report.Months = append(report.Months, *month)
+	}
+	return report
+}
+
+// usageProjectID orders the URLs outside projects first among projects with as many requests
+func usageProjectID(projectID *uint) uint {
+	if projectID == nil {
+		return 0
+	}
+	return *projectID
+}
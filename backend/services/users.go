@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// minPasswordLength is the shortest password accepted by ChangePassword
+const minPasswordLength = 8
+
+// ErrInvalidCredentials is returned for an unknown username or a wrong password
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// dummyPasswordHash is compared with the password of unknown usernames, so they take as long to reject
+// as wrong passwords and the response time doesn't tell which usernames exist
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+// PasswordPolicyError is returned by ChangePassword for a new password the password policy rejects
+type PasswordPolicyError struct {
+	Message string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Message
+}
+
+// UserService manages the accounts allowed to log in
+type UserService struct {
+	db *gorm.DB
+}
+
+// NewUserService creates a user service
+func NewUserService(db *gorm.DB) *UserService {
+	return &UserService{db: db}
+}
+
+// EnsureAdmin creates the admin account on first start
+// The configured password only seeds the account, so it has to be changed on first login
+func (s *UserService) EnsureAdmin(username, password string) error {
+	var count int64
+	if err := s.db.Model(&models.User{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count users: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	user := models.User{
+		Username:           username,
+		PasswordHash:       string(hash),
+		MustChangePassword: true,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		return fmt.Errorf("failed to create admin user: %v", err)
+	}
+	return nil
+}
+
+// Authenticate checks a username and password
+func (s *UserService) Authenticate(username, password string) (*models.User, error) {
+	var user models.User
+	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// ChangePassword replaces the user's password after verifying the current one
+// A new password the policy rejects is a *PasswordPolicyError
+func (s *UserService) ChangePassword(username, currentPassword, newPassword string) error {
+	if err := ValidatePassword(newPassword); err != nil {
+		return &PasswordPolicyError{Message: fmt.Sprintf("Invalid password: %v", err)}
+	}
+	if newPassword == currentPassword {
+		return &PasswordPolicyError{Message: "New password must differ from the current password"}
+	}
+
+	user, err := s.Authenticate(username, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	return s.db.Model(user).Updates(map[string]interface{}{
+		"password_hash":        string(hash),
+		"must_change_password": false,
+		"password_changed_at":  time.Now(),
+	}).Error
+}
+
+// ValidatePassword checks the strength requirements of a new password
+func ValidatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if len(password) > 72 {
+		// bcrypt ignores everything after 72 bytes
+		return fmt.Errorf("password must be at most 72 bytes")
+	}
+	return nil
+}
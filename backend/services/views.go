@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"gorm.io/gorm"
+)
+
+// URL statuses a view can filter by
+var viewStatuses = []string{"queued", "running", "completed", "error", "blocked"}
+
+// ViewService keeps the saved views of the URL list
+type ViewService struct {
+	db *gorm.DB
+}
+
+// NewViewService creates a view service
+func NewViewService(db *gorm.DB) *ViewService {
+	return &ViewService{db: db}
+}
+
+// ValidateView checks the name, filter, and sort of a view
+func ValidateView(view models.View) error {
+	if view.Name == "" || len(view.Name) > 255 {
+		return fmt.Errorf("name must be between 1 and 255 characters")
+	}
+	filter := view.Filter
+	if filter.Status != "" && !containsString(viewStatuses, filter.Status) {
+		return fmt.Errorf("status must be one of %s", strings.Join(viewStatuses, ", "))
+	}
+	if filter.BudgetStatus != "" && filter.BudgetStatus != models.BudgetPass && filter.BudgetStatus != models.BudgetFail {
+		return fmt.Errorf("budget_status must be %q or %q", models.BudgetPass, models.BudgetFail)
+	}
+	if filter.UpdatedWithinDays < 0 || filter.UpdatedWithinDays > 3650 {
+		return fmt.Errorf("updated_within_days must be between 0 and 3650")
+	}
+	if len(filter.Search) > 255 || len(filter.Tag) > 255 {
+		return fmt.Errorf("search and tag must be at most 255 characters")
+	}
+	if view.Sort != "" && !containsString(models.ViewSortFields, strings.TrimPrefix(view.Sort, "-")) {
+		return fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(models.ViewSortFields, ", "))
+	}
+	return nil
+}
+
+// List returns every view, ordered by name
+func (s *ViewService) List() ([]models.View, error) {
+	var views []models.View
+	if err := s.db.Order("name").Find(&views).Error; err != nil {
+		return nil, fmt.Errorf("failed to list views: %v", err)
+	}
+	return views, nil
+}
+
+// Get returns a view
+func (s *ViewService) Get(id uint) (*models.View, error) {
+	var view models.View
+	if err := s.db.First(&view, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to load view %d: %v", id, err)
+	}
+	return &view, nil
+}
+
+// FindByName returns the view with the name, ignoring the view with excludeID
+func (s *ViewService) FindByName(name string, excludeID uint) (*models.View, error) {
+	var view models.View
+	if err := s.db.Where("name = ? AND id <> ?", name, excludeID).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to look up view %q: %v", name, err)
+	}
+	return &view, nil
+}
+
+// Save creates or replaces a view; it must have been validated
+func (s *ViewService) Save(view *models.View) error {
+	if err := s.db.Save(view).Error; err != nil {
+		return fmt.Errorf("failed to save view: %v", err)
+	}
+	return nil
+}
+
+// Delete removes a view
+func (s *ViewService) Delete(id uint) error {
+	result := s.db.Delete(&models.View{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete view %d: %v", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+const maxWatchPagesListed = 100 // New and removed sitemap pages listed per URL; the counts cover all of them
+
+// WatchReport compares the latest crawl of every URL of a watch group with the crawl before it
+type WatchReport struct {
+	ProjectID   uint          `json:"project_id"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Pages       int           `json:"pages"`   // URLs with a crawl
+	Changed     int           `json:"changed"` // URLs whose title, sitemap pages, or technologies changed
+	URLs        []WatchChange `json:"urls"`    // Changed URLs first
+}
+
+// WatchChange is what changed on a competitor's URL between its last two crawls
+// Sitemaps and technologies are only compared when both crawls recorded them
+type WatchChange struct {
+	URLID             uint       `json:"url_id"`
+	URL               string     `json:"url"`
+	CrawledAt         time.Time  `json:"crawled_at"`
+	PreviousCrawledAt *time.Time `json:"previous_crawled_at"` // Nil for a URL crawled only once
+	Changed           bool       `json:"changed"`
+
+	Title         string `json:"title"`
+	PreviousTitle string `json:"previous_title,omitempty"`
+	TitleChanged  bool   `json:"title_changed"`
+
+	SitemapCompared   bool     `json:"sitemap_compared"`
+	SitemapPages      int      `json:"sitemap_pages"`
+	NewPages          []string `json:"new_pages"` // Listed in the sitemaps now but not before
+	NewPagesCount     int      `json:"new_pages_count"`
+	RemovedPages      []string `json:"removed_pages"`
+	RemovedPagesCount int      `json:"removed_pages_count"`
+
+	TechnologiesCompared bool     `json:"technologies_compared"`
+	Technologies         []string `json:"technologies"`
+	TechnologiesAdded    []string `json:"technologies_added"`
+	TechnologiesRemoved  []string `json:"technologies_removed"`
+}
+
+// BuildWatchReport compares the last two crawls of each of the urls of a watch group
+func BuildWatchReport(results repository.CrawlResultRepository, projectID uint, urls []models.URL) (WatchReport, error) {
+	report := WatchReport{ProjectID: projectID, GeneratedAt: time.Now().UTC(), URLs: []WatchChange{}}
+
+	ids := make([]uint, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	pairs, err := results.LatestPairs(ids)
+	if err != nil {
+		return report, fmt.Errorf("failed to load the latest crawls: %v", err)
+	}
+
+	var unchanged []WatchChange
+	for _, url := range urls {
+		pair, crawled := pairs[url.ID]
+		if !crawled {
+			continue
+		}
+		report.Pages++
+
+		change := compareWatchCrawls(url, pair.Latest, pair.Previous)
+		if change.Changed {
+			report.Changed++
+			report.URLs = append(report.URLs, change)
+		} else {
+			unchanged = append(unchanged, change)
+		}
+	}
+	report.URLs = append(report.URLs, unchanged...)
+	return report, nil
+}
+
+// compareWatchCrawls compares the latest crawl of a URL with the one before it; previous is nil for a first crawl
+func compareWatchCrawls(url models.URL, latest models.CrawlResult, previous *models.CrawlResult) WatchChange {
+	change := WatchChange{
+		URLID:               url.ID,
+		URL:                 url.URL,
+		CrawledAt:           latest.CrawledAt,
+		Title:               latest.Title,
+		NewPages:            []string{},
+		RemovedPages:        []string{},
+		Technologies:        latest.Technologies,
+		TechnologiesAdded:   []string{},
+		TechnologiesRemoved: []string{},
+	}
+	if latest.Sitemap != nil {
+		change.SitemapPages = len(latest.Sitemap.URLs)
+	}
+	if change.Technologies == nil {
+		change.Technologies = []string{}
+	}
+	if previous == nil {
+		return change
+	}
+	change.PreviousCrawledAt = &previous.CrawledAt
+
+	if latest.Title != previous.Title {
+		change.PreviousTitle = previous.Title
+		change.TitleChanged = true
+	}
+
+	// A sitemap that couldn't be read would report every page as removed
+	if latest.Sitemap != nil && previous.Sitemap != nil && latest.Sitemap.Error == "" && previous.Sitemap.Error == "" {
+		change.SitemapCompared = true
+		added, removed := diffStrings(previous.Sitemap.URLs, latest.Sitemap.URLs)
+		change.NewPagesCount, change.RemovedPagesCount = len(added), len(removed)
+		change.NewPages = firstStrings(added, maxWatchPagesListed)
+		change.RemovedPages = firstStrings(removed, maxWatchPagesListed)
+	}
+
+	// Technologies are nil for crawls from before they were detected, so only crawls that recorded them are compared
+	if latest.Technologies != nil && previous.Technologies != nil {
+		change.TechnologiesCompared = true
+		change.TechnologiesAdded, change.TechnologiesRemoved = diffStrings(previous.Technologies, latest.Technologies)
+	}
+
+	change.Changed = change.TitleChanged || change.NewPagesCount > 0 || change.RemovedPagesCount > 0 ||
+		len(change.TechnologiesAdded) > 0 || len(change.TechnologiesRemoved) > 0
+	return change
+}
+
+// diffStrings returns the values of after missing from before and those of before missing from after, in their order
+func diffStrings(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]bool, len(before))
+	for _, value := range before {
+		inBefore[value] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, value := range after {
+		inAfter[value] = true
+	}
+	added, removed = []string{}, []string{}
+	for _, value := range after {
+		if !inBefore[value] {
+			added = append(added, value)
+		}
+	}
+	for _, value := range before {
+		if !inAfter[value] {
+			removed = append(removed, value)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+)
+
+// wellKnownBodyLimit caps the bytes read from a well-known file
+const wellKnownBodyLimit = 64 * 1024
+
+// checkWellKnownFiles probes security.txt, robots.txt, and humans.txt on the page's origin
+func (c *CrawlerService) checkWellKnownFiles(ctx context.Context, pageURL string, settings models.Settings, tracker *throttleTracker) (models.WellKnownFiles, []models.Finding) {
+	var files models.WellKnownFiles
+
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return files, nil
+	}
+	origin := page.Scheme + "://" + page.Host
+	files.Host = page.Host
+
+	// RFC 9116 places the file under /.well-known/ and allows the root as a legacy location
+	var body []byte
+	for _, path := range []string{"/.well-known/security.txt", "/security.txt"} {
+		files.SecurityTxt.WellKnownProbe, body = c.fetchWellKnown(ctx, origin+path, settings, tracker)
+		if files.SecurityTxt.Present {
+			parseSecurityTxt(body, &files.SecurityTxt, path == "/security.txt")
+			break
+		}
+	}
+
+	files.RobotsTxt.WellKnownProbe, body = c.fetchWellKnown(ctx, origin+"/robots.txt", settings, tracker)
+	if files.RobotsTxt.Present {
+		parseRobotsTxt(body, &files.RobotsTxt)
+	}
+
+	files.HumansTxt, _ = c.fetchWellKnown(ctx, origin+"/humans.txt", settings, tracker)
+
+	var findings []models.Finding
+	switch {
+	case !files.SecurityTxt.Present:
+		findings = append(findings, securityTxtFinding(pageURL, models.SeverityInfo, "No security.txt published", nil))
+	case len(files.SecurityTxt.Problems) > 0:
+		findings = append(findings, securityTxtFinding(pageURL, models.SeverityWarning, "security.txt does not follow RFC 9116", files.SecurityTxt.Problems))
+	}
+
+	return files, findings
+}
+
+// fetchWellKnown downloads a plain text file
+// Sites that answer every path with their HTML page don't count as having the file
+func (c *CrawlerService) fetchWellKnown(ctx context.Context, fileURL string, settings models.Settings, tracker *throttleTracker) (models.WellKnownProbe, []byte) {
+	probe := models.WellKnownProbe{URL: fileURL}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(settings.LinkCheckTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	req, err := newCrawlRequest(ctx, http.MethodGet, fileURL, settings)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe, nil
+	}
+	req.Header.Set("Accept", "text/plain, */*;q=0.5")
+
+	resp, err := c.doWithBackoff(c.client, req, tracker)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe, nil
+	}
+	defer resp.Body.Close()
+
+	probe.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return probe, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/html" {
+		probe.Error = "served as text/html"
+		return probe, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, wellKnownBodyLimit))
+	if err != nil {
+		probe.Error = err.Error()
+		return probe, nil
+	}
+
+	probe.Present = true
+	probe.URL = resp.Request.URL.String()
+	return probe, body
+}
+
+// parseSecurityTxt reads the fields of a security.txt and records RFC 9116 violations
+func parseSecurityTxt(body []byte, txt *models.SecurityTxt, legacyLocation bool) {
+	txt.Signed = bytes.Contains(body, []byte("-----BEGIN PGP SIGNED MESSAGE-----"))
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "contact":
+			txt.Contact = append(txt.Contact, value)
+		case "expires":
+			if txt.Expires != "" {
+				txt.Problems = append(txt.Problems, "Expires must appear only once")
+			}
+			txt.Expires = value
+		case "encryption":
+			txt.Encryption = append(txt.Encryption, value)
+		case "policy":
+			txt.Policy = append(txt.Policy, value)
+		case "canonical":
+			txt.Canonical = append(txt.Canonical, value)
+		case "preferred-languages":
+			txt.PreferredLanguages = value
+		}
+	}
+
+	if len(txt.Contact) == 0 {
+		txt.Problems = append(txt.Problems, "Contact field is required")
+	}
+	if txt.Expires == "" {
+		txt.Problems = append(txt.Problems, "Expires field is required")
+	} else if expires, err := time.Parse(time.RFC3339, txt.Expires); err != nil {
+		txt.Problems = append(txt.Problems, "Expires must be an RFC 3339 date")
+	} else if expires.Before(time.Now()) {
+		txt.Expired = true
+		txt.Problems = append(txt.Problems, "security.txt has expired")
+	}
+	if legacyLocation {
+		txt.Problems = append(txt.Problems, "security.txt should be served from /.well-known/security.txt")
+	}
+}
+
+// parseRobotsTxt extracts the user agents, sitemaps, and crawl delay of a robots.txt
+func parseRobotsTxt(body []byte, robots *models.RobotsTxt) {
+	var group []string // User agents of the current group
+	groupHasRules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if groupHasRules {
+				group, groupHasRules = nil, false
+			}
+			group = append(group, value)
+			if !containsString(robots.UserAgents, value) {
+				robots.UserAgents = append(robots.UserAgents, value)
+			}
+		case "disallow":
+			groupHasRules = true
+			if value == "/" && containsString(group, "*") {
+				robots.DisallowAll = true
+			}
+		case "allow":
+			groupHasRules = true
+		case "crawl-delay":
+			groupHasRules = true
+			if containsString(group, "*") {
+				robots.CrawlDelay = value
+			}
+		case "sitemap":
+			robots.Sitemaps = append(robots.Sitemaps, value)
+		}
+	}
+}
+
+// securityTxtFinding reports a missing or invalid security.txt
+func securityTxtFinding(pageURL, severity, message string, problems []string) models.Finding {
+	finding := models.Finding{
+		Type:     models.FindingSecurityTxt,
+		Category: models.CategorySecurity,
+		Severity: severity,
+		URL:      pageURL,
+		Message:  message,
+	}
+	if len(problems) > 0 {
+		finding.Details = map[string]interface{}{
+			"problems": problems,
+		}
+	}
+	return finding
+}
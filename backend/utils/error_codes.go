@@ -0,0 +1,44 @@
+package utils
+
+// ErrorCode is a stable, machine-readable identifier for an API error
+// Clients should branch on these codes instead of parsing the English error messages
+type ErrorCode string
+
+// Error codes catalog
+const (
+	ErrCodeValidationFailed       ErrorCode = "VALIDATION_FAILED"        // Request body or parameters are invalid
+	ErrCodeInvalidID              ErrorCode = "INVALID_ID"               // Path ID is not a valid numeric ID
+	ErrCodeURLNotFound            ErrorCode = "URL_NOT_FOUND"            // URL does not exist
+	ErrCodeIdempotencyKeyReused   ErrorCode = "IDEMPOTENCY_KEY_REUSED"   // Idempotency-Key was already used for a different URL
+	ErrCodeURLUnreachable         ErrorCode = "URL_UNREACHABLE"          // URL failed the verify=true reachability check
+	ErrCodeURLAlreadyExists       ErrorCode = "URL_ALREADY_EXISTS"       // URL has already been added
+	ErrCodeCrawlInProgress        ErrorCode = "CRAWL_IN_PROGRESS"        // URL is already being crawled
+	ErrCodeCrawlResultNotFound    ErrorCode = "CRAWL_RESULT_NOT_FOUND"   // URL has not been crawled yet
+	ErrCodeProjectNotFound        ErrorCode = "PROJECT_NOT_FOUND"        // Project does not exist
+	ErrCodeProjectAlreadyExists   ErrorCode = "PROJECT_ALREADY_EXISTS"   // Project name is already taken
+	ErrCodeJobNotFound            ErrorCode = "JOB_NOT_FOUND"            // Batch job does not exist
+	ErrCodeHookNotFound           ErrorCode = "HOOK_NOT_FOUND"           // REST hook subscription does not exist
+	ErrCodeScheduleNotFound       ErrorCode = "SCHEDULE_NOT_FOUND"       // Project has no crawl schedule
+	ErrCodeViewNotFound           ErrorCode = "VIEW_NOT_FOUND"           // Saved view does not exist
+	ErrCodeViewAlreadyExists      ErrorCode = "VIEW_ALREADY_EXISTS"      // Saved view name is already taken
+	ErrCodeAnnotationNotFound     ErrorCode = "ANNOTATION_NOT_FOUND"     // Annotation does not exist
+	ErrCodeFindingNotFound        ErrorCode = "FINDING_NOT_FOUND"        // Latest crawl of the URL has no such finding
+	ErrCodeFetchAgentNotFound     ErrorCode = "FETCH_AGENT_NOT_FOUND"    // Fetch agent does not exist
+	ErrCodeQuotaExceeded          ErrorCode = "QUOTA_EXCEEDED"           // Plan or usage limit reached
+	ErrCodeAuthRequired           ErrorCode = "AUTH_REQUIRED"            // Authorization header is missing
+	ErrCodeInvalidAuthHeader      ErrorCode = "INVALID_AUTH_HEADER"      // Authorization header is malformed
+	ErrCodeInvalidToken           ErrorCode = "INVALID_TOKEN"            // Session token is invalid or expired
+	ErrCodeSessionNotFound        ErrorCode = "SESSION_NOT_FOUND"        // Session does not exist or has expired
+	ErrCodeTooManyAttempts        ErrorCode = "TOO_MANY_ATTEMPTS"        // Login is locked after repeated failures
+	ErrCodePasswordChangeRequired ErrorCode = "PASSWORD_CHANGE_REQUIRED" // Session may only change the password until it is changed
+	ErrCodeInvalidCSRFToken       ErrorCode = "INVALID_CSRF_TOKEN"       // Cookie session request lacks a valid X-CSRF-Token header
+	ErrCodeInvalidCredentials     ErrorCode = "INVALID_CREDENTIALS"      // Username or password is wrong
+	ErrCodeSchemaNotFound         ErrorCode = "SCHEMA_NOT_FOUND"         // No JSON Schema definition has this name
+	ErrCodeQueueFull              ErrorCode = "QUEUE_FULL"               // Every worker is busy and the crawl queue is full
+	ErrCodeMaintenance            ErrorCode = "MAINTENANCE_MODE"         // The API only serves reads during maintenance
+	ErrCodeCrawlingPaused         ErrorCode = "CRAWLING_PAUSED"          // Crawling is paused, pages can't be fetched synchronously
+	ErrCodeBackupInProgress       ErrorCode = "BACKUP_IN_PROGRESS"       // Another backup is still being written
+	ErrCodeBackupUploadFailed     ErrorCode = "BACKUP_UPLOAD_FAILED"     // Backup was written locally but not uploaded
+	ErrCodeCICheckFailed          ErrorCode = "CI_CHECK_FAILED"          // Page failed the pre-deploy check
+	ErrCodeInternalError          ErrorCode = "INTERNAL_ERROR"           // Unexpected server-side failure
+)
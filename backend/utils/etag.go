@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckETag sets a weak ETag derived from the given version parts and the request query
+// It returns true (after sending 304 Not Modified) when the client already has the current version
+func CheckETag(c *gin.Context, parts ...interface{}) bool {
+	hash := sha1.New()
+	fmt.Fprint(hash, c.Request.URL.RawQuery, "|", Language(c)) // Messages are localized
+	fmt.Fprint(hash, "|", c.GetString(JSONCaseContextKey))     // Field names follow the picked casing
+	for _, part := range parts {
+		fmt.Fprintf(hash, "|%v", part)
+	}
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// FieldSelection is the set of fields requested via the ?fields= query parameter
+// A nil selection means no parameter was given and all default fields are returned
+type FieldSelection map[string]bool
+
+// ParseFields reads a comma-separated ?fields=id,url,status parameter
+func ParseFields(c *gin.Context) FieldSelection {
+	param := strings.TrimSpace(c.Query("fields"))
+	if param == "" {
+		return nil
+	}
+
+	fields := FieldSelection{}
+	for _, field := range strings.Split(param, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields[field] = true
+		}
+	}
+	return fields
+}
+
+// Includes reports whether an opt-in heavy field such as links was requested
+func (f FieldSelection) Includes(field string) bool {
+	return f != nil && f[field]
+}
+
+// Apply removes all fields that were not requested; unknown field names are ignored
+func (f FieldSelection) Apply(data map[string]interface{}) map[string]interface{} {
+	if f == nil {
+		return data
+	}
+
+	selected := make(map[string]interface{}, len(f))
+	for field := range f {
+		if value, ok := data[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+// EnrichURLFields enriches a URL and trims it to the selected fields
+// The links of the latest crawl are only loaded when explicitly requested with fields=links
+// Without a selection the typed URLDetail is returned as is; timestamps are converted to loc
+func EnrichURLFields(results repository.CrawlResultRepository, url models.URL, fields FieldSelection, loc *time.Location) interface{} {
+	return SummaryFields(results, EnrichURL(results, url), fields, loc)
+}
+
+// SummaryFields trims an enriched URL to the selected fields like EnrichURLFields
+func SummaryFields(results repository.CrawlResultRepository, summary URLSummary, fields FieldSelection, loc *time.Location) interface{} {
+	detail := URLDetail{URLSummary: summary}
+	detail.In(loc)
+
+	if fields.Includes("links") {
+		if latest, err := results.Latest(summary.ID, repository.LoadOptions{Links: true}); err == nil {
+			detail.Links = latest.Links
+		}
+	}
+
+	if fields == nil {
+		return detail
+	}
+	return fields.Apply(fieldMap(detail))
+}
+
+// fieldMap converts a response struct to a map keyed by its JSON field names
+func fieldMap(value interface{}) map[string]interface{} {
+	data := map[string]interface{}{}
+	if encoded, err := json.Marshal(value); err == nil {
+		json.Unmarshal(encoded, &data)
+	}
+	return data
+}
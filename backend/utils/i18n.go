@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLanguage is the language the messages are written in, used when Accept-Language asks for no supported one
+const DefaultLanguage = "en"
+
+// messageCatalogs holds the translations of the API messages per language, keyed by the English message
+// Keys may contain fmt verbs: %d matches a number, %q a quoted string, %s and %v any text, which is
+// translated itself, so wrapped validation errors come out in one language
+var messageCatalogs = map[string]map[string]string{
+	"de": germanMessages,
+}
+
+// catalog is a compiled message catalog
+type catalog struct {
+	exact     map[string]string
+	templates []messageTemplate
+}
+
+type messageTemplate struct {
+	pattern     *regexp.Regexp
+	nested      []bool // Whether each captured argument is text to translate as well
+	translation string // Verbs replaced by %[n]s
+}
+
+var (
+	catalogs = compileCatalogs()
+
+	verbPattern = regexp.MustCompile(`%(\[\d+\])?[dqsv]`)
+)
+
+func compileCatalogs() map[string]*catalog {
+	compiled := make(map[string]*catalog, len(messageCatalogs))
+	for language, messages := range messageCatalogs {
+		cat := &catalog{exact: make(map[string]string)}
+		for message, translation := range messages {
+			if !verbPattern.MatchString(message) {
+				cat.exact[message] = translation
+				continue
+			}
+			cat.templates = append(cat.templates, compileTemplate(message, translation))
+		}
+		// Longer templates are more specific, so "Invalid request body: %v" wins over "%s is required"
+		sortTemplates(cat.templates)
+		compiled[language] = cat
+	}
+	return compiled
+}
+
+func compileTemplate(message, translation string) messageTemplate {
+	var pattern strings.Builder
+	var nested []bool
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range verbPattern.FindAllStringIndex(message, -1) {
+		pattern.WriteString(regexp.QuoteMeta(message[last:loc[0]]))
+		switch message[loc[1]-1] {
+		case 'd':
+			pattern.WriteString(`(-?\d+)`)
+			nested = append(nested, false)
+		case 'q':
+			pattern.WriteString(`(".*?")`)
+			nested = append(nested, false)
+		default:
+			pattern.WriteString(`(.+?)`)
+			nested = append(nested, true)
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(message[last:]) + "$")
+
+	// Translations may reorder the arguments with %[n]d; plain verbs take them in order
+	next := 0
+	translation = verbPattern.ReplaceAllStringFunc(translation, func(verb string) string {
+		if strings.HasPrefix(verb, "%[") {
+			return verb[:len(verb)-1] + "s"
+		}
+		next++
+		return "%[" + strconv.Itoa(next) + "]s"
+	})
+	return messageTemplate{pattern: regexp.MustCompile(pattern.String()), nested: nested, translation: translation}
+}
+
+func sortTemplates(templates []messageTemplate) {
+	sort.Slice(templates, func(i, j int) bool {
+		a, b := templates[i].pattern.String(), templates[j].pattern.String()
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+}
+
+// Translate returns message in the given language, or message itself when the catalog has no translation
+func Translate(language, message string) string {
+	cat, ok := catalogs[language]
+	if !ok || message == "" {
+		return message
+	}
+	return cat.translate(message)
+}
+
+func (cat *catalog) translate(message string) string {
+	if translation, ok := cat.exact[message]; ok {
+		return translation
+	}
+	// Several validation errors are joined with "; "
+	if parts := strings.Split(message, "; "); len(parts) > 1 {
+		for i, part := range parts {
+			parts[i] = cat.translate(part)
+		}
+		return strings.Join(parts, "; ")
+	}
+	for _, template := range cat.templates {
+		match := template.pattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		translation := template.translation
+		for i := len(match) - 1; i >= 1; i-- {
+			arg := match[i]
+			if template.nested[i-1] {
+				arg = cat.translate(arg)
+			}
+			translation = strings.ReplaceAll(translation, "%["+strconv.Itoa(i)+"]s", arg)
+		}
+		return translation
+	}
+	return message
+}
+
+// Language returns the supported language the client prefers most according to its Accept-Language header
+func Language(c *gin.Context) string {
+	best, bestQuality := DefaultLanguage, 0.0
+	for _, entry := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		// Regional variants such as de-AT use the catalog of their language
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if language != DefaultLanguage && catalogs[language] == nil {
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = language, quality
+		}
+	}
+	return best
+}
+
+// Localize translates a response message into the client's language and marks the response as varying by it
+func Localize(c *gin.Context, message string) string {
+	language := Language(c)
+	header := c.Writer.Header()
+	if !strings.Contains(header.Get("Vary"), "Accept-Language") {
+		header.Add("Vary", "Accept-Language")
+	}
+	header.Set("Content-Language", language)
+	return Translate(language, message)
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSONCaseHeader lets a client pick the field name casing of its responses, overriding JSON_FIELD_CASE
+const JSONCaseHeader = "X-JSON-Case"
+
+// JSONCaseContextKey holds the casing JSONCaseMiddleware picked for the request, see CheckETag
+const JSONCaseContextKey = "json_case"
+
+// Field name casings of JSON responses
+const (
+	JSONCaseSnake = "snake" // As declared in the json tags, e.g. url_id
+	JSONCaseCamel = "camel" // e.g. urlId
+)
+
+// JSONCase returns the casing the request asked for, or defaultCase when it asked for none or an unknown one
+func JSONCase(c *gin.Context, defaultCase string) string {
+	switch strings.ToLower(strings.TrimSpace(c.GetHeader(JSONCaseHeader))) {
+	case JSONCaseCamel:
+		return JSONCaseCamel
+	case JSONCaseSnake:
+		return JSONCaseSnake
+	}
+	return defaultCase
+}
+
+// CamelCaseJSON rewrites the object keys of a JSON document from snake_case to camelCase, keeping their order
+// Every key is rewritten, including those of free-form maps such as finding details; values stay as they are.
+func CamelCaseJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Numbers are copied as written instead of going through float64
+
+	// containers tracks the open objects and arrays; an object expects a key when keyNext is set
+	type container struct {
+		object, keyNext, empty bool
+	}
+	var containers []*container
+	var out bytes.Buffer
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var parent *container
+		if len(containers) > 0 {
+			parent = containers[len(containers)-1]
+		}
+		closing := token == json.Delim('}') || token == json.Delim(']')
+		if parent != nil && !closing {
+			switch {
+			case parent.object && !parent.keyNext:
+				out.WriteByte(':')
+			case !parent.empty:
+				out.WriteByte(',')
+			}
+			parent.empty = false
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			out.WriteByte(byte(value))
+			if !closing {
+				containers = append(containers, &container{object: value == '{', keyNext: true, empty: true})
+				continue
+			}
+			containers = containers[:len(containers)-1]
+			if len(containers) > 0 {
+				parent = containers[len(containers)-1]
+			} else {
+				parent = nil
+			}
+		case string:
+			if parent != nil && parent.object && parent.keyNext {
+				value = camelCase(value)
+			}
+			encoded, _ := json.Marshal(value)
+			out.Write(encoded)
+		default:
+			encoded, _ := json.Marshal(value)
+			out.Write(encoded)
+		}
+
+		// A key is followed by its value, a complete value by the next key
+		if parent != nil && parent.object {
+			parent.keyNext = !parent.keyNext
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// camelCase converts a snake_case name such as h1_count to h1Count; leading underscores are kept
+func camelCase(name string) string {
+	trimmed := strings.TrimLeft(name, "_")
+	if !strings.Contains(trimmed, "_") {
+		return name
+	}
+	parts := strings.Split(trimmed, "_")
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
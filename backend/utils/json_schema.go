@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated schemas
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaBuilder generates JSON Schema definitions from Go types using their json tags
+// Nested structs become separate definitions referenced with $ref, so every type is described once
+type SchemaBuilder struct {
+	defs map[string]interface{}
+}
+
+// NewSchemaBuilder creates an empty schema builder
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{defs: make(map[string]interface{})}
+}
+
+// Define adds a named definition generated from the type of value
+func (b *SchemaBuilder) Define(value interface{}) {
+	b.schemaFor(reflect.TypeOf(value))
+}
+
+// Definitions returns all definitions keyed by type name
+func (b *SchemaBuilder) Definitions() map[string]interface{} {
+	return b.defs
+}
+
+// Document returns a schema document holding all definitions under $defs
+func (b *SchemaBuilder) Document(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema": JSONSchemaDraft,
+		"$id":     id,
+		"$defs":   b.defs,
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of t, registering struct types as definitions
+func (b *SchemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return map[string]interface{}{"anyOf": []interface{}{b.schemaFor(t.Elem()), map[string]interface{}{"type": "null"}}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, defined := b.defs[name]; !defined {
+			b.defs[name] = true // Placeholder so recursive types terminate
+			b.defs[name] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema describes the JSON object encoding/json produces for the struct type t
+func (b *SchemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	b.addFields(t, properties, &required)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// addFields adds the exported fields of t, flattening embedded structs like encoding/json does
+func (b *SchemaBuilder) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.addFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = b.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
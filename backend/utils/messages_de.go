@@ -0,0 +1,262 @@
+package utils
+
+// germanMessages translates the API messages into German
+var germanMessages = map[string]string{
+	// Success messages
+	"Annotation deleted successfully":                "Anmerkung erfolgreich gelöscht",
+	"Annotation saved successfully":                  "Anmerkung erfolgreich gespeichert",
+	"Annotations retrieved successfully":             "Anmerkungen erfolgreich abgerufen",
+	"Backup %s written (%d rows)":                    "Sicherung %s geschrieben (%d Zeilen)",
+	"Facets retrieved successfully":                  "Facetten erfolgreich abgerufen",
+	"Fetch agent deleted successfully":               "Fetch-Agent erfolgreich gelöscht",
+	"Fetch agent registered successfully":            "Fetch-Agent erfolgreich registriert",
+	"Fetch agents retrieved successfully":            "Fetch-Agents erfolgreich abgerufen",
+	"Findings retrieved successfully":                "Befunde erfolgreich abgerufen",
+	"Meta description report retrieved successfully": "Meta-Description-Bericht erfolgreich abgerufen",
+	"Third-party trend retrieved successfully":       "Drittanbieter-Verlauf erfolgreich abgerufen",
+	"Usage retrieved successfully":                   "Nutzung erfolgreich abgerufen",
+	"Watch report retrieved successfully":            "Beobachtungsbericht erfolgreich abgerufen",
+	"Crawling paused":                                "Crawling pausiert",
+	"Crawling resumed":                               "Crawling fortgesetzt",
+	"Crawling status retrieved successfully":         "Crawling-Status erfolgreich abgerufen",
+	"Crawler identity retrieved successfully":        "Crawler-Identität erfolgreich abgerufen",
+	"Backups retrieved successfully":                 "Sicherungen erfolgreich abgerufen",
+	"Check passed":                                   "Prüfung bestanden",
+	"Deleted %d URL(s)":                              "%d URL(s) gelöscht",
+	"Encryption status retrieved successfully":       "Verschlüsselungsstatus erfolgreich abgerufen",
+	"HTML analyzed successfully":                     "HTML erfolgreich analysiert",
+	"Login successful":                               "Anmeldung erfolgreich",
+	"Authentication successful":                      "Authentifizierung erfolgreich",
+	"Logout successful":                              "Abmeldung erfolgreich",
+	"Maintenance mode disabled":                      "Wartungsmodus deaktiviert",
+	"Maintenance mode enabled":                       "Wartungsmodus aktiviert",
+	"Maintenance status retrieved successfully":      "Wartungsstatus erfolgreich abgerufen",
+	"Password changed":                               "Passwort geändert",
+	"Project created successfully":                   "Projekt erfolgreich erstellt",
+	"Project deleted successfully":                   "Projekt erfolgreich gelöscht",
+	"Project retrieved successfully":                 "Projekt erfolgreich abgerufen",
+	"Project updated successfully":                   "Projekt erfolgreich aktualisiert",
+	"Projects retrieved successfully":                "Projekte erfolgreich abgerufen",
+	"Re-encrypted %d project(s) and %d snapshot(s)":  "%d Projekt(e) und %d Snapshot(s) neu verschlüsselt",
+	"Schedule deleted successfully":                  "Zeitplan erfolgreich gelöscht",
+	"Schedule retrieved successfully":                "Zeitplan erfolgreich abgerufen",
+	"Schedule saved successfully":                    "Zeitplan erfolgreich gespeichert",
+	"Reprocessing %d URL(s)":                         "%d URL(s) werden neu verarbeitet",
+	"Restarted analysis for %d URL(s)":               "Analyse für %d URL(s) neu gestartet",
+	"Seeded %d demo URL(s)":                          "%d Demo-URL(s) angelegt",
+	"Plan retrieved successfully":                    "Tarif erfolgreich abgerufen",
+	"Session revoked":                                "Sitzung widerrufen",
+	"Settings retrieved successfully":                "Einstellungen erfolgreich abgerufen",
+	"Settings updated successfully":                  "Einstellungen erfolgreich aktualisiert",
+	"Started processing %d URL(s)":                   "Verarbeitung von %d URL(s) gestartet",
+	"Started processing URL":                         "Verarbeitung der URL gestartet",
+	"Stopped processing %d URL(s)":                   "Verarbeitung von %d URL(s) gestoppt",
+	"Stopped processing URL":                         "Verarbeitung der URL gestoppt",
+	"Subscribed successfully":                        "Erfolgreich abonniert",
+	"Transport statistics retrieved successfully":    "Transportstatistiken erfolgreich abgerufen",
+	"URL added successfully and crawling started":    "URL erfolgreich hinzugefügt, das Crawlen wurde gestartet",
+	"URL added successfully and queued for crawling": "URL erfolgreich hinzugefügt und zum Crawlen eingereiht",
+	"URL deleted successfully":                       "URL erfolgreich gelöscht",
+	"URL is valid":                                   "Die URL ist gültig",
+	"URL retrieved successfully":                     "URL erfolgreich abgerufen",
+	"URL updated successfully":                       "URL erfolgreich aktualisiert",
+	"URLs retrieved successfully":                    "URLs erfolgreich abgerufen",
+	"Unsubscribed successfully":                      "Abonnement erfolgreich beendet",
+	"View deleted successfully":                      "Ansicht erfolgreich gelöscht",
+	"View retrieved successfully":                    "Ansicht erfolgreich abgerufen",
+	"View saved successfully":                        "Ansicht erfolgreich gespeichert",
+	"Views retrieved successfully":                   "Ansichten erfolgreich abgerufen",
+	"Updated %d URL(s)":                              "%d URL(s) aktualisiert",
+	"Updated the tags of %d URL(s)":                  "Tags von %d URL(s) aktualisiert",
+	"Webhook processed":                              "Webhook verarbeitet",
+
+	// Request errors
+	"A backup is already in progress":                                 "Es läuft bereits eine Sicherung",
+	"A project with this name already exists":                         "Ein Projekt mit diesem Namen existiert bereits",
+	"A view with this name already exists":                            "Eine Ansicht mit diesem Namen existiert bereits",
+	"Annotation not found":                                            "Anmerkung nicht gefunden",
+	"Authorization header required":                                   "Authorization-Header erforderlich",
+	"Backup was written but could not be uploaded to object storage":  "Die Sicherung wurde geschrieben, konnte aber nicht in den Objektspeicher hochgeladen werden",
+	"Cannot rename a URL while it is being crawled":                   "Eine URL kann nicht umbenannt werden, während sie gecrawlt wird",
+	"Check failed: %s":                                                "Prüfung fehlgeschlagen: %s",
+	"Crawl queue is full, retry later":                                "Die Crawl-Warteschlange ist voll, bitte später erneut versuchen",
+	"Crawling is paused, retry later":                                 "Das Crawling ist pausiert, bitte später erneut versuchen",
+	"Current password is wrong":                                       "Das aktuelle Passwort ist falsch",
+	"Either finding_id or link_url is required":                       "Entweder finding_id oder link_url ist erforderlich",
+	"Fetch agent not found":                                           "Fetch-Agent nicht gefunden",
+	"Finding not found in the latest crawl":                           "Der Befund wurde im letzten Crawl nicht gefunden",
+	"Idempotency-Key must be at most 255 characters":                  "Idempotency-Key darf höchstens 255 Zeichen lang sein",
+	"Idempotency-Key was already used for a different URL":            "Idempotency-Key wurde bereits für eine andere URL verwendet",
+	"Idempotency-Key was used for a URL that has since been deleted":  "Idempotency-Key wurde für eine URL verwendet, die inzwischen gelöscht wurde",
+	"Invalid GitHub integration: %v":                                  "Ungültige GitHub-Integration: %v",
+	"Invalid URL ID":                                                  "Ungültige URL-ID",
+	"Invalid URL ID format":                                           "Ungültiges Format der URL-ID",
+	"Invalid view ID format":                                          "Ungültiges Format der Ansichts-ID",
+	"Invalid view: %v":                                                "Ungültige Ansicht: %v",
+	"Invalid URL: %v":                                                 "Ungültige URL: %v",
+	"Invalid webhook signature":                                       "Ungültige Webhook-Signatur",
+	"Invalid annotation ID":                                           "Ungültige Anmerkungs-ID",
+	"Invalid annotation: %v":                                          "Ungültige Anmerkung: %v",
+	"Invalid authorization header format":                             "Ungültiges Format des Authorization-Headers",
+	"Invalid budget: %v":                                              "Ungültiges Budget: %v",
+	"Invalid crawl config: %v":                                        "Ungültige Crawl-Konfiguration: %v",
+	"Invalid crawl schedule: %v":                                      "Ungültiger Crawl-Zeitplan: %v",
+	"Invalid custom rules: %v":                                        "Ungültige eigene Regeln: %v",
+	"Invalid credentials":                                             "Ungültige Anmeldedaten",
+	"Invalid fetch agent ID":                                          "Ungültige Fetch-Agent-ID",
+	"Invalid file upload: %v":                                         "Ungültiger Datei-Upload: %v",
+	"Invalid form data: %v":                                           "Ungültige Formulardaten: %v",
+	"Invalid heading rules: %v":                                       "Ungültige Überschriftenregeln: %v",
+	"Invalid link scope: %v":                                          "Ungültiger Link-Bereich: %v",
+	"Invalid issue tracker: %v":                                       "Ungültiger Issue-Tracker: %v",
+	"Invalid job ID format":                                           "Ungültiges Format der Job-ID",
+	"Invalid links_limit":                                             "Ungültiges links_limit",
+	"Invalid or expired token":                                        "Ungültiges oder abgelaufenes Token",
+	"Invalid password: %v":                                            "Ungültiges Passwort: %v",
+	"Invalid plugins: %v":                                             "Ungültige Plugins: %v",
+	"Invalid policy terms: %v":                                        "Ungültige Richtlinienbegriffe: %v",
+	"Invalid project ID format":                                       "Ungültiges Format der Projekt-ID",
+	"Invalid schedule: %v":                                            "Ungültiger Zeitplan: %v",
+	"Invalid request body":                                            "Ungültiger Request-Body",
+	"Invalid request body: %v":                                        "Ungültiger Request-Body: %v",
+	"Plan %s allows %d URLs":                                          "Der Tarif %s erlaubt %d URLs",
+	"Plan %s allows %d crawls per month":                              "Der Tarif %s erlaubt %d Crawls pro Monat",
+	"Invalid request format":                                          "Ungültiges Request-Format",
+	"Invalid session ID":                                              "Ungültige Sitzungs-ID",
+	"Invalid settings: %v":                                            "Ungültige Einstellungen: %v",
+	"Invalid subscription ID format":                                  "Ungültiges Format der Abonnement-ID",
+	"Invalid url_id":                                                  "Ungültige url_id",
+	"Job not found":                                                   "Job nicht gefunden",
+	"Missing or invalid CSRF token":                                   "Fehlendes oder ungültiges CSRF-Token",
+	"New password must differ from the current password":              "Das neue Passwort muss sich vom aktuellen unterscheiden",
+	"No URL IDs provided":                                             "Keine URL-IDs angegeben",
+	"No URL matches the filter":                                       "Keine URL entspricht dem Filter",
+	"View not found":                                                  "Ansicht nicht gefunden",
+	"Views don't support keyset paging":                               "Ansichten unterstützen kein Keyset-Paging",
+	"No changes provided":                                             "Keine Änderungen angegeben",
+	"No tags provided":                                                "Keine Tags angegeben",
+	"No crawl results for this URL yet":                               "Für diese URL gibt es noch keine Crawl-Ergebnisse",
+	"Password must be changed before continuing":                      "Das Passwort muss geändert werden, bevor es weitergeht",
+	"Project has no schedule":                                         "Das Projekt hat keinen Zeitplan",
+	"Project is not a watch group":                                    "Das Projekt ist keine Beobachtungsgruppe",
+	"Project name must be between 1 and 255 characters":               "Der Projektname muss zwischen 1 und 255 Zeichen lang sein",
+	"months must be between 1 and 60":                                 "months muss zwischen 1 und 60 liegen",
+	"Project not found":                                               "Projekt nicht gefunden",
+	"Schema not found":                                                "Schema nicht gefunden",
+	"Session not found":                                               "Sitzung nicht gefunden",
+	"Subscription not found":                                          "Abonnement nicht gefunden",
+	"The service is undergoing maintenance, please try again shortly": "Der Dienst wird gewartet, bitte in Kürze erneut versuchen",
+	"Too many failed login attempts, try again later":                 "Zu viele fehlgeschlagene Anmeldeversuche, bitte später erneut versuchen",
+	"URL already exists in the system":                                "Die URL existiert bereits im System",
+	"URL belongs to a deleted URL, add it again to restore it":        "Die URL gehört zu einer gelöschten URL, die durch erneutes Hinzufügen wiederhergestellt wird",
+	"URL is already being crawled":                                    "Die URL wird bereits gecrawlt",
+	"URL is not reachable: %v":                                        "Die URL ist nicht erreichbar: %v",
+	"URL not found":                                                   "URL nicht gefunden",
+	"Unknown event %q":                                                "Unbekanntes Ereignis %q",
+
+	// Server errors
+	"Failed to build the crawl bundle":     "Das Crawl-Archiv konnte nicht erstellt werden",
+	"Failed to build watch report":         "Beobachtungsbericht konnte nicht erstellt werden",
+	"Failed to change password":            "Passwort konnte nicht geändert werden",
+	"Failed to create project":             "Projekt konnte nicht erstellt werden",
+	"Failed to create session":             "Sitzung konnte nicht erstellt werden",
+	"Failed to create subscription":        "Abonnement konnte nicht erstellt werden",
+	"Failed to delete URL":                 "URL konnte nicht gelöscht werden",
+	"Failed to delete URLs":                "URLs konnten nicht gelöscht werden",
+	"Failed to delete annotation":          "Anmerkung konnte nicht gelöscht werden",
+	"Failed to delete view":                "Ansicht konnte nicht gelöscht werden",
+	"Failed to delete fetch agent":         "Fetch-Agent konnte nicht gelöscht werden",
+	"Failed to delete project":             "Projekt konnte nicht gelöscht werden",
+	"Failed to delete schedule":            "Zeitplan konnte nicht gelöscht werden",
+	"Failed to delete subscription":        "Abonnement konnte nicht gelöscht werden",
+	"Failed to list backups":               "Sicherungen konnten nicht aufgelistet werden",
+	"Failed to list sessions":              "Sitzungen konnten nicht aufgelistet werden",
+	"Failed to log in":                     "Anmeldung fehlgeschlagen",
+	"Failed to process webhook":            "Webhook konnte nicht verarbeitet werden",
+	"Failed to re-encrypt stored values":   "Gespeicherte Werte konnten nicht neu verschlüsselt werden",
+	"Failed to retrieve URL":               "URL konnte nicht abgerufen werden",
+	"Failed to retrieve URLs":              "URLs konnten nicht abgerufen werden",
+	"Failed to retrieve annotations":       "Anmerkungen konnten nicht abgerufen werden",
+	"Failed to retrieve plan":              "Tarif konnte nicht abgerufen werden",
+	"Failed to retrieve facets":            "Facetten konnten nicht abgerufen werden",
+	"Failed to retrieve view":              "Ansicht konnte nicht abgerufen werden",
+	"Failed to retrieve views":             "Ansichten konnten nicht abgerufen werden",
+	"Failed to retrieve crawl results":     "Crawl-Ergebnisse konnten nicht abgerufen werden",
+	"Failed to retrieve fetch agents":      "Fetch-Agents konnten nicht abgerufen werden",
+	"Failed to retrieve findings":          "Befunde konnten nicht abgerufen werden",
+	"Failed to retrieve meta descriptions": "Meta-Descriptions konnten nicht abgerufen werden",
+	"Failed to retrieve job":               "Job konnte nicht abgerufen werden",
+	"Failed to retrieve links":             "Links konnten nicht abgerufen werden",
+	"Failed to retrieve project":           "Projekt konnte nicht abgerufen werden",
+	"Failed to retrieve projects":          "Projekte konnten nicht abgerufen werden",
+	"Failed to retrieve schedule":          "Zeitplan konnte nicht abgerufen werden",
+	"Failed to retrieve subscriptions":     "Abonnements konnten nicht abgerufen werden",
+	"Failed to retrieve third-party trend": "Drittanbieter-Verlauf konnte nicht abgerufen werden",
+	"Failed to retrieve tracked issues":    "Verfolgte Issues konnten nicht abgerufen werden",
+	"Failed to retrieve usage":             "Nutzung konnte nicht abgerufen werden",
+	"Failed to register fetch agent":       "Fetch-Agent konnte nicht registriert werden",
+	"Failed to revoke session":             "Sitzung konnte nicht widerrufen werden",
+	"Failed to save URL":                   "URL konnte nicht gespeichert werden",
+	"Failed to save annotation":            "Anmerkung konnte nicht gespeichert werden",
+	"Failed to save schedule":              "Zeitplan konnte nicht gespeichert werden",
+	"Failed to seed demo data":             "Demodaten konnten nicht angelegt werden",
+	"Failed to start batch analysis":       "Stapelanalyse konnte nicht gestartet werden",
+	"Failed to start batch processing":     "Stapelverarbeitung konnte nicht gestartet werden",
+	"Failed to start reprocessing":         "Neuverarbeitung konnte nicht gestartet werden",
+	"Failed to tag URLs":                   "Tags der URLs konnten nicht aktualisiert werden",
+	"Failed to save view":                  "Ansicht konnte nicht gespeichert werden",
+	"Failed to update URL":                 "URL konnte nicht aktualisiert werden",
+	"Failed to update URL status":          "URL-Status konnte nicht aktualisiert werden",
+	"Failed to update URLs":                "URLs konnten nicht aktualisiert werden",
+	"Failed to update crawling status":     "Crawling-Status konnte nicht geändert werden",
+	"Failed to update maintenance mode":    "Wartungsmodus konnte nicht geändert werden",
+	"Failed to update project":             "Projekt konnte nicht aktualisiert werden",
+	"Failed to update settings":            "Einstellungen konnten nicht aktualisiert werden",
+	"Failed to write backup":               "Sicherung konnte nicht geschrieben werden",
+
+	// Validation errors, of BindingError and the services; field names stay as they are in the API
+	"%s has the wrong type":                                             "%s hat den falschen Typ",
+	"%s is invalid":                                                     "%s ist ungültig",
+	"%s is required":                                                    "%s ist erforderlich",
+	"%s are required":                                                   "%s sind erforderlich",
+	"%s must be at least %s":                                            "%s muss mindestens %s sein",
+	"%s must be at most %s":                                             "%s darf höchstens %s sein",
+	"%s must be at most %d characters":                                  "%s darf höchstens %d Zeichen lang sein",
+	"%s must be between %d and %d":                                      "%s muss zwischen %d und %d liegen",
+	"%s must be between %d and %d characters":                           "%s muss zwischen %d und %d Zeichen lang sein",
+	"%s must be one of %s":                                              "%s muss einer der folgenden Werte sein: %s",
+	"%s must be an absolute http or https URL":                          "%s muss eine absolute http- oder https-URL sein",
+	"%s must not be negative":                                           "%s darf nicht negativ sein",
+	"%s must start with %s":                                             "%s muss mit %s beginnen",
+	"body is not valid JSON":                                            "Der Body ist kein gültiges JSON",
+	"URL cannot be empty":                                               "Die URL darf nicht leer sein",
+	"URL must include a valid host":                                     "Die URL muss einen gültigen Host enthalten",
+	"invalid URL format: %v":                                            "ungültiges URL-Format: %v",
+	"%s must not exceed %s":                                             "%s darf %s nicht überschreiten",
+	"frequency must be one of hourly, daily, weekly, adaptive":          "frequency muss hourly, daily, weekly oder adaptive sein",
+	"weekday must be between 0 and 6":                                   "weekday muss zwischen 0 und 6 liegen",
+	"min_interval_hours must be between %d and %d":                      "min_interval_hours muss zwischen %d und %d liegen",
+	"max_interval_hours must be between %d and %d":                      "max_interval_hours muss zwischen %d und %d liegen",
+	"at most %d keywords are allowed":                                   "höchstens %d Keywords sind erlaubt",
+	"at most %d windows are allowed":                                    "höchstens %d Zeitfenster sind erlaubt",
+	"time %q must be HH:MM":                                             "die Uhrzeit %q muss das Format HH:MM haben",
+	"unknown time zone %q":                                              "unbekannte Zeitzone %q",
+	"window %s-%s is empty":                                             "das Zeitfenster %s-%s ist leer",
+	"keyword %q must contain a word and be at most %d characters":       "Keyword %q muss ein Wort enthalten und darf höchstens %d Zeichen lang sein",
+	"policy term %q must contain a word and be at most %d characters":   "Richtlinienbegriff %q muss ein Wort enthalten und darf höchstens %d Zeichen lang sein",
+	"policy term %q must have rule %q or %q":                            "Richtlinienbegriff %q muss die Regel %q oder %q haben",
+	"at most %d policy terms are allowed":                               "höchstens %d Richtlinienbegriffe sind erlaubt",
+	"base_url must be the https URL of the Jira site":                   "base_url muss die https-URL der Jira-Instanz sein",
+	"budget limits must not be negative":                                "Budgetgrenzen dürfen nicht negativ sein",
+	"compression_types must be a comma-separated list of content types": "compression_types muss eine kommagetrennte Liste von Content-Types sein",
+	"commit_sha must be a hexadecimal commit hash":                      "commit_sha muss ein hexadezimaler Commit-Hash sein",
+	"email and project_key are required for Jira":                       "email und project_key sind für Jira erforderlich",
+	"password must be at least %d characters":                           "das Passwort muss mindestens %d Zeichen lang sein",
+	"password must be at most 72 bytes":                                 "das Passwort darf höchstens 72 Byte lang sein",
+	"provider must be jira or linear":                                   "provider muss jira oder linear sein",
+	"repo must have the form owner/name":                                "repo muss die Form owner/name haben",
+	"scheduling_mode must be %q or %q":                                  "scheduling_mode muss %q oder %q sein",
+	"budget_status must be %q or %q":                                    "budget_status muss %q oder %q sein",
+	"sort must be one of %s, optionally prefixed with -":                "sort muss einer der folgenden Werte sein, optional mit vorangestelltem -: %s",
+	"team_id is required for Linear":                                    "team_id ist für Linear erforderlich",
+}
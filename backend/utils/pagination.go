@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits of paginated list endpoints
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// PageRequest is the page selected with ?page= and ?per_page=, or with a ?cursor= from a previous response
+// ?paging=keyset selects keyset paging, which stays fast on large tables but can't jump to a page
+type PageRequest struct {
+	Page    int
+	PerPage int
+	Keyset  bool               // Continue behind After instead of skipping an offset
+	After   *repository.Cursor // Last row of the previous keyset page, nil on the first one
+}
+
+// Offset returns the number of rows before the page
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// Pagination is the metadata returned with a page of results
+// Cursors are opaque; clients pass them back as ?cursor= instead of computing page numbers
+type Pagination struct {
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	TotalCount int64  `json:"total_count"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// KeysetPagination is the metadata returned with a keyset page
+// Counting every row would defeat the point of keyset paging, so there are no totals
+type KeysetPagination struct {
+	PerPage    int    `json:"per_page"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ParsePagination reads the paging parameters of a list request
+// It returns nil when none were given, in which case the whole list is returned
+func ParsePagination(c *gin.Context) (*PageRequest, error) {
+	rawPage, rawPerPage, rawCursor, paging := c.Query("page"), c.Query("per_page"), c.Query("cursor"), c.Query("paging")
+	if rawPage == "" && rawPerPage == "" && rawCursor == "" && paging == "" {
+		return nil, nil
+	}
+	if paging != "" && paging != "offset" && paging != "keyset" {
+		return nil, fmt.Errorf("paging must be offset or keyset")
+	}
+
+	request := &PageRequest{Page: 1, PerPage: DefaultPerPage, Keyset: paging == "keyset"}
+	if rawPerPage != "" {
+		perPage, err := strconv.Atoi(rawPerPage)
+		if err != nil || perPage < 1 || perPage > MaxPerPage {
+			return nil, fmt.Errorf("per_page must be between 1 and %d", MaxPerPage)
+		}
+		request.PerPage = perPage
+	}
+
+	switch {
+	case rawCursor != "":
+		page, perPage, after, err := decodeCursor(rawCursor)
+		if err != nil {
+			return nil, err
+		}
+		request.Page, request.Keyset, request.After = page, after != nil, after
+		if rawPerPage == "" {
+			request.PerPage = perPage
+		}
+	case rawPage != "" && request.Keyset:
+		return nil, fmt.Errorf("page can't be combined with keyset paging, follow next_cursor instead")
+	case rawPage != "":
+		page, err := strconv.Atoi(rawPage)
+		if err != nil || page < 1 {
+			return nil, fmt.Errorf("page must be a positive number")
+		}
+		request.Page = page
+	}
+
+	return request, nil
+}
+
+// NewPagination builds the metadata of the requested page out of the total row count
+func NewPagination(request PageRequest, totalCount int64) Pagination {
+	totalPages := int((totalCount + int64(request.PerPage) - 1) / int64(request.PerPage))
+	pagination := Pagination{
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+	}
+	if request.Page < totalPages {
+		pagination.NextCursor = encodePageCursor(request.Page+1, request.PerPage)
+	}
+	if request.Page > 1 {
+		// Clamp so a page past the end links back to the last existing page
+		prev := request.Page - 1
+		if prev > totalPages {
+			prev = totalPages
+		}
+		if prev >= 1 {
+			pagination.PrevCursor = encodePageCursor(prev, request.PerPage)
+		}
+	}
+	return pagination
+}
+
+// KeysetPage trims a keyset query that fetched PerPage+1 rows to the page, producing its metadata
+// The extra row only tells whether another page follows; cursorOf returns the position of a row
+func KeysetPage[T any](request PageRequest, rows []T, cursorOf func(T) repository.Cursor) ([]T, KeysetPagination) {
+	pagination := KeysetPagination{PerPage: request.PerPage}
+	if len(rows) > request.PerPage {
+		rows = rows[:request.PerPage]
+		pagination.HasMore = true
+		last := cursorOf(rows[len(rows)-1])
+		pagination.NextCursor = encodeKeysetCursor(last, request.PerPage)
+	}
+	return rows, pagination
+}
+
+// SetLinkHeader advertises the neighbouring pages in an RFC 5988 Link header
+func SetLinkHeader(c *gin.Context, pagination Pagination) {
+	var links []string
+	if pagination.NextCursor != "" {
+		links = append(links, pageLink(c, "next", pagination.PerPage, "cursor", pagination.NextCursor))
+	}
+	if pagination.PrevCursor != "" {
+		links = append(links, pageLink(c, "prev", pagination.PerPage, "cursor", pagination.PrevCursor))
+	}
+	links = append(links, pageLink(c, "first", pagination.PerPage, "page", "1"))
+	if pagination.TotalPages > 0 {
+		links = append(links, pageLink(c, "last", pagination.PerPage, "page", strconv.Itoa(pagination.TotalPages)))
+	}
+
+	c.Header("Link", strings.Join(links, ", "))
+	c.Header("X-Total-Count", strconv.FormatInt(pagination.TotalCount, 10))
+}
+
+// SetKeysetLinkHeader advertises the next keyset page and the first one in an RFC 5988 Link header
+func SetKeysetLinkHeader(c *gin.Context, pagination KeysetPagination) {
+	var links []string
+	if pagination.NextCursor != "" {
+		links = append(links, pageLink(c, "next", pagination.PerPage, "cursor", pagination.NextCursor))
+	}
+	links = append(links, pageLink(c, "first", pagination.PerPage, "paging", "keyset"))
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageLink formats one Link header entry pointing at the current request with other paging parameters
+func pageLink(c *gin.Context, rel string, perPage int, param, value string) string {
+	target := *c.Request.URL
+	query := target.Query()
+	query.Del("page")
+	query.Del("cursor")
+	query.Del("paging")
+	query.Set(param, value)
+	query.Set("per_page", strconv.Itoa(perPage))
+	target.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, target.RequestURI(), rel)
+}
+
+// encodePageCursor packs a page position into an opaque cursor
+func encodePageCursor(page, perPage int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("page:%d:%d", page, perPage)))
+}
+
+// encodeKeysetCursor packs the position of the last row of a keyset page into an opaque cursor
+func encodeKeysetCursor(after repository.Cursor, perPage int) string {
+	var createdAt int64
+	if !after.CreatedAt.IsZero() {
+		createdAt = after.CreatedAt.UnixNano()
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("key:%d:%d:%d", createdAt, after.ID, perPage)))
+}
+
+// decodeCursor unpacks a cursor created by encodePageCursor or encodeKeysetCursor
+// The keyset position is nil for page cursors
+func decodeCursor(cursor string) (int, int, *repository.Cursor, error) {
+	invalid := fmt.Errorf("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, nil, invalid
+	}
+
+	if strings.HasPrefix(string(raw), "key:") {
+		var createdAt int64
+		var id uint
+		var perPage int
+		if _, err := fmt.Sscanf(string(raw), "key:%d:%d:%d", &createdAt, &id, &perPage); err != nil || perPage < 1 || perPage > MaxPerPage {
+			return 0, 0, nil, invalid
+		}
+		after := &repository.Cursor{ID: id}
+		if createdAt != 0 {
+			after.CreatedAt = time.Unix(0, createdAt).UTC()
+		}
+		return 1, perPage, after, nil
+	}
+
+	var page, perPage int
+	if _, err := fmt.Sscanf(string(raw), "page:%d:%d", &page, &perPage); err != nil || page < 1 || perPage < 1 || perPage > MaxPerPage {
+		return 0, 0, nil, invalid
+	}
+	return page, perPage, nil, nil
+}
@@ -12,9 +12,11 @@ type APIResponse struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	Code    ErrorCode   `json:"code,omitempty"`
 }
 
 // ResponseUtil provides utilities for consistent API responses
+// Messages are translated into the language of the request's Accept-Language header
 type ResponseUtil struct{}
 
 // NewResponseUtil creates a new response utility
@@ -26,7 +28,7 @@ func NewResponseUtil() *ResponseUtil {
 func (r *ResponseUtil) Success(c *gin.Context, data interface{}, message string) {
 	c.JSON(http.StatusOK, APIResponse{
 		Success: true,
-		Message: message,
+		Message: Localize(c, message),
 		Data:    data,
 	})
 }
@@ -35,41 +37,63 @@ func (r *ResponseUtil) Success(c *gin.Context, data interface{}, message string)
 func (r *ResponseUtil) Created(c *gin.Context, data interface{}, message string) {
 	c.JSON(http.StatusCreated, APIResponse{
 		Success: true,
-		Message: message,
+		Message: Localize(c, message),
+		Data:    data,
+	})
+}
+
+// Accepted sends an accepted response for work that will finish later
+func (r *ResponseUtil) Accepted(c *gin.Context, data interface{}, message string) {
+	c.JSON(http.StatusAccepted, APIResponse{
+		Success: true,
+		Message: Localize(c, message),
 		Data:    data,
 	})
 }
 
 // BadRequest sends a bad request error response
-func (r *ResponseUtil) BadRequest(c *gin.Context, error string) {
+func (r *ResponseUtil) BadRequest(c *gin.Context, code ErrorCode, error string) {
 	c.JSON(http.StatusBadRequest, APIResponse{
 		Success: false,
-		Error:   error,
+		Error:   Localize(c, error),
+		Code:    code,
 	})
 }
 
 // NotFound sends a not found error response
-func (r *ResponseUtil) NotFound(c *gin.Context, error string) {
+func (r *ResponseUtil) NotFound(c *gin.Context, code ErrorCode, error string) {
 	c.JSON(http.StatusNotFound, APIResponse{
 		Success: false,
-		Error:   error,
+		Error:   Localize(c, error),
+		Code:    code,
 	})
 }
 
 // Conflict sends a conflict error response
-func (r *ResponseUtil) Conflict(c *gin.Context, error string, data interface{}) {
+func (r *ResponseUtil) Conflict(c *gin.Context, code ErrorCode, error string, data interface{}) {
 	c.JSON(http.StatusConflict, APIResponse{
 		Success: false,
-		Error:   error,
+		Error:   Localize(c, error),
+		Code:    code,
 		Data:    data,
 	})
 }
 
 // InternalServerError sends an internal server error response
-func (r *ResponseUtil) InternalServerError(c *gin.Context, error string) {
+func (r *ResponseUtil) InternalServerError(c *gin.Context, code ErrorCode, error string) {
 	c.JSON(http.StatusInternalServerError, APIResponse{
 		Success: false,
-		Error:   error,
+		Error:   Localize(c, error),
+		Code:    code,
+	})
+}
+
+// Error sends an error response with an arbitrary status code
+func (r *ResponseUtil) Error(c *gin.Context, status int, code ErrorCode, error string) {
+	c.JSON(status, APIResponse{
+		Success: false,
+		Error:   Localize(c, error),
+		Code:    code,
 	})
 }
 
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ParseTimezone reads the optional ?tz= parameter, an IANA zone name such as Europe/Berlin
+// Timestamps are stored in UTC and returned in UTC when the parameter is absent
+func ParseTimezone(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// In converts the timestamps of the summary to loc
+func (s *URLSummary) In(loc *time.Location) {
+	s.CreatedAt = s.CreatedAt.In(loc)
+	if s.CrawledAt != nil {
+		crawledAt := s.CrawledAt.In(loc)
+		s.CrawledAt = &crawledAt
+	}
+}
+
+// LocalizeURL converts the timestamps of a URL to loc
+func LocalizeURL(url *models.URL, loc *time.Location) {
+	url.CreatedAt = url.CreatedAt.In(loc)
+	url.UpdatedAt = url.UpdatedAt.In(loc)
+}
+
+// LocalizeCrawlResult converts the timestamps of a crawl result and its pages to loc
+func LocalizeCrawlResult(result *models.CrawlResult, loc *time.Location) {
+	result.CrawledAt = result.CrawledAt.In(loc)
+	for i := range result.Pages {
+		result.Pages[i].CrawledAt = result.Pages[i].CrawledAt.In(loc)
+	}
+}
@@ -4,52 +4,128 @@ import (
 	"time"
 
 	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
-	"gorm.io/gorm"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
 )
 
-// EnrichURL enhances a URL model with its latest crawl result data and calculated metrics
-// Returns a comprehensive map containing all URL information including crawl statistics
-func EnrichURL(db *gorm.DB, url models.URL) map[string]interface{} {
+// URLSummary is a URL together with the key figures of its latest crawl
+// Crawl fields keep their zero values, and crawled_at is null, until the URL has been crawled
+type URLSummary struct {
+	ID             uint       `json:"id"`
+	URL            string     `json:"url"`
+	DisplayURL     string     `json:"display_url"`
+	Status         string     `json:"status"`
+	Tags           []string   `json:"tags"`
+	MonitorEnabled bool       `json:"monitor_enabled"`
+	ProjectID      *uint      `json:"project_id"`
+	CommitSHA      string     `json:"commit_sha,omitempty"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Title          string     `json:"title"`
+	HTMLVersion    string     `json:"html_version"`
+	InternalLinks  int        `json:"internal_links"`
+	ExternalLinks  int        `json:"external_links"`
+	LinksCount     int        `json:"links_count"`
+	BrokenLinks    int64      `json:"broken_links"`
+	CrawledAt      *time.Time `json:"crawled_at"`
+	HasLoginForm   bool       `json:"has_login_form"`
+	Language       string     `json:"language"`
+	ReadingEase    float64    `json:"reading_ease"`
+	BudgetStatus   string     `json:"budget_status"`
+
+	AnalyzerVersion int `json:"analyzer_version"` // Generation of the analysis logic that produced the latest crawl
+
+	// Progress of the running crawl, so polling clients see more than the status
+	CrawlPhase   string `json:"crawl_phase,omitempty"`
+	LinksChecked int    `json:"links_checked,omitempty"`
+	LinksTotal   int    `json:"links_total,omitempty"`
+}
+
+// URLDetail is a URL summary that can carry the links of the latest crawl
+type URLDetail struct {
+	URLSummary
+	Links []models.Link `json:"links,omitempty"` // Only loaded when requested with ?fields=links
+}
+
+// EnrichURL combines a URL model with its latest crawl result data and calculated metrics
+func EnrichURL(results repository.CrawlResultRepository, url models.URL) URLSummary {
 	// Attempt to find the most recent crawl result for this URL
-	var crawlResult models.CrawlResult
-	err := db.Where("url_id = ?", url.ID).Order("crawled_at desc").First(&crawlResult).Error
-	crawlResultExists := err == nil
-
-	// Calculate broken links count if crawl results exist
-	var brokenLinks int64
-	if crawlResultExists {
-		db.Model(&models.Link{}).
-			Where("crawl_result_id = ? AND is_accessible = ?", crawlResult.ID, false).
-			Count(&brokenLinks)
-	}
-
-	// Build the enriched response with base URL information
-	enrichedData := map[string]interface{}{
-		"id":         url.ID,
-		"url":        url.URL,
-		"status":     url.Status,
-		"created_at": url.CreatedAt.Format(time.RFC3339),
-	}
-
-	// Add crawl result data if available, otherwise use default values
-	if crawlResultExists {
-		enrichedData["title"] = crawlResult.Title
-		enrichedData["html_version"] = crawlResult.HTMLVersion
-		enrichedData["internal_links"] = crawlResult.InternalLinks
-		enrichedData["external_links"] = crawlResult.ExternalLinks
-		enrichedData["broken_links"] = brokenLinks
-		enrichedData["crawled_at"] = crawlResult.CrawledAt.Format(time.RFC3339)
-		enrichedData["has_login_form"] = crawlResult.HasLoginForm
+	crawlResult, err := results.Latest(url.ID, repository.LoadOptions{})
+	if err != nil {
+		return summarizeURL(results, url, nil)
+	}
+	return summarizeURL(results, url, &crawlResult)
+}
+
+// EnrichURLs enriches a list of URLs like EnrichURL, loading their latest crawls in a fixed number of queries
+func EnrichURLs(results repository.CrawlResultRepository, urls []models.URL) ([]URLSummary, error) {
+	ids := make([]uint, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	latest, err := results.LatestResults(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]URLSummary, len(urls))
+	for i, url := range urls {
+		if crawlResult, ok := latest[url.ID]; ok {
+			summaries[i] = summarizeURL(results, url, &crawlResult)
+		} else {
+			summaries[i] = summarizeURL(results, url, nil)
+		}
+	}
+	return summaries, nil
+}
+
+// summarizeURL builds the summary of a URL from its latest crawl, nil when it hasn't been crawled
+func summarizeURL(results repository.CrawlResultRepository, url models.URL, crawlResult *models.CrawlResult) URLSummary {
+	// URLs added before display forms were stored are shown as they are crawled
+	displayURL := url.DisplayURL
+	if displayURL == "" {
+		displayURL = url.URL
+	}
+
+	summary := URLSummary{
+		ID:             url.ID,
+		URL:            url.URL,
+		DisplayURL:     displayURL,
+		Status:         url.Status,
+		Tags:           url.Tags,
+		MonitorEnabled: url.MonitorEnabled,
+		ProjectID:      url.ProjectID,
+		CommitSHA:      url.CommitSHA,
+		LastError:      url.LastError,
+		CreatedAt:      url.CreatedAt,
+		CrawlPhase:     url.CrawlPhase,
+		LinksChecked:   url.LinksChecked,
+		LinksTotal:     url.LinksTotal,
+	}
+	if crawlResult == nil {
+		return summary
+	}
+
+	crawledAt := crawlResult.CrawledAt
+	summary.Title = crawlResult.Title
+	summary.HTMLVersion = crawlResult.HTMLVersion
+	summary.InternalLinks = crawlResult.InternalLinks
+	summary.ExternalLinks = crawlResult.ExternalLinks
+	summary.CrawledAt = &crawledAt
+	summary.HasLoginForm = crawlResult.HasLoginForm
+	summary.Language = crawlResult.Content.Language
+	summary.ReadingEase = crawlResult.Content.ReadingEase
+	summary.BudgetStatus = crawlResult.BudgetStatus
+	summary.AnalyzerVersion = crawlResult.AnalyzerVersion
+
+	// Use the counters stored with the crawl; only URLs the startup backfill hasn't reached yet are aggregated
+	if url.LatestCrawlID != nil && *url.LatestCrawlID == crawlResult.ID {
+		summary.LinksCount = url.LinksCount
+		summary.BrokenLinks = int64(url.BrokenLinksCount)
 	} else {
-		// Provide default values for URLs that haven't been crawled yet
-		enrichedData["title"] = ""
-		enrichedData["html_version"] = ""
-		enrichedData["internal_links"] = 0
-		enrichedData["external_links"] = 0
-		enrichedData["broken_links"] = 0
-		enrichedData["crawled_at"] = nil
-		enrichedData["has_login_form"] = false
+		linksCount, _ := results.CountLinks(crawlResult.ID)
+		summary.LinksCount = int(linksCount)
+		summary.BrokenLinks, _ = results.CountBrokenLinks(crawlResult.ID)
 	}
 
-	return enrichedData
+	return summary
 }
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report the JSON names of invalid fields rather than the Go struct fields
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+				if name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// BindingError formats an error of binding a request body, one sentence per invalid field
+// The sentences use the fixed wording of the message catalogs, so they are translated like every other message
+func BindingError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("%s has the wrong type", typeErr.Field)
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return "body is not valid JSON"
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err.Error()
+	}
+
+	messages := make([]string, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		field := fieldErr.Field()
+		switch fieldErr.Tag() {
+		case "required":
+			messages[i] = fmt.Sprintf("%s is required", field)
+		case "oneof":
+			messages[i] = fmt.Sprintf("%s must be one of %s", field, strings.ReplaceAll(fieldErr.Param(), " ", ", "))
+		case "min", "gte":
+			messages[i] = fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+		case "max", "lte":
+			messages[i] = fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+		default:
+			messages[i] = fmt.Sprintf("%s is invalid", field)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
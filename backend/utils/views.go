@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/models"
+	"github.com-personal/muhammadharis4/sykell-url-analyzer/backend/repository"
+)
+
+// ViewURLs enriches the URLs matching the view's filter and orders them by the view's sort
+// urls must be newest first, the order of an empty sort and of URLs that sort equal
+func ViewURLs(results repository.CrawlResultRepository, urls []models.URL, view models.View, now time.Time) ([]URLSummary, error) {
+	filter := view.Filter
+	search := strings.ToLower(strings.TrimSpace(filter.Search))
+	tag := strings.ToLower(strings.TrimSpace(filter.Tag))
+
+	// Filter on the URL first, so only the matching ones are enriched
+	matching := []models.URL{}
+	for _, url := range urls {
+		switch {
+		case filter.Status != "" && url.Status != filter.Status:
+			continue
+		case filter.ProjectID != nil && (url.ProjectID == nil || *url.ProjectID != *filter.ProjectID):
+			continue
+		case tag != "" && !containsTag(url.Tags, tag):
+			continue
+		case filter.UpdatedWithinDays > 0 && url.UpdatedAt.Before(now.AddDate(0, 0, -filter.UpdatedWithinDays)):
+			continue
+		}
+		matching = append(matching, url)
+	}
+
+	enriched, err := EnrichURLs(results, matching)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []URLSummary{}
+	for _, summary := range enriched {
+		switch {
+		case search != "" && !strings.Contains(strings.ToLower(summary.URL), search) &&
+			!strings.Contains(strings.ToLower(summary.DisplayURL), search) &&
+			!strings.Contains(strings.ToLower(summary.Title), search):
+			continue
+		case filter.HasBrokenLinks && summary.BrokenLinks == 0:
+			continue
+		case filter.BudgetStatus != "" && summary.BudgetStatus != filter.BudgetStatus:
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	field := strings.TrimPrefix(view.Sort, "-")
+	descending := strings.HasPrefix(view.Sort, "-")
+	if field == "" || (field == "created_at" && descending) {
+		return summaries, nil
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if descending {
+			return lessSummary(field, summaries[j], summaries[i])
+		}
+		return lessSummary(field, summaries[i], summaries[j])
+	})
+	return summaries, nil
+}
+
+// lessSummary orders two summaries by a sort field; URLs that were never crawled sort before crawled ones
+func lessSummary(field string, a, b URLSummary) bool {
+	switch field {
+	case "created_at":
+		return a.CreatedAt.Before(b.CreatedAt)
+	case "crawled_at":
+		if a.CrawledAt == nil || b.CrawledAt == nil {
+			return a.CrawledAt == nil && b.CrawledAt != nil
+		}
+		return a.CrawledAt.Before(*b.CrawledAt)
+	case "url":
+		return a.URL < b.URL
+	case "title":
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	case "status":
+		return a.Status < b.Status
+	case "links_count":
+		return a.LinksCount < b.LinksCount
+	case "broken_links":
+		return a.BrokenLinks < b.BrokenLinks
+	}
+	return false
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if strings.EqualFold(candidate, tag) {
+			return true
+		}
+	}
+	return false
+}